@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
@@ -8,9 +9,18 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/api"
 	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/debugserver"
 	"github.com/patrickjane/lazydodo-bot/internal/discord"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/lease"
+	"github.com/patrickjane/lazydodo-bot/internal/metrics"
+	"github.com/patrickjane/lazydodo-bot/internal/migrate"
+	"github.com/patrickjane/lazydodo-bot/internal/store"
+	"github.com/patrickjane/lazydodo-bot/internal/tracing"
 	"github.com/patrickjane/lazydodo-bot/internal/utils"
 )
 
@@ -19,8 +29,47 @@ var version = ""
 func main() {
 	var logFile *os.File
 
+	cfg.Version = version
 	cfg.ParseConfig()
 
+	defer errorreport.Recover("main")
+
+	// "generate-observability [dir]" writes the Grafana dashboard and Prometheus rules for the config and exits
+
+	if flag.Arg(0) == "generate-observability" {
+		dir := flag.Arg(1)
+
+		if dir == "" {
+			dir = "."
+		}
+
+		if err := metrics.Generate(dir); err != nil {
+			log.Fatalf("Failed to generate dashboard and alert rules: %v", err)
+		}
+
+		return
+	}
+
+	// "migrate-server <old key> <new key>" moves the stored state of a renamed or merged server and exits
+
+	if flag.Arg(0) == "migrate-server" {
+		if err := migrate.Server(flag.Arg(1), flag.Arg(2)); err != nil {
+			log.Fatalf("Failed to migrate server: %v", err)
+		}
+
+		return
+	}
+
+	// "import-history <format> <file> [server key]" adds the sessions of old join/leave or server logs to the history and exits
+
+	if flag.Arg(0) == "import-history" {
+		if err := migrate.Import(flag.Arg(1), flag.Arg(2), flag.Arg(3)); err != nil {
+			log.Fatalf("Failed to import history: %v", err)
+		}
+
+		return
+	}
+
 	if cfg.Config.LogFile != "" {
 		logFile, err := os.OpenFile(cfg.Config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 
@@ -34,55 +83,182 @@ func main() {
 	slog.Info(fmt.Sprintf("LazyDodoBot %s", version))
 	slog.Info("https://github.com/patrickjane/lazydodo-bot")
 
-	slog.Info(fmt.Sprintf("Initializing cache at %s", cfg.Config.CachePath))
+	if cfg.Config.StateDir != "" {
+		if err := os.MkdirAll(cfg.Config.StateDir, 0755); err != nil {
+			log.Fatalf("Failed to create state directory: %v", err)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Initializing history at %s", cfg.Config.HistoryPath))
+
+	if err := history.Init(); err != nil {
+		slog.Error(fmt.Sprintf("Failed to load history: %s", err))
+	}
+
+	var database *store.Store
 
-	cache.Init()
+	if cfg.Config.Database != "" {
+		slog.Info(fmt.Sprintf("Storing join/leave events and snapshots in %s", cfg.Config.Database))
 
-	if cfg.Config.Eventer != nil {
-		slog.Info("Event monitoring enabled, setting reminders for every event at:")
+		var err error
+		database, err = store.Open(cfg.Config.Database)
 
-		for _, r := range cfg.Config.Eventer.ReminderOffsets {
-			slog.Info(fmt.Sprintf("   - %s before", utils.FormatDuration(r, utils.English)))
+		if err != nil {
+			log.Fatalf("Failed to open database: %v", err)
 		}
-	} else {
-		slog.Info("Event monitoring disabled")
 	}
 
-	slog.Info("Monitoring the following servers via RCON:")
+	if cfg.Config.Simulate {
+		slog.Info("Simulation mode enabled, using synthetic servers and players")
+	}
+
+	for _, bot := range cfg.Config.AllBots() {
+		slog.Info(fmt.Sprintf("Bot '%s':", bot.Name))
+
+		if bot.ShardCount > 0 {
+			slog.Info(fmt.Sprintf("Running as shard %d of %d", bot.ShardID, bot.ShardCount))
+		}
+
+		if bot.Eventer != nil {
+			slog.Info("Event monitoring enabled, setting reminders for every event at:")
+
+			for _, r := range bot.Eventer.ReminderOffsets {
+				slog.Info(fmt.Sprintf("   - %s before", utils.FormatDuration(r, utils.English)))
+			}
+		} else {
+			slog.Info("Event monitoring disabled")
+		}
+
+		if bot.ServerStatus != nil {
+			slog.Info("Monitoring the following servers via RCON:")
+
+			for _, s := range bot.ServerStatus.Rcon.Servers {
+				slog.Info(fmt.Sprintf("   %s (%s) at %s", s.Name, s.Type, s.Address))
+			}
 
-	if cfg.Config.ServerStatus != nil {
-		for _, s := range cfg.Config.ServerStatus.Rcon.Servers {
-			slog.Info(fmt.Sprintf("   %s at %s", s.Name, s.Address))
+			slog.Info(fmt.Sprintf("Query RCON servers every %d seconds", bot.ServerStatus.Rcon.QueryEverySeconds))
 		}
 
-		slog.Info(fmt.Sprintf("Query RCON servers every %d seconds", cfg.Config.ServerStatus.Rcon.QueryEverySeconds))
+		if bot.Crosschat != nil {
+			slog.Info("Cross chat enabled")
+		}
 	}
 
-	if cfg.Config.Crosschat != nil {
-		slog.Info("Cross chat enabled")
+	if cfg.Config.PprofPort != 0 {
+		slog.Info(fmt.Sprintf("Serving pprof at http://127.0.0.1:%d/debug/pprof/", cfg.Config.PprofPort))
+
+		debugserver.Start()
 	}
 
-	slog.Info("Starting discord bot")
+	if cfg.Config.MetricsPort != 0 {
+		slog.Info(fmt.Sprintf("Serving metrics at :%d/metrics", cfg.Config.MetricsPort))
 
-	discordBot := discord.NewBot()
+		metrics.Start()
+	}
 
-	err := discordBot.Start()
+	if cfg.Config.HealthHTTPPort != 0 {
+		slog.Info(fmt.Sprintf("Serving health endpoints at :%d/healthz and /readyz", cfg.Config.HealthHTTPPort))
 
-	if err != nil {
-		slog.Error(fmt.Sprintf("Failed to start discord bot: %s", err))
-		os.Exit(1)
+		health.Serve()
 	}
 
+	if cfg.Config.Api != nil {
+		slog.Info(fmt.Sprintf("Serving API at %s:%d", cfg.Config.Api.Bind, cfg.Config.Api.Port))
+
+		if err := api.Start(); err != nil {
+			log.Fatalf("Failed to start API: %v", err)
+		}
+	}
+
+	if cfg.Config.ErrorReports != nil {
+		slog.Info("Reporting panics and repeated errors")
+
+		if err := errorreport.Init(); err != nil {
+			slog.Error(fmt.Sprintf("Failed to initialize sentry: %s", err))
+		}
+	}
+
+	if cfg.Config.Tracing != nil {
+		slog.Info(fmt.Sprintf("Exporting traces to %s", cfg.Config.Tracing.Endpoint))
+
+		if err := tracing.Init(); err != nil {
+			slog.Error(fmt.Sprintf("Failed to initialize tracing: %s", err))
+		}
+	}
+
+	var discordBots []*discord.DiscordBot
+
+	for _, bot := range cfg.Config.AllBots() {
+		slog.Info(fmt.Sprintf("Starting discord bot '%s'", bot.Name))
+
+		discordBot := discord.NewBot(bot, database)
+
+		err := discordBot.Start()
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to start discord bot '%s': %s", bot.Name, err))
+			os.Exit(1)
+		}
+
+		discordBots = append(discordBots, discordBot)
+	}
+
+	if cfg.Config.HeartbeatFile != "" || cfg.Config.HealthPort != 0 {
+		slog.Info(fmt.Sprintf("Reporting health via heartbeat file '%s' / port %d", cfg.Config.HeartbeatFile, cfg.Config.HealthPort))
+
+		health.Start()
+	}
+
+	// reload the config on SIGHUP and whenever the config file changes
+
+	if err := cfg.Watch(func() { discord.Reload(discordBots) }); err != nil {
+		slog.Error(fmt.Sprintf("Failed to watch config file, reload with SIGHUP only: %s", err))
+	}
+
+	sigReload := make(chan os.Signal, 1)
+	signal.Notify(sigReload, syscall.SIGHUP)
+
 	sigShutdown := make(chan os.Signal, 1)
 	signal.Notify(sigShutdown, syscall.SIGTERM, syscall.SIGINT)
 
-	<-sigShutdown
+	restart := false
+
+	for done := false; !done; {
+		select {
+		case <-sigReload:
+			discord.Reload(discordBots)
+		case <-cfg.RestartRequested():
+			restart = true
+			done = true
+		case <-sigShutdown:
+			done = true
+		}
+	}
 
 	slog.Info("Shutting down.")
 
-	discordBot.Stop()
+	for _, discordBot := range discordBots {
+		discordBot.Stop()
+	}
+
+	database.Close()
+	tracing.Shutdown()
+	errorreport.Flush()
 
 	if logFile != nil {
 		logFile.Close()
 	}
+
+	if restart {
+		// replace the process, so the bot keeps running without relying on a supervisor
+
+		exe, err := os.Executable()
+
+		if err == nil {
+			err = syscall.Exec(exe, os.Args, lease.Environ())
+		}
+
+		slog.Error(fmt.Sprintf("Failed to restart: %s", err))
+		os.Exit(1)
+	}
 }
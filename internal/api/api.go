@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// history returned when the request has no since parameter
+const defaultHistory = 24 * time.Hour
+
+type ServerStatus struct {
+	Key       string             `json:"key"`
+	Name      string             `json:"name"`
+	Map       string             `json:"map"`
+	Reachable bool               `json:"reachable"`
+	Version   string             `json:"version,omitempty"`
+	Day       int                `json:"day,omitempty"`
+	Players   []model.PlayerInfo `json:"players"`
+	Updated   time.Time          `json:"updated"`
+}
+
+// Status is the result of the last poll of every server, of all bots
+type Status struct {
+	sync.Mutex
+	Servers map[string]ServerStatus
+}
+
+var status = &Status{Servers: make(map[string]ServerStatus)}
+
+// RecordPoll updates the status served by the API with the result of a poll, and pushes it to the websocket clients
+func RecordPoll(serverInfos map[string]*model.ServerInfo) {
+	defer notifyPush()
+
+	status.Lock()
+	defer status.Unlock()
+
+	now := time.Now()
+
+	for serverKey, serverInfo := range serverInfos {
+		status.Servers[serverKey] = ServerStatus{
+			Key:       serverKey,
+			Name:      serverInfo.Name,
+			Map:       serverInfo.Map,
+			Reachable: serverInfo.Reachable,
+			Version:   serverInfo.ServerVersion,
+			Day:       serverInfo.Day,
+			Players:   serverInfo.Players,
+			Updated:   now,
+		}
+	}
+}
+
+// Start serves the API at the configured address, via HTTPS if a certificate is configured. Every request
+// needs a token with the scope of the endpoint as "Authorization: Bearer <token>", except the player count
+// at /overlay/players, which is public (for any origin) if api.overlay is set.
+func Start() error {
+	var err error
+
+	tokens, err = openTokens(cfg.Config.Api)
+
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", authorized(cfg.ScopeReadStatus, serveStatus))
+	mux.HandleFunc("GET /api/status/ws", authorized(cfg.ScopeReadStatus, serveStatusPush))
+	mux.HandleFunc("GET /api/history", authorized(cfg.ScopeReadHistory, serveHistory))
+	mux.HandleFunc("POST /api/graphql", authorized(cfg.ScopeReadHistory, serveGraphQL))
+	mux.HandleFunc("GET /api/tokens", authorized(cfg.ScopeAdmin, serveTokens))
+	mux.HandleFunc("POST /api/tokens", authorized(cfg.ScopeAdmin, createToken))
+	mux.HandleFunc("DELETE /api/tokens/{name}", authorized(cfg.ScopeAdmin, revokeToken))
+
+	if cfg.Config.Api.Overlay {
+		mux.HandleFunc("GET /overlay/players", serveOverlay)
+		mux.HandleFunc("OPTIONS /overlay/players", serveOverlay)
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.Config.Api.Bind, cfg.Config.Api.Port)
+	certFile, keyFile := cfg.Config.Api.CertFile, cfg.Config.Api.KeyFile
+
+	if certFile == "" && !isLoopback(cfg.Config.Api.Bind) {
+		slog.Warn(fmt.Sprintf("API is served at %s without TLS, configure api.certFile and api.keyFile", addr))
+	}
+
+	go func() {
+		var err error
+
+		if certFile != "" {
+			err = http.ListenAndServeTLS(addr, certFile, keyFile, mux)
+		} else {
+			err = http.ListenAndServe(addr, mux)
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to start API: %s", err))
+		}
+	}()
+
+	return nil
+}
+
+type scopesKey struct{}
+
+// granted reports whether the token of the request has the scope
+func granted(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(scopesKey{}).([]string)
+	return grants(scopes, scope)
+}
+
+func authorized(scope string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || secret == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+
+		name, scopes := tokens.lookup(secret)
+
+		if name == "" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		if !grants(scopes, scope) {
+			slog.Warn(fmt.Sprintf("API token '%s' denied access to %s %s", name, r.Method, r.URL.Path))
+			http.Error(w, fmt.Sprintf("token lacks scope %s or was revoked", scope), http.StatusForbidden)
+			return
+		}
+
+		handler(w, r.WithContext(context.WithValue(r.Context(), scopesKey{}, scopes)))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write API response: %s", err))
+	}
+}
+
+func serveStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentStatus())
+}
+
+// currentStatus returns the status of all servers, ordered by name
+func currentStatus() []ServerStatus {
+	status.Lock()
+
+	res := make([]ServerStatus, 0, len(status.Servers))
+
+	for _, server := range status.Servers {
+		res = append(res, server)
+	}
+
+	status.Unlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res
+}
+
+// isLoopback reports whether the bind address is only reachable from the host itself
+func isLoopback(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(bind)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveHistory returns the samples of the server with the key ?server= within ?since= (a Go duration like 6h, default 24h)
+func serveHistory(w http.ResponseWriter, r *http.Request) {
+	server := r.URL.Query().Get("server")
+
+	if server == "" {
+		http.Error(w, "missing server", http.StatusBadRequest)
+		return
+	}
+
+	since := defaultHistory
+
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		d, err := time.ParseDuration(raw)
+
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid since, use e.g. 6h", http.StatusBadRequest)
+			return
+		}
+
+		since = min(d, history.Retention)
+	}
+
+	samples := history.Samples(server, time.Now().Add(-since))
+
+	if samples == nil {
+		samples = []history.Sample{}
+	}
+
+	writeJSON(w, http.StatusOK, samples)
+}
+
+func serveTokens(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, tokens.list())
+}
+
+func createToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := tokens.create(req.Name, req.Scopes)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Created API token '%s' with scopes %v", req.Name, req.Scopes))
+
+	writeJSON(w, http.StatusCreated, map[string]string{"name": req.Name, "token": secret})
+}
+
+func revokeToken(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	if err := tokens.revoke(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Revoked API token '%s'", name))
+
+	w.WriteHeader(http.StatusNoContent)
+}
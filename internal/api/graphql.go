@@ -0,0 +1,362 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+)
+
+// EventSources return the completed events of the bots running an eventer
+type EventSources struct {
+	sync.Mutex
+	Sources []func() []cache.PastEvent
+}
+
+var eventSources = &EventSources{}
+
+// AddEventSource makes the completed events returned by source queryable
+func AddEventSource(source func() []cache.PastEvent) {
+	eventSources.Lock()
+	defer eventSources.Unlock()
+
+	eventSources.Sources = append(eventSources.Sources, source)
+}
+
+var timeArgs = graphql.FieldConfigArgument{
+	"from": &graphql.ArgumentConfig{Type: graphql.String, Description: "RFC 3339 time, default 24 hours ago"},
+	"to":   &graphql.ArgumentConfig{Type: graphql.String, Description: "RFC 3339 time, default now"},
+}
+
+var schema = func() graphql.Schema {
+	player := graphql.NewObject(graphql.ObjectConfig{Name: "Player", Fields: graphql.Fields{
+		"name":  &graphql.Field{Type: graphql.String},
+		"tribe": &graphql.Field{Type: graphql.String},
+		"id":    &graphql.Field{Type: graphql.String},
+	}})
+
+	server := graphql.NewObject(graphql.ObjectConfig{Name: "Server", Fields: graphql.Fields{
+		"key":       &graphql.Field{Type: graphql.String},
+		"name":      &graphql.Field{Type: graphql.String},
+		"map":       &graphql.Field{Type: graphql.String},
+		"reachable": &graphql.Field{Type: graphql.Boolean},
+		"version":   &graphql.Field{Type: graphql.String},
+		"day":       &graphql.Field{Type: graphql.Int},
+		"updated":   &graphql.Field{Type: graphql.String},
+		"players":   &graphql.Field{Type: graphql.NewList(player)},
+	}})
+
+	sample := graphql.NewObject(graphql.ObjectConfig{Name: "Sample", Fields: graphql.Fields{
+		"time":        &graphql.Field{Type: graphql.String},
+		"players":     &graphql.Field{Type: graphql.Int, Description: "most players online in a poll of the bucket"},
+		"polls":       &graphql.Field{Type: graphql.Int},
+		"unreachable": &graphql.Field{Type: graphql.Int, Description: "polls in which the server was unreachable"},
+		"names":       &graphql.Field{Type: graphql.NewList(graphql.String)},
+	}})
+
+	uptime := graphql.NewObject(graphql.ObjectConfig{Name: "Uptime", Fields: graphql.Fields{
+		"percent":        &graphql.Field{Type: graphql.Float},
+		"outages":        &graphql.Field{Type: graphql.Int},
+		"longestSeconds": &graphql.Field{Type: graphql.Int},
+		"coveredSeconds": &graphql.Field{Type: graphql.Int, Description: "time covered by the history, while the bot was running"},
+	}})
+
+	session := graphql.NewObject(graphql.ObjectConfig{Name: "Session", Fields: graphql.Fields{
+		"player": &graphql.Field{Type: graphql.String},
+		"server": &graphql.Field{Type: graphql.String, Description: "server key"},
+		"start":  &graphql.Field{Type: graphql.String},
+		"end":    &graphql.Field{Type: graphql.String},
+	}})
+
+	event := graphql.NewObject(graphql.ObjectConfig{Name: "Event", Fields: graphql.Fields{
+		"id":         &graphql.Field{Type: graphql.String},
+		"guildID":    &graphql.Field{Type: graphql.String},
+		"name":       &graphql.Field{Type: graphql.String},
+		"location":   &graphql.Field{Type: graphql.String},
+		"start":      &graphql.Field{Type: graphql.String},
+		"end":        &graphql.Field{Type: graphql.String},
+		"interested": &graphql.Field{Type: graphql.Int},
+		"players":    &graphql.Field{Type: graphql.Int, Description: "players seen in game during the event, -1 without server status"},
+	}})
+
+	serverArg := func(required bool) *graphql.ArgumentConfig {
+		if required {
+			return &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "server key"}
+		}
+
+		return &graphql.ArgumentConfig{Type: graphql.String, Description: "server key, all servers if not given"}
+	}
+
+	withArgs := func(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		res := graphql.FieldConfigArgument{}
+
+		for name, arg := range timeArgs {
+			res[name] = arg
+		}
+
+		for name, arg := range args {
+			res[name] = arg
+		}
+
+		return res
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"servers": &graphql.Field{
+			Type:        graphql.NewList(server),
+			Description: "status of the last poll, needs the read-status scope",
+			Resolve:     resolveServers,
+		},
+		"samples": &graphql.Field{
+			Type:    graphql.NewList(sample),
+			Args:    withArgs(graphql.FieldConfigArgument{"server": serverArg(true)}),
+			Resolve: resolveSamples,
+		},
+		"uptime": &graphql.Field{
+			Type:    uptime,
+			Args:    withArgs(graphql.FieldConfigArgument{"server": serverArg(true)}),
+			Resolve: resolveUptime,
+		},
+		"sessions": &graphql.Field{
+			Type: graphql.NewList(session),
+			Args: withArgs(graphql.FieldConfigArgument{
+				"server": serverArg(false),
+				"player": &graphql.ArgumentConfig{Type: graphql.String, Description: "name of the player, case insensitive"},
+			}),
+			Resolve: resolveSessions,
+		},
+		"events": &graphql.Field{
+			Type:        graphql.NewList(event),
+			Description: "completed events which started between from and to",
+			Args: withArgs(graphql.FieldConfigArgument{
+				"name": &graphql.ArgumentConfig{Type: graphql.String, Description: "part of the event name, case insensitive"},
+			}),
+			Resolve: resolveEvents,
+		},
+	}})
+
+	s, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}()
+
+// serveGraphQL executes a query of {"query": ..., "variables": ...}
+func serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        r.Context(),
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// timeRange returns the from and to arguments
+func timeRange(p graphql.ResolveParams) (time.Time, time.Time, error) {
+	now := time.Now()
+	from, to := now.Add(-defaultHistory), now
+
+	for name, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		raw, ok := p.Args[name].(string)
+
+		if !ok {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, raw)
+
+		if err != nil {
+			return from, to, fmt.Errorf("invalid %s, use RFC 3339 like 2006-01-02T15:04:05Z", name)
+		}
+
+		*t = parsed
+	}
+
+	return from, to, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func resolveServers(p graphql.ResolveParams) (any, error) {
+	if !granted(p.Context, cfg.ScopeReadStatus) {
+		return nil, fmt.Errorf("token lacks scope %s", cfg.ScopeReadStatus)
+	}
+
+	status.Lock()
+	defer status.Unlock()
+
+	var res []map[string]any
+
+	for _, server := range status.Servers {
+		var players []map[string]any
+
+		for _, player := range server.Players {
+			players = append(players, map[string]any{"name": player.Name, "tribe": player.Tribe, "id": player.ID})
+		}
+
+		res = append(res, map[string]any{
+			"key":       server.Key,
+			"name":      server.Name,
+			"map":       server.Map,
+			"reachable": server.Reachable,
+			"version":   server.Version,
+			"day":       server.Day,
+			"updated":   formatTime(server.Updated),
+			"players":   players,
+		})
+	}
+
+	return res, nil
+}
+
+func resolveSamples(p graphql.ResolveParams) (any, error) {
+	from, to, err := timeRange(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []map[string]any
+
+	server := p.Args["server"].(string)
+	sessions := history.Sessions(server, from, to)
+
+	for _, sample := range history.Samples(server, from) {
+		if !sample.Time.Before(to) {
+			break
+		}
+
+		// the names of the players whose sessions overlap the bucket
+
+		names := []string{}
+
+		for _, session := range sessions {
+			if session.Start.Before(sample.Time.Add(history.Resolution)) && session.End.After(sample.Time) && !slices.Contains(names, session.Player) {
+				names = append(names, session.Player)
+			}
+		}
+
+		res = append(res, map[string]any{
+			"time":        formatTime(sample.Time),
+			"players":     sample.Players,
+			"polls":       sample.Polls,
+			"unreachable": sample.Unreachable,
+			"names":       names,
+		})
+	}
+
+	return res, nil
+}
+
+func resolveUptime(p graphql.ResolveParams) (any, error) {
+	from, to, err := timeRange(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := history.Uptime(p.Args["server"].(string), from, to)
+
+	return map[string]any{
+		"percent":        report.Uptime,
+		"outages":        report.Outages,
+		"longestSeconds": int(report.Longest.Seconds()),
+		"coveredSeconds": int(report.Covered.Seconds()),
+	}, nil
+}
+
+func resolveSessions(p graphql.ResolveParams) (any, error) {
+	from, to, err := timeRange(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	server, _ := p.Args["server"].(string)
+	player, _ := p.Args["player"].(string)
+
+	var res []map[string]any
+
+	for _, session := range history.Sessions(server, from, to) {
+		if player != "" && !strings.EqualFold(session.Player, player) {
+			continue
+		}
+
+		res = append(res, map[string]any{
+			"player": session.Player,
+			"server": session.Server,
+			"start":  formatTime(session.Start),
+			"end":    formatTime(session.End),
+		})
+	}
+
+	return res, nil
+}
+
+func resolveEvents(p graphql.ResolveParams) (any, error) {
+	from, to, err := timeRange(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := p.Args["name"].(string)
+
+	eventSources.Lock()
+	sources := eventSources.Sources
+	eventSources.Unlock()
+
+	var res []map[string]any
+
+	for _, source := range sources {
+		for _, event := range source() {
+			if event.Start.Before(from) || !event.Start.Before(to) {
+				continue
+			}
+
+			if name != "" && !strings.Contains(strings.ToLower(event.Name), strings.ToLower(name)) {
+				continue
+			}
+
+			res = append(res, map[string]any{
+				"id":         event.ID,
+				"guildID":    event.GuildID,
+				"name":       event.Name,
+				"location":   event.Location,
+				"start":      formatTime(event.Start),
+				"end":        formatTime(event.End),
+				"interested": event.Interested,
+				"players":    event.Players,
+			})
+		}
+	}
+
+	return res, nil
+}
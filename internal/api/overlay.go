@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// how often overlays reload the player count, unless the request asks for another ?refresh=
+const defaultOverlayRefresh = 30 * time.Second
+const minOverlayRefresh = 5 * time.Second
+
+// Overlay is the player count returned by /overlay/players
+type Overlay struct {
+	Server    string `json:"server,omitempty"`
+	Name      string `json:"name"`
+	Players   int    `json:"players"`
+	Reachable bool   `json:"reachable"`
+}
+
+// transparent background, so the browser source only shows the text on top of the stream
+var overlayPage = template.Must(template.New("overlay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<style>
+body { margin: 0; background: transparent; color: #fff; font: bold 32px sans-serif; text-shadow: 0 0 4px #000; }
+</style>
+</head>
+<body>{{if .Overlay.Reachable}}{{.Overlay.Name}}: {{.Overlay.Players}} {{.Label}}{{else}}{{.Overlay.Name}}: offline{{end}}</body>
+</html>
+`))
+
+// serveOverlay returns the player count of the server with the key ?server=, or of all servers, as HTML
+// page or with ?format=json as JSON. ?label= replaces the word "players" of the page, ?refresh= (seconds)
+// sets how often it reloads.
+func serveOverlay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	query := r.URL.Query()
+	refresh := defaultOverlayRefresh
+
+	if raw := query.Get("refresh"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+
+		if err != nil {
+			http.Error(w, "invalid refresh, use seconds like 30", http.StatusBadRequest)
+			return
+		}
+
+		refresh = max(time.Duration(seconds)*time.Second, minOverlayRefresh)
+	}
+
+	overlay, ok := overlayFor(query.Get("server"))
+
+	if !ok {
+		http.Error(w, "unknown server", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Refresh", strconv.Itoa(int(refresh.Seconds())))
+
+	if query.Get("format") == "json" {
+		writeJSON(w, http.StatusOK, overlay)
+		return
+	}
+
+	label := query.Get("label")
+
+	if label == "" {
+		label = "players"
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := overlayPage.Execute(w, map[string]any{"Overlay": overlay, "Label": label, "Refresh": int(refresh.Seconds())})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to write overlay: %s", err))
+	}
+}
+
+// overlayFor returns the player count of the server with the given key, the total of all servers if empty
+func overlayFor(serverKey string) (Overlay, bool) {
+	status.Lock()
+	defer status.Unlock()
+
+	if serverKey != "" {
+		server, ok := status.Servers[serverKey]
+
+		return Overlay{Server: serverKey, Name: server.Name, Players: len(server.Players), Reachable: server.Reachable}, ok
+	}
+
+	res := Overlay{Name: "All servers"}
+
+	for _, server := range status.Servers {
+		res.Players += len(server.Players)
+		res.Reachable = res.Reachable || server.Reachable
+	}
+
+	return res, true
+}
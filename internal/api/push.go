@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// a client which doesn't read the pushed status within this is disconnected
+const pushWriteTimeout = 10 * time.Second
+
+// PushClients are the websocket clients of the status push, each is woken after every poll
+type PushClients struct {
+	sync.Mutex
+	Clients map[chan struct{}]bool
+}
+
+var push = &PushClients{Clients: make(map[chan struct{}]bool)}
+
+// browsers must connect from the same origin, other clients send no origin
+var upgrader = websocket.Upgrader{}
+
+func notifyPush() {
+	push.Lock()
+	defer push.Unlock()
+
+	for wake := range push.Clients {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// serveStatusPush upgrades the request to a websocket, which receives the status like /api/status right away
+// and after every poll. The connection is closed once the token is revoked.
+func serveStatusPush(w http.ResponseWriter, r *http.Request) {
+	secret, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Failed to upgrade API request to websocket: %s", err))
+		return
+	}
+
+	defer conn.Close()
+
+	wake := make(chan struct{}, 1)
+
+	push.Lock()
+	push.Clients[wake] = true
+	push.Unlock()
+
+	defer func() {
+		push.Lock()
+		delete(push.Clients, wake)
+		push.Unlock()
+	}()
+
+	// nothing is expected from the client, reading fails once it disconnects
+
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		if _, scopes := tokens.lookup(secret); !grants(scopes, cfg.ScopeReadStatus) {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token revoked"),
+				time.Now().Add(pushWriteTimeout))
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+
+		if err := conn.WriteJSON(currentStatus()); err != nil {
+			return
+		}
+
+		select {
+		case <-wake:
+		case <-closed:
+			return
+		}
+	}
+}
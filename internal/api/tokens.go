@@ -0,0 +1,207 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// Token is a token created at runtime, only its hash is stored
+type Token struct {
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash"`
+	Scopes  []string  `json:"scopes"`
+	Created time.Time `json:"created"`
+}
+
+// Tokens are the config tokens, which can be revoked but not changed at runtime, and the tokens created via the API
+type Tokens struct {
+	sync.Mutex
+	file   string
+	Config []cfg.ConfigApiToken `json:"-"`
+
+	// created tokens by name
+	Created map[string]Token `json:"created"`
+
+	// hashes of revoked config tokens -> name, so a new token of the same name is valid again
+	Revoked map[string]string `json:"revoked"`
+}
+
+// TokenInfo describes a token without its secret
+type TokenInfo struct {
+	Name    string    `json:"name"`
+	Scopes  []string  `json:"scopes"`
+	Source  string    `json:"source"`
+	Revoked bool      `json:"revoked,omitempty"`
+	Created time.Time `json:"created,omitzero"`
+}
+
+var tokens *Tokens
+
+func openTokens(config *cfg.ConfigApi) (*Tokens, error) {
+	t := &Tokens{
+		file:    config.TokensFile,
+		Config:  config.Tokens,
+		Created: make(map[string]Token),
+		Revoked: make(map[string]string),
+	}
+
+	dat, err := os.ReadFile(t.file)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(dat, t); err != nil {
+		return nil, fmt.Errorf("failed to parse API tokens file %s: %w", t.file, err)
+	}
+
+	return t, nil
+}
+
+// SetConfigTokens replaces the config tokens with the ones of the reloaded config
+func SetConfigTokens(config *cfg.ConfigApi) {
+	if tokens == nil || config == nil {
+		return
+	}
+
+	tokens.Lock()
+	defer tokens.Unlock()
+
+	tokens.Config = config.Tokens
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup returns the name and scopes of the token, scopes are nil if the token was revoked
+func (t *Tokens) lookup(secret string) (string, []string) {
+	t.Lock()
+	defer t.Unlock()
+
+	hash := hashToken(secret)
+
+	for _, token := range t.Config {
+		if subtle.ConstantTimeCompare([]byte(token.Token), []byte(secret)) == 1 {
+			if _, revoked := t.Revoked[hash]; revoked {
+				return token.Name, nil
+			}
+
+			return token.Name, token.Scopes
+		}
+	}
+
+	for _, token := range t.Created {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) == 1 {
+			return token.Name, token.Scopes
+		}
+	}
+
+	return "", nil
+}
+
+// grants reports whether the scopes include the given one
+func grants(scopes []string, scope string) bool {
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, cfg.ScopeAdmin)
+}
+
+// create adds a token with the scopes and returns its secret, which is not stored
+func (t *Tokens) create(name string, scopes []string) (string, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if name == "" || len(scopes) == 0 {
+		return "", fmt.Errorf("a token needs a name and scopes")
+	}
+
+	for _, scope := range scopes {
+		if !cfg.ValidScope(scope) {
+			return "", fmt.Errorf("unknown scope '%s'", scope)
+		}
+	}
+
+	if _, ok := t.Created[name]; ok || slices.ContainsFunc(t.Config, func(c cfg.ConfigApiToken) bool { return c.Name == name }) {
+		return "", fmt.Errorf("a token named '%s' already exists", name)
+	}
+
+	secret := rand.Text()
+
+	t.Created[name] = Token{Name: name, Hash: hashToken(secret), Scopes: scopes, Created: time.Now()}
+
+	return secret, t.save()
+}
+
+// revoke deletes a created token or revokes a config token
+func (t *Tokens) revoke(name string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.Created[name]; ok {
+		delete(t.Created, name)
+		return t.save()
+	}
+
+	i := slices.IndexFunc(t.Config, func(c cfg.ConfigApiToken) bool { return c.Name == name })
+
+	if i < 0 {
+		return fmt.Errorf("no token named '%s'", name)
+	}
+
+	t.Revoked[hashToken(t.Config[i].Token)] = name
+
+	return t.save()
+}
+
+func (t *Tokens) list() []TokenInfo {
+	t.Lock()
+	defer t.Unlock()
+
+	var res []TokenInfo
+
+	for _, token := range t.Config {
+		_, revoked := t.Revoked[hashToken(token.Token)]
+		res = append(res, TokenInfo{Name: token.Name, Scopes: token.Scopes, Source: "config", Revoked: revoked})
+	}
+
+	for _, token := range t.Created {
+		res = append(res, TokenInfo{Name: token.Name, Scopes: token.Scopes, Source: "api", Created: token.Created})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res
+}
+
+// save writes the created and revoked tokens atomically, must be called with the lock held
+func (t *Tokens) save() error {
+	dat, err := json.Marshal(t)
+
+	if err != nil {
+		return err
+	}
+
+	tmp := t.file + ".tmp"
+
+	if err := os.WriteFile(tmp, dat, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, t.file)
+}
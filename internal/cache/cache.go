@@ -3,50 +3,186 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"sync"
 	"time"
-
-	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
 )
 
 type CacheData struct {
 	DbLastRowIdChat        uint64    `json:"dbLastRowIdChat"`
 	DbLastQueryServers     time.Time `json:"dbLastQueryServers"`
 	DiscordMessageIdStatus string    `json:"discordMessageIdStatus"`
+	LastSnapshot           time.Time `json:"lastSnapshot"`
+	LastArchive            time.Time `json:"lastArchive"`
+	LastSlaReport          time.Time `json:"lastSlaReport"`
+	LastPlaytimeReport     time.Time `json:"lastPlaytimeReport"`
+
+	// reminder key -> event start time, used to prune entries of past events
+	DeliveredReminders map[string]time.Time `json:"deliveredReminders"`
+
+	// status message ids by channel, if one status message per guild is configured
+	DiscordMessageIdsStatus map[string]string `json:"discordMessageIdsStatus"`
+
+	// runtime settings changed with /config set, applied on top of the config file
+	Settings map[string]string `json:"settings"`
+
+	// open outage incidents by server key
+	Incidents map[string]Incident `json:"incidents"`
+
+	// DM subscriptions by user ID
+	Subscriptions map[string]Subscription `json:"subscriptions"`
+
+	// language codes chosen with /language, by user ID
+	Languages map[string]string `json:"languages"`
+
+	// players by platform ID, so names can change without losing their statistics
+	Players map[string]PlayerIdentity `json:"players"`
+
+	// in-game announcements set with /announcements, nil if the ones of the config are used
+	Announcements []string `json:"announcements"`
+
+	// per-server threads of the status message by "<channel id>/<server key>"
+	StatusThreads map[string]StatusThread `json:"statusThreads"`
+
+	// bans issued with /ban by platform ID, applied to all servers of the ban sync group
+	Bans map[string]Ban `json:"bans"`
+
+	// hash of the avatar image set last, it's only uploaded again when changed
+	AvatarHash string `json:"avatarHash"`
+
+	// completed scheduled events, oldest first
+	PastEvents []PastEvent `json:"pastEvents"`
+
+	// wipe reminders sent and wipe actions run by "<wipe name>/<offset>", with the date of the wipe
+	Wipes map[string]time.Time `json:"wipes"`
+
+	// players whose joins and leaves are not announced, by lowercased name
+	OptOuts map[string]OptOut `json:"optOuts"`
+
+	// messages of the bot which are deleted once their lifetime is over
+	Expiring []ExpiringMessage `json:"expiring"`
+}
+
+type ExpiringMessage struct {
+	ChannelID string    `json:"channelID"`
+	MessageID string    `json:"messageID"`
+	DeleteAt  time.Time `json:"deleteAt"`
+}
+
+type OptOut struct {
+	// the discord user who linked the player with /privacy
+	UserID string `json:"userID"`
+	Name   string `json:"name"`
+
+	// the player is left out of the history and playtime too
+	NoStats bool `json:"noStats"`
+}
+
+type StatusThread struct {
+	ThreadID  string `json:"threadID"`
+	MessageID string `json:"messageID"`
+}
+
+type Ban struct {
+	Name     string    `json:"name"`
+	Reason   string    `json:"reason"`
+	BannedBy string    `json:"bannedBy"`
+	Time     time.Time `json:"time"`
+}
+
+type PastEvent struct {
+	ID         string    `json:"id"`
+	GuildID    string    `json:"guildID"`
+	Name       string    `json:"name"`
+	Location   string    `json:"location"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Interested int       `json:"interested"`
+
+	// players seen in game while the event ran, -1 without server status
+	Players int `json:"players"`
+}
+
+type PlayerIdentity struct {
+	Name     string        `json:"name"`
+	Previous []string      `json:"previous"`
+	Playtime time.Duration `json:"playtime"`
+	LastSeen time.Time     `json:"lastSeen"`
+
+	// keys of the servers the player was seen on
+	Servers []string `json:"servers,omitempty"`
+
+	// playtime by server key, in total and in the week starting at WeekStart and the week before
+	ServerPlaytime map[string]time.Duration `json:"serverPlaytime,omitempty"`
+	Week           map[string]time.Duration `json:"week,omitempty"`
+	WeekStart      time.Time                `json:"weekStart"`
+	LastWeek       map[string]time.Duration `json:"lastWeek,omitempty"`
+
+	// start of the current sessions by server key, while online
+	Sessions map[string]time.Time `json:"sessions,omitempty"`
+
+	// the last session which ended
+	Last Session `json:"last"`
+}
+
+type Session struct {
+	Server string    `json:"server"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+type Subscription struct {
+	// keys of the servers
+	Servers []string `json:"servers"`
+	Players []string `json:"players"`
+	Outages bool     `json:"outages"`
+	Events  bool     `json:"events"`
+
+	// one DM when two or more of the followed players are on the same server
+	Friends bool `json:"friends"`
+}
+
+type Incident struct {
+	ThreadID   string    `json:"threadID"`
+	Since      time.Time `json:"since"`
+	LastUpdate time.Time `json:"lastUpdate"`
 }
 
 type Store struct {
 	mu   sync.RWMutex
 	file string
 	data CacheData
-}
 
-var singletonStore *Store
+	// state directory, if the cache is kept as one file per section
+	dir     string
+	written map[string][]byte
+}
 
-func Init() error {
-	singletonStore = &Store{file: cfg.Config.CachePath}
+// Open loads the cache at the given path. A missing file results in an empty cache.
+func Open(file string) (*Store, error) {
+	s := &Store{file: file}
 
-	if err := singletonStore.load(); err != nil {
-		return err
+	if err := s.load(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s, nil
 }
 
 func (s *Store) save() error {
-	f, err := os.Create(s.file)
+	if s.dir != "" {
+		return s.saveDir()
+	}
+
+	dat, err := json.MarshalIndent(s.data, "", "  ")
 
 	if err != nil {
 		return err
 	}
 
-	defer f.Close()
-
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-
-	return enc.Encode(s.data)
+	return writeAtomic(s.file, dat)
 }
 
 func (s *Store) load() error {
@@ -65,26 +201,77 @@ func (s *Store) load() error {
 	return json.NewDecoder(f).Decode(&s.data)
 }
 
-func Update(fn func(*CacheData)) error {
-	if singletonStore == nil {
+func (s *Store) Update(fn func(*CacheData)) error {
+	if s == nil {
 		return fmt.Errorf("Cache not initialized")
 	}
 
-	singletonStore.mu.Lock()
-	defer singletonStore.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	fn(&singletonStore.data)
+	fn(&s.data)
 
-	return singletonStore.save()
+	return s.save()
 }
 
-func Get() (CacheData, error) {
-	if singletonStore == nil {
+// Get returns a copy of the cache. Maps and slices are copied too, so it can be read without the lock
+// while Update changes the cache.
+func (s *Store) Get() (CacheData, error) {
+	if s == nil {
 		return CacheData{}, fmt.Errorf("Cache not initialized")
 	}
 
-	singletonStore.mu.RLock()
-	defer singletonStore.mu.RUnlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.data.clone(), nil
+}
+
+// clone returns a deep copy of the cache data
+func (c CacheData) clone() CacheData {
+	res := c
+
+	res.DeliveredReminders = maps.Clone(c.DeliveredReminders)
+	res.DiscordMessageIdsStatus = maps.Clone(c.DiscordMessageIdsStatus)
+	res.Settings = maps.Clone(c.Settings)
+	res.Incidents = maps.Clone(c.Incidents)
+	res.Languages = maps.Clone(c.Languages)
+	res.Announcements = slices.Clone(c.Announcements)
+	res.StatusThreads = maps.Clone(c.StatusThreads)
+	res.Bans = maps.Clone(c.Bans)
+	res.PastEvents = slices.Clone(c.PastEvents)
+	res.Wipes = maps.Clone(c.Wipes)
+	res.OptOuts = maps.Clone(c.OptOuts)
+	res.Expiring = slices.Clone(c.Expiring)
+
+	if c.Subscriptions != nil {
+		res.Subscriptions = make(map[string]Subscription, len(c.Subscriptions))
+
+		for userID, sub := range c.Subscriptions {
+			sub.Servers = slices.Clone(sub.Servers)
+			sub.Players = slices.Clone(sub.Players)
+			res.Subscriptions[userID] = sub
+		}
+	}
+
+	if c.Players != nil {
+		res.Players = make(map[string]PlayerIdentity, len(c.Players))
+
+		for id, player := range c.Players {
+			res.Players[id] = player.clone()
+		}
+	}
+
+	return res
+}
+
+func (p PlayerIdentity) clone() PlayerIdentity {
+	p.Previous = slices.Clone(p.Previous)
+	p.Servers = slices.Clone(p.Servers)
+	p.ServerPlaytime = maps.Clone(p.ServerPlaytime)
+	p.Week = maps.Clone(p.Week)
+	p.LastWeek = maps.Clone(p.LastWeek)
+	p.Sessions = maps.Clone(p.Sessions)
 
-	return singletonStore.data, nil
+	return p
 }
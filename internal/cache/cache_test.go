@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetCopiesMaps(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "cache.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+
+	store.Update(func(k *CacheData) {
+		k.DeliveredReminders = map[string]time.Time{"1": start}
+		k.Players = map[string]PlayerIdentity{"1": {Name: "Alice", Sessions: map[string]time.Time{"island": start}}}
+	})
+
+	// the copy is read while the cache is changed, which fails with -race if they share the maps
+
+	cacheData, _ := store.Get()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		store.Update(func(k *CacheData) {
+			k.DeliveredReminders["2"] = start
+			k.Players["1"].Sessions["center"] = start
+		})
+	}()
+
+	for range cacheData.DeliveredReminders {
+	}
+
+	for range cacheData.Players["1"].Sessions {
+	}
+
+	wg.Wait()
+
+	if len(cacheData.DeliveredReminders) != 1 || len(cacheData.Players["1"].Sessions) != 1 {
+		t.Errorf("copy changed with the cache: %v, %v", cacheData.DeliveredReminders, cacheData.Players["1"].Sessions)
+	}
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// MigrateServer moves everything stored for the server with the key from to the server with the key to,
+// after a server was renamed or merged into another one. It returns the number of entries changed.
+func (s *Store) MigrateServer(from string, to string) (int, error) {
+	changed := 0
+
+	err := s.Update(func(k *CacheData) {
+		if incident, ok := k.Incidents[from]; ok {
+			delete(k.Incidents, from)
+
+			if _, open := k.Incidents[to]; !open {
+				k.Incidents[to] = incident
+			}
+
+			changed++
+		}
+
+		for user, sub := range k.Subscriptions {
+			if servers, ok := replaceKey(sub.Servers, from, to); ok {
+				sub.Servers = servers
+				k.Subscriptions[user] = sub
+				changed++
+			}
+		}
+
+		for id, player := range k.Players {
+			servers, replaced := replaceKey(player.Servers, from, to)
+			player.Servers = servers
+
+			if migratePlaytime(&player, from, to) || replaced {
+				k.Players[id] = player
+				changed++
+			}
+		}
+
+		// threads of the old server are kept if the new one has none yet, otherwise they are dropped
+
+		for key, thread := range k.StatusThreads {
+			channelID, server, _ := strings.Cut(key, "/")
+
+			if server != from {
+				continue
+			}
+
+			delete(k.StatusThreads, key)
+
+			if _, ok := k.StatusThreads[channelID+"/"+to]; !ok {
+				k.StatusThreads[channelID+"/"+to] = thread
+			}
+
+			changed++
+		}
+	})
+
+	return changed, err
+}
+
+// migratePlaytime adds the playtime on the server from to the server to, false if the player never played on from
+func migratePlaytime(p *PlayerIdentity, from string, to string) bool {
+	_, played := p.ServerPlaytime[from]
+
+	for _, m := range []map[string]time.Duration{p.ServerPlaytime, p.Week, p.LastWeek} {
+		if d, ok := m[from]; ok {
+			delete(m, from)
+			m[to] += d
+		}
+	}
+
+	if start, ok := p.Sessions[from]; ok {
+		delete(p.Sessions, from)
+
+		if _, open := p.Sessions[to]; !open {
+			p.Sessions[to] = start
+		}
+	}
+
+	if p.Last.Server == from {
+		p.Last.Server = to
+	}
+
+	return played
+}
+
+// replaceKey replaces the server key from with to, which is added only once
+func replaceKey(keys []string, from string, to string) ([]string, bool) {
+	if !slices.Contains(keys, from) {
+		return keys, false
+	}
+
+	res := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if key == from {
+			key = to
+		}
+
+		if !slices.Contains(res, key) {
+			res = append(res, key)
+		}
+	}
+
+	return res, true
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// PseudonymizePlayers stores the known players by the platform ID returned by fn, after the privacy setting
+// was changed. Players which end up with the same ID are merged. It returns the number of players changed.
+func (s *Store) PseudonymizePlayers(fn func(id string) string) (int, error) {
+	changed := 0
+
+	err := s.Update(func(k *CacheData) {
+		for id, identity := range k.Players {
+			stored := fn(id)
+
+			if stored == id {
+				continue
+			}
+
+			delete(k.Players, id)
+			changed++
+
+			if other, ok := k.Players[stored]; ok {
+				identity = mergeIdentities(identity, other)
+			}
+
+			k.Players[stored] = identity
+		}
+	})
+
+	return changed, err
+}
+
+// mergeIdentities combines two players, the name of the one seen last is kept
+func mergeIdentities(a PlayerIdentity, b PlayerIdentity) PlayerIdentity {
+	if b.LastSeen.After(a.LastSeen) {
+		a, b = b, a
+	}
+
+	a.Playtime += b.Playtime
+	a.ServerPlaytime = addPlaytime(a.ServerPlaytime, b.ServerPlaytime)
+
+	// the weeks are only added up if they are the same, otherwise the ones of the last seen player are kept
+
+	if a.WeekStart.Equal(b.WeekStart) {
+		a.Week = addPlaytime(a.Week, b.Week)
+		a.LastWeek = addPlaytime(a.LastWeek, b.LastWeek)
+	}
+
+	if a.Last.End.Before(b.Last.End) {
+		a.Last = b.Last
+	}
+
+	for server, start := range b.Sessions {
+		if _, ok := a.Sessions[server]; !ok {
+			if a.Sessions == nil {
+				a.Sessions = make(map[string]time.Time)
+			}
+
+			a.Sessions[server] = start
+		}
+	}
+
+	for _, name := range append([]string{b.Name}, b.Previous...) {
+		if name != a.Name && !slices.Contains(a.Previous, name) {
+			a.Previous = append(a.Previous, name)
+		}
+	}
+
+	for _, server := range b.Servers {
+		if !slices.Contains(a.Servers, server) {
+			a.Servers = append(a.Servers, server)
+		}
+	}
+
+	return a
+}
+
+// addPlaytime adds the playtime by server of b to a
+func addPlaytime(a map[string]time.Duration, b map[string]time.Duration) map[string]time.Duration {
+	if len(b) == 0 {
+		return a
+	}
+
+	if a == nil {
+		a = make(map[string]time.Duration)
+	}
+
+	for server, d := range b {
+		a[server] += d
+	}
+
+	return a
+}
+
+// ForgetUser deletes the subscriptions, the language and the privacy settings of the discord user, false if
+// nothing was stored
+func (s *Store) ForgetUser(userID string) (bool, error) {
+	found := false
+
+	err := s.Update(func(k *CacheData) {
+		_, subscribed := k.Subscriptions[userID]
+		_, language := k.Languages[userID]
+
+		delete(k.Subscriptions, userID)
+		delete(k.Languages, userID)
+
+		found = subscribed || language
+
+		for key, optOut := range k.OptOuts {
+			if optOut.UserID == userID {
+				delete(k.OptOuts, key)
+				found = true
+			}
+		}
+	})
+
+	return found, err
+}
+
+// ForgetPlayer deletes the known player with the (stored) platform ID, which may be empty for players without
+// one, and removes its names from the followed players of the subscriptions. It returns all names of the player
+// and the number of entries deleted.
+func (s *Store) ForgetPlayer(id string, names []string) ([]string, int, error) {
+	deleted := 0
+
+	err := s.Update(func(k *CacheData) {
+		if identity, ok := k.Players[id]; ok {
+			delete(k.Players, id)
+			deleted++
+
+			for _, name := range append([]string{identity.Name}, identity.Previous...) {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if _, ok := k.OptOuts[strings.ToLower(utils.SanitizeName(name))]; ok {
+				delete(k.OptOuts, strings.ToLower(utils.SanitizeName(name)))
+				deleted++
+			}
+		}
+
+		for userID, sub := range k.Subscriptions {
+			players := slices.DeleteFunc(slices.Clone(sub.Players), func(p string) bool {
+				return slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(p, name) })
+			})
+
+			if len(players) != len(sub.Players) {
+				sub.Players = players
+				k.Subscriptions[userID] = sub
+				deleted++
+			}
+		}
+	})
+
+	return names, deleted, err
+}
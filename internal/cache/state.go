@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// version of the state files, increased on incompatible changes
+const stateVersion = 1
+
+type stateFile struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// OpenDir loads the cache from the state directory, which has one file per section of the cache (e.g.
+// statusThreads.json), so a write only replaces the sections which changed. If the directory has no state
+// yet, the cache file of older versions at legacyFile is imported and renamed to <legacyFile>.migrated.
+func OpenDir(dir string, legacyFile string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, written: make(map[string][]byte)}
+
+	if err := s.loadDir(); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(legacyFile); len(s.written) > 0 || legacyFile == "" || err != nil {
+		return s, nil
+	}
+
+	slog.Info(fmt.Sprintf("Migrating cache %s to state directory %s", legacyFile, dir))
+
+	legacy := &Store{file: legacyFile}
+
+	if err := legacy.load(); err != nil {
+		return nil, fmt.Errorf("failed to read cache %s for migration: %w", legacyFile, err)
+	}
+
+	s.data = legacy.data
+
+	if err := s.saveDir(); err != nil {
+		return nil, err
+	}
+
+	return s, os.Rename(legacyFile, legacyFile+".migrated")
+}
+
+func (s *Store) loadDir() error {
+	entries, err := os.ReadDir(s.dir)
+
+	if err != nil {
+		return err
+	}
+
+	sections := make(map[string]json.RawMessage)
+	known := sectionNames()
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+
+		// other files in the directory, like the reminder store, are not sections of the cache
+
+		if !ok || entry.IsDir() || !known[name] {
+			continue
+		}
+
+		dat, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+
+		if err != nil {
+			return err
+		}
+
+		var state stateFile
+
+		if err := json.Unmarshal(dat, &state); err != nil {
+			return fmt.Errorf("failed to parse state file %s: %w", entry.Name(), err)
+		}
+
+		if state.Version > stateVersion {
+			return fmt.Errorf("state file %s was written by a newer version of the bot (version %d)", entry.Name(), state.Version)
+		}
+
+		sections[name] = state.Data
+		s.written[name] = dat
+	}
+
+	dat, err := json.Marshal(sections)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(dat, &s.data)
+}
+
+// sectionNames returns the JSON names of the sections of the cache, one state file each
+func sectionNames() map[string]bool {
+	res := make(map[string]bool)
+	t := reflect.TypeFor[CacheData]()
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+
+		if name != "" && name != "-" {
+			res[name] = true
+		}
+	}
+
+	return res
+}
+
+// saveDir writes the sections which changed since they were written last
+func (s *Store) saveDir() error {
+	dat, err := json.Marshal(s.data)
+
+	if err != nil {
+		return err
+	}
+
+	var sections map[string]json.RawMessage
+
+	if err := json.Unmarshal(dat, &sections); err != nil {
+		return err
+	}
+
+	for name, section := range sections {
+		dat, err := json.MarshalIndent(stateFile{Version: stateVersion, Data: section}, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(s.written[name], dat) {
+			continue
+		}
+
+		if err := writeAtomic(filepath.Join(s.dir, name+".json"), dat); err != nil {
+			return err
+		}
+
+		s.written[name] = dat
+	}
+
+	return nil
+}
+
+// writeAtomic replaces the file with the data, a crash leaves either the old or the new content
+func writeAtomic(file string, dat []byte) error {
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(dat); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
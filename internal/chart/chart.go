@@ -0,0 +1,95 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+const panelWidth = 720
+const panelHeight = 120
+const padding = 8
+
+var (
+	colorBackground = color.RGBA{0x2b, 0x2d, 0x31, 0xff}
+	colorPanel      = color.RGBA{0x1e, 0x1f, 0x22, 0xff}
+	colorGrid       = color.RGBA{0x3f, 0x41, 0x47, 0xff}
+	colorBar        = color.RGBA{0x58, 0x65, 0xf2, 0xff} // Discord blurple
+	colorDown       = color.RGBA{0xc1, 0x12, 0x1f, 0xff}
+)
+
+// Panel is one bar chart, values are scaled to Max. Buckets flagged in Down are drawn
+// as a full height red bar.
+type Panel struct {
+	Values []float64
+	Down   []bool
+	Max    float64
+}
+
+// Render draws the given panels stacked on top of each other and returns the PNG image.
+func Render(panels []Panel) (*bytes.Buffer, error) {
+	height := padding + len(panels)*(panelHeight+padding)
+	img := image.NewRGBA(image.Rect(0, 0, panelWidth+2*padding, height))
+
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	for i, p := range panels {
+		top := padding + i*(panelHeight+padding)
+		area := image.Rect(padding, top, padding+panelWidth, top+panelHeight)
+
+		drawPanel(img, area, p)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func drawPanel(img *image.RGBA, area image.Rectangle, p Panel) {
+	draw.Draw(img, area, &image.Uniform{colorPanel}, image.Point{}, draw.Src)
+
+	// grid lines at 25%, 50% and 75%
+
+	for q := 1; q < 4; q++ {
+		y := area.Max.Y - area.Dy()*q/4
+		draw.Draw(img, image.Rect(area.Min.X, y, area.Max.X, y+1), &image.Uniform{colorGrid}, image.Point{}, draw.Src)
+	}
+
+	if len(p.Values) == 0 {
+		return
+	}
+
+	barWidth := float64(area.Dx()) / float64(len(p.Values))
+
+	for i, v := range p.Values {
+		x0 := area.Min.X + int(float64(i)*barWidth)
+		x1 := area.Min.X + int(float64(i+1)*barWidth)
+
+		if x1 > x0+1 {
+			x1-- // small gap between bars
+		}
+
+		if i < len(p.Down) && p.Down[i] {
+			draw.Draw(img, image.Rect(x0, area.Min.Y, x1, area.Max.Y), &image.Uniform{colorDown}, image.Point{}, draw.Src)
+			continue
+		}
+
+		if p.Max <= 0 || v <= 0 {
+			continue
+		}
+
+		h := int(v / p.Max * float64(area.Dy()))
+
+		if h > area.Dy() {
+			h = area.Dy()
+		}
+
+		draw.Draw(img, image.Rect(x0, area.Max.Y-h, x1, area.Max.Y), &image.Uniform{colorBar}, image.Point{}, draw.Src)
+	}
+}
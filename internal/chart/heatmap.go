@@ -0,0 +1,74 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+const heatmapCell = 28
+const heatmapGap = 2
+
+// Heatmap is a grid of values, e.g. weekdays by hours. Cells are shaded by their value relative
+// to Max, cells flagged in Empty have no data and are left blank.
+type Heatmap struct {
+	Values [][]float64
+	Empty  [][]bool
+	Max    float64
+}
+
+// RenderHeatmap draws the rows of the heatmap top to bottom and returns the PNG image.
+func RenderHeatmap(h Heatmap) (*bytes.Buffer, error) {
+	columns := 0
+
+	for _, row := range h.Values {
+		columns = max(columns, len(row))
+	}
+
+	width := padding*2 + columns*heatmapCell
+	height := padding*2 + len(h.Values)*heatmapCell
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	for y, row := range h.Values {
+		for x, v := range row {
+			x0 := padding + x*heatmapCell
+			y0 := padding + y*heatmapCell
+			cell := image.Rect(x0, y0, x0+heatmapCell-heatmapGap, y0+heatmapCell-heatmapGap)
+
+			c := colorPanel
+
+			if y >= len(h.Empty) || x >= len(h.Empty[y]) || !h.Empty[y][x] {
+				c = shade(v, h.Max)
+			}
+
+			draw.Draw(img, cell, &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// shade blends from the grid color (no players) to the bar color (max)
+func shade(v float64, max float64) color.RGBA {
+	f := 0.0
+
+	if max > 0 {
+		f = min(v/max, 1)
+	}
+
+	blend := func(a uint8, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f)
+	}
+
+	return color.RGBA{blend(colorGrid.R, colorBar.R), blend(colorGrid.G, colorBar.G), blend(colorGrid.B, colorBar.B), 0xff}
+}
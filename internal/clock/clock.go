@@ -0,0 +1,194 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time and of timers. The bot uses Real, tests can use a Fake to
+// control time deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{t: time.NewTicker(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// Fake is a clock which only moves when advanced. Timers and tickers fire during Advance, like the
+// real ones their channels hold at most one pending tick.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Set moves the clock to the given time and fires all timers due until then
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+	f.fire()
+}
+
+// Advance moves the clock forward and fires all timers due until then
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.schedule(d, 0)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+
+	return fakeTicker{f.schedule(d, d)}
+}
+
+func (f *Fake) schedule(d time.Duration, period time.Duration) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{fake: f, c: make(chan time.Time, 1), at: f.now.Add(d), period: period, active: true}
+
+	f.waiters = append(f.waiters, t)
+	f.fire()
+
+	return t
+}
+
+// fire delivers the ticks of all due timers, must be called with the lock held
+func (f *Fake) fire() {
+	var remaining []*fakeTimer
+
+	for _, t := range f.waiters {
+		for t.active && !t.at.After(f.now) {
+			select {
+			case t.c <- t.at:
+			default:
+			}
+
+			if t.period > 0 {
+				t.at = t.at.Add(t.period)
+			} else {
+				t.active = false
+			}
+		}
+
+		if t.active {
+			remaining = append(remaining, t)
+		}
+	}
+
+	f.waiters = remaining
+}
+
+type fakeTimer struct {
+	fake   *Fake
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.fake
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	wasActive := t.active
+	t.at = f.now.Add(d)
+	t.active = true
+
+	if !slices.Contains(f.waiters, t) {
+		f.waiters = append(f.waiters, t)
+	}
+
+	f.fire()
+
+	return wasActive
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
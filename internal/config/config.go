@@ -1,173 +1,1579 @@
 package config
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
 )
 
-var Version string
+var Version string
+
+// server types, selecting how the server is queried
+const ServerTypeArkAse = "ark-ase"
+const ServerTypeArkAsa = "ark-asa"
+const ServerTypeRust = "rust"
+const ServerTypeValheim = "valheim"
+const ServerTypeSource = "source"
+const ServerTypeSquad = "squad"
+
+var ServerTypes = []string{ServerTypeArkAse, ServerTypeArkAsa, ServerTypeRust, ServerTypeValheim, ServerTypeSource, ServerTypeSquad}
+
+// server types whose status comes from the database of the status plugin
+var DbServerTypes = []string{ServerTypeArkAse, ServerTypeArkAsa}
+
+// server types whose game chat can be read and written via RCON
+var ChatServerTypes = []string{ServerTypeArkAse, ServerTypeArkAsa, ServerTypeRust}
+
+type ConfigRconServer struct {
+	Type     string   `json:"type"`
+	Address  string   `json:"address"`
+	Name     string   `json:"name"`
+	Map      string   `json:"map"`
+	Password string   `json:"password"`
+	Mods     []string `json:"mods"`
+
+	// identifies the server in references like guild servers, in caches and in the history, defaults to
+	// the name. Set it to rename a server without losing its state, or for servers with the same name.
+	Key string `json:"key"`
+
+	// name of the bot the server belongs to, set on load
+	Bot string `json:"-"`
+
+	// alternative password sources, re-read whenever authentication fails. The command is split into
+	// arguments at spaces, arguments containing spaces are put in single or double quotes.
+	PasswordFile    string `json:"passwordFile"`
+	PasswordEnv     string `json:"passwordEnv"`
+	PasswordCommand string `json:"passwordCommand"`
+
+	// public game address (ip:port) and optional join password shown in the status embed if ShowConnect is set
+	ShowConnect     bool   `json:"showConnect"`
+	ConnectAddress  string `json:"connectAddress"`
+	ConnectPassword string `json:"connectPassword"`
+
+	// valheim only: server log to take the player names from, the address is the A2S query port
+	LogFile string `json:"logFile"`
+
+	// known restart windows in the bot's time zone, e.g. "04:00-04:10", in which the server is expected to be unreachable
+	RestartWindows []string `json:"restartWindows"`
+
+	// shown in front of the server name in the status embed and join/leave messages. The emoji may be
+	// a custom one like "<:island:123456789>".
+	Emoji   string `json:"emoji"`
+	IconURL string `json:"iconURL"`
+
+	// player slots, the snapshot forecast warns when the expected peak comes close
+	MaxPlayers int `json:"maxPlayers"`
+
+	// labels like "pvp", "modded" or "seasonal" to filter commands and status messages by
+	Tags []string `json:"tags"`
+
+	// shown in front of the game chat lines relayed to channelIDGameChat, defaults to the name
+	ChatPrefix string `json:"chatPrefix"`
+}
+
+// ServerByKey returns the server with the given key
+func ServerByKey(servers []ConfigRconServer, key string) (ConfigRconServer, bool) {
+	idx := slices.IndexFunc(servers, func(s ConfigRconServer) bool { return s.Key == key })
+
+	if idx < 0 {
+		return ConfigRconServer{}, false
+	}
+
+	return servers[idx], true
+}
+
+// ServerName returns the name of the server with the given key, the key itself for unknown servers
+func ServerName(servers []ConfigRconServer, key string) string {
+	if server, ok := ServerByKey(servers, key); ok {
+		return server.Name
+	}
+
+	return key
+}
+
+// HasTag returns if the server is tagged with the given tag, ignoring case
+func (s ConfigRconServer) HasTag(tag string) bool {
+	return slices.ContainsFunc(s.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// Tagged returns the servers with the given tag
+func Tagged(servers []ConfigRconServer, tag string) []ConfigRconServer {
+	var res []ConfigRconServer
+
+	for _, server := range servers {
+		if server.HasTag(tag) {
+			res = append(res, server)
+		}
+	}
+
+	return res
+}
+
+type ConfigRcon struct {
+	Servers           []ConfigRconServer `json:"servers"`
+	QueryEverySeconds int                `json:"queryEverySeconds"`
+}
+
+// ConfigStatusGuild is a status message in a guild of its own, showing only the given servers
+type ConfigStatusGuild struct {
+	GuildID   string   `json:"guildID" snowflake:"true"`
+	ChannelID string   `json:"channelID" snowflake:"true"`
+	Servers   []string `json:"servers"`
+	Pin       bool     `json:"pin"`
+
+	// the servers with any of the tags are shown in addition to Servers
+	Tags []string `json:"tags"`
+
+	// overrides the branding of the server status, field by field
+	Branding *ConfigBranding `json:"branding"`
+}
+
+// ConfigBranding customizes the status message and the bot's nickname
+type ConfigBranding struct {
+	// heading of the status message, default "# Server status"
+	Title string `json:"title"`
+
+	// author line of the first embed (unless it shows a server icon) and footer of the last one
+	AuthorName    string `json:"authorName"`
+	AuthorIconURL string `json:"authorIconURL"`
+	AuthorURL     string `json:"authorURL"`
+	Footer        string `json:"footer"`
+	FooterIconURL string `json:"footerIconURL"`
+
+	// nickname of the bot in the guild, set on startup
+	Nickname string `json:"nickname"`
+}
+
+// merge returns the branding with the fields set in override replaced
+func (b *ConfigBranding) merge(override *ConfigBranding) *ConfigBranding {
+	var res ConfigBranding
+
+	if b != nil {
+		res = *b
+	}
+
+	if override == nil {
+		return &res
+	}
+
+	for _, f := range []struct{ dst, src *string }{
+		{&res.Title, &override.Title},
+		{&res.AuthorName, &override.AuthorName},
+		{&res.AuthorIconURL, &override.AuthorIconURL},
+		{&res.AuthorURL, &override.AuthorURL},
+		{&res.Footer, &override.Footer},
+		{&res.FooterIconURL, &override.FooterIconURL},
+		{&res.Nickname, &override.Nickname},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+
+	return &res
+}
+
+type ConfigServerStatus struct {
+	Rcon ConfigRcon `json:"rcon"`
+
+	// database of the ARK status plugin, required for ARK servers. The other server types have no database
+	// and are queried via RCON only.
+	DbConnection       string `json:"DbConnection"`
+	ChannelID          string `json:"channelID" snowflake:"true"`
+	ChannelIDJoinLeave string `json:"channelIDJoinLeave" snowflake:"true"`
+	ShowJoinLeave      bool   `json:"showJoinLeave"`
+	ArchiveJoinLeave   bool   `json:"archiveJoinLeave"`
+	PurgeJoinLeave     bool   `json:"purgeJoinLeave"`
+	ChannelIDSnapshot  string `json:"channelIDSnapshot" snowflake:"true"`
+	SnapshotTime       string `json:"snapshotTime"`
+	ShowButtons        bool   `json:"showButtons"`
+	ChannelIDSla       string `json:"channelIDSla" snowflake:"true"`
+
+	// adds the expected peak for the rest of the day to the snapshot, from the same weekday of the past weeks
+	SnapshotForecast bool `json:"snapshotForecast"`
+
+	// the status message only shows the player counts, each server gets a thread in the status channel
+	// with its player list and recent activity
+	StatusThreads bool `json:"statusThreads"`
+
+	// a player leaving a server and joining another one within this window is reported as move
+	// instead of leave and join, this delays the leave messages by up to the window ("0" by default)
+	TransferWindow    time.Duration `json:"-"`
+	TransferWindowRaw string        `json:"transferWindow"`
+
+	// join/leave messages are deleted after this (e.g. "24 hours"), they are kept by default. Must be at
+	// least 31 days with ArchiveJoinLeave, which archives the messages of a month once it is over.
+	JoinLeaveLifetime    time.Duration `json:"-"`
+	JoinLeaveLifetimeRaw string        `json:"joinLeaveLifetime"`
+
+	// alerts when a server with maxPlayers is projected to be full within FullWithin (default 15 minutes),
+	// based on its join rate during the past FullWithin
+	ChannelIDFull string        `json:"channelIDFull" snowflake:"true"`
+	FullWithin    time.Duration `json:"-"`
+	FullWithinRaw string        `json:"fullWithin"`
+
+	ChannelIDIncidents   string        `json:"channelIDIncidents" snowflake:"true"`
+	IncidentThreshold    time.Duration `json:"-"`
+	IncidentThresholdRaw string        `json:"incidentThreshold"`
+
+	// the incidents channel is an announcement channel, the start and end of outages are crossposted to
+	// the servers following it
+	CrosspostOutages bool `json:"crosspostOutages"`
+
+	// branding of the status message, each guild can override it
+	Branding *ConfigBranding `json:"branding"`
+
+	// with multiple guilds, one status message per guild instead of the one in ChannelID
+	Guilds []ConfigStatusGuild `json:"guilds"`
+	// the status message is marked stale when no server was polled successfully for this many intervals (default 3)
+	StaleAfterPolls int `json:"staleAfterPolls"`
+	// post "X is now known as Y" in the join/leave channel when a player with a platform ID changed the name
+	ShowRenames bool `json:"showRenames"`
+	// player names are shown sanitized (invisible characters and excess emoji removed), this adds the
+	// name as reported by the server in inline code whenever the two differ
+	ShowRawNames bool `json:"showRawNames"`
+
+	Announcements *ConfigAnnouncements `json:"announcements"`
+	BanSync       *ConfigBanSync       `json:"banSync"`
+
+	// seasons ending with a wipe of their servers
+	Wipes []ConfigWipe `json:"wipes"`
+
+	// moderator channel for the reports filed with /report, the command is available if set
+	ChannelIDReports string `json:"channelIDReports" snowflake:"true"`
+
+	// the game chat of all servers supporting it is posted to this channel
+	ChannelIDGameChat string `json:"channelIDGameChat" snowflake:"true"`
+
+	// the top 10 players by playtime of the past week are posted to this channel every monday
+	ChannelIDPlaytime string `json:"channelIDPlaytime" snowflake:"true"`
+
+	// the bot's time zones
+	Timezones *Timezones `json:"-"`
+
+	// the bot's languages
+	Languages *Languages `json:"-"`
+}
+
+// bans issued with /ban are applied to all servers of the group. The ban lists of the servers are compared
+// every reconcile interval (default 1 hour, "0" disables), differences are reported to the admin channel.
+type ConfigBanSync struct {
+	// servers of the group, all servers if empty
+	Servers              []string      `json:"servers"`
+	ReconcileInterval    time.Duration `json:"-"`
+	ReconcileIntervalRaw string        `json:"reconcileInterval"`
+}
+
+// maintenance actions which can run at a wipe, see /maintenance
+var WipeActions = []string{"save", "wipe-dinos", "restart"}
+
+// MaintenanceCommands are the RCON commands of the maintenance actions by server type, an action is not
+// supported on the types not listed
+var MaintenanceCommands = map[string]map[string][]string{
+	"save":       {ServerTypeArkAse: {"SaveWorld"}, ServerTypeArkAsa: {"SaveWorld"}, ServerTypeRust: {"server.save"}},
+	"wipe-dinos": {ServerTypeArkAse: {"DestroyWildDinos"}, ServerTypeArkAsa: {"DestroyWildDinos"}},
+	"restart": {ServerTypeArkAse: {"SaveWorld", "DoExit"}, ServerTypeArkAsa: {"SaveWorld", "DoExit"}, ServerTypeRust: {"server.save", "quit"},
+		ServerTypeSource: {"quit"}},
+}
+
+// ConfigWipe is the end of the season of a group of servers. Their status embeds count down to the wipe,
+// and reminders are broadcast in game (and posted to the channel, if set) at the reminder offsets before it.
+type ConfigWipe struct {
+	Name string `json:"name"`
+
+	// servers of the group, all servers if empty
+	Servers []string `json:"servers"`
+
+	// as "2006-01-02 15:04" in the bot's time zone
+	Date    time.Time `json:"-"`
+	DateRaw string    `json:"date"`
+
+	// default 7 days, 1 day and 1 hour
+	ReminderOffsets    []time.Duration `json:"-"`
+	ReminderOffsetsRaw []string        `json:"reminderOffsets"`
+
+	ChannelID string `json:"channelID" snowflake:"true"`
+
+	// maintenance action run on the servers at the wipe, the results are posted to the admin channel
+	Action string `json:"action"`
+}
+
+// Includes returns if the server with the given key is wiped
+func (w ConfigWipe) Includes(serverKey string) bool {
+	return len(w.Servers) == 0 || slices.Contains(w.Servers, serverKey)
+}
+
+// NextWipe returns the next wipe of the server with the given key
+func (c *ConfigServerStatus) NextWipe(serverKey string, now time.Time) (ConfigWipe, bool) {
+	var res ConfigWipe
+
+	for _, wipe := range c.Wipes {
+		if wipe.Date.After(now) && wipe.Includes(serverKey) && (res.Date.IsZero() || wipe.Date.Before(res.Date)) {
+			res = wipe
+		}
+	}
+
+	return res, !res.Date.IsZero()
+}
+
+// in-game broadcasts sent one after another, one every interval (default 30 minutes). Admins can
+// replace the messages with /announcements, which takes precedence over the config.
+type ConfigAnnouncements struct {
+	Messages    []string      `json:"messages"`
+	Interval    time.Duration `json:"-"`
+	IntervalRaw string        `json:"interval"`
+
+	// servers to broadcast to, all servers if empty
+	Servers []string `json:"servers"`
+}
+
+// modes of the instance lock
+const InstanceLockRefuse = "refuse"
+const InstanceLockStandby = "standby"
+const InstanceLockOff = "off"
+
+// reminder queue backends
+const ReminderStoreMemory = "memory"
+const ReminderStoreFile = "file"
+const ReminderStoreSqlite = "sqlite"
+const ReminderStoreDatabase = "database"
+
+// characters of bot names which are not allowed in table names
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9_]`)
+
+type ConfigEventer struct {
+	ChannelID          string          `json:"channelID" snowflake:"true"`
+	ReminderOffsets    []time.Duration `json:"-"`
+	ReminderOffsetsRaw []string        `json:"reminderOffsets"`
+	MentionCooldown    time.Duration   `json:"-"`
+	MentionCooldownRaw string          `json:"mentionCooldown"`
+	MentionDigest      bool            `json:"mentionDigest"`
+
+	// don't post a notice when an upcoming event is deleted
+	SkipCancelNotice bool `json:"skipCancelNotice"`
+
+	// the channel is an announcement channel, the notices of new events are crossposted to the servers following it
+	CrosspostEvents bool `json:"crosspostEvents"`
+
+	// re-fetch the scheduled events this often to heal missed gateway events (default 30 minutes, "0" disables)
+	ResyncInterval    time.Duration `json:"-"`
+	ResyncIntervalRaw string        `json:"resyncInterval"`
+
+	// keyword (case insensitive) in the location of external events -> key of the server to broadcast the reminders to
+	LocationServers map[string]string `json:"locationServers"`
+
+	// when a voice or stage event starts, link its channel (optionally mentioning the interested users)
+	// and post who attended once the event ended
+	VoicePing         bool `json:"voicePing"`
+	MentionInterested bool `json:"mentionInterested"`
+
+	// set events active at their start and completed at their end, or after the default duration (2 hours)
+	AutoManage         bool          `json:"autoManage"`
+	DefaultDuration    time.Duration `json:"-"`
+	DefaultDurationRaw string        `json:"defaultDuration"`
+
+	// where pending reminders are kept: memory (default), file, sqlite or database (the database of the
+	// config, in a table of the bot). The path defaults to the cache path with a "-reminders" suffix.
+	ReminderStore      string `json:"reminderStore"`
+	ReminderStorePath  string `json:"reminderStorePath"`
+	ReminderStoreTable string `json:"-"`
+
+	// post reminders as embed with the event's cover image and a button linking the event, instead of plain text
+	RichReminders *ConfigRichReminders `json:"richReminders"`
+
+	// add a button to reminders which sends the clicking user a DM 10 minutes later
+	SnoozeButton bool `json:"snoozeButton"`
+
+	// reminders are deleted after this (e.g. "1 day"), they are kept by default
+	ReminderLifetime    time.Duration `json:"-"`
+	ReminderLifetimeRaw string        `json:"reminderLifetime"`
+
+	// once an event completed, post who was interested and which players were online during the event
+	// (on the server of its location, or on all servers)
+	AttendanceRecap bool `json:"attendanceRecap"`
+
+	// create events from the in-game chat relayed by crosschat, e.g. "!event Boss fight Saturday 20:00"
+	GameTrigger *ConfigGameTrigger `json:"gameTrigger"`
+
+	// the bot's time zones, defaulting to Europe/Berlin
+	Timezones *Timezones `json:"-"`
+
+	// the bot's languages, defaulting to german
+	Languages *Languages `json:"-"`
+}
+
+type ConfigGameTrigger struct {
+	// the chat prefix, defaults to "!event"
+	Keyword string `json:"keyword"`
+
+	// the guild the events are created in
+	GuildID string `json:"guildID" snowflake:"true"`
+
+	// player names or platform IDs allowed to create events
+	Organizers []string `json:"organizers"`
+}
+
+const RichReminderFieldStart = "start"
+const RichReminderFieldDuration = "duration"
+const RichReminderFieldLocation = "location"
+
+var RichReminderFields = []string{RichReminderFieldStart, RichReminderFieldDuration, RichReminderFieldLocation}
+
+type ConfigRichReminders struct {
+	// embed color, defaults to discord blurple
+	Color int `json:"color"`
+
+	// fields shown below the reminder text, in this order (default all: start, duration, location)
+	Fields []string `json:"fields"`
+
+	HideImage   bool   `json:"hideImage"`
+	ButtonLabel string `json:"buttonLabel"`
+}
+
+type ConfigAdmin struct {
+	RoleIDs            []string      `json:"roleIDs" snowflake:"true"`
+	ChannelID          string        `json:"channelID" snowflake:"true"`
+	ApprovalTimeout    time.Duration `json:"-"`
+	ApprovalTimeoutRaw string        `json:"approvalTimeout"`
+
+	// members with one of these roles can send any command to a server with /rcon, which is only
+	// available if set
+	RconRoleIDs []string `json:"rconRoleIDs" snowflake:"true"`
+}
+
+type ConfigCrosschat struct {
+	ChannelID             string `json:"channelID" snowflake:"true"`
+	DbConnection          string `json:"DbConnection"`
+	WebhookCrosschat      string `json:"WebhookCrosschat"`
+	WebhookIdCrosschat    string `json:"-"`
+	WebhookTokenCrosschat string `json:"-"`
+
+	// server status servers whose chat is relayed via RCON (e.g. rust), the database is optional then
+	Servers     []string           `json:"servers"`
+	RconServers []ConfigRconServer `json:"-"`
+
+	// answer "!players" and "!nextevent" in the game chat
+	GameCommands bool `json:"gameCommands"`
+
+	// watch list of the game chat, matching messages are reported to the moderators
+	Filter *ConfigChatFilter `json:"filter"`
+}
+
+// ConfigChatFilter reports game chat messages containing one of the keywords (case insensitive, whole
+// words) or matching one of the patterns (regular expressions, "(?i)" makes them case insensitive)
+type ConfigChatFilter struct {
+	Keywords []string         `json:"keywords"`
+	Patterns []string         `json:"patterns"`
+	Regexps  []*regexp.Regexp `json:"-"`
+
+	// moderator channel of the alerts, defaults to the admin channel
+	ChannelID string `json:"channelID" snowflake:"true"`
+
+	// sent to the game chat after a match, "{player}" is replaced by the name of the sender
+	Warning string `json:"warning"`
+
+	// matching messages are not relayed to discord
+	Hide bool `json:"hide"`
+}
+
+// ConfigErrorReports sends panics and repeated errors to sentry and/or a webhook
+type ConfigErrorReports struct {
+	SentryDSN   string `json:"sentryDSN"`
+	Environment string `json:"environment"`
+
+	// receives every report as JSON POST
+	Webhook string `json:"webhook"`
+
+	// an error is reported once it occurred Threshold times (default 3) within Window (default 1 hour),
+	// then at most once per Window
+	Threshold int           `json:"threshold"`
+	Window    time.Duration `json:"-"`
+	WindowRaw string        `json:"window"`
+}
+
+// API token scopes, admin includes all others
+const ScopeReadStatus = "read-status"
+const ScopeReadHistory = "read-history"
+const ScopeAdmin = "admin"
+
+// ConfigApi is the HTTP API serving status and history, every request needs a bearer token
+type ConfigApi struct {
+	Port int    `json:"port"`
+	Bind string `json:"bind"`
+
+	// serve the player count at /overlay/players without a token, for stream overlays like OBS browser sources
+	Overlay bool `json:"overlay"`
+
+	Tokens []ConfigApiToken `json:"tokens"`
+
+	// tokens created and revoked at runtime via the API
+	TokensFile string `json:"tokensFile"`
+
+	// serve HTTPS with the certificate and its key (PEM files), so the tokens aren't sent in plain text when
+	// the API is reachable beyond localhost
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type ConfigApiToken struct {
+	Name   string   `json:"name"`
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+// ConfigCooldown limits how often the public slash commands and buttons (those without permission checks)
+// can be used, so a busy guild can't make the bot hammer RCON or the discord API. A duration of "0 seconds" or
+// a maxConcurrent of -1 disables the respective limit.
+type ConfigCooldown struct {
+	PerUser       time.Duration `json:"-"`
+	PerUserRaw    string        `json:"perUser"`
+	PerChannel    time.Duration `json:"-"`
+	PerChannelRaw string        `json:"perChannel"`
+
+	// max. number of public commands and buttons handled at the same time
+	MaxConcurrent int `json:"maxConcurrent"`
+}
+
+// ConfigBot is everything tied to a single discord session. The first bot is configured
+// at the top level of the config file, additional bots (other tokens or shards) in "bots".
+type ConfigBot struct {
+	Name       string `json:"name"`
+	BotToken   string `json:"botToken"`
+	CachePath  string `json:"cachePath"`
+	StateDir   string `json:"-"`
+	ShardID    int    `json:"shardID"`
+	ShardCount int    `json:"shardCount"`
+
+	// image file set as avatar of the bot on startup, applies to all guilds
+	Avatar string `json:"avatar"`
+
+	// time zone (e.g. "America/New_York") of the schedules like snapshotTime, restartWindows and event
+	// triggers and of the dates shown, the host's time zone if empty (Europe/Berlin for the eventer).
+	// guildTimezones maps guild IDs to a time zone of their own.
+	Timezone       string            `json:"timezone"`
+	GuildTimezones map[string]string `json:"guildTimezones"`
+
+	// language code (e.g. "de") of the messages posted to the channels and of the replies in the game chat,
+	// english if empty (german for the eventer channel). guildLanguages maps guild IDs to a language of their own.
+	Language       string            `json:"language"`
+	GuildLanguages map[string]string `json:"guildLanguages"`
+
+	// the bot's languages, the game chat replies use the default one
+	Languages *Languages `json:"-"`
+
+	// lease held while the bot runs, see ConfigRoot.InstanceLock
+	LockFile string `json:"-"`
+
+	// limits of the public slash commands, defaults apply if omitted
+	Cooldown *ConfigCooldown `json:"cooldown,ommitempty"`
+
+	ServerStatus *ConfigServerStatus `json:"serverStatus,ommitempty"`
+	Eventer      *ConfigEventer      `json:"eventer,ommitempty"`
+	Admin        *ConfigAdmin        `json:"admin,ommitempty"`
+	Crosschat    *ConfigCrosschat    `json:"crosschat,ommitempty"`
+}
+
+type ConfigRoot struct {
+	LogFile     string `json:"logFile"`
+	HistoryPath string `json:"historyPath"`
+	Simulate    bool   `json:"-"`
+
+	// SQLite database keeping the join/leave events and the status of every poll, and the reminders of
+	// eventers with reminderStore "database". Nothing is stored in it if not set.
+	Database string `json:"database"`
+
+	// directory for the state (cache, history, reminders, API tokens) instead of files in the working directory,
+	// every bot keeps its cache in a subdirectory with one versioned file per section
+	StateDir string `json:"stateDir"`
+
+	// what to do when another instance runs the same bot: "refuse" (default) to exit, "standby" to wait
+	// until it disappears and take over, or "off". Standby needs the state of both instances on a shared disk.
+	InstanceLock string `json:"instanceLock"`
+
+	RetryQueueSize int    `json:"retryQueueSize"`
+	DeadLetterFile string `json:"deadLetterFile"`
+
+	PprofPort int `json:"pprofPort"`
+
+	// port of the Prometheus metrics endpoint at /metrics, disabled if 0
+	MetricsPort int `json:"metricsPort"`
+
+	// refreshed/open only while all loops are healthy, for docker HEALTHCHECK or a watchdog
+	HeartbeatFile string `json:"heartbeatFile"`
+	HealthPort    int    `json:"healthPort"`
+
+	// port of the HTTP health endpoints /healthz (all loops are running) and /readyz (additionally the bots
+	// are connected to discord, and one of the RCON servers of each bot was reachable within the last
+	// readyPolls polls, 3 by default), disabled if 0
+	HealthHTTPPort int `json:"healthHTTPPort"`
+	ReadyPolls     int `json:"readyPolls"`
+
+	Api *ConfigApi `json:"api,ommitempty"`
+
+	ErrorReports *ConfigErrorReports `json:"errorReports,ommitempty"`
+
+	Privacy *ConfigPrivacy `json:"privacy"`
+
+	Tracing *struct {
+		Endpoint    string  `json:"endpoint"`
+		Insecure    bool    `json:"insecure"`
+		ServiceName string  `json:"serviceName"`
+		SampleRatio float64 `json:"sampleRatio"`
+	} `json:"tracing,ommitempty"`
+
+	ConfigBot
+
+	Bots []*ConfigBot `json:"bots"`
+
+	// profile name -> settings which override the top level settings when the
+	// profile is selected with --profile
+	Profiles map[string]json.RawMessage `json:"profiles,ommitempty"`
+}
+
+var Config ConfigRoot
+
+// AllBots returns the top level bot (if configured) followed by all additional bots
+func (c *ConfigRoot) AllBots() []*ConfigBot {
+	var res []*ConfigBot
+
+	if c.BotToken != "" {
+		res = append(res, &c.ConfigBot)
+	}
+
+	return append(res, c.Bots...)
+}
+
+// the command line flags, kept for reloading the config
+var configFile string
+var profile string
+var sets overrides
+
+// ConfigFile returns the path of the config file in use
+func ConfigFile() string {
+	return configFile
+}
+
+func ParseConfig() {
+	flag.StringVar(&configFile, "config-file", "", "Path to the JSON configuration file")
+	flag.StringVar(&profile, "profile", "", "Name of the configuration profile to use")
+	flag.Var(&sets, "set", "Override a config value, e.g. --set serverStatus.channelID=123 (can be repeated)")
+	flag.BoolVar(&Config.Simulate, "simulate", false, "Use synthetic servers and players instead of RCON/database")
+	flag.Parse()
+
+	if configFile == "" {
+		configFile = "config.json"
+	}
+
+	parseConfig(&Config)
+}
+
+// parseConfig reads, validates and completes the config file into c
+func parseConfig(c *ConfigRoot) {
+	problems = nil
+
+	dat, err := os.ReadFile(configFile)
+
+	if err != nil {
+		fail(fmt.Sprintf("Failed to read config file %s: %s", configFile, err))
+	}
+
+	dat, err = decryptConfig(configFile, dat)
+
+	if err != nil {
+		fail(fmt.Sprintf("Failed to decrypt config file %s: %s", configFile, err))
+	}
+
+	if err = json.Unmarshal(dat, c); err != nil {
+		fail(fmt.Sprintf("Failed to parse config file %s: %s", configFile, err))
+	}
+
+	var raw any
+
+	if err = json.Unmarshal(dat, &raw); err == nil {
+		checkUnknownFields("", raw, reflect.TypeFor[ConfigRoot]())
+	}
+
+	checkProfiles(c.Profiles)
+
+	// -------------
+	// profiles
+	// -------------
+
+	if profile != "" {
+		raw, ok := c.Profiles[profile]
+
+		if !ok {
+			fail(fmt.Sprintf("Profile '%s' not found in config file %s", profile, configFile))
+		}
+
+		// decoding on top of the already parsed config only replaces the settings given in the profile
+
+		if err = json.Unmarshal(raw, c); err != nil {
+			fail(fmt.Sprintf("Failed to parse profile '%s' in config file %s: %s", profile, configFile, err))
+		}
+
+		slog.Info(fmt.Sprintf("Using configuration profile '%s'", profile))
+	}
+
+	c.Profiles = nil
+
+	// -------------
+	// overrides
+	// -------------
+
+	for _, override := range sets {
+		if err = applyOverride(c, override); err != nil {
+			fail(fmt.Sprintf("Failed to apply config override '%s': %s", override, err))
+		}
+	}
+
+	// -------------
+	// history
+	// -------------
+
+	if c.HistoryPath == "" {
+		c.HistoryPath = filepath.Join(c.StateDir, "history.json")
+	}
+
+	// -------------
+	// instance lock
+	// -------------
+
+	switch c.InstanceLock {
+	case "":
+		c.InstanceLock = InstanceLockRefuse
+	case InstanceLockRefuse, InstanceLockStandby, InstanceLockOff:
+	default:
+		invalid("instanceLock", fmt.Sprintf("unknown mode '%s', expected %s, %s or %s", c.InstanceLock, InstanceLockRefuse, InstanceLockStandby, InstanceLockOff))
+	}
+
+	// -------------
+	// retry queue
+	// -------------
+
+	if c.RetryQueueSize == 0 {
+		c.RetryQueueSize = 100
+	}
+
+	// -------------
+	// health
+	// -------------
+
+	if c.ReadyPolls == 0 {
+		c.ReadyPolls = 3
+	}
+
+	if c.ReadyPolls < 0 {
+		invalid("readyPolls", "must be positive")
+	}
+
+	// -------------
+	// api
+	// -------------
+
+	if c.Api != nil {
+		if c.Api.Port == 0 {
+			invalid("api.port", "must be set")
+		}
+
+		if c.Api.Bind == "" {
+			c.Api.Bind = "127.0.0.1"
+		}
+
+		if c.Api.TokensFile == "" {
+			c.Api.TokensFile = filepath.Join(c.StateDir, "api-tokens.json")
+		}
+
+		if (c.Api.CertFile == "") != (c.Api.KeyFile == "") {
+			invalid("api.certFile", "certFile and keyFile must be configured together")
+		} else if c.Api.CertFile != "" {
+			if _, err := tls.LoadX509KeyPair(c.Api.CertFile, c.Api.KeyFile); err != nil {
+				invalid("api.certFile", fmt.Sprintf("failed to load the certificate: %s", err))
+			}
+		}
+
+		names := make(map[string]bool)
+
+		for i, token := range c.Api.Tokens {
+			path := fmt.Sprintf("api.tokens[%d]", i)
+
+			if token.Name == "" || token.Token == "" {
+				invalid(path, "needs a name and a token")
+			}
+
+			if names[token.Name] {
+				invalid(at(path, "name"), fmt.Sprintf("'%s' is used twice", token.Name))
+			}
+
+			names[token.Name] = true
+
+			if len(token.Scopes) == 0 {
+				invalid(at(path, "scopes"), "no scopes configured")
+			}
+
+			for _, scope := range token.Scopes {
+				if !ValidScope(scope) {
+					invalid(at(path, "scopes"), fmt.Sprintf("unknown scope '%s', use %s, %s or %s", scope,
+						ScopeReadStatus, ScopeReadHistory, ScopeAdmin))
+				}
+			}
+		}
+	}
+
+	// -------------
+	// error reports
+	// -------------
+
+	if c.ErrorReports != nil {
+		if c.ErrorReports.SentryDSN == "" && c.ErrorReports.Webhook == "" {
+			invalid("errorReports", "needs a sentryDSN or a webhook")
+		}
+
+		if c.ErrorReports.Threshold == 0 {
+			c.ErrorReports.Threshold = 3
+		}
+
+		c.ErrorReports.Window = time.Hour
+
+		if c.ErrorReports.WindowRaw != "" {
+			d, err := parseDurationString(c.ErrorReports.WindowRaw)
+
+			if err != nil {
+				invalid("errorReports.window", err.Error())
+			}
+
+			c.ErrorReports.Window = d
+		}
+	}
+
+	// -------------
+	// privacy
+	// -------------
+
+	if c.Privacy != nil {
+		if !slices.Contains(PlatformIDModes, c.Privacy.PlatformIDs) {
+			invalid("privacy.platformIDs", fmt.Sprintf("unknown mode '%s' (expected one of %s)", c.Privacy.PlatformIDs, strings.Join(PlatformIDModes, ", ")))
+		}
+
+		if c.Privacy.PlatformIDs == PlatformIDsHash && c.Privacy.Salt == "" {
+			invalid("privacy.salt", "needs a secret salt to hash platform IDs")
+		}
+	}
+
+	// -------------
+	// tracing
+	// -------------
+
+	if c.Tracing != nil {
+		if c.Tracing.Endpoint == "" {
+			c.Tracing.Endpoint = "localhost:4318"
+		}
+
+		if c.Tracing.ServiceName == "" {
+			c.Tracing.ServiceName = "lazydodobot"
+		}
+
+		if c.Tracing.SampleRatio == 0 {
+			c.Tracing.SampleRatio = 1
+		}
+	}
+
+	// -------------
+	// Discord
+	// -------------
+
+	bots := c.AllBots()
+
+	if len(bots) == 0 {
+		invalid("botToken", "no discord bot token configured")
+	}
+
+	cachePaths := make(map[string]bool)
+
+	// the history, the metrics, the API and the database are shared by the bots and keyed by server key
+
+	serverKeys := make(map[string]int)
+	reminderTables := make(map[string]int)
+
+	for i, bot := range bots {
+		if bot.Name == "" {
+			bot.Name = fmt.Sprintf("bot-%d", i+1)
+		}
+
+		if bot.CachePath == "" {
+			bot.CachePath = "cache.json"
+
+			if i > 0 {
+				bot.CachePath = fmt.Sprintf("cache-%s.json", bot.Name)
+			}
+		}
+
+		path := ""
+
+		if bot != &c.ConfigBot {
+			path = fmt.Sprintf("bots[%d]", slices.Index(c.Bots, bot))
+		}
+
+		if cachePaths[bot.CachePath] {
+			invalid(at(path, "cachePath"), fmt.Sprintf("%s is used by multiple bots", bot.CachePath))
+		}
+
+		cachePaths[bot.CachePath] = true
+
+		// with a state directory, the cache file is only read once to migrate it
+
+		if c.StateDir != "" {
+			bot.StateDir = filepath.Join(c.StateDir, bot.Name)
+		}
+
+		bot.LockFile = bot.CachePath + ".lock"
+
+		if bot.StateDir != "" {
+			bot.LockFile = filepath.Join(bot.StateDir, "instance.lock")
+		}
+
+		parseBotConfig(bot, path)
+
+		if bot.ServerStatus != nil {
+			for n, server := range bot.ServerStatus.Rcon.Servers {
+				if other, ok := serverKeys[server.Key]; ok && other != i {
+					invalid(at(path, fmt.Sprintf("serverStatus.rcon.servers[%d].key", n)),
+						fmt.Sprintf("'%s' is already used by bot '%s', server keys must be distinct across all bots", server.Key, bots[other].Name))
+				} else if !ok {
+					serverKeys[server.Key] = i
+				}
+			}
+		}
+
+		// the bots share the database, each keeps its reminders in its own table
+
+		if bot.Eventer != nil && bot.Eventer.ReminderStore == ReminderStoreDatabase {
+			if c.Database == "" {
+				invalid(at(path, "eventer.reminderStore"), "no database configured")
+			}
+
+			bot.Eventer.ReminderStorePath = c.Database
+			bot.Eventer.ReminderStoreTable = "reminders_" + nonIdentifier.ReplaceAllString(strings.ToLower(bot.Name), "_")
+
+			// names differing in case or special characters only map to the same table
+
+			if other, ok := reminderTables[bot.Eventer.ReminderStoreTable]; ok {
+				invalid(at(path, "eventer.reminderStore"), fmt.Sprintf("bot '%s' would keep its reminders in table '%s' of bot '%s', rename one of them",
+					bot.Name, bot.Eventer.ReminderStoreTable, bots[other].Name))
+			} else {
+				reminderTables[bot.Eventer.ReminderStoreTable] = i
+			}
+		}
+	}
+
+	checkSnowflakes("", reflect.ValueOf(c))
+	check()
+}
+
+// parseBotConfig validates and completes the config of a bot, path is where it is found in the config file
+func parseBotConfig(bot *ConfigBot, path string) {
+	if bot.BotToken == "" {
+		invalid(at(path, "botToken"), "no discord bot token configured")
+	}
+
+	if bot.Avatar != "" {
+		if _, err := os.Stat(bot.Avatar); err != nil {
+			invalid(at(path, "avatar"), fmt.Sprintf("failed to read avatar: %s", err))
+		}
+	}
+
+	if bot.Cooldown == nil {
+		bot.Cooldown = &ConfigCooldown{}
+	}
+
+	bot.Cooldown.PerUser = 10 * time.Second
+	bot.Cooldown.PerChannel = 3 * time.Second
+
+	if bot.Cooldown.PerUserRaw != "" {
+		d, err := parseDurationString(bot.Cooldown.PerUserRaw)
+
+		if err != nil {
+			invalid(at(path, "cooldown.perUser"), err.Error())
+		}
+
+		bot.Cooldown.PerUser = d
+	}
+
+	if bot.Cooldown.PerChannelRaw != "" {
+		d, err := parseDurationString(bot.Cooldown.PerChannelRaw)
 
-type ConfigRconServer struct {
-	Address  string `json:"address"`
-	Name     string `json:"name"`
-	Map      string `json:"map"`
-	Password string `json:"password"`
-}
+		if err != nil {
+			invalid(at(path, "cooldown.perChannel"), err.Error())
+		}
 
-type ConfigRcon struct {
-	Servers           []ConfigRconServer `json:"servers"`
-	QueryEverySeconds int                `json:"queryEverySeconds"`
-}
+		bot.Cooldown.PerChannel = d
+	}
 
-type ConfigRoot struct {
-	LogFile   string `json:"logFile"`
-	CachePath string `json:"cachePath"`
+	if bot.Cooldown.MaxConcurrent == 0 {
+		bot.Cooldown.MaxConcurrent = 4
+	}
 
-	BotToken string `json:"botToken"`
+	if bot.ShardCount > 0 && (bot.ShardID < 0 || bot.ShardID >= bot.ShardCount) {
+		invalid(at(path, "shardID"), fmt.Sprintf("%d is out of range for %d shards", bot.ShardID, bot.ShardCount))
+	}
 
-	ServerStatus *struct {
-		Rcon ConfigRcon `json:"rcon"`
+	timezones := parseTimezones(bot, path)
+	bot.Languages = parseLanguages(bot, path)
 
-		DbConnection       string `json:"DbConnection"`
-		ChannelID          string `json:"channelID"`
-		ChannelIDJoinLeave string `json:"channelIDJoinLeave"`
-		ShowJoinLeave      bool   `json:"showJoinLeave"`
-	} `json:"serverStatus,ommitempty"`
+	if bot.ServerStatus != nil && Config.Simulate {
+		if len(bot.ServerStatus.Rcon.Servers) == 0 {
+			bot.ServerStatus.Rcon.Servers = []ConfigRconServer{
+				{Name: "The Island", Map: "TheIsland_WP", Address: "simulated"},
+				{Name: "Scorched Earth", Map: "ScorchedEarth_WP", Address: "simulated"},
+				{Name: "Aberration", Map: "Aberration_WP", Address: "simulated"},
+			}
+		}
 
-	Eventer *struct {
-		ChannelID          string          `json:"channelID"`
-		ReminderOffsets    []time.Duration `json:"-"`
-		ReminderOffsetsRaw []string        `json:"reminderOffsets"`
-	} `json:"eventer,ommitempty"`
+		if bot.ServerStatus.DbConnection == "" {
+			bot.ServerStatus.DbConnection = "simulated"
+		}
+	}
 
-	Crosschat *struct {
-		ChannelID             string `json:"channelID"`
-		DbConnection          string `json:"DbConnection"`
-		WebhookCrosschat      string `json:"WebhookCrosschat"`
-		WebhookIdCrosschat    string `json:"-"`
-		WebhookTokenCrosschat string `json:"-"`
-	} `json:"crosschat,ommitempty"`
-}
+	if bot.ServerStatus != nil {
+		bot.ServerStatus.Timezones = timezones.orDefault(time.Local)
+		bot.ServerStatus.Languages = bot.Languages.orDefault(i18n.English)
 
-var Config ConfigRoot
+		if bot.ServerStatus.Rcon.Servers == nil || len(bot.ServerStatus.Rcon.Servers) == 0 {
+			invalid(at(path, "serverStatus.rcon.servers"), "no RCON servers configured")
+		}
 
-func ParseConfig() {
-	var configFile string
-	flag.StringVar(&configFile, "config-file", "", "Path to the JSON configuration file")
-	flag.Parse()
+		keys := make(map[string]int)
 
-	if configFile == "" {
-		configFile = "config.json"
-	}
+		for i, server := range bot.ServerStatus.Rcon.Servers {
+			serverPath := at(path, fmt.Sprintf("serverStatus.rcon.servers[%d]", i))
 
-	dat, err := os.ReadFile(configFile)
+			if server.Name == "" {
+				invalid(at(serverPath, "name"), "no name configured")
+			}
 
-	if err != nil {
-		slog.Info(fmt.Sprintf("Failed to read config file %s: %s", configFile, err))
-		os.Exit(1)
-	}
+			// the key identifies the server in references, the cache and the history, so it may be renamed
 
-	if err = json.Unmarshal(dat, &Config); err != nil {
-		slog.Info(fmt.Sprintf("Failed to parse config file %s: %s", configFile, err))
-		os.Exit(1)
-	}
+			if server.Key == "" {
+				server.Key = server.Name
+				bot.ServerStatus.Rcon.Servers[i].Key = server.Name
+			}
 
-	// -------------
-	// cache
-	// -------------
+			if server.ChatPrefix == "" {
+				bot.ServerStatus.Rcon.Servers[i].ChatPrefix = server.Name
+			}
 
-	if Config.CachePath == "" {
-		Config.CachePath = "cache.json"
-	}
+			server.Bot = bot.Name
+			bot.ServerStatus.Rcon.Servers[i].Bot = bot.Name
 
-	// -------------
-	// Discord
-	// -------------
+			if first, ok := keys[server.Key]; ok {
+				invalid(at(serverPath, "key"), fmt.Sprintf("'%s' is already used by servers[%d], configure a distinct key", server.Key, first))
+			} else {
+				keys[server.Key] = i
+			}
 
-	if Config.BotToken == "" {
-		slog.Info(fmt.Sprintf("No discord bot token configured"))
-		os.Exit(1)
-	}
+			if server.Type == "" {
+				bot.ServerStatus.Rcon.Servers[i].Type = ServerTypeArkAse
+			} else if !slices.Contains(ServerTypes, server.Type) {
+				invalid(at(serverPath, "type"), fmt.Sprintf("unknown type '%s' (expected one of %s)", server.Type, strings.Join(ServerTypes, ", ")))
+			}
+
+			if server.HasPasswordSource() {
+				password, err := server.LoadPassword()
+
+				if err != nil {
+					invalid(at(serverPath, "password"), fmt.Sprintf("failed to load RCON password: %s", err))
+				}
+
+				bot.ServerStatus.Rcon.Servers[i].Password = password
+			}
+
+			for _, window := range server.RestartWindows {
+				if _, _, err := parseTimeWindow(window); err != nil {
+					invalid(at(serverPath, "restartWindows"), fmt.Sprintf("invalid restart window '%s': %s", window, err))
+				}
+			}
+
+			if server.ShowConnect && server.ConnectAddress == "" {
+				invalid(at(serverPath, "connectAddress"), "no connect address configured, but showConnect is set")
+			}
+		}
+
+		if bot.ServerStatus.DbConnection == "" {
+			for i, server := range bot.ServerStatus.Rcon.Servers {
+				if slices.Contains(DbServerTypes, server.Type) {
+					invalid(at(path, "serverStatus.DbConnection"), fmt.Sprintf("no db connection configured, required for %s servers like servers[%d]", server.Type, i))
+					break
+				}
+			}
+		}
+
+		if bot.ServerStatus.Rcon.QueryEverySeconds == 0 {
+			bot.ServerStatus.Rcon.QueryEverySeconds = 60
+		}
+
+		if bot.ServerStatus.StaleAfterPolls == 0 {
+			bot.ServerStatus.StaleAfterPolls = 3
+		}
 
-	if Config.ServerStatus != nil {
-		if Config.ServerStatus.Rcon.Servers == nil || len(Config.ServerStatus.Rcon.Servers) == 0 {
-			slog.Info(fmt.Sprintf("No RCON servers configured"))
-			os.Exit(1)
+		if bot.ServerStatus.ChannelID == "" && len(bot.ServerStatus.Guilds) == 0 {
+			invalid(at(path, "serverStatus.channelID"), "no discord channel ID configured for server status")
 		}
 
-		if Config.ServerStatus.Rcon.QueryEverySeconds == 0 {
-			Config.ServerStatus.Rcon.QueryEverySeconds = 60
+		for i, guild := range bot.ServerStatus.Guilds {
+			guildPath := at(path, fmt.Sprintf("serverStatus.guilds[%d]", i))
+
+			if guild.ChannelID == "" {
+				invalid(at(guildPath, "channelID"), fmt.Sprintf("no discord channel ID configured for server status of guild %s", guild.GuildID))
+			}
+
+			if bot.ServerStatus.Branding != nil || guild.Branding != nil {
+				bot.ServerStatus.Guilds[i].Branding = bot.ServerStatus.Branding.merge(guild.Branding)
+			}
+
+			for _, name := range guild.Servers {
+				if _, ok := ServerByKey(bot.ServerStatus.Rcon.Servers, name); !ok {
+					invalid(at(guildPath, "servers"), fmt.Sprintf("unknown server '%s'", name))
+				}
+			}
+
+			for _, tag := range guild.Tags {
+				tagged := Tagged(bot.ServerStatus.Rcon.Servers, tag)
+
+				if len(tagged) == 0 {
+					invalid(at(guildPath, "tags"), fmt.Sprintf("no server has the tag '%s'", tag))
+				}
+
+				for _, server := range tagged {
+					if !slices.Contains(bot.ServerStatus.Guilds[i].Servers, server.Key) {
+						bot.ServerStatus.Guilds[i].Servers = append(bot.ServerStatus.Guilds[i].Servers, server.Key)
+					}
+				}
+			}
+		}
+
+		if bot.ServerStatus.ChannelIDJoinLeave == "" {
+			bot.ServerStatus.ChannelIDJoinLeave = bot.ServerStatus.ChannelID
+		}
+
+		if bot.ServerStatus.ShowJoinLeave && bot.ServerStatus.ChannelIDJoinLeave == "" {
+			invalid(at(path, "serverStatus.channelIDJoinLeave"), "no discord channel ID configured for join/leave messages")
+		}
+
+		if bot.ServerStatus.TransferWindowRaw != "" && bot.ServerStatus.TransferWindowRaw != "0" {
+			d, err := parseDurationString(bot.ServerStatus.TransferWindowRaw)
+
+			if err != nil {
+				invalid(at(path, "serverStatus.transferWindow"), err.Error())
+			}
+
+			bot.ServerStatus.TransferWindow = d
+		}
+
+		if bot.ServerStatus.JoinLeaveLifetimeRaw != "" {
+			d, err := parseDurationString(bot.ServerStatus.JoinLeaveLifetimeRaw)
+
+			if err != nil {
+				invalid(at(path, "serverStatus.joinLeaveLifetime"), err.Error())
+			}
+
+			bot.ServerStatus.JoinLeaveLifetime = d
+
+			// the archive of a month is written once it is over, from the messages still in the channel
+
+			if bot.ServerStatus.ArchiveJoinLeave && d > 0 && d < 31*24*time.Hour {
+				invalid(at(path, "serverStatus.joinLeaveLifetime"), "must be at least 31 days with archiveJoinLeave, otherwise the messages are deleted before they are archived")
+			}
+		}
+
+		if bot.ServerStatus.ChannelIDFull != "" {
+			bot.ServerStatus.FullWithin = 15 * time.Minute
+
+			if bot.ServerStatus.FullWithinRaw != "" {
+				d, err := parseDurationString(bot.ServerStatus.FullWithinRaw)
+
+				if err != nil {
+					invalid(at(path, "serverStatus.fullWithin"), err.Error())
+				}
+
+				bot.ServerStatus.FullWithin = d
+			}
+
+			if !slices.ContainsFunc(bot.ServerStatus.Rcon.Servers, func(s ConfigRconServer) bool { return s.MaxPlayers > 0 }) {
+				slog.Warn("channelIDFull is set, but no server has maxPlayers configured")
+			}
+		}
+
+		bot.ServerStatus.IncidentThreshold = 5 * time.Minute
+
+		if bot.ServerStatus.IncidentThresholdRaw != "" {
+			d, err := parseDurationString(bot.ServerStatus.IncidentThresholdRaw)
+
+			if err != nil {
+				invalid(at(path, "serverStatus.incidentThreshold"), err.Error())
+			}
+
+			bot.ServerStatus.IncidentThreshold = d
+		}
+
+		if bot.ServerStatus.CrosspostOutages && bot.ServerStatus.ChannelIDIncidents == "" {
+			invalid(at(path, "serverStatus.crosspostOutages"), "outages are only crossposted to the channelIDIncidents")
 		}
 
-		if Config.ServerStatus.ChannelID == "" {
-			slog.Info(fmt.Sprintf("No discord channel ID configured for server status"))
-			os.Exit(1)
+		if bot.ServerStatus.SnapshotTime != "" {
+			if _, err := time.Parse("15:04", bot.ServerStatus.SnapshotTime); err != nil {
+				invalid(at(path, "serverStatus.snapshotTime"), fmt.Sprintf("invalid time '%s', expected HH:MM", bot.ServerStatus.SnapshotTime))
+			}
+
+			if bot.ServerStatus.ChannelIDSnapshot == "" {
+				invalid(at(path, "serverStatus.channelIDSnapshot"), "no discord channel ID configured for player list snapshots")
+			}
+		}
+
+		if announcements := bot.ServerStatus.Announcements; announcements != nil {
+			announcements.Interval = 30 * time.Minute
+
+			if announcements.IntervalRaw != "" {
+				d, err := parseDurationString(announcements.IntervalRaw)
+
+				if err != nil {
+					invalid(at(path, "serverStatus.announcements.interval"), err.Error())
+				}
+
+				announcements.Interval = d
+			}
+
+			for _, name := range announcements.Servers {
+				if _, ok := ServerByKey(bot.ServerStatus.Rcon.Servers, name); !ok {
+					invalid(at(path, "serverStatus.announcements.servers"), fmt.Sprintf("unknown server '%s'", name))
+				}
+			}
 		}
 
-		if Config.ServerStatus.DbConnection == "" {
-			slog.Info(fmt.Sprintf("No db connection configured for server status"))
-			os.Exit(1)
+		for n := range bot.ServerStatus.Wipes {
+			wipe := &bot.ServerStatus.Wipes[n]
+			wipePath := fmt.Sprintf("%s[%d]", at(path, "serverStatus.wipes"), n)
+
+			if wipe.Name == "" {
+				invalid(at(wipePath, "name"), "must be set")
+			}
+
+			date, err := time.ParseInLocation("2006-01-02 15:04", wipe.DateRaw, bot.ServerStatus.Timezones.Location(""))
+
+			if err != nil {
+				invalid(at(wipePath, "date"), fmt.Sprintf("invalid date '%s', expected YYYY-MM-DD HH:MM", wipe.DateRaw))
+			}
+
+			wipe.Date = date
+			wipe.ReminderOffsets = []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour}
+
+			if len(wipe.ReminderOffsetsRaw) > 0 {
+				o, err := parseDurations(wipe.ReminderOffsetsRaw)
+
+				if err != nil {
+					invalid(at(wipePath, "reminderOffsets"), err.Error())
+				}
+
+				wipe.ReminderOffsets = o
+			}
+
+			for _, name := range wipe.Servers {
+				if _, ok := ServerByKey(bot.ServerStatus.Rcon.Servers, name); !ok {
+					invalid(at(wipePath, "servers"), fmt.Sprintf("unknown server '%s'", name))
+				}
+			}
+
+			if wipe.Action != "" && !slices.Contains(WipeActions, wipe.Action) {
+				invalid(at(wipePath, "action"), fmt.Sprintf("unknown action '%s', expected one of %s", wipe.Action, strings.Join(WipeActions, ", ")))
+			}
+
+			if wipe.Action != "" && (bot.Admin == nil || bot.Admin.ChannelID == "") {
+				invalid(at(wipePath, "action"), "needs an admin channel for the results")
+			}
+
+			for _, server := range bot.ServerStatus.Rcon.Servers {
+				if _, ok := MaintenanceCommands[wipe.Action][server.Type]; ok || wipe.Action == "" || !wipe.Includes(server.Key) {
+					continue
+				}
+
+				if slices.Contains(WipeActions, wipe.Action) {
+					invalid(at(wipePath, "action"), fmt.Sprintf("'%s' is not supported on server '%s' (%s)", wipe.Action, server.Key, server.Type))
+				}
+			}
 		}
 
-		if Config.ServerStatus.ChannelIDJoinLeave == "" {
-			Config.ServerStatus.ChannelIDJoinLeave = Config.ServerStatus.ChannelID
+		if banSync := bot.ServerStatus.BanSync; banSync != nil {
+			banSync.ReconcileInterval = time.Hour
+
+			if banSync.ReconcileIntervalRaw == "0" {
+				banSync.ReconcileInterval = 0
+			} else if banSync.ReconcileIntervalRaw != "" {
+				d, err := parseDurationString(banSync.ReconcileIntervalRaw)
+
+				if err != nil {
+					invalid(at(path, "serverStatus.banSync.reconcileInterval"), err.Error())
+				}
+
+				banSync.ReconcileInterval = d
+			}
+
+			for _, name := range banSync.Servers {
+				if _, ok := ServerByKey(bot.ServerStatus.Rcon.Servers, name); !ok {
+					invalid(at(path, "serverStatus.banSync.servers"), fmt.Sprintf("unknown server '%s'", name))
+				}
+			}
 		}
 
 	}
 
-	if Config.Eventer != nil {
-		if Config.Eventer.ChannelID == "" {
-			slog.Info(fmt.Sprintf("No discord channel ID configured for eventer"))
-			os.Exit(1)
+	if bot.Eventer != nil {
+		cet, _ := time.LoadLocation(eventerDefaultTimezone)
+		bot.Eventer.Timezones = timezones.orDefault(cet)
+		bot.Eventer.Languages = bot.Languages.orDefault(i18n.German)
+
+		if bot.Eventer.ChannelID == "" {
+			invalid(at(path, "eventer.channelID"), "no discord channel ID configured for eventer")
 		}
 
-		if len(Config.Eventer.ReminderOffsets) == 0 {
-			if len(Config.Eventer.ReminderOffsetsRaw) > 0 {
-				o, err := parseDurations(Config.Eventer.ReminderOffsetsRaw)
+		if len(bot.Eventer.ReminderOffsets) == 0 {
+			if len(bot.Eventer.ReminderOffsetsRaw) > 0 {
+				o, err := parseDurations(bot.Eventer.ReminderOffsetsRaw)
 
 				if err != nil {
-					slog.Info(fmt.Sprintf("Failed to parse reminder offsets: %s", err))
-					os.Exit(1)
+					invalid(at(path, "eventer.reminderOffsets"), err.Error())
 				}
 
-				Config.Eventer.ReminderOffsets = o
+				bot.Eventer.ReminderOffsets = o
 			} else {
-				Config.Eventer.ReminderOffsets = []time.Duration{
+				bot.Eventer.ReminderOffsets = []time.Duration{
 					24 * time.Hour,
 					2 * time.Hour,
 					15 * time.Minute,
 				}
 			}
 		}
+
+		if bot.Eventer.MentionCooldownRaw != "" {
+			d, err := parseDurationString(bot.Eventer.MentionCooldownRaw)
+
+			if err != nil {
+				invalid(at(path, "eventer.mentionCooldown"), err.Error())
+			}
+
+			bot.Eventer.MentionCooldown = d
+		}
+
+		if bot.Eventer.ReminderLifetimeRaw != "" {
+			d, err := parseDurationString(bot.Eventer.ReminderLifetimeRaw)
+
+			if err != nil {
+				invalid(at(path, "eventer.reminderLifetime"), err.Error())
+			}
+
+			bot.Eventer.ReminderLifetime = d
+		}
+
+		for keyword, server := range bot.Eventer.LocationServers {
+			if bot.ServerStatus == nil || !slices.ContainsFunc(bot.ServerStatus.Rcon.Servers, func(s ConfigRconServer) bool { return s.Key == server }) {
+				invalid(at(path, "eventer.locationServers."+keyword), fmt.Sprintf("unknown server '%s'", server))
+			}
+		}
+
+		bot.Eventer.ReminderStoreTable = "reminders"
+
+		switch bot.Eventer.ReminderStore {
+		case "", ReminderStoreMemory, ReminderStoreDatabase:
+		case ReminderStoreFile, ReminderStoreSqlite:
+			if bot.Eventer.ReminderStorePath == "" {
+				ext := ".json"
+
+				if bot.Eventer.ReminderStore == ReminderStoreSqlite {
+					ext = ".db"
+				}
+
+				bot.Eventer.ReminderStorePath = strings.TrimSuffix(bot.CachePath, filepath.Ext(bot.CachePath)) + "-reminders" + ext
+
+				if bot.StateDir != "" {
+					bot.Eventer.ReminderStorePath = filepath.Join(bot.StateDir, "reminders"+ext)
+				}
+			}
+		default:
+			invalid(at(path, "eventer.reminderStore"), fmt.Sprintf("unknown reminder store '%s'", bot.Eventer.ReminderStore))
+		}
+
+		if rich := bot.Eventer.RichReminders; rich != nil {
+			if rich.Color == 0 {
+				rich.Color = 0x5865F2 // Discord blurple
+			}
+
+			if rich.Fields == nil {
+				rich.Fields = RichReminderFields
+			}
+
+			for _, field := range rich.Fields {
+				if !slices.Contains(RichReminderFields, field) {
+					invalid(at(path, "eventer.richReminders.fields"), fmt.Sprintf("unknown field '%s' (expected one of %s)", field, strings.Join(RichReminderFields, ", ")))
+				}
+			}
+
+			if rich.ButtonLabel == "" {
+				rich.ButtonLabel = "Zum Event"
+			}
+		}
+
+		if trigger := bot.Eventer.GameTrigger; trigger != nil {
+			if trigger.Keyword == "" {
+				trigger.Keyword = "!event"
+			}
+
+			if trigger.GuildID == "" {
+				invalid(at(path, "eventer.gameTrigger.guildID"), "no guild ID configured for in-game event triggers")
+			}
+
+			if len(trigger.Organizers) == 0 {
+				invalid(at(path, "eventer.gameTrigger.organizers"), "no organizers configured for in-game event triggers")
+			}
+
+			if bot.Crosschat == nil && bot.ServerStatus == nil {
+				slog.Warn("In-game event triggers configured without crosschat or server status, the game chat is not read")
+			}
+		}
+
+		bot.Eventer.DefaultDuration = 2 * time.Hour
+
+		if bot.Eventer.DefaultDurationRaw != "" {
+			d, err := parseDurationString(bot.Eventer.DefaultDurationRaw)
+
+			if err != nil {
+				invalid(at(path, "eventer.defaultDuration"), err.Error())
+			}
+
+			bot.Eventer.DefaultDuration = d
+		}
+
+		bot.Eventer.ResyncInterval = 30 * time.Minute
+
+		if bot.Eventer.ResyncIntervalRaw == "0" {
+			bot.Eventer.ResyncInterval = 0
+		} else if bot.Eventer.ResyncIntervalRaw != "" {
+			d, err := parseDurationString(bot.Eventer.ResyncIntervalRaw)
+
+			if err != nil {
+				invalid(at(path, "eventer.resyncInterval"), err.Error())
+			}
+
+			bot.Eventer.ResyncInterval = d
+		}
+	}
+
+	if bot.Admin != nil {
+		if len(bot.Admin.RoleIDs) == 0 {
+			invalid(at(path, "admin.roleIDs"), "no admin role IDs configured")
+		}
+
+		bot.Admin.ApprovalTimeout = 5 * time.Minute
+
+		if bot.Admin.ApprovalTimeoutRaw != "" {
+			d, err := parseDurationString(bot.Admin.ApprovalTimeoutRaw)
+
+			if err != nil {
+				invalid(at(path, "admin.approvalTimeout"), err.Error())
+			} else if d <= 0 {
+				invalid(at(path, "admin.approvalTimeout"), "must be positive, approvals would expire right away")
+			}
+
+			bot.Admin.ApprovalTimeout = d
+		}
 	}
 
-	if Config.Crosschat != nil {
-		if Config.Crosschat.ChannelID == "" {
-			slog.Info(fmt.Sprintf("No discord channel ID configured for crosschat"))
-			os.Exit(1)
+	if bot.Crosschat != nil {
+		if bot.Crosschat.ChannelID == "" {
+			invalid(at(path, "crosschat.channelID"), "no discord channel ID configured for crosschat")
+		}
+
+		if bot.Crosschat.DbConnection == "" && len(bot.Crosschat.Servers) == 0 {
+			invalid(at(path, "crosschat.DbConnection"), "no db connection configured for crosschat")
 		}
 
-		if Config.Crosschat.DbConnection == "" {
-			slog.Info(fmt.Sprintf("No db connection configured for crosschat"))
-			os.Exit(1)
+		for _, name := range bot.Crosschat.Servers {
+			idx := -1
+
+			if bot.ServerStatus != nil {
+				idx = slices.IndexFunc(bot.ServerStatus.Rcon.Servers, func(s ConfigRconServer) bool { return s.Key == name })
+			}
+
+			if idx < 0 {
+				invalid(at(path, "crosschat.servers"), fmt.Sprintf("unknown server '%s'", name))
+				continue
+			}
+
+			server := bot.ServerStatus.Rcon.Servers[idx]
+
+			if !slices.Contains(ChatServerTypes, server.Type) {
+				invalid(at(path, "crosschat.servers"), fmt.Sprintf("chat of server '%s' (%s) cannot be relayed via RCON", name, server.Type))
+			}
+
+			// ARK returns every chat message only once, so it can only be read by one of them
+
+			if server.Type != ServerTypeRust && bot.ServerStatus.ChannelIDGameChat != "" {
+				invalid(at(path, "crosschat.servers"), fmt.Sprintf("chat of server '%s' is already relayed to serverStatus.channelIDGameChat", name))
+			}
+
+			bot.Crosschat.RconServers = append(bot.Crosschat.RconServers, server)
 		}
 
-		if Config.Crosschat.WebhookCrosschat != "" {
-			id, token := parseWebhookURL(Config.Crosschat.WebhookCrosschat)
+		if bot.Crosschat.WebhookCrosschat != "" {
+			id, token := parseWebhookURL(bot.Crosschat.WebhookCrosschat)
+
+			bot.Crosschat.WebhookIdCrosschat = id
+			bot.Crosschat.WebhookTokenCrosschat = token
+		}
 
-			Config.Crosschat.WebhookIdCrosschat = id
-			Config.Crosschat.WebhookTokenCrosschat = token
+		if len(bot.Crosschat.WebhookIdCrosschat) == 0 || len(bot.Crosschat.WebhookTokenCrosschat) == 0 {
+			invalid(at(path, "crosschat.WebhookCrosschat"), "malformed webhook URL")
 		}
 
-		if len(Config.Crosschat.WebhookIdCrosschat) == 0 || len(Config.Crosschat.WebhookTokenCrosschat) == 0 {
-			slog.Info(fmt.Sprintf("Malformed webhook URL"))
-			os.Exit(1)
+		if filter := bot.Crosschat.Filter; filter != nil {
+			// \b only knows ASCII word characters, so keywords with umlauts or other letters need their own
+			// boundaries. The keyword itself is the group "keyword", without the surrounding characters.
+
+			for _, keyword := range filter.Keywords {
+				filter.Regexps = append(filter.Regexps, regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(?P<keyword>`+regexp.QuoteMeta(keyword)+`)(?:$|[^\p{L}\p{N}])`))
+			}
+
+			for n, pattern := range filter.Patterns {
+				re, err := regexp.Compile(pattern)
+
+				if err != nil {
+					invalid(fmt.Sprintf("%s[%d]", at(path, "crosschat.filter.patterns"), n), fmt.Sprintf("invalid regular expression: %s", err))
+					continue
+				}
+
+				filter.Regexps = append(filter.Regexps, re)
+			}
+
+			if len(filter.Regexps) == 0 {
+				invalid(at(path, "crosschat.filter"), "no keywords or patterns configured")
+			}
+
+			if filter.ChannelID == "" && bot.Admin != nil {
+				filter.ChannelID = bot.Admin.ChannelID
+			}
+
+			if filter.ChannelID == "" {
+				invalid(at(path, "crosschat.filter.channelID"), "no moderator channel configured, and no admin channel to fall back to")
+			}
 		}
 	}
 }
@@ -187,6 +1593,8 @@ func parseDurationString(s string) (time.Duration, error) {
 	unit := strings.ToLower(parts[1])
 
 	switch unit {
+	case "second", "seconds":
+		return time.Duration(value) * time.Second, nil
 	case "minute", "minutes":
 		return time.Duration(value) * time.Minute, nil
 	case "hour", "hours":
@@ -218,6 +1626,11 @@ func parseDurations(durations []string) ([]time.Duration, error) {
 
 func parseWebhookURL(url string) (string, string) {
 	parts := strings.Split(url, "/")
+
+	if len(parts) < 2 {
+		return "", ""
+	}
+
 	return parts[len(parts)-2], parts[len(parts)-1]
 }
 
@@ -231,3 +1644,8 @@ func CleanDbString(dsn string) string {
 
 	return dsn[:colon+1] + dsn[at:]
 }
+
+// ValidScope reports whether the API token scope is known
+func ValidScope(scope string) bool {
+	return scope == ScopeReadStatus || scope == ScopeReadHistory || scope == ScopeAdmin
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// parse validates the config like a reload does, returning the problems as error instead of exiting
+func parse(t *testing.T, config string) (*ConfigRoot, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := configFile
+	configFile = file
+
+	t.Cleanup(func() { configFile = prev })
+
+	return Reload()
+}
+
+func TestPrivacyModes(t *testing.T) {
+	for _, mode := range []string{PlatformIDsHash, PlatformIDsTruncate} {
+		t.Run(mode, func(t *testing.T) {
+			root, err := parse(t, `{"botToken": "token", "privacy": {"platformIDs": "`+mode+`", "salt": "s"}}`)
+
+			if err != nil {
+				t.Fatalf("privacy mode %s rejected: %s", mode, err)
+			}
+
+			if root.Privacy.PlatformID("76561198000000001") == "76561198000000001" {
+				t.Errorf("platform ID kept in full with mode %s", mode)
+			}
+		})
+	}
+}
+
+func TestPrivacyHashNeedsSalt(t *testing.T) {
+	_, err := parse(t, `{"botToken": "token", "privacy": {"platformIDs": "hash"}}`)
+
+	if err == nil || !strings.Contains(err.Error(), "privacy.salt") {
+		t.Fatalf("expected missing salt to be reported, got %v", err)
+	}
+}
+
+func TestSnowflakes(t *testing.T) {
+	_, err := parse(t, `{"botToken": "token", "admin": {"channelID": "#admins", "roleIDs": ["123456789012345678"]}}`)
+
+	if err == nil || !strings.Contains(err.Error(), "admin.channelID: '#admins' is not a discord ID") {
+		t.Fatalf("expected invalid channel ID to be reported, got %v", err)
+	}
+
+	if strings.Contains(err.Error(), "roleIDs") {
+		t.Errorf("valid role ID reported: %s", err)
+	}
+}
+
+func TestWipeActionServerTypes(t *testing.T) {
+	config := `{"botToken": "token", "admin": {"channelID": "123456789012345678", "roleIDs": ["123456789012345678"]},
+		"serverStatus": {"channelID": "123456789012345678", "DbConnection": "user:password@tcp/ark", "rcon": {"servers": [
+			{"key": "island", "name": "The Island", "address": "127.0.0.1:27020", "password": "p"},
+			{"key": "valheim", "name": "Valheim", "address": "127.0.0.1:2457", "password": "p", "type": "valheim"}]},
+		"wipes": [{"name": "Season 2", "date": "2099-01-01 20:00", "servers": [%s], "action": "restart"}]}}`
+
+	_, err := parse(t, strings.Replace(config, "%s", `"island"`, 1))
+
+	if err != nil {
+		t.Fatalf("restart of ark server rejected: %s", err)
+	}
+
+	_, err = parse(t, strings.Replace(config, "%s", "", 1))
+
+	if err == nil || !strings.Contains(err.Error(), "'restart' is not supported on server 'valheim'") {
+		t.Fatalf("expected unsupported restart to be reported, got %v", err)
+	}
+}
+
+func TestServerKeysAcrossBots(t *testing.T) {
+	_, err := parse(t, `{"bots": [
+		{"name": "one", "botToken": "token", "serverStatus": {"channelID": "123456789012345678", "rcon": {"servers": [
+			{"key": "island", "name": "The Island", "address": "127.0.0.1:27020", "password": "p"}]}}},
+		{"name": "two", "botToken": "token2", "serverStatus": {"channelID": "123456789012345679", "rcon": {"servers": [
+			{"key": "island", "name": "The Island", "address": "127.0.0.1:27021", "password": "p"}]}}}]}`)
+
+	if err == nil || !strings.Contains(err.Error(), "bots[1].serverStatus.rcon.servers[0].key: 'island' is already used by bot 'one'") {
+		t.Fatalf("expected duplicate key across bots to be reported, got %v", err)
+	}
+}
+
+func TestReminderTablesAcrossBots(t *testing.T) {
+	_, err := parse(t, `{"database": "lazydodo.db", "bots": [
+		{"name": "My Bot", "botToken": "token", "eventer": {"channelID": "123456789012345678", "reminderStore": "database"}},
+		{"name": "my-bot", "botToken": "token2", "eventer": {"channelID": "123456789012345679", "reminderStore": "database"}}]}`)
+
+	if err == nil || !strings.Contains(err.Error(), "bots[1].eventer.reminderStore: bot 'my-bot' would keep its reminders in table 'reminders_my_bot' of bot 'My Bot'") {
+		t.Fatalf("expected shared reminder table to be reported, got %v", err)
+	}
+}
+
+func TestLiveUpdate(t *testing.T) {
+	first := &ConfigBot{Name: "bot", ServerStatus: &ConfigServerStatus{Rcon: ConfigRcon{QueryEverySeconds: 30}}}
+	live := NewLive(first)
+
+	if err := live.Update(func(b *ConfigBot) error { return b.ApplySetting(SettingPollInterval, "10") }); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ServerStatus.Rcon.QueryEverySeconds != 30 {
+		t.Errorf("loaded config was changed to %d", first.ServerStatus.Rcon.QueryEverySeconds)
+	}
+
+	if got := live.Load().ServerStatus.Rcon.QueryEverySeconds; got != 10 {
+		t.Errorf("published poll interval is %d, want 10", got)
+	}
+
+	if err := live.Update(func(b *ConfigBot) error { return b.ApplySetting(SettingPollInterval, "1") }); err == nil {
+		t.Error("invalid setting was applied")
+	}
+
+	if got := live.Load().ServerStatus.Rcon.QueryEverySeconds; got != 10 {
+		t.Errorf("failed update published poll interval %d", got)
+	}
+}
+
+func TestLiveReload(t *testing.T) {
+	current := &ConfigBot{Name: "bot", Crosschat: &ConfigCrosschat{Filter: &ConfigChatFilter{Keywords: []string{"scam"}}}}
+	next := &ConfigBot{Name: "bot", Crosschat: &ConfigCrosschat{Filter: &ConfigChatFilter{Keywords: []string{"scam", "cheat"}}}}
+
+	live := NewLive(current)
+	done := make(chan struct{})
+
+	// the features keep reading while the reload is applied, go test -race reports shared writes
+
+	go func() {
+		defer close(done)
+
+		for range 1000 {
+			if n := len(live.Load().Crosschat.Filter.Keywords); n != 1 && n != 2 {
+				t.Errorf("read %d keywords", n)
+			}
+		}
+	}()
+
+	for range 100 {
+		if err := live.Update(func(b *ConfigBot) error { b.ApplyLive(next, []string{"crosschat.filter"}); return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	<-done
+
+	if len(current.Crosschat.Filter.Keywords) != 1 {
+		t.Errorf("reload changed the running config")
+	}
+
+	if live.Load().Crosschat.Filter != next.Crosschat.Filter {
+		t.Errorf("reloaded filter was not published")
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	root, err := parse(t, `{"bots": [
+		{"name": "one", "botToken": "token", "eventer": {"channelID": "123456789012345678"}},
+		{"name": "two", "botToken": "token2", "language": "en", "guildLanguages": {"123456789012345670": "de"},
+			"eventer": {"channelID": "123456789012345679"}}]}`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one, two := root.AllBots()[0], root.AllBots()[1]
+
+	if lang := one.Eventer.Languages.Language(""); lang != i18n.German {
+		t.Errorf("eventer without language posts in %s, want german", i18n.Name(lang))
+	}
+
+	if lang := one.Languages.Language(""); lang != i18n.English {
+		t.Errorf("game chat without language replies in %s, want english", i18n.Name(lang))
+	}
+
+	if lang := two.Eventer.Languages.Language("123456789012345671"); lang != i18n.English {
+		t.Errorf("eventer posts in %s to other guilds, want the default english", i18n.Name(lang))
+	}
+
+	if lang := two.Eventer.Languages.Language("123456789012345670"); lang != i18n.German {
+		t.Errorf("eventer posts in %s to the guild, want its german", i18n.Name(lang))
+	}
+
+	_, err = parse(t, `{"bots": [{"name": "one", "botToken": "token", "language": "xx"}]}`)
+
+	if err == nil || !strings.Contains(err.Error(), "unknown language 'xx'") {
+		t.Errorf("expected unknown language to be reported, got %v", err)
+	}
+}
+
+func TestPasswordCommandQuotes(t *testing.T) {
+	args, err := splitCommand(`pass show "ark/The Island" 'rcon key' a\ b`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"pass", "show", "ark/The Island", "rcon key", "a b"}
+
+	if !slices.Equal(args, want) {
+		t.Errorf("split into %q, want %q", args, want)
+	}
+
+	if _, err := splitCommand(`pass show "ark/The Island`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestArkServersNeedDatabase(t *testing.T) {
+	config := `{"botToken": "token", "serverStatus": {"channelID": "123456789012345678", "rcon": {"servers": [
+		{"key": "rust", "name": "Rust", "address": "127.0.0.1:28016", "password": "p", "type": "rust"}%s]}}}`
+
+	if _, err := parse(t, strings.Replace(config, "%s", "", 1)); err != nil {
+		t.Fatalf("rust server without database rejected: %s", err)
+	}
+
+	_, err := parse(t, strings.Replace(config, "%s", `, {"key": "island", "name": "The Island", "address": "127.0.0.1:27020", "password": "p"}`, 1))
+
+	if err == nil || !strings.Contains(err.Error(), "serverStatus.DbConnection: no db connection configured, required for ark-ase servers like servers[1]") {
+		t.Fatalf("expected missing database to be reported, got %v", err)
+	}
+}
+
+func TestChatFilterKeywords(t *testing.T) {
+	root, err := parse(t, `{"botToken": "token", "crosschat": {"channelID": "123456789012345678", "DbConnection": "user:password@tcp/ark",
+		"WebhookCrosschat": "https://discord.com/api/webhooks/123456789012345678/token",
+		"filter": {"keywords": ["größe", "scam"], "channelID": "123456789012345678"}}}`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter := root.Crosschat.Filter
+
+	matches := func(message string) bool {
+		return slices.ContainsFunc(filter.Regexps, func(re *regexp.Regexp) bool { return re.MatchString(message) })
+	}
+
+	for _, message := range []string{"Größe", "die größe ist egal", "SCAM!", "a scam"} {
+		if !matches(message) {
+			t.Errorf("%q does not match", message)
+		}
+	}
+
+	for _, message := range []string{"übergröße", "größer", "scammer", "ascam"} {
+		if matches(message) {
+			t.Errorf("%q matches", message)
+		}
+	}
+}
+
+func TestJoinLeaveLifetimeKeepsArchive(t *testing.T) {
+	config := `{"botToken": "token", "serverStatus": {"channelID": "123456789012345678", "channelIDJoinLeave": "123456789012345678",
+		"archiveJoinLeave": true, "joinLeaveLifetime": "%s", "rcon": {"servers": [
+		{"key": "rust", "name": "Rust", "address": "127.0.0.1:28016", "password": "p", "type": "rust"}]}}}`
+
+	if _, err := parse(t, strings.Replace(config, "%s", "31 days", 1)); err != nil {
+		t.Fatalf("lifetime of a month rejected: %s", err)
+	}
+
+	_, err := parse(t, strings.Replace(config, "%s", "24 hours", 1))
+
+	if err == nil || !strings.Contains(err.Error(), "serverStatus.joinLeaveLifetime: must be at least 31 days") {
+		t.Fatalf("expected the short lifetime to be reported, got %v", err)
+	}
+}
+
+func TestRedactedHidesPasswords(t *testing.T) {
+	bot := &ConfigBot{BotToken: "token", ServerStatus: &ConfigServerStatus{Rcon: ConfigRcon{Servers: []ConfigRconServer{
+		{Key: "island", Password: "rcon secret", ConnectPassword: "join secret"},
+	}}}}
+
+	redacted, err := bot.Redacted()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"token", "rcon secret", "join secret"} {
+		if strings.Contains(redacted, `"`+secret+`"`) {
+			t.Errorf("%q is shown", secret)
+		}
+	}
+}
+
+func TestApprovalTimeoutPositive(t *testing.T) {
+	config := `{"botToken": "token", "admin": {"channelID": "123456789012345678", "roleIDs": ["123456789012345678"], "approvalTimeout": "%s"}}`
+
+	if _, err := parse(t, strings.Replace(config, "%s", "10 minutes", 1)); err != nil {
+		t.Fatalf("approval timeout rejected: %s", err)
+	}
+
+	for _, timeout := range []string{"0 minutes", "-5 minutes"} {
+		_, err := parse(t, strings.Replace(config, "%s", timeout, 1))
+
+		if err == nil || !strings.Contains(err.Error(), "admin.approvalTimeout: must be positive") {
+			t.Errorf("expected approval timeout %q to be reported, got %v", timeout, err)
+		}
+	}
+}
+
+func TestCrosschatNeedsDatabase(t *testing.T) {
+	_, err := parse(t, `{"botToken": "token", "crosschat": {"channelID": "123456789012345678",
+		"WebhookCrosschat": "https://discord.com/api/webhooks/123456789012345678/token"}}`)
+
+	if err == nil || !strings.Contains(err.Error(), "crosschat.DbConnection: no db connection configured") {
+		t.Fatalf("expected missing database to be reported at its key, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// HasPasswordSource reports whether the RCON password is read from a file, environment variable or command
+func (s ConfigRconServer) HasPasswordSource() bool {
+	return s.PasswordCommand != "" || s.PasswordFile != "" || s.PasswordEnv != ""
+}
+
+// LoadPassword reads the RCON password from its configured source. The command takes precedence over the
+// file, the file over the environment variable. Without any source the inline password is returned.
+func (s ConfigRconServer) LoadPassword() (string, error) {
+	switch {
+	case s.PasswordCommand != "":
+		args, err := splitCommand(s.PasswordCommand)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(args) == 0 {
+			return "", fmt.Errorf("empty password command")
+		}
+
+		out, err := exec.Command(args[0], args[1:]...).Output()
+
+		if err != nil {
+			return "", fmt.Errorf("password command failed: %w", err)
+		}
+
+		return strings.TrimSpace(string(out)), nil
+
+	case s.PasswordFile != "":
+		dat, err := os.ReadFile(s.PasswordFile)
+
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(string(dat)), nil
+
+	case s.PasswordEnv != "":
+		password, ok := os.LookupEnv(s.PasswordEnv)
+
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.PasswordEnv)
+		}
+
+		return password, nil
+	}
+
+	return s.Password, nil
+}
+
+// splitCommand splits the command into its arguments at spaces. Arguments in single or double quotes may contain
+// spaces, a backslash escapes the next character outside of single quotes.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, c := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(c)
+			escaped = false
+
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+
+		case quote != 0 && c == quote:
+			quote = 0
+
+		case quote != 0:
+			arg.WriteRune(c)
+
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in password command")
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const ageHeader = "age-encryption.org/v1"
+
+// decryptConfig returns the plain config file contents. age encrypted files are decrypted with the identity
+// from LAZYDODO_AGE_KEY (or the file given in LAZYDODO_AGE_KEY_FILE), SOPS encrypted files are passed to the
+// sops binary, which picks up its keys (SOPS_AGE_KEY, KMS credentials, ...) from the environment itself.
+// Unencrypted files are returned unchanged.
+func decryptConfig(configFile string, dat []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(dat, []byte(ageHeader)), bytes.HasPrefix(dat, []byte(armor.Header)):
+		return decryptAge(dat)
+	case isSops(dat):
+		return decryptSops(configFile)
+	}
+
+	return dat, nil
+}
+
+func decryptAge(dat []byte) ([]byte, error) {
+	key := os.Getenv("LAZYDODO_AGE_KEY")
+
+	if key == "" && os.Getenv("LAZYDODO_AGE_KEY_FILE") != "" {
+		keyFile, err := os.ReadFile(os.Getenv("LAZYDODO_AGE_KEY_FILE"))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age key file: %w", err)
+		}
+
+		key = string(keyFile)
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("config file is age encrypted, but neither LAZYDODO_AGE_KEY nor LAZYDODO_AGE_KEY_FILE is set")
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(key))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age key: %w", err)
+	}
+
+	var in io.Reader = bytes.NewReader(dat)
+
+	if bytes.HasPrefix(dat, []byte(armor.Header)) {
+		in = armor.NewReader(in)
+	}
+
+	r, err := age.Decrypt(in, identities...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func isSops(dat []byte) bool {
+	var probe struct {
+		Sops json.RawMessage `json:"sops"`
+	}
+
+	return json.Unmarshal(dat, &probe) == nil && len(probe.Sops) > 0
+}
+
+func decryptSops(configFile string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("sops", "--decrypt", "--input-type", "json", "--output-type", "json", configFile)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("sops failed: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
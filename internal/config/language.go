@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// Languages resolves the language of the messages posted to the channels of a guild. DMs and replies are
+// sent in the language the user has chosen with /language, falling back to the guild's.
+type Languages struct {
+	Default i18n.Language
+	Guilds  map[string]i18n.Language
+
+	// false if the bot has no default language, see orDefault
+	configured bool
+}
+
+// Language returns the language of the guild, the default one for other guilds or without a guild ("").
+// The replies in the game chat, which belongs to no guild, are sent in the default language.
+func (l *Languages) Language(guildID string) i18n.Language {
+	if l == nil {
+		return i18n.English
+	}
+
+	if lang, ok := l.Guilds[guildID]; ok {
+		return lang
+	}
+
+	return l.Default
+}
+
+// orDefault returns the languages with the given default if the bot has none
+func (l *Languages) orDefault(fallback i18n.Language) *Languages {
+	if l.configured {
+		return l
+	}
+
+	return &Languages{Default: fallback, Guilds: l.Guilds, configured: true}
+}
+
+// parseLanguages loads the languages of the bot, english if the bot has none
+func parseLanguages(bot *ConfigBot, path string) *Languages {
+	res := &Languages{Default: i18n.English, Guilds: make(map[string]i18n.Language)}
+
+	if bot.Language != "" {
+		res.Default, res.configured = parseLanguage(at(path, "language"), bot.Language)
+	}
+
+	for _, guildID := range slices.Sorted(maps.Keys(bot.GuildLanguages)) {
+		guildPath := at(path, "guildLanguages."+guildID)
+
+		checkSnowflake(guildPath, guildID)
+
+		if lang, ok := parseLanguage(guildPath, bot.GuildLanguages[guildID]); ok {
+			res.Guilds[guildID] = lang
+		}
+	}
+
+	return res
+}
+
+func parseLanguage(path string, code string) (i18n.Language, bool) {
+	lang, ok := i18n.Parse(code)
+
+	if !ok {
+		invalid(path, fmt.Sprintf("unknown language '%s' (expected one of %s)", code, strings.Join(slices.Sorted(maps.Keys(i18n.Codes)), ", ")))
+	}
+
+	return lang, ok
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Live is the running config of a bot. Reloads publish a changed copy instead of changing the running
+// config, so the features read it without locking. A loaded config must never be changed, and a feature
+// should load it once per use: two loads may return different configs.
+type Live struct {
+	// updates are serialized, so none is lost
+	mu      sync.Mutex
+	current atomic.Pointer[ConfigBot]
+}
+
+func NewLive(b *ConfigBot) *Live {
+	l := &Live{}
+	l.current.Store(b)
+
+	return l
+}
+
+// Load returns the current config of the bot
+func (l *Live) Load() *ConfigBot {
+	return l.current.Load()
+}
+
+// Update applies fn to a copy of the current config, which is published unless fn fails
+func (l *Live) Update(fn func(b *ConfigBot) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := l.current.Load().clone()
+
+	if err := fn(next); err != nil {
+		return err
+	}
+
+	l.current.Store(next)
+
+	return nil
+}
+
+// clone copies the bot config and the sections holding live settings. The live settings are always replaced
+// as a whole, so slices and maps may be shared with the copy.
+func (b *ConfigBot) clone() *ConfigBot {
+	res := *b
+
+	if b.ServerStatus != nil {
+		status := *b.ServerStatus
+		res.ServerStatus = &status
+
+		if b.ServerStatus.Announcements != nil {
+			announcements := *b.ServerStatus.Announcements
+			res.ServerStatus.Announcements = &announcements
+		}
+	}
+
+	if b.Eventer != nil {
+		eventer := *b.Eventer
+		res.Eventer = &eventer
+	}
+
+	if b.Admin != nil {
+		admin := *b.Admin
+		res.Admin = &admin
+	}
+
+	if b.Crosschat != nil {
+		crosschat := *b.Crosschat
+		res.Crosschat = &crosschat
+	}
+
+	if b.Cooldown != nil {
+		cooldown := *b.Cooldown
+		res.Cooldown = &cooldown
+	}
+
+	return &res
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// overrides collects repeated --set key=value flags
+type overrides []string
+
+func (o *overrides) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got '%s'", value)
+	}
+
+	*o = append(*o, value)
+
+	return nil
+}
+
+// applyOverride sets a single config value. The key is the dot separated path of JSON keys, e.g.
+// serverStatus.channelID. Values which are valid JSON (numbers, booleans, arrays) are decoded as such,
+// if that does not match the type of the setting the value is taken as a plain string.
+func applyOverride(c *ConfigRoot, override string) error {
+	key, value, _ := strings.Cut(override, "=")
+
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+
+	path := strings.Split(key, ".")
+	str, _ := json.Marshal(value)
+
+	if json.Valid([]byte(value)) {
+		if err := decodeOverride(c, path, []byte(value)); err == nil {
+			return nil
+		}
+	}
+
+	return decodeOverride(c, path, str)
+}
+
+// decodeOverride builds {"a":{"b":value}} for the path a.b and decodes it on top of the config c, this way
+// the override goes through the same parsing as the config file itself
+func decodeOverride(c *ConfigRoot, path []string, value json.RawMessage) error {
+	raw := value
+
+	for i := len(path) - 1; i >= 0; i-- {
+		wrapped, err := json.Marshal(map[string]json.RawMessage{path[i]: raw})
+
+		if err != nil {
+			return err
+		}
+
+		raw = wrapped
+	}
+
+	return json.Unmarshal(raw, c)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// how the platform IDs of the known players are stored: as reported by the server (default), as hash, or
+// only their last characters. Bans always keep the full ID, it is needed to lift them.
+const PlatformIDsFull = ""
+const PlatformIDsHash = "hash"
+const PlatformIDsTruncate = "truncate"
+
+var PlatformIDModes = []string{PlatformIDsFull, PlatformIDsHash, PlatformIDsTruncate}
+
+// stored IDs start with these, so IDs are not hashed or truncated twice
+const hashedIDPrefix = "h:"
+const truncatedIDPrefix = "t:"
+
+// characters of the platform ID kept when truncating
+const truncatedIDLength = 6
+
+type ConfigPrivacy struct {
+	PlatformIDs string `json:"platformIDs"`
+
+	// secret key of the hash, the stored IDs cannot be matched to players without it
+	Salt string `json:"salt"`
+}
+
+// PlatformID returns the platform ID as it is stored, nil means full IDs
+func (p *ConfigPrivacy) PlatformID(id string) string {
+	if p == nil || id == "" || Pseudonymous(id) {
+		return id
+	}
+
+	switch p.PlatformIDs {
+	case PlatformIDsHash:
+		mac := hmac.New(sha256.New, []byte(p.Salt))
+		mac.Write([]byte(id))
+
+		return hashedIDPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	case PlatformIDsTruncate:
+		if len(id) > truncatedIDLength {
+			id = id[len(id)-truncatedIDLength:]
+		}
+
+		return truncatedIDPrefix + id
+	}
+
+	return id
+}
+
+// Pseudonymous reports whether the stored ID was hashed or truncated, it cannot be banned then
+func Pseudonymous(id string) bool {
+	return strings.HasPrefix(id, hashedIDPrefix) || strings.HasPrefix(id, truncatedIDPrefix)
+}
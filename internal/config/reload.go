@@ -0,0 +1,278 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// config keys of the live settings which need more than copying the value
+const KeyPollInterval = "serverStatus.rcon.queryEverySeconds"
+const KeyRconServers = "serverStatus.rcon.servers"
+const KeyReminderOffsets = "eventer.reminderOffsets"
+const KeyCooldown = "cooldown"
+
+// settings which are applied to the running bot when the config file changes, everything else
+// (token, servers, channels, ...) needs a restart
+var liveSettings = map[string]func(b *ConfigBot, next *ConfigBot){
+	KeyCooldown: func(b *ConfigBot, next *ConfigBot) {
+		b.Cooldown = next.Cooldown
+	},
+	KeyPollInterval: func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.Rcon.QueryEverySeconds = next.ServerStatus.Rcon.QueryEverySeconds
+	},
+	KeyRconServers: func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.Rcon.Servers = next.ServerStatus.Rcon.Servers
+	},
+	"serverStatus.staleAfterPolls": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.StaleAfterPolls = next.ServerStatus.StaleAfterPolls
+	},
+	"serverStatus.snapshotForecast": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.SnapshotForecast = next.ServerStatus.SnapshotForecast
+	},
+	"serverStatus.showRenames": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.ShowRenames = next.ServerStatus.ShowRenames
+	},
+	"serverStatus.showRawNames": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.ShowRawNames = next.ServerStatus.ShowRawNames
+	},
+	"serverStatus.announcements.messages": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.Announcements.Messages = next.ServerStatus.Announcements.Messages
+	},
+	"serverStatus.incidentThreshold": func(b *ConfigBot, next *ConfigBot) {
+		b.ServerStatus.IncidentThresholdRaw = next.ServerStatus.IncidentThresholdRaw
+		b.ServerStatus.IncidentThreshold = next.ServerStatus.IncidentThreshold
+	},
+	KeyReminderOffsets: func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.ReminderOffsetsRaw = next.Eventer.ReminderOffsetsRaw
+		b.Eventer.ReminderOffsets = next.Eventer.ReminderOffsets
+	},
+	"eventer.mentionCooldown": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.MentionCooldownRaw = next.Eventer.MentionCooldownRaw
+		b.Eventer.MentionCooldown = next.Eventer.MentionCooldown
+	},
+	"eventer.mentionDigest": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.MentionDigest = next.Eventer.MentionDigest
+	},
+	"eventer.skipCancelNotice": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.SkipCancelNotice = next.Eventer.SkipCancelNotice
+	},
+	"eventer.richReminders": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.RichReminders = next.Eventer.RichReminders
+	},
+	"eventer.snoozeButton": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.SnoozeButton = next.Eventer.SnoozeButton
+	},
+	"eventer.attendanceRecap": func(b *ConfigBot, next *ConfigBot) {
+		b.Eventer.AttendanceRecap = next.Eventer.AttendanceRecap
+	},
+	"crosschat.filter": func(b *ConfigBot, next *ConfigBot) {
+		b.Crosschat.Filter = next.Crosschat.Filter
+	},
+	"admin.roleIDs": func(b *ConfigBot, next *ConfigBot) {
+		b.Admin.RoleIDs = next.Admin.RoleIDs
+	},
+	"admin.rconRoleIDs": func(b *ConfigBot, next *ConfigBot) {
+		b.Admin.RconRoleIDs = next.Admin.RconRoleIDs
+	},
+	"admin.approvalTimeout": func(b *ConfigBot, next *ConfigBot) {
+		b.Admin.ApprovalTimeoutRaw = next.Admin.ApprovalTimeoutRaw
+		b.Admin.ApprovalTimeout = next.Admin.ApprovalTimeout
+	},
+}
+
+// reloading is set while Reload parses the config, an invalid config is returned as error instead of exiting
+var reloading bool
+var reloadLock sync.Mutex
+
+type invalidConfig string
+
+// fail reports an invalid config. On startup the bot exits, on reload the config is rejected.
+func fail(msg string) {
+	if reloading {
+		panic(invalidConfig(msg))
+	}
+
+	slog.Info(msg)
+	os.Exit(1)
+}
+
+// Reload parses the config file again, with the flags given on startup. The running config is not changed.
+func Reload() (root *ConfigRoot, err error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	reloading = true
+
+	defer func() {
+		reloading = false
+
+		if r := recover(); r != nil {
+			msg, ok := r.(invalidConfig)
+
+			if !ok {
+				panic(r)
+			}
+
+			root, err = nil, errors.New(string(msg))
+		}
+	}()
+
+	root = &ConfigRoot{Simulate: Config.Simulate}
+	parseConfig(root)
+
+	return root, nil
+}
+
+// Changes are the settings of a bot changed in the reloaded config, as JSON key paths like serverStatus.rcon.servers
+type Changes struct {
+	Live    []string
+	Restart []string
+}
+
+// Changes compares the bot config with the reloaded one
+func (b *ConfigBot) Changes(next *ConfigBot) (Changes, error) {
+	var res Changes
+
+	cur, err := jsonTree(b)
+
+	if err != nil {
+		return res, err
+	}
+
+	upd, err := jsonTree(next)
+
+	if err != nil {
+		return res, err
+	}
+
+	var paths []string
+
+	diffTree("", cur, upd, &paths)
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if key := liveKey(path); key != "" {
+			if !slices.Contains(res.Live, key) {
+				res.Live = append(res.Live, key)
+			}
+		} else {
+			res.Restart = append(res.Restart, path)
+		}
+	}
+
+	return res, nil
+}
+
+// ApplyLive copies the given live settings from the reloaded config. It changes b, so it must only be applied
+// to the copy passed to Live.Update, never to a loaded config.
+func (b *ConfigBot) ApplyLive(next *ConfigBot, keys []string) {
+	for _, key := range keys {
+		if apply, ok := liveSettings[key]; ok {
+			apply(b, next)
+		}
+	}
+}
+
+// liveKey returns the live setting the changed path belongs to, empty if it needs a restart
+func liveKey(path string) string {
+	for key := range liveSettings {
+		if path == key || strings.HasPrefix(path, key+".") {
+			return key
+		}
+	}
+
+	return ""
+}
+
+func jsonTree(v any) (any, error) {
+	dat, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tree any
+
+	return tree, json.Unmarshal(dat, &tree)
+}
+
+// diffTree collects the paths of all values which differ, objects are compared key by key,
+// everything else (including arrays) as a whole
+func diffTree(prefix string, a any, b any, paths *[]string) {
+	ma, okA := a.(map[string]any)
+	mb, okB := b.(map[string]any)
+
+	if !okA || !okB {
+		if !reflect.DeepEqual(a, b) {
+			*paths = append(*paths, prefix)
+		}
+
+		return
+	}
+
+	keys := make(map[string]bool)
+
+	for key := range ma {
+		keys[key] = true
+	}
+
+	for key := range mb {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		path := key
+
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		diffTree(path, ma[key], mb[key], paths)
+	}
+}
+
+// PendingChanges are the changes of each bot which wait for /config apply
+type PendingChanges struct {
+	sync.Mutex
+	Keys map[string][]string
+}
+
+var pending = &PendingChanges{Keys: make(map[string][]string)}
+
+// SetPending replaces the changes of the bot waiting for a restart
+func SetPending(botName string, keys []string) {
+	pending.Lock()
+	defer pending.Unlock()
+
+	pending.Keys[botName] = keys
+}
+
+// Pending returns the changes of the bot waiting for a restart
+func Pending(botName string) []string {
+	pending.Lock()
+	defer pending.Unlock()
+
+	return pending.Keys[botName]
+}
+
+var restart = make(chan struct{}, 1)
+
+// RequestRestart asks the main loop to restart the bot with the current config file
+func RequestRestart() {
+	select {
+	case restart <- struct{}{}:
+	default:
+	}
+}
+
+// RestartRequested is signalled by RequestRestart
+func RestartRequested() <-chan struct{} {
+	return restart
+}
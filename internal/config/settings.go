@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// settings which can be changed at runtime with /config set
+const SettingPollInterval = "poll-interval"
+const SettingReminderOffsets = "reminder-offsets"
+
+var Settings = []string{SettingPollInterval, SettingReminderOffsets}
+
+// config keys whose values are never shown
+var secretKeys = map[string]bool{
+	"botToken":         true,
+	"password":         true,
+	"connectPassword":  true,
+	"WebhookCrosschat": true,
+}
+
+// ApplySetting validates the value and changes the given runtime setting of the bot.
+// poll-interval is given in seconds, reminder-offsets as comma separated durations, e.g. "1 day, 2 hours".
+func (b *ConfigBot) ApplySetting(name string, value string) error {
+	switch name {
+	case SettingPollInterval:
+		if b.ServerStatus == nil {
+			return fmt.Errorf("server status is not configured")
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+
+		if err != nil || seconds < 5 {
+			return fmt.Errorf("poll interval must be a number of seconds (at least 5)")
+		}
+
+		b.ServerStatus.Rcon.QueryEverySeconds = seconds
+
+	case SettingReminderOffsets:
+		if b.Eventer == nil {
+			return fmt.Errorf("eventer is not configured")
+		}
+
+		var raw []string
+
+		for _, part := range strings.Split(value, ",") {
+			raw = append(raw, strings.TrimSpace(part))
+		}
+
+		offsets, err := parseDurations(raw)
+
+		if err != nil {
+			return err
+		}
+
+		b.Eventer.ReminderOffsetsRaw = raw
+		b.Eventer.ReminderOffsets = offsets
+
+	default:
+		return fmt.Errorf("unknown setting '%s'", name)
+	}
+
+	return nil
+}
+
+// Redacted returns the bot config as indented JSON with all secrets removed
+func (b *ConfigBot) Redacted() (string, error) {
+	dat, err := json.Marshal(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	var tree any
+
+	if err := json.Unmarshal(dat, &tree); err != nil {
+		return "", err
+	}
+
+	redact(tree)
+
+	dat, err = json.MarshalIndent(tree, "", "  ")
+
+	return string(dat), err
+}
+
+func redact(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if s, ok := value.(string); ok && s != "" && secretKeys[key] {
+				n[key] = "***"
+			} else if ok && key == "DbConnection" {
+				n[key] = CleanDbString(s)
+			} else {
+				redact(value)
+			}
+		}
+	case []any:
+		for _, value := range n {
+			redact(value)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// the eventer used CET before time zones were configurable, it keeps doing so without a timezone
+const eventerDefaultTimezone = "Europe/Berlin"
+
+// Timezones resolves the time zone of the schedules and dates of a guild. Wall clock times like "20:00"
+// are applied in the time zone, so they keep their local time across DST changes.
+type Timezones struct {
+	Default *time.Location
+	Guilds  map[string]*time.Location
+}
+
+// Location returns the time zone of the guild, the default one for other guilds or without a guild ("")
+func (t *Timezones) Location(guildID string) *time.Location {
+	if t == nil {
+		return time.Local
+	}
+
+	if loc, ok := t.Guilds[guildID]; ok {
+		return loc
+	}
+
+	return t.Default
+}
+
+// orDefault returns the time zones with the given default if the bot has none
+func (t *Timezones) orDefault(fallback *time.Location) *Timezones {
+	if t.Default != nil {
+		return t
+	}
+
+	return &Timezones{Default: fallback, Guilds: t.Guilds}
+}
+
+// parseTimezones loads the time zones of the bot, the default is nil if the bot has none
+func parseTimezones(bot *ConfigBot, path string) *Timezones {
+	res := &Timezones{Guilds: make(map[string]*time.Location)}
+
+	if bot.Timezone != "" {
+		res.Default = loadTimezone(at(path, "timezone"), bot.Timezone)
+	}
+
+	for _, guildID := range slices.Sorted(maps.Keys(bot.GuildTimezones)) {
+		guildPath := at(path, "guildTimezones."+guildID)
+
+		checkSnowflake(guildPath, guildID)
+
+		if loc := loadTimezone(guildPath, bot.GuildTimezones[guildID]); loc != nil {
+			res.Guilds[guildID] = loc
+		}
+	}
+
+	return res
+}
+
+func loadTimezone(path string, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		invalid(path, fmt.Sprintf("unknown time zone '%s', expected a name like \"America/New_York\"", name))
+		return nil
+	}
+
+	return loc
+}
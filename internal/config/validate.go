@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// discord IDs are 64 bit integers, written as 17 to 20 digits
+var snowflakePattern = regexp.MustCompile(`^[0-9]{17,20}$`)
+
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// problems found while parsing the config, all are reported together by check
+var problems []string
+
+// invalid records a problem with the setting at the given path, e.g. "bots[1].eventer.channelID"
+func invalid(path string, msg string) {
+	if path == "" {
+		path = "(root)"
+	}
+
+	problems = append(problems, path+": "+msg)
+}
+
+// check fails if any problems were found, listing all of them
+func check() {
+	if len(problems) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("Config file %s has %d problem(s):\n  %s", configFile, len(problems), strings.Join(problems, "\n  "))
+	problems = nil
+
+	fail(msg)
+}
+
+// at returns the path of the key below the given path
+func at(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// checkUnknownFields reports the keys of the raw JSON value which don't match any setting of the given type,
+// those are silently ignored when decoding (and mostly typos)
+func checkUnknownFields(path string, raw any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == rawMessageType {
+		return
+	}
+
+	switch value := raw.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+
+			for _, key := range slices.Sorted(maps.Keys(value)) {
+				field, ok := fields[strings.ToLower(key)]
+
+				if !ok {
+					invalid(at(path, key), "unknown setting")
+					continue
+				}
+
+				checkUnknownFields(at(path, key), value[key], field.Type)
+			}
+		case reflect.Map:
+			for _, key := range slices.Sorted(maps.Keys(value)) {
+				checkUnknownFields(at(path, key), value[key], t.Elem())
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+
+		for i, v := range value {
+			checkUnknownFields(fmt.Sprintf("%s[%d]", path, i), v, t.Elem())
+		}
+	}
+}
+
+// checkProfiles reports unknown settings in all profiles, not just the selected one
+func checkProfiles(profiles map[string]json.RawMessage) {
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		var raw any
+
+		if err := json.Unmarshal(profiles[name], &raw); err == nil {
+			checkUnknownFields("profiles."+name, raw, reflect.TypeFor[ConfigRoot]())
+		}
+	}
+}
+
+// jsonFields returns the fields of the struct by their lowercased JSON name, like encoding/json
+// matches keys. Fields of embedded structs are included.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	res := make(map[string]reflect.StructField)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			for name, f := range jsonFields(field.Type) {
+				res[name] = f
+			}
+
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if name == "" {
+			name = field.Name
+		}
+
+		res[strings.ToLower(name)] = field
+	}
+
+	return res
+}
+
+// checkSnowflakes reports discord IDs (settings tagged `snowflake:"true"`, like "channelID" or "roleIDs")
+// which are not numeric, like names or mentions pasted instead of the ID
+func checkSnowflakes(path string, v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			tag := field.Tag.Get("json")
+
+			if tag == "-" || !field.IsExported() {
+				continue
+			}
+
+			if field.Anonymous && tag == "" {
+				checkSnowflakes(path, v.Field(i))
+				continue
+			}
+
+			name, _, _ := strings.Cut(tag, ",")
+
+			if field.Tag.Get("snowflake") != "true" {
+				checkSnowflakes(at(path, name), v.Field(i))
+				continue
+			}
+
+			switch value := v.Field(i).Interface().(type) {
+			case string:
+				checkSnowflake(at(path, name), value)
+			case []string:
+				for n, id := range value {
+					checkSnowflake(fmt.Sprintf("%s[%d]", at(path, name), n), id)
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			checkSnowflakes(fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+	}
+}
+
+func checkSnowflake(path string, id string) {
+	if id != "" && !snowflakePattern.MatchString(id) {
+		invalid(path, fmt.Sprintf("'%s' is not a discord ID", id))
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// editors often write a file in several steps, the reload waits until the writes settled
+const watchDebounce = time.Second
+
+// Watch calls onChange whenever the config file was written. The directory is watched instead of
+// the file, so files replaced by editors or config management are picked up as well.
+func Watch(onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	name := filepath.Base(configFile)
+
+	go func() {
+		var debounce *time.Timer
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Base(event.Name) != name || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+
+				if debounce == nil {
+					debounce = time.AfterFunc(watchDebounce, onChange)
+				} else {
+					debounce.Reset(watchDebounce)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				slog.Error(fmt.Sprintf("Failed to watch config file: %s", err))
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InRestartWindow reports whether the given time is within one of the restart windows of the server
+func (s ConfigRconServer) InRestartWindow(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, window := range s.RestartWindows {
+		from, to, err := parseTimeWindow(window)
+
+		if err != nil {
+			continue
+		}
+
+		// windows may span midnight, e.g. 23:55-00:05
+
+		if from <= to && minute >= from && minute < to {
+			return true
+		}
+
+		if from > to && (minute >= from || minute < to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTimeWindow parses "HH:MM-HH:MM" into minutes since midnight
+func parseTimeWindow(window string) (int, int, error) {
+	fromStr, toStr, ok := strings.Cut(window, "-")
+
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+
+	from, err := time.Parse("15:04", strings.TrimSpace(fromStr))
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := time.Parse("15:04", strings.TrimSpace(toStr))
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), nil
+}
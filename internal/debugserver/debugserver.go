@@ -0,0 +1,29 @@
+package debugserver
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// Start serves the pprof endpoints on localhost at the configured port. Only
+// bound to the loopback interface, use e.g. an SSH tunnel to profile remotely.
+func Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", cfg.Config.PprofPort)
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error(fmt.Sprintf("Failed to start pprof endpoint: %s", err))
+		}
+	}()
+}
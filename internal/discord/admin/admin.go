@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/eventer"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/subscriptions"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/store"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+type Admin struct {
+	live         *cfg.Live
+	cache        *cache.Store
+	eventer      *eventer.Eventer
+	subs         *subscriptions.Subscriptions
+	pollInterval chan<- int
+	database     *store.Store
+}
+
+// NewAdmin creates the admin commands of a bot. eventer and database may be nil if the bot has no eventer
+// or no database configured, changes of the poll interval are sent to pollInterval.
+func NewAdmin(live *cfg.Live, cache *cache.Store, eventer *eventer.Eventer, subs *subscriptions.Subscriptions,
+	pollInterval chan<- int, database *store.Store) *Admin {
+	return &Admin{live: live, cache: cache, eventer: eventer, subs: subs, pollInterval: pollInterval, database: database}
+}
+
+// bot returns the current config of the bot, see cfg.Live
+func (a *Admin) bot() *cfg.ConfigBot {
+	return a.live.Load()
+}
+
+// IsAdmin checks whether the member who triggered the interaction has one of the configured admin roles.
+func (a *Admin) IsAdmin(i *discordgo.InteractionCreate) bool {
+	if a.bot().Admin == nil || i.Member == nil {
+		return false
+	}
+
+	for _, role := range i.Member.Roles {
+		if slices.Contains(a.bot().Admin.RoleIDs, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireAdmin replies with an error and returns false if the invoking member is no admin.
+func (a *Admin) RequireAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if a.IsAdmin(i) {
+		return true
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Content: i18n.T(a.subs.Language(interactions.UserID(i), utils.English), "admin.denied"),
+	})
+
+	return false
+}
+
+// Register adds all admin commands to the interaction dispatcher.
+func (a *Admin) Register(d *interactions.Dispatcher) {
+	a.registerBotStats(d)
+	a.registerConfig(d)
+	a.registerFind(d)
+
+	if a.bot().ServerStatus != nil {
+		a.registerMaintenance(d)
+		a.registerCredentials(d)
+		a.registerForget(d)
+
+		if a.bot().Admin != nil && len(a.bot().Admin.RconRoleIDs) > 0 {
+			a.registerRcon(d)
+		}
+
+		if a.bot().ServerStatus.Announcements != nil {
+			a.registerAnnouncements(d)
+		}
+
+		if a.bot().ServerStatus.BanSync != nil {
+			a.registerBans(d)
+		}
+	}
+}
+
+// serverName returns the name of the server with the given key
+func (a *Admin) serverName(key string) string {
+	if a.bot().ServerStatus == nil {
+		return key
+	}
+
+	return cfg.ServerName(a.bot().ServerStatus.Rcon.Servers, key)
+}
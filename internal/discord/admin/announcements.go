@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/announcements"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+)
+
+func (a *Admin) registerAnnouncements(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "announcements",
+		Description: "Manage the in-game announcements (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show the announcements in rotation",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add an announcement to the rotation",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "The message broadcast in the game",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove an announcement from the rotation",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "number",
+						Description: "Number of the announcement as shown by list",
+						Required:    true,
+						MinValue:    &minAnnouncementNumber,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Use the announcements of the config file again",
+			},
+		},
+	}, a.handleAnnouncements)
+
+	d.Restrict("announcements", a.IsAdmin)
+}
+
+var minAnnouncementNumber = 1.0
+
+func (a *Admin) handleAnnouncements(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	messages := slices.Clone(announcements.Messages(a.bot().ServerStatus.Announcements, a.cache))
+
+	switch sub.Name {
+	case "list":
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: announcementList(messages)})
+		return
+
+	case "add":
+		messages = append(messages, sub.Options[0].StringValue())
+
+	case "remove":
+		number := int(sub.Options[0].IntValue())
+
+		if number > len(messages) {
+			interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("There is no announcement %d.", number)})
+			return
+		}
+
+		messages = slices.Delete(messages, number-1, number)
+
+		// an empty list must not fall back to the config
+
+		if messages == nil {
+			messages = []string{}
+		}
+
+	case "reset":
+		messages = nil
+	}
+
+	slog.Info(fmt.Sprintf("User %s changed the announcements (%s)", interactions.UserID(i), sub.Name))
+
+	err := a.cache.Update(func(k *cache.CacheData) {
+		k.Announcements = messages
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store announcements in cache: %s", err))
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Failed to change the announcements: %s", err)})
+		return
+	}
+
+	if messages == nil {
+		messages = a.bot().ServerStatus.Announcements.Messages
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: announcementList(messages)})
+}
+
+func announcementList(messages []string) string {
+	if len(messages) == 0 {
+		return "No announcements in rotation."
+	}
+
+	var lines []string
+
+	for n, message := range messages {
+		lines = append(lines, fmt.Sprintf("%d. %s", n+1, message))
+	}
+
+	res := "Announcements in rotation:\n" + strings.Join(lines, "\n")
+
+	if len(res) > 2000 {
+		res = res[:1996] + "\n..."
+	}
+
+	return res
+}
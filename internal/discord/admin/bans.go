@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/bans"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+func (a *Admin) registerBans(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "ban",
+		Description: "Ban a player on all servers (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "Platform ID or name of a known player",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "The reason, shown to the player where supported",
+			},
+		},
+	}, a.handleBan)
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "unban",
+		Description: "Lift the ban of a player on all servers (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "Platform ID or name of a known player",
+				Required:    true,
+			},
+		},
+	}, a.handleBan)
+
+	d.Restrict("ban", a.IsAdmin)
+	d.Restrict("unban", a.IsAdmin)
+}
+
+// handleBan handles /ban and /unban
+func (a *Admin) handleBan(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+
+	var player string
+	var reason string
+
+	for _, o := range data.Options {
+		switch o.Name {
+		case "player":
+			player = o.StringValue()
+		case "reason":
+			reason = o.StringValue()
+		}
+	}
+
+	id, name, err := a.resolvePlayer(player)
+
+	if err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: err.Error()})
+		return
+	}
+
+	userID := interactions.UserID(i)
+
+	slog.Info(fmt.Sprintf("User %s runs /%s for %s", userID, data.Name, id))
+
+	// every server of the group takes a few RCON commands
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to respond to interaction: %s", err))
+		return
+	}
+
+	sync := bans.New(a.live, a.cache)
+
+	var errs map[string]error
+
+	title := fmt.Sprintf("Banned %s", name)
+
+	if data.Name == "unban" {
+		title = fmt.Sprintf("Unbanned %s", name)
+		errs = sync.Unban(id)
+	} else {
+		errs = sync.Ban(id, cache.Ban{Name: name, Reason: reason, BannedBy: userID, Time: time.Now()})
+	}
+
+	embed := &discordgo.MessageEmbed{Title: title, Color: 0x57F287} // Discord green
+
+	var lines []string
+
+	for _, server := range sync.Servers() {
+		if err, failed := errs[server]; failed {
+			lines = append(lines, fmt.Sprintf("- %s: failed, %s", cfg.ServerName(a.bot().ServerStatus.Rcon.Servers, server), err))
+			embed.Color = 0xc1121f
+		} else {
+			lines = append(lines, fmt.Sprintf("- %s: done", cfg.ServerName(a.bot().ServerStatus.Rcon.Servers, server)))
+		}
+	}
+
+	embed.Description = fmt.Sprintf("Platform ID `%s`\n\n%s", id, strings.Join(lines, "\n"))
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}}); err != nil {
+		slog.Error(fmt.Sprintf("Failed to post ban result: %s", err))
+	}
+}
+
+// resolvePlayer returns the platform ID and name of the player, which is either given by platform ID or by
+// the current name of a known player
+func (a *Admin) resolvePlayer(player string) (string, string, error) {
+	cacheData, err := a.cache.Get()
+
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to load players: %s", err)
+	}
+
+	player = strings.TrimSpace(player)
+
+	if rcon.ValidPlatformID(player) {
+		if identity, ok := cacheData.Players[cfg.Config.Privacy.PlatformID(player)]; ok {
+			return player, utils.SanitizeName(identity.Name), nil
+		}
+
+		return player, player, nil
+	}
+
+	var ids []string
+
+	for id, identity := range cacheData.Players {
+		if strings.EqualFold(utils.SanitizeName(identity.Name), utils.SanitizeName(player)) {
+			ids = append(ids, id)
+		}
+	}
+
+	// without the full IDs the players cannot be banned by name
+
+	if len(ids) > 0 && cfg.Pseudonymous(ids[0]) {
+		return "", "", fmt.Errorf("Platform IDs are not stored in full, use the platform ID of '%s' instead.", player)
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", "", fmt.Errorf("No known player is named '%s', use the platform ID instead.", player)
+	case 1:
+		return ids[0], utils.SanitizeName(cacheData.Players[ids[0]].Name), nil
+	}
+
+	sort.Strings(ids)
+
+	return "", "", fmt.Errorf("Several players are named '%s', use one of the platform IDs instead: `%s`", player, strings.Join(ids, "`, `"))
+}
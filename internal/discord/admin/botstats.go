@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+var startedAt = time.Now()
+
+func (a *Admin) registerBotStats(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "botstats",
+		Description: "Show bot diagnostics (admins only)",
+	}, a.handleBotStats)
+
+	d.Restrict("botstats", a.IsAdmin)
+}
+
+func (a *Admin) handleBotStats(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	inline := func(name string, value string) *discordgo.MessageEmbedField {
+		return &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true}
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		inline("Version", orDash(cfg.Version)),
+		inline("Uptime", utils.FormatDuration(time.Since(startedAt), utils.English)),
+		inline("Gateway latency", fmt.Sprintf("%d ms", s.HeartbeatLatency().Milliseconds())),
+		inline("Memory", fmt.Sprintf("%.1f MiB (heap %.1f MiB)", float64(mem.Sys)/1024/1024, float64(mem.HeapAlloc)/1024/1024)),
+		inline("Goroutines", fmt.Sprintf("%d", runtime.NumGoroutine())),
+	}
+
+	if a.eventer != nil {
+		fields = append(fields, inline("Pending reminders", fmt.Sprintf("%d", a.eventer.PendingCount())))
+	}
+
+	if a.bot().ServerStatus != nil {
+		var lines []string
+
+		errorCounts := alerts.Counts()
+
+		for _, server := range a.bot().ServerStatus.Rcon.Servers {
+			last := rcon.LastSuccess(server.Key)
+			line := fmt.Sprintf("- %s: <t:%d:R>", server.Name, last.Unix())
+
+			if last.IsZero() {
+				line = fmt.Sprintf("- %s: never", server.Name)
+			}
+
+			if counts, ok := errorCounts[server.Key]; ok {
+				line += fmt.Sprintf(" (errors: %s)", counts)
+			}
+
+			lines = append(lines, line)
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Last successful poll", Value: strings.Join(lines, "\n")})
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:  "Bot statistics",
+			Color:  0x5865F2, // Discord blurple
+			Fields: fields,
+		}},
+	})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+)
+
+func (a *Admin) registerConfig(d *interactions.Dispatcher) {
+	var settingChoices []*discordgo.ApplicationCommandOptionChoice
+
+	for _, setting := range cfg.Settings {
+		settingChoices = append(settingChoices, &discordgo.ApplicationCommandOptionChoice{Name: setting, Value: setting})
+	}
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "config",
+		Description: "Show or change the bot configuration (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the effective configuration",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Change a setting at runtime",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "setting",
+						Description: "The setting",
+						Required:    true,
+						Choices:     settingChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "value",
+						Description: "Poll interval in seconds, or reminder offsets like '1 day, 2 hours'",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "apply",
+				Description: "Restart the bot to apply the config file changes which need a reconnect",
+			},
+		},
+	}, a.handleConfig)
+
+	d.Restrict("config", a.IsAdmin)
+}
+
+func (a *Admin) handleConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+
+	if sub.Name == "show" {
+		a.showConfig(s, i)
+		return
+	}
+
+	if sub.Name == "apply" {
+		a.applyConfig(s, i)
+		return
+	}
+
+	var setting string
+	var value string
+
+	for _, o := range sub.Options {
+		switch o.Name {
+		case "setting":
+			setting = o.StringValue()
+		case "value":
+			value = o.StringValue()
+		}
+	}
+
+	if err := a.applySetting(s, setting, value); err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Failed to change %s: %s", setting, err)})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s changed setting %s to '%s'", interactions.UserID(i), setting, value))
+
+	err := a.cache.Update(func(k *cache.CacheData) {
+		if k.Settings == nil {
+			k.Settings = make(map[string]string)
+		}
+
+		k.Settings[setting] = value
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store setting %s in cache: %s", setting, err))
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Changed %s to '%s', but failed to persist it: %s", setting, value, err)})
+		return
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Changed %s to '%s'.", setting, value)})
+}
+
+func (a *Admin) applySetting(s *discordgo.Session, setting string, value string) error {
+	switch setting {
+	case cfg.SettingPollInterval:
+		if err := a.update(setting, value); err != nil {
+			return err
+		}
+
+		// the poll loop picks up the new interval, if it is busy the change is dropped and applied on restart
+
+		select {
+		case a.pollInterval <- a.bot().ServerStatus.Rcon.QueryEverySeconds:
+		default:
+		}
+
+		return nil
+
+	case cfg.SettingReminderOffsets:
+		if a.eventer == nil {
+			return fmt.Errorf("eventer is not configured")
+		}
+
+		return a.eventer.Reschedule(s, func() error {
+			return a.update(setting, value)
+		})
+	}
+
+	return a.update(setting, value)
+}
+
+// update publishes the config with the setting changed
+func (a *Admin) update(setting string, value string) error {
+	return a.live.Update(func(b *cfg.ConfigBot) error {
+		return b.ApplySetting(setting, value)
+	})
+}
+
+// applyConfig restarts the bot with the config file, once changes which cannot be applied at runtime are pending
+func (a *Admin) applyConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	keys := cfg.Pending(a.bot().Name)
+
+	if len(keys) == 0 {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: "No pending changes, the config file is applied already."})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s requested a restart to apply config changes: %s", interactions.UserID(i), strings.Join(keys, ", ")))
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Restarting to apply the changed settings: `%s`", strings.Join(keys, "`, `")),
+	})
+
+	cfg.RequestRestart()
+}
+
+func (a *Admin) showConfig(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	redacted, err := a.bot().Redacted()
+
+	if err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Failed to render configuration: %s", err)})
+		return
+	}
+
+	description := fmt.Sprintf("```json\n%s\n```", redacted)
+
+	if len(description) > 4000 {
+		description = description[:3990] + "\n...\n```"
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       fmt.Sprintf("Configuration of bot '%s'", a.bot().Name),
+			Description: description,
+			Color:       0x5865F2, // Discord blurple
+		}},
+	})
+}
@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+func (a *Admin) registerCredentials(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "rotatepassword",
+		Description: "Set a new random RCON password on a server (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(a.bot().ServerStatus.Rcon.Servers),
+			},
+		},
+	}, a.handleRotatePassword)
+
+	d.Restrict("rotatepassword", a.IsAdmin)
+}
+
+func (a *Admin) handleRotatePassword(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	server := i.ApplicationCommandData().Options[0].StringValue()
+
+	slog.Info(fmt.Sprintf("User %s rotates the RCON password of %s", interactions.UserID(i), server))
+
+	// setting and verifying the password takes a few round trips to the server
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to respond to interaction: %s", err))
+		return
+	}
+
+	rotation, err := rcon.RotatePassword(a.bot().ServerStatus.Rcon, server)
+	embed := rotationResult(a.serverName(server), rotation, err)
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}}); err != nil {
+		slog.Error(fmt.Sprintf("Failed to post password rotation result: %s", err))
+	}
+}
+
+func rotationResult(server string, rotation rcon.Rotation, err error) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("RCON password: %s", server),
+		Color: 0x57F287, // Discord green
+	}
+
+	// without a new password nothing was changed
+
+	if err != nil && rotation.Password == "" {
+		slog.Error(fmt.Sprintf("Failed to rotate RCON password of %s: %s", server, err))
+
+		embed.Description = fmt.Sprintf("Failed: %s\n\nThe password was not changed.", err)
+		embed.Color = 0xc1121f
+
+		return embed
+	}
+
+	embed.Description = "The server uses the new password, the bot connected successfully."
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to rotate RCON password of %s: %s", server, err))
+
+		embed.Description = fmt.Sprintf("The new password was set, but %s.", err)
+		embed.Color = 0xc1121f
+	}
+
+	if rotation.File != "" {
+		embed.Description += fmt.Sprintf("\n\nThe password file `%s` was updated.", rotation.File)
+	} else {
+		embed.Description += "\n\nThe bot only keeps the new password until it is restarted, update the password in the config " +
+			"or password source now."
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{{Name: "New password", Value: fmt.Sprintf("||`%s`||", rotation.Password)}}
+
+	return embed
+}
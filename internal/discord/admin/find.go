@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// max. number of players listed by /find
+const maxFindResults = 10
+
+type playerMatch struct {
+	id       string
+	identity cache.PlayerIdentity
+	score    int
+}
+
+func (a *Admin) registerFind(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "find",
+		Description: "Search the known players by (former) name (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "The name or part of it",
+				Required:    true,
+			},
+		},
+	}, a.handleFind)
+
+	d.Restrict("find", a.IsAdmin)
+}
+
+func (a *Admin) handleFind(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	query := i.ApplicationCommandData().Options[0].StringValue()
+
+	cacheData, err := a.cache.Get()
+
+	if err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Failed to load players: %s", err)})
+		return
+	}
+
+	var matches []playerMatch
+
+	for id, identity := range cacheData.Players {
+		best := -1
+
+		for _, name := range append([]string{identity.Name}, identity.Previous...) {
+			if score := matchName(query, name); score >= 0 && (best < 0 || score < best) {
+				best = score
+			}
+		}
+
+		if best >= 0 {
+			matches = append(matches, playerMatch{id: id, identity: identity, score: best})
+		}
+	}
+
+	if len(matches) == 0 {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("No player matches '%s'.", query)})
+		return
+	}
+
+	// best matches first, recently seen players before the others
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+
+		return matches[i].identity.LastSeen.After(matches[j].identity.LastSeen)
+	})
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Players matching '%s'", query),
+		Color: 0x5865F2, // Discord blurple
+	}
+
+	for n, m := range matches {
+		if n == maxFindResults {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d more matches, refine the search to see them", len(matches)-n)}
+			break
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: utils.SanitizeName(m.identity.Name), Value: a.playerDetails(m)})
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}})
+}
+
+func (a *Admin) playerDetails(m playerMatch) string {
+	lines := []string{fmt.Sprintf("ID `%s`", m.id)}
+
+	if len(m.identity.Previous) > 0 {
+		var previous []string
+
+		for _, name := range m.identity.Previous {
+			previous = append(previous, utils.SanitizeName(name))
+		}
+
+		lines = append(lines, "Formerly: "+strings.Join(previous, ", "))
+	}
+
+	if len(m.identity.Servers) > 0 {
+		var servers []string
+
+		for _, key := range m.identity.Servers {
+			servers = append(servers, a.serverName(key))
+		}
+
+		lines = append(lines, "Servers: "+strings.Join(servers, ", "))
+	}
+
+	lines = append(lines, fmt.Sprintf("Last seen <t:%d:R>, playtime %s", m.identity.LastSeen.Unix(),
+		utils.FormatDurationStyle(m.identity.Playtime, utils.English, utils.DurationStyle{Compact: true})))
+
+	return strings.Join(lines, "\n")
+}
+
+// matchName rates how well the name matches the query, lower is better: 0 for the same name, 1 if the name
+// starts with the query, 2 if it contains it and 3 if it contains its letters in order. -1 if it does not match.
+// Names are compared case insensitive and sanitized, so decorations don't get in the way.
+func matchName(query string, name string) int {
+	q := strings.ToLower(utils.SanitizeName(query))
+	n := strings.ToLower(utils.SanitizeName(name))
+
+	switch {
+	case q == "":
+		return -1
+	case n == q:
+		return 0
+	case strings.HasPrefix(n, q):
+		return 1
+	case strings.Contains(n, q):
+		return 2
+	}
+
+	rest := []rune(q)
+
+	for _, r := range n {
+		if len(rest) > 0 && r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+
+	if len(rest) == 0 {
+		return 3
+	}
+
+	return -1
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+func (a *Admin) registerForget(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "forget",
+		Description: "Delete everything stored about a player (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "The platform ID, or the name of the player",
+				Required:    true,
+			},
+		},
+	}, a.handleForget)
+
+	d.Restrict("forget", a.IsAdmin)
+}
+
+// handleForget deletes the player's identity, its sessions in the history and its follows. Bans are kept,
+// lifting them needs the platform ID.
+func (a *Admin) handleForget(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	player := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	id, known, err := a.forgettablePlayer(player)
+
+	if err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: err.Error()})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s runs /forget for %s", interactions.UserID(i), player))
+
+	names, deleted, err := a.cache.ForgetPlayer(id, known)
+
+	if err != nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Failed to delete the player: %s", err)})
+		return
+	}
+
+	sessions, err := history.ForgetPlayer(names)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to delete the sessions of %s from the history: %s", player, err))
+	}
+
+	events, err := a.database.ForgetPlayer(names)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to delete the join/leave events of %s from the database: %s", player, err))
+	}
+
+	deleted += events
+
+	if deleted == 0 && sessions == 0 {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: fmt.Sprintf("Nothing is stored about '%s'.", player)})
+		return
+	}
+
+	for n, name := range names {
+		names[n] = utils.SanitizeName(name)
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Deleted %s: %d stored entries and %d sessions of the history. Bans are kept.",
+			strings.Join(names, ", "), deleted, sessions),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// forgettablePlayer returns the stored platform ID of the player given by ID or name, and the names of a
+// player without ID
+func (a *Admin) forgettablePlayer(player string) (string, []string, error) {
+	if rcon.ValidPlatformID(player) || cfg.Pseudonymous(player) {
+		return cfg.Config.Privacy.PlatformID(player), nil, nil
+	}
+
+	cacheData, err := a.cache.Get()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed to load players: %s", err)
+	}
+
+	var ids []string
+
+	for id, identity := range cacheData.Players {
+		if strings.EqualFold(utils.SanitizeName(identity.Name), utils.SanitizeName(player)) {
+			ids = append(ids, id)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", []string{player}, nil
+	case 1:
+		return ids[0], nil, nil
+	}
+
+	sort.Strings(ids)
+
+	return "", nil, fmt.Errorf("Several players are named '%s', use one of the platform IDs instead: `%s`", player, strings.Join(ids, "`, `"))
+}
@@ -0,0 +1,351 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+const buttonApprove = "maintenance-approve"
+const buttonReject = "maintenance-reject"
+
+type maintenanceAction struct {
+	Name        string
+	Label       string
+	Destructive bool
+}
+
+var maintenanceActions = []maintenanceAction{
+	{Name: "save", Label: "Save world"},
+	{Name: "wipe-dinos", Label: "Wipe wild dinos", Destructive: true},
+	{Name: "restart", Label: "Restart server", Destructive: true},
+}
+
+// commandsFor returns the commands of the action for the given server type, none if it isn't supported
+func (action maintenanceAction) commandsFor(serverType string) []string {
+	return cfg.MaintenanceCommands[action.Name][serverType]
+}
+
+type PendingAction struct {
+	ID          string
+	Server      string
+	ServerName  string
+	RequestedBy string
+	Action      maintenanceAction
+	ChannelID   string
+	MessageID   string
+	Expires     time.Time
+}
+
+type PendingStore struct {
+	sync.Mutex
+	Actions map[string]*PendingAction
+}
+
+var pending = &PendingStore{Actions: make(map[string]*PendingAction)}
+
+func (a *Admin) registerMaintenance(d *interactions.Dispatcher) {
+	var actionChoices []*discordgo.ApplicationCommandOptionChoice
+
+	for _, action := range maintenanceActions {
+		actionChoices = append(actionChoices, &discordgo.ApplicationCommandOptionChoice{Name: action.Label, Value: action.Name})
+	}
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "maintenance",
+		Description: "Run a maintenance action on a server (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(a.bot().ServerStatus.Rcon.Servers),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "action",
+				Description: "The action to run",
+				Required:    true,
+				Choices:     actionChoices,
+			},
+		},
+	}, a.handleMaintenance)
+
+	d.Restrict("maintenance", a.IsAdmin)
+
+	d.HandleComponent(buttonApprove, a.handleApprove)
+	d.HandleComponent(buttonReject, a.handleReject)
+
+	d.Restrict(buttonApprove, a.IsAdmin)
+	d.Restrict(buttonReject, a.IsAdmin)
+}
+
+func (a *Admin) handleMaintenance(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	var server string
+	var action maintenanceAction
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "server":
+			server = o.StringValue()
+		case "action":
+			for _, a := range maintenanceActions {
+				if a.Name == o.StringValue() {
+					action = a
+				}
+			}
+		}
+	}
+
+	if action.Name == "" {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: "Unknown action."})
+		return
+	}
+
+	userID := interactions.UserID(i)
+
+	// non destructive actions are executed right away. RCON may take longer than discord waits for the
+	// response, so it is deferred and replaced by the result.
+
+	if !action.Destructive {
+		slog.Info(fmt.Sprintf("User %s runs maintenance action '%s' on %s", userID, action.Name, server))
+
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to respond to interaction: %s", err))
+			return
+		}
+
+		embeds := []*discordgo.MessageEmbed{a.runAction(server, action)}
+
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds}); err != nil {
+			slog.Error(fmt.Sprintf("Failed to post result of maintenance action '%s': %s", action.Name, err))
+		}
+
+		return
+	}
+
+	p := &PendingAction{
+		ID:          i.ID,
+		Server:      server,
+		ServerName:  cfg.ServerName(a.bot().ServerStatus.Rcon.Servers, server),
+		RequestedBy: userID,
+		Action:      action,
+		ChannelID:   i.ChannelID,
+		Expires:     time.Now().Add(a.bot().Admin.ApprovalTimeout),
+	}
+
+	slog.Info(fmt.Sprintf("User %s requested maintenance action '%s' on %s, waiting for approval", userID, action.Name, server))
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: fmt.Sprintf("%s: %s", action.Label, p.ServerName),
+				Description: fmt.Sprintf("Requested by <@%s>.\n\nAnother admin must approve this action <t:%d:R>, otherwise it is discarded.",
+					userID, p.Expires.Unix()),
+				Color: 0xfee75c, // Discord yellow
+			}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: "Approve", Style: discordgo.SuccessButton, CustomID: buttonApprove + ":" + p.ID},
+						discordgo.Button{Label: "Reject", Style: discordgo.DangerButton, CustomID: buttonReject + ":" + p.ID},
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to post approval request for maintenance action '%s': %s", action.Name, err))
+		return
+	}
+
+	msg, err := s.InteractionResponse(i.Interaction)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to fetch approval request message: %s", err))
+		return
+	}
+
+	p.MessageID = msg.ID
+
+	pending.Lock()
+	pending.Actions[p.ID] = p
+	pending.Unlock()
+
+	time.AfterFunc(a.bot().Admin.ApprovalTimeout, func() {
+		if takePending(p.ID) == nil {
+			return
+		}
+
+		slog.Info(fmt.Sprintf("Maintenance action '%s' on %s expired without approval", action.Name, server))
+
+		closeRequest(s, p, fmt.Sprintf("Requested by <@%s>.\n\nExpired without approval.", p.RequestedBy), 0x99aab5)
+	})
+}
+
+func (a *Admin) handleApprove(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	_, id, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	userID := interactions.UserID(i)
+
+	pending.Lock()
+	p := pending.Actions[id]
+
+	if p != nil && p.RequestedBy == userID {
+		pending.Unlock()
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: "The action must be approved by a different admin."})
+		return
+	}
+
+	delete(pending.Actions, id)
+	pending.Unlock()
+
+	if p == nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: "This request is no longer pending."})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s approved maintenance action '%s' on %s", userID, p.Action.Name, p.Server))
+
+	interactions.Acknowledge(s, i)
+
+	result := a.runAction(p.Server, p.Action)
+	result.Description = fmt.Sprintf("Requested by <@%s>, approved by <@%s>.\n\n%s", p.RequestedBy, userID, result.Description)
+
+	editRequest(s, p, result)
+}
+
+func (a *Admin) handleReject(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.RequireAdmin(s, i) {
+		return
+	}
+
+	_, id, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	p := takePending(id)
+
+	if p == nil {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: "This request is no longer pending."})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s rejected maintenance action '%s' on %s", interactions.UserID(i), p.Action.Name, p.Server))
+
+	interactions.Acknowledge(s, i)
+
+	closeRequest(s, p, fmt.Sprintf("Requested by <@%s>.\n\nRejected by <@%s>.", p.RequestedBy, interactions.UserID(i)), 0x99aab5)
+}
+
+func takePending(id string) *PendingAction {
+	pending.Lock()
+	defer pending.Unlock()
+
+	p := pending.Actions[id]
+	delete(pending.Actions, id)
+
+	return p
+}
+
+func closeRequest(s *discordgo.Session, p *PendingAction, description string, color int) {
+	editRequest(s, p, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s: %s", p.Action.Label, p.ServerName),
+		Description: description,
+		Color:       color,
+	})
+}
+
+func editRequest(s *discordgo.Session, p *PendingAction, embed *discordgo.MessageEmbed) {
+	embeds := []*discordgo.MessageEmbed{embed}
+	components := []discordgo.MessageComponent{}
+
+	_, err := output.FromDiscord(s).EditMessage(&discordgo.MessageEdit{
+		ID:         p.MessageID,
+		Channel:    p.ChannelID,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to update approval request message: %s", err))
+	}
+}
+
+// RunMaintenance runs the maintenance action with the given name on the server right away, e.g. at a wipe
+func (a *Admin) RunMaintenance(server string, name string) *discordgo.MessageEmbed {
+	idx := slices.IndexFunc(maintenanceActions, func(action maintenanceAction) bool { return action.Name == name })
+
+	if idx < 0 {
+		return &discordgo.MessageEmbed{Title: fmt.Sprintf("Unknown maintenance action '%s'", name), Color: 0xc1121f}
+	}
+
+	return a.runAction(server, maintenanceActions[idx])
+}
+
+func (a *Admin) runAction(server string, action maintenanceAction) *discordgo.MessageEmbed {
+	var out []string
+	color := 0x57F287 // Discord green
+
+	serverType := ""
+	serverName := cfg.ServerName(a.bot().ServerStatus.Rcon.Servers, server)
+
+	if candidate, ok := cfg.ServerByKey(a.bot().ServerStatus.Rcon.Servers, server); ok {
+		serverType = candidate.Type
+	}
+
+	commands := action.commandsFor(serverType)
+
+	if len(commands) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("%s: %s", action.Label, serverName),
+			Description: fmt.Sprintf("Not supported on %s servers.", serverType),
+			Color:       0xc1121f,
+		}
+	}
+
+	for _, command := range commands {
+		response, err := rcon.Execute(a.bot().ServerStatus.Rcon, server, command)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to execute '%s' on %s: %s", command, server, err))
+			out = append(out, fmt.Sprintf("> %s\nFailed: %s", command, err))
+			color = 0xc1121f
+			break
+		}
+
+		out = append(out, fmt.Sprintf("> %s\n%s", command, strings.TrimSpace(response)))
+	}
+
+	// discord allows 4096 characters in the description, the output is cut to leave room for the code block
+
+	description := fmt.Sprintf("```\n%s\n```", utils.Truncate(strings.Join(out, "\n\n"), 4000))
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s: %s", action.Label, serverName),
+		Description: description,
+		Color:       color,
+	}
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// discord limits the description of an embed to 4096 characters
+const maxRconReplyLength = 4000
+
+func (a *Admin) registerRcon(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "rcon",
+		Description: "Send a command to a server and show its reply (restricted)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(a.bot().ServerStatus.Rcon.Servers),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "command",
+				Description: "The RCON command, e.g. ListPlayers",
+				Required:    true,
+			},
+		},
+	}, a.handleRcon)
+
+	d.Restrict("rcon", a.mayRunRcon)
+}
+
+// mayRunRcon checks whether the member who triggered the interaction has one of the roles allowed to use /rcon
+func (a *Admin) mayRunRcon(i *discordgo.InteractionCreate) bool {
+	if a.bot().Admin == nil || i.Member == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(i.Member.Roles, func(role string) bool { return slices.Contains(a.bot().Admin.RconRoleIDs, role) })
+}
+
+func (a *Admin) handleRcon(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !a.mayRunRcon(i) {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+			Content: i18n.T(a.subs.Language(interactions.UserID(i), utils.English), "admin.denied"),
+		})
+		return
+	}
+
+	var server string
+	var command string
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "server":
+			server = o.StringValue()
+		case "command":
+			command = strings.TrimSpace(o.StringValue())
+		}
+	}
+
+	slog.Info(fmt.Sprintf("User %s sends RCON command '%s' to %s", interactions.UserID(i), command, server))
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to respond to interaction: %s", err))
+		return
+	}
+
+	reply, err := rcon.Execute(a.bot().ServerStatus.Rcon, server, command)
+	embed := rconResult(a.serverName(server), command, reply, err)
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}}); err != nil {
+		slog.Error(fmt.Sprintf("Failed to post RCON reply: %s", err))
+	}
+}
+
+func rconResult(server string, command string, reply string, err error) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("RCON: %s", server),
+		Color:  0x57F287, // Discord green
+		Fields: []*discordgo.MessageEmbedField{{Name: "Command", Value: fmt.Sprintf("`%s`", command)}},
+	}
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("RCON command '%s' on %s failed: %s", command, server, err))
+
+		embed.Description = fmt.Sprintf("Failed: %s", err)
+		embed.Color = 0xc1121f
+
+		return embed
+	}
+
+	reply = strings.TrimSpace(reply)
+
+	if reply == "" {
+		embed.Description = "The server sent no reply."
+		return embed
+	}
+
+	if len(reply) > maxRconReplyLength {
+		reply = reply[:maxRconReplyLength] + "\n..."
+	}
+
+	embed.Description = "```\n" + strings.ReplaceAll(reply, "```", "'''") + "\n```"
+
+	return embed
+}
@@ -0,0 +1,141 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/metrics"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+// don't ping admins more often than this for the same server
+const alertInterval = time.Hour
+
+type Counters struct {
+	sync.Mutex
+	Counts    map[string]map[string]int
+	LastAlert map[string]time.Time
+}
+
+var counters = &Counters{
+	Counts:    make(map[string]map[string]int),
+	LastAlert: make(map[string]time.Time),
+}
+
+// Run consumes RCON query errors. Authentication failures are reported to the admins right away,
+// all other errors are just counted.
+func Run(s *discordgo.Session, errorChan <-chan error, live *cfg.Live) {
+	for err := range errorChan {
+		var queryErr *rcon.QueryError
+
+		if !errors.As(err, &queryErr) {
+			slog.Error(fmt.Sprintf("RCON error: %s", err))
+			continue
+		}
+
+		counters.Lock()
+
+		if counters.Counts[queryErr.Server] == nil {
+			counters.Counts[queryErr.Server] = make(map[string]int)
+		}
+
+		counters.Counts[queryErr.Server][queryErr.Kind.Error()]++
+		metrics.CountError(queryErr.Server, queryErr.Kind.Error())
+		errorreport.Repeated("rcon", queryErr.Server, queryErr.Kind.Error(), err)
+
+		alert := errors.Is(err, rcon.ErrAuthFailure) && time.Since(counters.LastAlert[queryErr.Server]) > alertInterval
+
+		if alert {
+			counters.LastAlert[queryErr.Server] = time.Now()
+		}
+
+		counters.Unlock()
+
+		if alert {
+			notifyAdmins(s, live.Load().Admin, fmt.Sprintf("**RCON authentication failed** for server '%s', please check the configured password.\n\n`%s`",
+				queryErr.Server, queryErr.Err))
+		}
+	}
+}
+
+// PollingStalled tells the admins that no RCON poll result arrived for the given duration
+func PollingStalled(s *discordgo.Session, admin *cfg.ConfigAdmin, since time.Duration) {
+	notifyAdmins(s, admin, fmt.Sprintf("**Server status polling stalled**, no update from RCON for %s. Restarting the RCON polling, "+
+		"the player list shown is outdated until it recovers.", since.Round(time.Second)))
+}
+
+// PollingRecovered tells the admins that RCON poll results arrive again after a stall
+func PollingRecovered(s *discordgo.Session, admin *cfg.ConfigAdmin) {
+	notifyAdmins(s, admin, "**Server status polling recovered**, RCON updates arrive again.")
+}
+
+// StatusChannelUnavailable tells the admins that the status message can't be posted to the channel
+func StatusChannelUnavailable(s *discordgo.Session, admin *cfg.ConfigAdmin, channelID string, reason string, retry time.Duration) {
+	notifyAdmins(s, admin, fmt.Sprintf("**Status channel <#%s> is unavailable**, it was deleted or the bot lacks the permission to view it, "+
+		"read its history or send messages (%s). Retrying every %s.", channelID, reason, retry))
+}
+
+// StatusChannelAvailable tells the admins that the status message is posted to the channel again
+func StatusChannelAvailable(s *discordgo.Session, admin *cfg.ConfigAdmin, channelID string) {
+	notifyAdmins(s, admin, fmt.Sprintf("**Status channel <#%s> is available again**, the status message is updated.", channelID))
+}
+
+// MissingPermissions tells the admins in which channels the bot lacks permissions for its configured features
+func MissingPermissions(s *discordgo.Session, admin *cfg.ConfigAdmin, bot string, problems []string) {
+	notifyAdmins(s, admin, fmt.Sprintf("**Missing permissions** of bot '%s', the affected features will fail:\n\n- %s",
+		bot, strings.Join(problems, "\n- ")))
+}
+
+// BanDrift tells the admins which bans are missing on which servers of the ban sync group
+func BanDrift(s *discordgo.Session, admin *cfg.ConfigAdmin, drift string) {
+	notifyAdmins(s, admin, "**Ban lists differ**, these players are not banned on all servers:\n\n"+drift)
+}
+
+// Counts returns a summary of all errors per server, e.g. "timeout: 3, parse error: 1"
+func Counts() map[string]string {
+	counters.Lock()
+	defer counters.Unlock()
+
+	res := make(map[string]string)
+
+	for server, kinds := range counters.Counts {
+		var parts []string
+
+		for kind, count := range kinds {
+			parts = append(parts, fmt.Sprintf("%s: %d", kind, count))
+		}
+
+		sort.Strings(parts)
+		res[server] = strings.Join(parts, ", ")
+	}
+
+	return res
+}
+
+func notifyAdmins(s *discordgo.Session, admin *cfg.ConfigAdmin, msg string) {
+	slog.Warn(msg)
+
+	if admin == nil || admin.ChannelID == "" {
+		return
+	}
+
+	var mentions []string
+
+	for _, role := range admin.RoleIDs {
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", role))
+	}
+
+	if err := retry.Send(output.FromDiscord(s), admin.ChannelID, strings.Join(mentions, " ")+"\n\n"+msg, nil); err != nil {
+		slog.Error(fmt.Sprintf("Failed to send alert to admin channel: %s", err))
+	}
+}
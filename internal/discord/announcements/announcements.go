@@ -0,0 +1,86 @@
+package announcements
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+// Announcer broadcasts the announcements to the game servers, one message after another
+type Announcer struct {
+	live  *cfg.Live
+	cache *cache.Store
+	next  int
+}
+
+func New(live *cfg.Live, cache *cache.Store) *Announcer {
+	return &Announcer{live: live, cache: cache}
+}
+
+func (a *Announcer) config() *cfg.ConfigServerStatus {
+	return a.live.Load().ServerStatus
+}
+
+// Messages returns the announcements in rotation, the ones set with /announcements take precedence over the config
+func Messages(config *cfg.ConfigAnnouncements, c *cache.Store) []string {
+	cacheData, err := c.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load announcements from cache: %s", err))
+	}
+
+	if cacheData.Announcements != nil {
+		return cacheData.Announcements
+	}
+
+	return config.Messages
+}
+
+func (a *Announcer) Run() {
+	slog.Info(fmt.Sprintf("Broadcasting announcements every %s", a.config().Announcements.Interval))
+
+	ticker := time.NewTicker(a.config().Announcements.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.announceNext()
+	}
+}
+
+func (a *Announcer) announceNext() {
+	messages := Messages(a.config().Announcements, a.cache)
+
+	if len(messages) == 0 {
+		return
+	}
+
+	message := messages[a.next%len(messages)]
+	a.next++
+
+	for _, server := range a.servers() {
+		if err := rcon.Broadcast(a.config().Rcon, server, message); err != nil {
+			slog.Error(fmt.Sprintf("Failed to broadcast announcement to %s: %s", server, err))
+		}
+	}
+}
+
+// servers returns the keys of the configured servers, or of all servers which support broadcasts
+func (a *Announcer) servers() []string {
+	if len(a.config().Announcements.Servers) > 0 {
+		return a.config().Announcements.Servers
+	}
+
+	var res []string
+
+	for _, server := range a.config().Rcon.Servers {
+		if rcon.SupportsBroadcast(server) {
+			res = append(res, server.Key)
+		}
+	}
+
+	return res
+}
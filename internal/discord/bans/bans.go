@@ -0,0 +1,195 @@
+package bans
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// BanSync applies bans to all servers of the group and reports differences between their ban lists
+type BanSync struct {
+	live      *cfg.Live
+	cache     *cache.Store
+	lastDrift string
+}
+
+func New(live *cfg.Live, cache *cache.Store) *BanSync {
+	return &BanSync{live: live, cache: cache}
+}
+
+func (b *BanSync) config() *cfg.ConfigServerStatus {
+	return b.live.Load().ServerStatus
+}
+
+// Servers returns the keys of the servers in the group
+func (b *BanSync) Servers() []string {
+	if len(b.config().BanSync.Servers) > 0 {
+		return b.config().BanSync.Servers
+	}
+
+	var res []string
+
+	for _, server := range b.config().Rcon.Servers {
+		res = append(res, server.Key)
+	}
+
+	return res
+}
+
+// Ban bans the player with the given platform ID on all servers of the group and remembers the ban.
+// Returns the error by server key for the servers which failed.
+func (b *BanSync) Ban(id string, ban cache.Ban) map[string]error {
+	errs := make(map[string]error)
+
+	for _, server := range b.Servers() {
+		if err := rcon.Ban(b.config().Rcon, server, id, ban.Reason); err != nil {
+			slog.Error(fmt.Sprintf("Failed to ban %s on %s: %s", id, server, err))
+			errs[server] = err
+		}
+	}
+
+	err := b.cache.Update(func(k *cache.CacheData) {
+		if k.Bans == nil {
+			k.Bans = make(map[string]cache.Ban)
+		}
+
+		k.Bans[id] = ban
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store ban of %s in cache: %s", id, err))
+	}
+
+	return errs
+}
+
+// Unban lifts the ban of the player with the given platform ID on all servers of the group.
+// Returns the error by server key for the servers which failed.
+func (b *BanSync) Unban(id string) map[string]error {
+	errs := make(map[string]error)
+
+	for _, server := range b.Servers() {
+		if err := rcon.Unban(b.config().Rcon, server, id); err != nil {
+			slog.Error(fmt.Sprintf("Failed to unban %s on %s: %s", id, server, err))
+			errs[server] = err
+		}
+	}
+
+	err := b.cache.Update(func(k *cache.CacheData) {
+		delete(k.Bans, id)
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to remove ban of %s from cache: %s", id, err))
+	}
+
+	return errs
+}
+
+// Run compares the ban lists of the servers every reconcile interval
+func (b *BanSync) Run(s *discordgo.Session) {
+	if b.config().BanSync.ReconcileInterval == 0 {
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Reconciling ban lists every %s", b.config().BanSync.ReconcileInterval))
+
+	ticker := time.NewTicker(b.config().BanSync.ReconcileInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.reconcile(s)
+	}
+}
+
+// reconcile reports the players which are banned on some servers of the group (or with /ban), but not on
+// all of them. The same drift is reported only once.
+func (b *BanSync) reconcile(s *discordgo.Session) {
+	cacheData, err := b.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load bans from cache: %s", err))
+		return
+	}
+
+	banned := make(map[string]bool)
+	lists := make(map[string][]string)
+
+	for id := range cacheData.Bans {
+		banned[id] = true
+	}
+
+	for _, server := range b.config().Rcon.Servers {
+		if !slices.Contains(b.Servers(), server.Key) || !rcon.CanListBans(server) {
+			continue
+		}
+
+		ids, err := rcon.Bans(b.config().Rcon, server.Key)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to read ban list of %s: %s", server.Name, err))
+			continue
+		}
+
+		lists[server.Key] = ids
+
+		for _, id := range ids {
+			banned[id] = true
+		}
+	}
+
+	var lines []string
+
+	for server, ids := range lists {
+		var missing []string
+
+		for id := range banned {
+			if !slices.Contains(ids, id) {
+				missing = append(missing, playerName(cacheData, id))
+			}
+		}
+
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			lines = append(lines, fmt.Sprintf("- %s: %s", cfg.ServerName(b.config().Rcon.Servers, server), strings.Join(missing, ", ")))
+		}
+	}
+
+	sort.Strings(lines)
+	drift := strings.Join(lines, "\n")
+
+	if drift == b.lastDrift {
+		return
+	}
+
+	b.lastDrift = drift
+
+	if drift == "" {
+		slog.Info("Ban lists are in sync again")
+		return
+	}
+
+	alerts.BanDrift(s, b.live.Load().Admin, drift)
+}
+
+func playerName(cacheData cache.CacheData, id string) string {
+	if ban, ok := cacheData.Bans[id]; ok && ban.Name != "" {
+		return fmt.Sprintf("%s (`%s`)", utils.SanitizeName(ban.Name), id)
+	}
+
+	if identity, ok := cacheData.Players[cfg.Config.Privacy.PlatformID(id)]; ok {
+		return fmt.Sprintf("%s (`%s`)", utils.SanitizeName(identity.Name), id)
+	}
+
+	return fmt.Sprintf("`%s`", id)
+}
@@ -0,0 +1,84 @@
+package discord
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+)
+
+// applyBranding sets the configured nicknames of the bot in the status guilds, and its avatar if the
+// image changed since it was set last (changing the avatar is heavily rate limited)
+func (bot *DiscordBot) applyBranding() {
+	if bot.config().Avatar != "" {
+		bot.applyAvatar()
+	}
+
+	status := bot.config().ServerStatus
+
+	if status == nil {
+		return
+	}
+
+	nicknames := make(map[string]string)
+
+	if len(status.Guilds) == 0 && status.Branding != nil && status.Branding.Nickname != "" {
+		channel, err := bot.session.Channel(status.ChannelID)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("[%s] Failed to look up the guild of the status channel: %s", bot.config().Name, err))
+		} else {
+			nicknames[channel.GuildID] = status.Branding.Nickname
+		}
+	}
+
+	for _, guild := range status.Guilds {
+		if guild.Branding != nil && guild.Branding.Nickname != "" && guild.GuildID != "" {
+			nicknames[guild.GuildID] = guild.Branding.Nickname
+		}
+	}
+
+	for guildID, nickname := range nicknames {
+		if err := bot.session.GuildMemberNickname(guildID, "@me", nickname); err != nil {
+			slog.Error(fmt.Sprintf("[%s] Failed to set nickname in guild %s: %s", bot.config().Name, guildID, err))
+		}
+	}
+}
+
+func (bot *DiscordBot) applyAvatar() {
+	dat, err := os.ReadFile(bot.config().Avatar)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("[%s] Failed to read avatar: %s", bot.config().Name, err))
+		return
+	}
+
+	sum := sha256.Sum256(dat)
+	hash := hex.EncodeToString(sum[:])
+
+	if cacheData, err := bot.cache.Get(); err == nil && cacheData.AvatarHash == hash {
+		return
+	}
+
+	avatar := fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(dat), base64.StdEncoding.EncodeToString(dat))
+
+	if _, err := bot.session.UserUpdate("", avatar, ""); err != nil {
+		slog.Error(fmt.Sprintf("[%s] Failed to set avatar: %s", bot.config().Name, err))
+		return
+	}
+
+	slog.Info(fmt.Sprintf("[%s] Avatar set to %s", bot.config().Name, bot.config().Avatar))
+
+	err = bot.cache.Update(func(k *cache.CacheData) {
+		k.AvatarHash = hash
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("[%s] Failed to store avatar hash in cache: %s", bot.config().Name, err))
+	}
+}
@@ -0,0 +1,217 @@
+package chatrelay
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/crosschat"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// how often the game chat is read
+const pollInterval = 5 * time.Second
+
+// discord limits messages to 2000 characters, the lines read in one poll are sent in as few messages as possible
+const maxMessageLength = 2000
+
+// game chat must not format the discord message
+var markdown = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`, "#", `\#`)
+
+// sender of the bot's own replies in the game chat
+const botSender = "LazyDodoBot"
+
+// messages from discord waiting to be sent to the game chat, more are dropped
+const incomingBuffer = 50
+
+// custom emoji are written as "<:name:id>" (or "<a:name:id>" if animated), the game chat shows ":name:"
+var customEmoji = regexp.MustCompile(`<a?(:\w+:)\d+>`)
+
+// Relay posts the game chat of the servers to the game chat channel, and the messages of the members
+// in that channel to the game chat
+type Relay struct {
+	live     *cfg.Live
+	session  *discordgo.Session
+	out      output.Session
+	incoming chan incomingMessage
+	filter   *crosschat.Filter
+	onChat   crosschat.ChatHandler
+}
+
+type incomingMessage struct {
+	sender  string
+	message string
+}
+
+func New(s *discordgo.Session, live *cfg.Live) *Relay {
+	return &Relay{live: live, session: s, out: output.FromDiscord(s), incoming: make(chan incomingMessage, incomingBuffer)}
+}
+
+func (r *Relay) config() *cfg.ConfigServerStatus {
+	return r.live.Load().ServerStatus
+}
+
+// SetFilter sets the chat filter of crosschat, matching messages are hidden or reported like there. Must be
+// called before Run.
+func (r *Relay) SetFilter(filter *crosschat.Filter) {
+	r.filter = filter
+}
+
+// OnChat sets the handler of the in-game commands, like for crosschat. Must be called before Run.
+func (r *Relay) OnChat(fn crosschat.ChatHandler) {
+	r.onChat = fn
+}
+
+// HandleMessage queues the message to be sent to the game chat if it was posted by a member in the game chat
+// channel. It never blocks, so it is safe to be called by the gateway event handler.
+func (r *Relay) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.ChannelID != r.config().ChannelIDGameChat || m.Author == nil || m.Author.Bot || m.WebhookID != "" {
+		return
+	}
+
+	message := customEmoji.ReplaceAllString(m.ContentWithMentionsReplaced(), "$1")
+
+	if strings.TrimSpace(message) == "" {
+		return
+	}
+
+	sender := m.Author.DisplayName()
+
+	if m.Member != nil && m.Member.Nick != "" {
+		sender = m.Member.Nick
+	}
+
+	select {
+	case r.incoming <- incomingMessage{sender: utils.SanitizeName(sender), message: message}:
+	default:
+		slog.Warn(fmt.Sprintf("Dropping message of %s to the game chat, too many messages waiting", sender))
+	}
+}
+
+// servers returns the servers of the current config which support reading the game chat
+func (r *Relay) servers() []cfg.ConfigRconServer {
+	var res []cfg.ConfigRconServer
+
+	for _, server := range r.config().Rcon.Servers {
+		if rcon.SupportsChat(server) {
+			res = append(res, server)
+		}
+	}
+
+	return res
+}
+
+// Run relays the chat of the servers, which are read from the config on every poll to follow reloads
+func (r *Relay) Run() {
+	servers := r.servers()
+
+	if len(servers) == 0 {
+		slog.Warn("No server supports reading the game chat, nothing is relayed until one is configured")
+	} else {
+		slog.Info(fmt.Sprintf("Relaying the game chat of %d servers", len(servers)))
+	}
+
+	feed := rcon.NewChatFeed(servers)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m := <-r.incoming:
+			for _, server := range r.servers() {
+				if err := rcon.SendChat(server, m.sender, m.message); err != nil {
+					slog.Error(fmt.Sprintf("Failed to send message of %s to %s: %s", m.sender, server.Name, err))
+				}
+			}
+
+			continue
+		case <-ticker.C:
+		}
+
+		for _, server := range r.servers() {
+			messages, err := feed.Read(server)
+
+			if err != nil {
+				// unreachable servers are reported by the RCON alerts already
+
+				slog.Debug(fmt.Sprintf("Failed to read chat of %s: %s", server.Name, err))
+				continue
+			}
+
+			r.post(server, r.handle(server, messages))
+		}
+	}
+}
+
+// handle runs the messages of the server through the chat filter and the in-game commands, and returns
+// the ones to be relayed
+func (r *Relay) handle(server cfg.ConfigRconServer, messages []rcon.ChatMessage) []rcon.ChatMessage {
+	var res []rcon.ChatMessage
+
+	for _, m := range messages {
+		relay, warning := r.filter.Check(r.out, server.Name, m.Sender, m.SenderID, m.Message)
+
+		if relay {
+			res = append(res, m)
+		}
+
+		if warning != "" {
+			if err := rcon.SendChat(server, botSender, warning); err != nil {
+				slog.Error(fmt.Sprintf("Failed to send chat filter warning to %s: %s", server.Name, err))
+			}
+		}
+
+		if r.onChat == nil {
+			continue
+		}
+
+		if reply := r.onChat(r.session, server.Name, m.Sender, m.SenderID, m.Message); reply != "" {
+			if err := rcon.SendChat(server, botSender, reply); err != nil {
+				slog.Error(fmt.Sprintf("Failed to send reply to %s: %s", server.Name, err))
+			}
+		}
+	}
+
+	return res
+}
+
+// post sends the messages of the server as lines like "**[prefix]** sender: message"
+func (r *Relay) post(server cfg.ConfigRconServer, messages []rcon.ChatMessage) {
+	var lines []string
+
+	for _, m := range messages {
+		line := utils.Truncate(fmt.Sprintf("**[%s]** %s: %s", markdown.Replace(server.ChatPrefix), markdown.Replace(utils.SanitizeName(m.Sender)),
+			markdown.Replace(m.Message)), maxMessageLength)
+
+		lines = append(lines, line)
+	}
+
+	for len(lines) > 0 {
+		n := 1
+		length := len(lines[0])
+
+		for n < len(lines) && length+1+len(lines[n]) <= maxMessageLength {
+			length += 1 + len(lines[n])
+			n++
+		}
+
+		err := retry.SendComplex(r.out, r.config().ChannelIDGameChat, &discordgo.MessageSend{
+			Content:         strings.Join(lines[:n], "\n"),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}, nil)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to post game chat of %s: %s", server.Name, err))
+		}
+
+		lines = lines[n:]
+	}
+}
@@ -12,16 +12,39 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/patrickjane/lazydodo-bot/internal/cache"
 	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
 )
 
 const tableChat = "cross_chat"
 const tableServers = "crosschat_servers"
 
+// chat of servers relayed via RCON is polled this often
+const rconChatInterval = 5 * time.Second
+
+// sender of the bot's own replies in the game chat
+const botSender = "LazyDodoBot"
+
+// ChatHandler is called for every chat message read from the game, with the server (or map, for chat
+// read from the database) it was posted on. A non empty result is posted as reply in the game chat.
+type ChatHandler func(session *discordgo.Session, location string, sender string, senderID string, message string) string
+
 type CrossChat struct {
+	config            *cfg.ConfigCrosschat
+	cache             *cache.Store
 	db                *sql.DB
 	insertStatement   string
 	queryChatMessages string
 	queryLastRowId    string
+
+	// the chat of the RCON servers not relayed yet
+	rconChat *rcon.ChatFeed
+
+	filter *Filter
+
+	onChat ChatHandler
 }
 
 type ChatMessage struct {
@@ -37,8 +60,12 @@ type ChatMessage struct {
 	MapPrefix string
 }
 
-func NewCrossChat() (*CrossChat, error) {
-	db, err := sql.Open("mysql", cfg.Config.Crosschat.DbConnection)
+func NewCrossChat(config *cfg.ConfigCrosschat, cache *cache.Store) (*CrossChat, error) {
+	if config.DbConnection == "" {
+		return &CrossChat{config: config, cache: cache}, nil
+	}
+
+	db, err := sql.Open("mysql", config.DbConnection)
 
 	if err != nil {
 		return nil, err
@@ -56,11 +83,23 @@ func NewCrossChat() (*CrossChat, error) {
 	queryChatMessages := fmt.Sprintf("SELECT Id, Map, Sender, Message, TribeName, Mode, isPm, PmRecipient FROM %s WHERE Id > ? and mode = 0 and isPm = 0 and Map != 'Discord' order by id asc", tableChat)
 	queryLastRowId := fmt.Sprintf("SELECT max(Id) FROM %s", tableChat)
 
-	return &CrossChat{db, insertStatement, queryChatMessages, queryLastRowId}, nil
+	return &CrossChat{config: config, cache: cache, db: db, insertStatement: insertStatement,
+		queryChatMessages: queryChatMessages, queryLastRowId: queryLastRowId}, nil
+}
+
+// SetFilter sets the chat filter, which may be shared with other features reading the game chat. Must be
+// called before Run.
+func (s *CrossChat) SetFilter(filter *Filter) {
+	s.filter = filter
+}
+
+// OnChat sets the handler for the chat messages read from the game, must be called before Run
+func (s *CrossChat) OnChat(fn ChatHandler) {
+	s.onChat = fn
 }
 
 func (s *CrossChat) Run(session *discordgo.Session, fromDiscord <-chan ChatMessage) error {
-	cacheData, err := cache.Get()
+	cacheData, err := s.cache.Get()
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to load last chat query time from cache: %s", err))
@@ -70,9 +109,29 @@ func (s *CrossChat) Run(session *discordgo.Session, fromDiscord <-chan ChatMessa
 	ticker := time.NewTicker(time.Duration(500) * time.Millisecond)
 	defer ticker.Stop()
 
+	rconTicker := time.NewTicker(rconChatInterval)
+	defer rconTicker.Stop()
+
+	if s.db == nil {
+		ticker.Stop()
+	}
+
+	if len(s.config.RconServers) == 0 {
+		rconTicker.Stop()
+	}
+
+	// only messages written after the start are relayed
+
+	s.rconChat = rcon.NewChatFeed(s.config.RconServers)
+
+	reads := make(chan chatRead)
+	reading := make(map[string]bool)
+
 	lastId := cacheData.DbLastRowIdChat
 
-	if lastId == 0 {
+	if s.db == nil {
+		slog.Debug("No database configured, relaying chat via RCON only")
+	} else if lastId == 0 {
 		id, err := s.fetchLastRowId()
 
 		if err != nil {
@@ -86,17 +145,52 @@ func (s *CrossChat) Run(session *discordgo.Session, fromDiscord <-chan ChatMessa
 		slog.Debug(fmt.Sprintf("Fetched last row id from cache (%d)", lastId))
 	}
 
+	name := fmt.Sprintf("crosschat (channel %s)", s.config.ChannelID)
+
 	for {
+		health.Beat(name, time.Minute)
+
 		select {
 		case msg := <-fromDiscord:
 			slog.Debug(fmt.Sprintf("Got message from discord: %s", msg.Message))
 
-			err := s.insertChatRow(msg.Sender, msg.Message)
+			if s.db != nil {
+				err := s.insertChatRow(msg.Sender, msg.Message)
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to store incoming discord message in database: %s", err))
+				if err != nil {
+					slog.Error(fmt.Sprintf("Failed to store incoming discord message in database: %s", err))
+				}
+			}
+
+			for _, server := range s.config.RconServers {
+				if err := rcon.SendChat(server, msg.Sender, msg.Message); err != nil {
+					slog.Error(fmt.Sprintf("Failed to send incoming discord message to %s: %s", server.Name, err))
+				}
 			}
 
+		case <-rconTicker.C:
+			// the servers are read at the same time, a server still being read is skipped
+
+			for _, server := range s.config.RconServers {
+				if reading[server.Key] {
+					continue
+				}
+
+				reading[server.Key] = true
+
+				errorreport.Go("crosschat", func() {
+					read := chatRead{server: server}
+					defer func() { reads <- read }()
+
+					read.messages, read.err = s.rconChat.Read(server)
+				})
+			}
+
+		case read := <-reads:
+			delete(reading, read.server.Key)
+
+			s.relayRconChat(session, read)
+
 		case <-ticker.C:
 			messages, err := s.fetchChatMessages(lastId)
 
@@ -105,28 +199,26 @@ func (s *CrossChat) Run(session *discordgo.Session, fromDiscord <-chan ChatMessa
 			}
 
 			for _, m := range messages {
-				slog.Debug(fmt.Sprintf("Forward message to discord: %d %s", m.Id, m.Message))
+				relay, warning := s.filter.Check(output.FromDiscord(session), m.Map, m.Sender, "", m.Message)
 
-				userNameString := fmt.Sprintf("[%s] %s (%s)", m.MapPrefix, m.Sender, m.TribeName)
+				if relay {
+					slog.Debug(fmt.Sprintf("Forward message to discord: %d %s", m.Id, m.Message))
 
-				if len(m.TribeName) == 0 {
-					userNameString = fmt.Sprintf("[%s] %s", m.MapPrefix, m.Sender)
+					s.forward(session, m)
 				}
 
-				_, err := session.WebhookExecute(cfg.Config.Crosschat.WebhookIdCrosschat, cfg.Config.Crosschat.WebhookTokenCrosschat,
-					false, &discordgo.WebhookParams{
-						Content:  m.Message,
-						Username: userNameString,
-					})
+				if warning != "" {
+					s.insertChatRow(botSender, warning)
+				}
 
-				if err != nil {
-					slog.Error(fmt.Sprintf("Failed to send message to discord: %s", err))
+				if reply := s.handleChat(session, m.Map, m.Sender, "", m.Message); reply != "" {
+					s.insertChatRow(botSender, reply)
 				}
 
 				lastId = m.Id
 			}
 
-			err = cache.Update(func(k *cache.CacheData) {
+			err = s.cache.Update(func(k *cache.CacheData) {
 				k.DbLastRowIdChat = lastId
 			})
 
@@ -139,6 +231,77 @@ func (s *CrossChat) Run(session *discordgo.Session, fromDiscord <-chan ChatMessa
 	return nil
 }
 
+func (s *CrossChat) forward(session *discordgo.Session, m ChatMessage) {
+	userNameString := fmt.Sprintf("[%s] %s (%s)", m.MapPrefix, m.Sender, m.TribeName)
+
+	if len(m.TribeName) == 0 {
+		userNameString = fmt.Sprintf("[%s] %s", m.MapPrefix, m.Sender)
+	}
+
+	_, err := session.WebhookExecute(s.config.WebhookIdCrosschat, s.config.WebhookTokenCrosschat,
+		false, &discordgo.WebhookParams{
+			Content:  m.Message,
+			Username: userNameString,
+		})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send message to discord: %s", err))
+	}
+}
+
+// chatRead is the result of reading the chat of a server
+type chatRead struct {
+	server   cfg.ConfigRconServer
+	messages []rcon.ChatMessage
+	err      error
+}
+
+// relayRconChat forwards the chat messages read from the server, which were not relayed yet
+func (s *CrossChat) relayRconChat(session *discordgo.Session, read chatRead) {
+	server := read.server
+
+	if read.err != nil {
+		slog.Error(fmt.Sprintf("Failed to read chat of %s: %s", server.Name, read.err))
+		return
+	}
+
+	prefix := server.Name
+
+	if server.Map != "" {
+		prefix = generatePrefixFromMap(server.Map)
+	}
+
+	for _, m := range read.messages {
+		relay, warning := s.filter.Check(output.FromDiscord(session), server.Name, m.Sender, m.SenderID, m.Message)
+
+		if relay {
+			slog.Debug(fmt.Sprintf("Forward message of %s to discord: %s", server.Name, m.Message))
+
+			s.forward(session, ChatMessage{Map: server.Map, Sender: m.Sender, Message: m.Message, MapPrefix: prefix})
+		}
+
+		if warning != "" {
+			if err := rcon.SendChat(server, botSender, warning); err != nil {
+				slog.Error(fmt.Sprintf("Failed to send chat filter warning to %s: %s", server.Name, err))
+			}
+		}
+
+		if reply := s.handleChat(session, server.Name, m.Sender, m.SenderID, m.Message); reply != "" {
+			if err := rcon.SendChat(server, botSender, reply); err != nil {
+				slog.Error(fmt.Sprintf("Failed to send reply to %s: %s", server.Name, err))
+			}
+		}
+	}
+}
+
+func (s *CrossChat) handleChat(session *discordgo.Session, location string, sender string, senderID string, message string) string {
+	if s.onChat == nil {
+		return ""
+	}
+
+	return s.onChat(session, location, sender, senderID, message)
+}
+
 func (s *CrossChat) fetchChatMessages(lastId uint64) ([]ChatMessage, error) {
 	rows, err := s.db.Query(s.queryChatMessages, lastId)
 
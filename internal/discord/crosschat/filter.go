@@ -0,0 +1,147 @@
+package crosschat
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// the moderators are alerted at most once within this time per player and server, so a spamming
+// player doesn't flood the moderator channel. The in-game warning is sent for every match.
+const filterAlertCooldown = time.Minute
+
+// discord limits the value of an embed field to 1024 characters
+const maxFieldLength = 1024
+
+// Filter checks game chat messages against the watch list of the crosschat config. It is shared by the
+// features reading the game chat, a message read by several of them is only reported once.
+type Filter struct {
+	live *cfg.Live
+
+	mu sync.Mutex
+
+	// per server and player the time of the last alert
+	lastAlert map[string]time.Time
+
+	// per server, player and message the time of the last match
+	matched map[string]time.Time
+}
+
+func NewFilter(live *cfg.Live) *Filter {
+	return &Filter{live: live, lastAlert: make(map[string]time.Time), matched: make(map[string]time.Time)}
+}
+
+// Check checks a game chat message against the watch list. On a match the moderators are alerted, and
+// the configured warning is returned to be posted in the game chat. relay is false if the message
+// must not be forwarded to discord. A nil filter relays everything.
+func (f *Filter) Check(out output.Session, server string, sender string, senderID string, message string) (relay bool, warning string) {
+	if f == nil {
+		return true, ""
+	}
+
+	filter := f.live.Load().Crosschat.Filter
+
+	if filter == nil {
+		return true, ""
+	}
+
+	var match string
+
+	for _, re := range filter.Regexps {
+		found := re.FindStringSubmatch(message)
+
+		if found == nil {
+			continue
+		}
+
+		// keywords report the keyword itself, not the characters bounding it
+
+		match = found[0]
+
+		if n := re.SubexpIndex("keyword"); n > 0 {
+			match = found[n]
+		}
+
+		if match != "" {
+			break
+		}
+	}
+
+	if match == "" {
+		return true, ""
+	}
+
+	f.mu.Lock()
+
+	now := time.Now()
+	key := server + "/" + sender
+	messageKey := key + "/" + message
+
+	// the same message is read again by another feature
+
+	if now.Sub(f.matched[messageKey]) < filterAlertCooldown {
+		f.mu.Unlock()
+		return !filter.Hide, ""
+	}
+
+	maps.DeleteFunc(f.matched, func(_ string, t time.Time) bool { return now.Sub(t) >= filterAlertCooldown })
+	f.matched[messageKey] = now
+
+	alert := now.Sub(f.lastAlert[key]) >= filterAlertCooldown
+
+	if alert {
+		f.lastAlert[key] = now
+	}
+
+	f.mu.Unlock()
+
+	slog.Info(fmt.Sprintf("Chat message of %s on %s matched the chat filter (%s)", sender, server, match))
+
+	if alert {
+		f.alertModerators(out, server, sender, senderID, message, match)
+	}
+
+	if filter.Warning != "" {
+		warning = strings.ReplaceAll(filter.Warning, "{player}", sender)
+	}
+
+	return !filter.Hide, warning
+}
+
+func (f *Filter) alertModerators(out output.Session, server string, sender string, senderID string, message string, match string) {
+	message = utils.Truncate(message, maxFieldLength)
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Chat filter: %s", utils.SanitizeName(sender)),
+		Color: 0xed4245, // Discord red
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Player", Value: sender, Inline: true},
+			{Name: "Server", Value: server, Inline: true},
+			{Name: "Matched", Value: fmt.Sprintf("`%s`", match), Inline: true},
+			{Name: "Message", Value: message},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if senderID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Platform ID", Value: fmt.Sprintf("`%s`", senderID), Inline: true})
+	}
+
+	err := retry.SendComplex(out, f.live.Load().Crosschat.Filter.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, nil)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to post chat filter alert for %s: %s", sender, err))
+	}
+}
@@ -1,59 +1,222 @@
 package discord
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"sync"
+	"time"
 
 	_ "time/tzdata"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/api"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
 	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/admin"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/announcements"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/bans"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/chatrelay"
 	"github.com/patrickjane/lazydodo-bot/internal/discord/crosschat"
 	"github.com/patrickjane/lazydodo-bot/internal/discord/eventer"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/lifetime"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
 	"github.com/patrickjane/lazydodo-bot/internal/discord/serverstatus"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/subscriptions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/wipes"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/lease"
 	"github.com/patrickjane/lazydodo-bot/internal/model"
-	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/store"
 )
 
+// the retry queue is shared by all bots, each queued message remembers its session
+var startRetry sync.Once
+
 type DiscordBot struct {
+	clock                  clock.Clock
+	live                   *cfg.Live
+	cache                  *cache.Store
 	session                *discordgo.Session
+	dispatcher             *interactions.Dispatcher
 	serverStatus           *serverstatus.ServerStatus
-	rconUpdates            chan map[string]*model.ServerInfo
+	eventer                *eventer.Eventer
+	subscriptions          *subscriptions.Subscriptions
+	refresh                chan struct{}
+	pollInterval           chan int
+	rconRestart            chan struct{}
+	rconUpdates            chan model.ServerUpdate
+	rconErrors             chan error
 	chatUpdatesFromDiscord chan crosschat.ChatMessage
+	gameCommandUses        map[string]time.Time
+	gameCommandMu          sync.Mutex
+	chatFilter             *crosschat.Filter
+	lease                  *lease.Lease
+	admin                  *admin.Admin
+
+	// shared by all bots, nil without a database configured
+	database *store.Store
 }
 
-func NewBot() *DiscordBot {
+// NewBot creates the bot of the config. database keeps the join/leave events and snapshots, it may be nil.
+func NewBot(config *cfg.ConfigBot, database *store.Store) *DiscordBot {
 	return &DiscordBot{
+		clock:                  clock.Real,
+		live:                   cfg.NewLive(config),
+		database:               database,
 		session:                nil,
+		dispatcher:             interactions.NewDispatcher(),
 		serverStatus:           nil,
-		rconUpdates:            make(chan map[string]*model.ServerInfo, 100),
+		refresh:                make(chan struct{}, 1),
+		pollInterval:           make(chan int, 1),
+		rconRestart:            make(chan struct{}, 1),
+		rconUpdates:            make(chan model.ServerUpdate, 100),
+		rconErrors:             make(chan error, 100),
 		chatUpdatesFromDiscord: make(chan crosschat.ChatMessage, 100),
+		gameCommandUses:        make(map[string]time.Time),
 	}
 }
 
+// config returns the current config of the bot, see cfg.Live
+func (bot *DiscordBot) config() *cfg.ConfigBot {
+	return bot.live.Load()
+}
+
 func (bot *DiscordBot) Start() error {
-	slog.Info("Connecting to discord")
+	// two instances of the same bot would fight over the status message
+
+	if cfg.Config.InstanceLock != cfg.InstanceLockOff {
+		l, err := lease.Acquire(bot.config().LockFile)
+
+		if errors.Is(err, lease.ErrHeld) && cfg.Config.InstanceLock == cfg.InstanceLockStandby {
+			slog.Info(fmt.Sprintf("[%s] Standing by, another instance is running this bot: %v", bot.config().Name, err))
+
+			go bot.standBy()
+			return nil
+		}
+
+		if errors.Is(err, lease.ErrHeld) {
+			slog.Error(fmt.Sprintf("[%s] Refusing to start, another instance is running this bot: %v", bot.config().Name, err))
+			return err
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("[%s] Failed to acquire instance lock %s: %v", bot.config().Name, bot.config().LockFile, err))
+			return err
+		}
+
+		bot.holdLease(l)
+	}
+
+	return bot.start()
+}
+
+func (bot *DiscordBot) start() error {
+	var store *cache.Store
+	var err error
+
+	if bot.config().StateDir != "" {
+		slog.Info(fmt.Sprintf("[%s] Initializing state directory %s", bot.config().Name, bot.config().StateDir))
+
+		store, err = cache.OpenDir(bot.config().StateDir, bot.config().CachePath)
+	} else {
+		slog.Info(fmt.Sprintf("[%s] Initializing cache at %s", bot.config().Name, bot.config().CachePath))
+
+		store, err = cache.Open(bot.config().CachePath)
+	}
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load cache: %v", err))
+		return err
+	}
+
+	bot.cache = store
+	bot.live.Update(func(b *cfg.ConfigBot) error {
+		bot.applySettings(b)
+		return nil
+	})
+
+	// players stored before the privacy setting was changed
+
+	if cfg.Config.Privacy != nil {
+		if n, err := store.PseudonymizePlayers(cfg.Config.Privacy.PlatformID); err != nil {
+			slog.Error(fmt.Sprintf("Failed to pseudonymize the platform IDs of the players: %s", err))
+		} else if n > 0 {
+			slog.Info(fmt.Sprintf("[%s] Pseudonymized the platform IDs of %d players", bot.config().Name, n))
+		}
+	}
+
+	slog.Info(fmt.Sprintf("[%s] Connecting to discord", bot.config().Name))
 
 	var userID string
 
-	s, err := discordgo.New("Bot " + cfg.Config.BotToken)
+	s, err := discordgo.New("Bot " + bot.config().BotToken)
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to create new discord bot/connection: %v", err))
 		return err
 	}
 
+	if bot.config().ShardCount > 0 {
+		s.ShardID = bot.config().ShardID
+		s.ShardCount = bot.config().ShardCount
+	}
+
 	bot.session = s
 
+	startRetry.Do(func() { go errorreport.Supervise("retry", retry.Run) })
+
+	bot.subscriptions = subscriptions.New(bot.cache)
+
+	go errorreport.Supervise("subscriptions", bot.subscriptions.Run)
+
+	if bot.config().Eventer != nil {
+		bot.eventer, err = eventer.NewEventer(bot.session, bot.live, bot.cache, bot.subscriptions)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to open reminder store: %v", err))
+			return err
+		}
+	}
+
+	// buttons and slash commands
+
+	s.AddHandler(safe("interactions", bot.dispatcher.Dispatch))
+	bot.subscriptions.Register(bot.dispatcher, bot.config())
+	bot.dispatcher.SetLanguages(bot.subscriptions.Language)
+	bot.dispatcher.SetCooldown(bot.config().Cooldown)
+
+	if bot.config().Admin != nil {
+		bot.admin = admin.NewAdmin(bot.live, bot.cache, bot.eventer, bot.subscriptions, bot.pollInterval, bot.database)
+		bot.admin.Register(bot.dispatcher)
+	}
+
 	// register event monitoring callbacks
 
-	if cfg.Config.Eventer != nil {
-		s.AddHandler(eventer.CreateRemindersForEvent)
-		s.AddHandler(eventer.UpdateRemindersForEvent)
-		s.AddHandler(eventer.DeleteRemindersForEvent)
+	if bot.eventer != nil {
+		s.AddHandler(safe("eventer", bot.eventer.CreateRemindersForEvent))
+		s.AddHandler(safe("eventer", bot.eventer.UpdateRemindersForEvent))
+		s.AddHandler(safe("eventer", bot.eventer.DeleteRemindersForEvent))
+		bot.eventer.RegisterInteractions(bot.dispatcher)
+		api.AddEventSource(bot.eventer.PastEvents)
 
 		s.Identify.Intents = discordgo.IntentsGuildScheduledEvents | discordgo.IntentsGuildMessages
+
+		if bot.config().Eventer.VoicePing {
+			s.AddHandler(safe("eventer", bot.eventer.TrackVoice))
+
+			s.Identify.Intents |= discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates
+		}
+	}
+
+	// messages of the game chat channel are sent to the game, reading them needs the privileged message content intent
+
+	if bot.config().ServerStatus != nil && bot.config().ServerStatus.ChannelIDGameChat != "" {
+		s.Identify.Intents |= discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
 	}
 
 	// Opening a Gateway session is optional for pure REST, but it populates s.State.User.
@@ -74,50 +237,120 @@ func (bot *DiscordBot) Start() error {
 		}
 	}
 
+	bot.checkPermissions(userID)
+	bot.applyBranding()
+	bot.addReadiness()
+
+	if bot.config().ServerStatus != nil {
+		bot.serverStatus = serverstatus.NewServerStatus(bot.session, userID, bot.live, bot.cache, bot.refresh, bot.subscriptions, bot.database)
+		bot.serverStatus.RegisterInteractions(bot.dispatcher)
+	}
+
+	// application id of a bot equals its user id, all commands must be added by now
+
+	bot.dispatcher.AddHelp()
+
+	if err := bot.dispatcher.RegisterCommands(s, userID); err != nil {
+		slog.Error(fmt.Sprintf("Failed to register slash commands: %v", err))
+		return err
+	}
+
+	// the game chat is filtered alike by crosschat and the chat relay
+
+	if bot.config().Crosschat != nil {
+		bot.chatFilter = crosschat.NewFilter(bot.live)
+	}
+
 	// server status scaffold
 
-	if cfg.Config.ServerStatus != nil {
-		slog.Info("Starting server status loop")
+	if bot.config().ServerStatus != nil {
+		slog.Info(fmt.Sprintf("[%s] Starting server status loop", bot.config().Name))
 
-		bot.serverStatus = serverstatus.NewServerStatus(bot.session, userID)
+		// workers which panicked are started again
 
-		go func() {
-			err := rcon.Run(cfg.Config.ServerStatus.Rcon, bot.rconUpdates)
+		go errorreport.Supervise("rcon", bot.superviseRcon)
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to start RCON connection(s): %s", err))
-				os.Exit(1)
+		go errorreport.Supervise("alerts", func() { alerts.Run(bot.session, bot.rconErrors, bot.live) })
+
+		if bot.config().ServerStatus.Announcements != nil {
+			go errorreport.Supervise("announcements", announcements.New(bot.live, bot.cache).Run)
+		}
+
+		if len(bot.config().ServerStatus.Wipes) > 0 {
+			var maintenance wipes.MaintenanceFunc
+
+			if bot.admin != nil {
+				maintenance = bot.admin.RunMaintenance
 			}
-		}()
 
-		go func() {
+			go errorreport.Supervise("wipes", wipes.New(bot.session, bot.live, bot.cache, maintenance).Run)
+		}
+
+		if bot.config().ServerStatus.ChannelIDGameChat != "" {
+			relay := chatrelay.New(bot.session, bot.live)
+			relay.SetFilter(bot.chatFilter)
+			relay.OnChat(bot.relayGameChat)
+
+			bot.session.AddHandler(safe("chatrelay", relay.HandleMessage))
+
+			go errorreport.Supervise("chatrelay", relay.Run)
+		}
+
+		if bot.config().ServerStatus.BanSync != nil {
+			syncer := bans.New(bot.live, bot.cache)
+
+			go errorreport.Supervise("bans", func() { syncer.Run(bot.session) })
+		}
+
+		go errorreport.Supervise("serverstatus", func() {
 			err := bot.serverStatus.RunServerStatus(bot.rconUpdates)
 
 			if err != nil {
 				slog.Error(fmt.Sprintf("Failed to start server status loop: %s", err))
 				os.Exit(1)
 			}
-		}()
+		})
+	}
+
+	if (bot.config().ServerStatus != nil && bot.config().ServerStatus.JoinLeaveLifetime > 0) || (bot.config().Eventer != nil && bot.config().Eventer.ReminderLifetime > 0) {
+		go errorreport.Supervise("lifetime", func() { lifetime.Run(bot.session, bot.cache, bot.clock) })
 	}
 
 	// eventer scaffold
 
-	if cfg.Config.Eventer != nil {
-		slog.Info("Starting eventer loop")
+	if bot.eventer != nil {
+		slog.Info(fmt.Sprintf("[%s] Starting eventer loop", bot.config().Name))
+
+		go bot.eventer.Run(s)
 
-		go eventer.Run(s)
+		// without crosschat the game chat is read for the in-game event triggers only
+
+		if bot.config().Eventer.GameTrigger != nil && bot.config().Crosschat == nil && (bot.config().ServerStatus == nil || bot.config().ServerStatus.ChannelIDGameChat == "") {
+			go errorreport.Supervise("event triggers", func() { bot.eventer.RunGameTrigger(s) })
+		}
 	}
 
 	// crosschat
 
-	if cfg.Config.Crosschat != nil {
-		slog.Info("Starting cross chat loop")
+	if bot.config().Crosschat != nil {
+		slog.Info(fmt.Sprintf("[%s] Starting cross chat loop", bot.config().Name))
 
-		slog.Info(fmt.Sprintf("Connecting to database '%s'", cfg.CleanDbString(cfg.Config.Crosschat.DbConnection)))
+		if bot.config().Crosschat.DbConnection != "" {
+			slog.Info(fmt.Sprintf("Connecting to database '%s'", cfg.CleanDbString(bot.config().Crosschat.DbConnection)))
+		}
 
-		crossChat, err := crosschat.NewCrossChat()
+		crossChat, err := crosschat.NewCrossChat(bot.config().Crosschat, bot.cache)
 
-		bot.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		if err == nil {
+			crossChat.SetFilter(bot.chatFilter)
+			crossChat.OnChat(bot.gameChat)
+
+			if bot.serverStatus != nil {
+				bot.serverStatus.EnableGameChat()
+			}
+		}
+
+		bot.session.AddHandler(safe("crosschat", func(s *discordgo.Session, m *discordgo.MessageCreate) {
 			if m.Author == nil {
 				return
 			}
@@ -132,7 +365,7 @@ func (bot *DiscordBot) Start() error {
 				return
 			}
 
-			if m.ChannelID != cfg.Config.Crosschat.ChannelID {
+			if m.ChannelID != bot.config().Crosschat.ChannelID {
 				return
 			}
 
@@ -140,28 +373,54 @@ func (bot *DiscordBot) Start() error {
 				Sender:  m.Author.DisplayName(),
 				Message: m.Message.Content,
 			}
-		})
+		}))
 
 		if err != nil {
 			slog.Error(fmt.Sprintf("Failed to start chat syncer: %s", err))
 			os.Exit(1)
 		}
 
-		go func() {
+		go errorreport.Supervise("crosschat", func() {
 			err := crossChat.Run(bot.session, bot.chatUpdatesFromDiscord)
 
 			if err != nil {
 				slog.Error(fmt.Sprintf("Failed to start ChatSyncer: %s", err))
 				os.Exit(1)
 			}
-		}()
+		})
 	}
 
 	return nil
 }
 
+// applySettings applies the settings changed with /config set on top of the config file
+func (bot *DiscordBot) applySettings(config *cfg.ConfigBot) {
+	cacheData, err := bot.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load settings from cache: %s", err))
+		return
+	}
+
+	for name, value := range cacheData.Settings {
+		if err := config.ApplySetting(name, value); err != nil {
+			slog.Error(fmt.Sprintf("[%s] Ignoring stored setting %s: %s", bot.config().Name, name, err))
+			continue
+		}
+
+		slog.Info(fmt.Sprintf("[%s] Using stored setting %s = '%s'", bot.config().Name, name, value))
+	}
+}
+
 func (bot *DiscordBot) Stop() {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
 	if bot.session != nil {
 		bot.session.Close()
 	}
+
+	if bot.lease != nil {
+		bot.lease.Release()
+	}
 }
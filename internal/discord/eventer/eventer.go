@@ -2,121 +2,320 @@ package eventer
 
 import (
 	"fmt"
-	"log"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
 	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/lifetime"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/subscriptions"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
 	"github.com/patrickjane/lazydodo-bot/internal/utils"
 )
 
 type Reminder struct {
+	GuildID   string
 	EventID   string
 	EventName string
 	EventURL  string
 	StartTime time.Time // The actual 24h start time
 	RemindAt  time.Time // When the bot should post the message
 	Now       bool
+	Location  string    // only external events
+	EndTime   time.Time // zero if the event has no scheduled end
+	Image     string    // cover image url, if set
+	UserID    string    // personal reminders (snoozed) are sent as DM to this user
 }
 
-type ReminderStore struct {
-	sync.Mutex
-	Pending []Reminder
+// key identifies a single delivery of a reminder, i.e. an (event, offset) pair for
+// the event's current start time
+func (r Reminder) key() string {
+	key := fmt.Sprintf("%s/%d/%d", r.EventID, r.StartTime.Unix(), int64(r.StartTime.Sub(r.RemindAt).Seconds()))
+
+	if r.UserID != "" {
+		key += "/" + r.UserID
+	}
+
+	return key
 }
 
-var store = &ReminderStore{Pending: []Reminder{}}
-var eventerWorkerTick time.Duration = 1 * time.Second
-var cetLocation *time.Location
+type Eventer struct {
+	// held while reminders are sent or the queue is rebuilt
+	mu sync.Mutex
+
+	clock    clock.Clock
+	out      output.Session
+	live     *cfg.Live
+	cache    *cache.Store
+	store    ReminderStore
+	wake     chan struct{}
+	mentions *MentionLimiter
+	subs     *subscriptions.Subscriptions
+
+	attendance *Attendance
+	running    *Running
+}
 
-func init() {
-	// Initialize the timezone during startup
-	var err error
+// reminders which became due while the bot was down are still sent after a restart,
+// as long as they are not older than this
+var reminderGracePeriod = 5 * time.Minute
 
-	cetLocation, err = time.LoadLocation("Europe/Berlin") // "Europe/Berlin" is the standard TZ database name for CET/CEST
+// NewEventer creates the eventer of the bot, the RCON config of the server status is used for in-game broadcasts
+func NewEventer(s *discordgo.Session, live *cfg.Live, cache *cache.Store, subs *subscriptions.Subscriptions) (*Eventer, error) {
+	store, err := NewReminderStore(live.Load().Eventer)
 
 	if err != nil {
-		log.Printf("Warning: Could not load CET location: %s", err)
-		panic(err)
+		return nil, err
 	}
-}
 
-func Run(s *discordgo.Session) {
-	syncExistingEvents(s)
+	return &Eventer{
+		clock:    clock.Real,
+		out:      output.FromDiscord(s),
+		live:     live,
+		cache:    cache,
+		store:    store,
+		wake:     make(chan struct{}, 1),
+		mentions: &MentionLimiter{},
+		subs:     subs,
+
+		attendance: &Attendance{Events: make(map[string]*EventAttendance)},
+		running:    &Running{Events: make(map[string]RunningEvent)},
+	}, nil
+}
 
-	ticker := time.NewTicker(time.Duration(eventerWorkerTick))
+// config returns the current eventer config, see cfg.Live
+func (ev *Eventer) config() *cfg.ConfigEventer {
+	return ev.live.Load().Eventer
+}
 
-	for range ticker.C {
-		now := time.Now()
-		store.Lock()
+// language returns the language of the messages about the guild's events posted to the event channel,
+// DMs are sent in the user's language and fall back to it
+func (ev *Eventer) language(guildID string) utils.Language {
+	return ev.config().Languages.Language(guildID)
+}
 
-		var remaining []Reminder
+// gameLanguage returns the language of the replies in the game chat
+func (ev *Eventer) gameLanguage() utils.Language {
+	return ev.live.Load().Languages.Language("")
+}
 
-		slog.Debug(fmt.Sprintf("Checking %d reminders:", len(store.Pending)))
+// rcon returns the current RCON config of the server status, nil without server status
+func (ev *Eventer) rcon() *cfg.ConfigRcon {
+	if status := ev.live.Load().ServerStatus; status != nil {
+		return &status.Rcon
+	}
 
-		for _, r := range store.Pending {
-			cetTime := r.RemindAt.In(cetLocation)
+	return nil
+}
 
-			slog.Debug(fmt.Sprintf("   Event '%s' reminder due at: %s", r.EventName, cetTime.Format("02.01. 15:04")))
+func (ev *Eventer) Run(s *discordgo.Session) {
+	ev.syncExistingEvents(s)
 
-			if now.After(r.RemindAt) {
-				cetTime := r.StartTime.In(cetLocation)
-				timeStr := cetTime.Format("15:04")
-				dateStr := cetTime.Format("02.01.")
-				msg := ""
+	if cfg.Config.Simulate {
+		ev.queueSimulatedEvent(s)
+	}
 
-				if r.Now {
-					msg = fmt.Sprintf("**Reminder** \n\n@everyone\n\nEvent '%s' startet JETZT!\n\n%s",
-						r.EventName, r.EventURL)
-				} else {
-					msg = fmt.Sprintf("**Reminder** \n\n@everyone\n\nEvent '%s' startet am %s um %s! (in %s)\n\n%s",
-						r.EventName, dateStr, timeStr, utils.FormatDuration(r.StartTime.Sub(time.Now()).Round(time.Second),
-							utils.German), r.EventURL)
-				}
+	if ev.config().ResyncInterval > 0 {
+		go errorreport.Supervise("eventer resync", func() { ev.resyncLoop(s) })
+	}
 
-				slog.Info(fmt.Sprintf("Sending event '%s' reminder NOW", r.EventName))
+	name := fmt.Sprintf("eventer (channel %s)", ev.config().ChannelID)
 
-				_, err := s.ChannelMessageSend(cfg.Config.Eventer.ChannelID, msg)
+	timer := ev.clock.NewTimer(0)
+	defer timer.Stop()
 
-				if err != nil {
-					slog.Error(fmt.Sprintf("Failed to send discord reminder for event '%s': %s", r.EventName, err))
-				}
-			} else {
-				remaining = append(remaining, r)
-			}
+	for {
+		select {
+		case <-timer.C():
+		case <-ev.wake:
+			timer.Stop()
 		}
 
-		if len(remaining) != len(store.Pending) {
-			slog.Info(fmt.Sprintf("Now %d reminders in queue", len(remaining)))
+		health.Beat(name, time.Minute)
+
+		ev.work(s)
+
+		timer.Reset(ev.nextWakeup(ev.clock.Now()))
+	}
+}
+
+// work sends the due reminders and completes the due events. A panic is recovered, so the loop keeps
+// running.
+func (ev *Eventer) work(s *discordgo.Session) {
+	defer errorreport.Catch("eventer")
+
+	ev.sendDue(s)
+	ev.sendDigest(s)
+
+	if ev.config().AutoManage {
+		ev.completeDueEvents(s)
+	}
+}
+
+func (ev *Eventer) sendDue(s *discordgo.Session) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+
+	due, err := ev.store.TakeDue(ev.clock.Now())
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to take due reminders from the queue: %s", err))
+	}
+
+	for _, r := range due {
+		ev.sendTaken(s, r)
+	}
+
+	if len(due) > 0 {
+		slog.Info(fmt.Sprintf("Now %d reminders in queue", ev.PendingCount()))
+	}
+}
+
+// sendTaken sends a reminder taken from the queue. A panic is recovered, the other reminders taken with it
+// are gone from the queue already and must still be sent.
+func (ev *Eventer) sendTaken(s *discordgo.Session, r Reminder) {
+	defer errorreport.Catch("eventer")
+
+	if r.UserID != "" {
+		ev.sendPersonalReminder(s, r)
+	} else {
+		ev.sendReminder(s, r)
+	}
+}
+
+func (ev *Eventer) sendReminder(s *discordgo.Session, r Reminder) {
+	lang := ev.language(r.GuildID)
+	headline := ev.headline(r, lang)
+	body := ev.reminderBody(r, lang)
+
+	if server := ev.locationServer(r.Location); server != "" {
+		errorreport.Go("eventer", func() { ev.broadcast(server, headline) })
+	}
+
+	if r.Now && ev.config().AutoManage {
+		errorreport.Go("eventer", func() { ev.startEvent(s, r) })
+	}
+
+	mention := ev.mention()
+
+	slog.Info(fmt.Sprintf("Sending event '%s' reminder NOW", r.EventName))
+
+	reminder := func(lang utils.Language) string {
+		return "**Reminder** \n\n" + ev.reminderBody(r, lang)
+	}
+
+	ev.subs.Notify(s, ev.subs.Events(), lang, reminder)
+
+	msg := &discordgo.MessageSend{Content: fmt.Sprintf("**Reminder** \n\n%s%s", mention, body)}
+
+	if ev.config().RichReminders != nil {
+		msg = ev.richReminder(r, lang, headline, mention)
+	}
+
+	if ev.config().SnoozeButton {
+		addSnoozeButton(msg, r)
+	}
+
+	expire := lifetime.Expire(ev.clock, ev.cache, ev.config().ReminderLifetime)
+
+	err := retry.SendMessage(ev.out, ev.config().ChannelID, msg, func(m *discordgo.Message) {
+		ev.markDelivered(r)
+
+		if expire != nil {
+			expire(m)
 		}
+	})
 
-		store.Pending = remaining
-		store.Unlock()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send discord reminder for event '%s': %s", r.EventName, err))
 	}
 }
 
-func CreateRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
+// location returns the time zone the event times of the guild are shown and parsed in
+func (ev *Eventer) location(guildID string) *time.Location {
+	return ev.config().Timezones.Location(guildID)
+}
+
+// headline is the reminder's first line, e.g. "Event 'X' startet am 01.02. um 20:00! (in 1 Stunde)"
+func (ev *Eventer) headline(r Reminder, lang utils.Language) string {
+	localTime := r.StartTime.In(ev.location(r.GuildID))
+	now := ev.clock.Now()
+
+	switch {
+	case r.Now:
+		return i18n.T(lang, "event.starts_now", r.EventName)
+	case !now.Before(r.StartTime):
+		// snoozed reminders may be due after the start
+
+		return i18n.T(lang, "event.started", r.EventName, localTime.Format("15:04"))
+	}
+
+	return i18n.T(lang, "event.starts_at", r.EventName, localTime.Format("02.01."), localTime.Format("15:04"),
+		utils.FormatDuration(r.StartTime.Sub(now).Round(time.Second), lang))
+}
+
+func (ev *Eventer) reminderBody(r Reminder, lang utils.Language) string {
+	return fmt.Sprintf("%s\n\n%s%s", ev.headline(r, lang), locationLine(r.Location, lang), r.EventURL)
+}
+
+func (ev *Eventer) CreateRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
 	event := e.GuildScheduledEvent
 	eventURL := fmt.Sprintf("https://discord.com/events/%s/%s", event.GuildID, event.ID)
-	cetTime := event.ScheduledStartTime.In(cetLocation)
+	localTime := event.ScheduledStartTime.In(ev.location(event.GuildID))
 
 	slog.Info(fmt.Sprintf("New event '%s' at %s has been created in discord, scheduling reminders and posting notification",
-		event.Name, cetTime.Format("02.01. 15:04")))
+		event.Name, localTime.Format("02.01. 15:04")))
 
-	msg := fmt.Sprintf("**Neues Event wurde erstellt** \n\n@everyone\n\nName: %s\nStart: %s\n%s",
-		event.Name, cetTime.Format("02.01. 15:04"), eventURL)
+	ev.queueReminders(event, 0)
 
-	_, err := s.ChannelMessageSend(cfg.Config.Eventer.ChannelID, msg)
+	// DMs are not subject to the mention cooldown
+
+	body := func(lang utils.Language) string {
+		return fmt.Sprintf("Name: %s\nStart: %s\n%s%s", event.Name, localTime.Format("02.01. 15:04"),
+			locationLine(eventLocation(event), lang), eventURL)
+	}
+
+	created := func(lang utils.Language) string {
+		return i18n.T(lang, "event.created") + " \n\n" + body(lang)
+	}
+
+	lang := ev.language(event.GuildID)
+
+	ev.subs.Notify(s, ev.subs.Events(), lang, created)
+
+	if ev.deferToDigest(event) {
+		return
+	}
+
+	msg := fmt.Sprintf("%s \n\n%s%s", i18n.T(lang, "event.created"), ev.mention(), body(lang))
+
+	m, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{Content: msg})
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to send discord notification for new event '%s': %s", event.Name, err))
+		return
+	}
+
+	ev.crosspost(m)
+}
+
+// crosspost queues publishing the notice of new events to the servers following the event channel, if configured
+func (ev *Eventer) crosspost(m *discordgo.Message) {
+	if !ev.config().CrosspostEvents {
+		return
 	}
 
-	queueReminders(event)
+	retry.Crosspost(ev.out, m.ChannelID, m.ID)
 }
 
-func UpdateRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
+func (ev *Eventer) UpdateRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
 	if e.Status != discordgo.GuildScheduledEventStatusScheduled {
 		var statusName string
 
@@ -131,117 +330,302 @@ func UpdateRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEv
 			statusName = fmt.Sprintf("Unknown (%d)", e.Status)
 		}
 
-		slog.Info(fmt.Sprintf("Event '%s' status update: %s", e.Name, statusName))
+		slog.Info(fmt.Sprintf("Event '%s' status update: %s, removing pending reminders", e.Name, statusName))
+
+		// started events only keep the snoozed reminders, which are still sent while the event runs.
+		// Completed or cancelled events need no more reminders.
+
+		if e.Status == discordgo.GuildScheduledEventStatusActive {
+			ev.keepReminders(e.ID, func(r Reminder) bool { return r.UserID != "" })
+		} else {
+			ev.removeRemindersForEvent(e.ID)
+		}
+
+		ev.dropFromDigest(e.ID)
+
+		if ev.config().AutoManage && e.Status == discordgo.GuildScheduledEventStatusActive {
+			ev.trackRunning(e.GuildScheduledEvent)
+		} else {
+			ev.untrackRunning(e.ID)
+		}
+
+		if ev.config().VoicePing && e.Status == discordgo.GuildScheduledEventStatusActive && isVoiceEvent(e.GuildScheduledEvent) {
+			ev.voiceEventStarted(s, e.GuildScheduledEvent)
+		} else if e.Status != discordgo.GuildScheduledEventStatusActive {
+			ev.voiceEventEnded(s, e.ID)
+		}
+
+		if e.Status == discordgo.GuildScheduledEventStatusCompleted {
+			errorreport.Go("eventer", func() { ev.eventCompleted(s, e.GuildScheduledEvent) })
+		}
+
 		return
 	}
 
 	slog.Info(fmt.Sprintf("Event '%s' was updated. Rescheduling reminders.", e.Name))
 
-	// 1. Remove any old/stale reminders for this specific event
-	removeRemindersForEvent(e.ID)
+	// 1. Remove any old/stale reminders for this specific event, snoozed ones are kept unless the event was moved
+	ev.keepReminders(e.ID, func(r Reminder) bool { return r.UserID != "" && r.StartTime.Equal(e.ScheduledStartTime) })
 
 	// 2. Queue new reminders based on the updated time
-	queueReminders(e.GuildScheduledEvent)
+	ev.queueReminders(e.GuildScheduledEvent, 0)
 }
 
-func DeleteRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
+func (ev *Eventer) DeleteRemindersForEvent(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
 	event := e.GuildScheduledEvent
-	cetTime := event.ScheduledStartTime.In(cetLocation)
+	localTime := event.ScheduledStartTime.In(ev.location(event.GuildID))
+
+	ev.removeRemindersForEvent(e.ID)
+	ev.dropFromDigest(e.ID)
+	ev.voiceEventEnded(s, e.ID)
+	ev.untrackRunning(e.ID)
+
+	slog.Info(fmt.Sprintf("Event '%s' at %s has been deleted, removed its reminders. Now %d reminders in queue",
+		event.Name, localTime.Format("02.01. 15:04"), ev.PendingCount()))
+
+	// only upcoming events are announced as cancelled, not those which already took place
+
+	if ev.config().SkipCancelNotice || event.Status != discordgo.GuildScheduledEventStatusScheduled ||
+		ev.clock.Now().After(event.ScheduledStartTime) {
+		return
+	}
+
+	cancelled := func(lang utils.Language) string {
+		return i18n.T(lang, "event.cancelled") + " \n\n" + i18n.T(lang, "event.cancelled.body", event.Name, localTime.Format("02.01. 15:04"))
+	}
 
-	slog.Info(fmt.Sprintf("Event '%s' at %s has been CANCELLED, posting notification",
-		event.Name, cetTime.Format("02.01. 15:04")))
+	lang := ev.language(event.GuildID)
 
-	msg := fmt.Sprintf("**Event wurde GECANCELT** \n\n@everyone\n\nEvent '%s - %s' wurde gecancelt.",
-		event.Name, cetTime.Format("02.01. 15:04"))
+	msg := fmt.Sprintf("%s \n\n%s%s", i18n.T(lang, "event.cancelled"), ev.mention(),
+		i18n.T(lang, "event.cancelled.body", event.Name, localTime.Format("02.01. 15:04")))
 
-	_, err := s.ChannelMessageSend(cfg.Config.Eventer.ChannelID, msg)
+	ev.subs.Notify(s, ev.subs.Events(), lang, cancelled)
+
+	_, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{Content: msg})
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to send discord notification for cancelled event '%s': %s", event.Name, err))
 	}
+}
 
-	removeRemindersForEvent(e.ID)
+// PendingCount returns the number of reminders currently in the queue
+func (ev *Eventer) PendingCount() int {
+	pending, err := ev.store.All()
 
-	store.Lock()
-	defer store.Unlock()
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load reminders: %s", err))
+	}
 
-	slog.Info(fmt.Sprintf("Now %d reminders in queue", len(store.Pending)))
+	return len(pending)
 }
 
-func removeRemindersForEvent(eventID string) {
-	store.Lock()
-	defer store.Unlock()
+// Reschedule runs update (which may change the eventer config, e.g. the reminder offsets) and
+// rebuilds the reminder queue from the scheduled events. Already delivered reminders are not repeated.
+func (ev *Eventer) Reschedule(s *discordgo.Session, update func() error) error {
+	ev.mu.Lock()
 
-	var updatedList []Reminder
-	for _, r := range store.Pending {
-		// Only keep reminders that DON'T match the updated EventID
-		if r.EventID != eventID {
-			updatedList = append(updatedList, r)
-		}
+	if err := update(); err != nil {
+		ev.mu.Unlock()
+		return err
 	}
 
-	store.Pending = updatedList
+	err := ev.store.Clear()
+	ev.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	ev.syncExistingEvents(s)
+
+	return nil
 }
 
-func queueReminders(event *discordgo.GuildScheduledEvent) {
-	store.Lock()
-	defer store.Unlock()
+func (ev *Eventer) removeRemindersForEvent(eventID string) {
+	if err := ev.store.RemoveEvent(eventID); err != nil {
+		slog.Error(fmt.Sprintf("Failed to remove reminders of event %s: %s", eventID, err))
+	}
+}
 
-	eventURL := fmt.Sprintf("https://discord.com/events/%s/%s", event.GuildID, event.ID)
+// keepReminders removes the reminders of the event except the ones keep returns true for
+func (ev *Eventer) keepReminders(eventID string, keep func(r Reminder) bool) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
 
-	for _, offset := range cfg.Config.Eventer.ReminderOffsets {
-		remindTime := event.ScheduledStartTime.Add(-offset)
+	pending, err := ev.store.All()
 
-		if time.Now().Before(remindTime) {
-			store.Pending = append(store.Pending, Reminder{
-				EventID:   event.ID,
-				EventName: event.Name,
-				EventURL:  eventURL,
-				StartTime: event.ScheduledStartTime, // Store the fixed start time
-				RemindAt:  remindTime,
-				Now:       false,
-			})
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load reminders of event %s: %s", eventID, err))
+		return
+	}
 
-			cetTime := remindTime.In(cetLocation)
+	ev.removeRemindersForEvent(eventID)
 
-			slog.Info(fmt.Sprintf("   Scheduling reminder for event '%s' at %s (in %s)", event.Name,
-				cetTime.Format("02.01. 15:04"), utils.FormatDuration(remindTime.Sub(time.Now()), utils.English)))
+	for _, r := range pending {
+		if r.EventID != eventID || !keep(r) {
+			continue
+		}
+
+		if err := ev.store.Add(r); err != nil {
+			slog.Error(fmt.Sprintf("Failed to keep reminder of event %s: %s", eventID, err))
 		}
 	}
+}
 
-	if time.Now().Before(event.ScheduledStartTime) {
-		store.Pending = append(store.Pending, Reminder{
+func (ev *Eventer) queueReminders(event *discordgo.GuildScheduledEvent, grace time.Duration) {
+	delivered := ev.deliveredReminders()
+	now := ev.clock.Now()
+
+	eventURL := fmt.Sprintf("https://discord.com/events/%s/%s", event.GuildID, event.ID)
+
+	for _, offset := range ev.config().ReminderOffsets {
+		remindTime := event.ScheduledStartTime.Add(-offset)
+
+		r := Reminder{
+			GuildID:   event.GuildID,
 			EventID:   event.ID,
 			EventName: event.Name,
 			EventURL:  eventURL,
 			StartTime: event.ScheduledStartTime, // Store the fixed start time
-			RemindAt:  event.ScheduledStartTime,
-			Now:       true,
-		})
+			RemindAt:  remindTime,
+			Now:       false,
+			Location:  eventLocation(event),
+			EndTime:   eventEnd(event),
+			Image:     eventImage(event),
+		}
 
-		cetTime := event.ScheduledStartTime.In(cetLocation)
+		if _, ok := delivered[r.key()]; ok {
+			slog.Info(fmt.Sprintf("   Reminder for event '%s' at %s was already sent, skipping", event.Name,
+				remindTime.In(ev.location(event.GuildID)).Format("02.01. 15:04")))
+			continue
+		}
+
+		if now.Before(remindTime.Add(grace)) && now.Before(event.ScheduledStartTime) {
+			ev.queue(r)
+
+			localTime := remindTime.In(ev.location(event.GuildID))
+
+			slog.Info(fmt.Sprintf("   Scheduling reminder for event '%s' at %s (in %s)", event.Name,
+				localTime.Format("02.01. 15:04"), utils.FormatDuration(remindTime.Sub(now), utils.English)))
+		}
+	}
+
+	r := Reminder{
+		GuildID:   event.GuildID,
+		EventID:   event.ID,
+		EventName: event.Name,
+		EventURL:  eventURL,
+		StartTime: event.ScheduledStartTime, // Store the fixed start time
+		RemindAt:  event.ScheduledStartTime,
+		Now:       true,
+		Location:  eventLocation(event),
+		EndTime:   eventEnd(event),
+		Image:     eventImage(event),
+	}
+
+	if _, ok := delivered[r.key()]; !ok && now.Before(event.ScheduledStartTime.Add(grace)) {
+		ev.queue(r)
+
+		localTime := event.ScheduledStartTime.In(ev.location(event.GuildID))
 
 		slog.Info(fmt.Sprintf("   Scheduling reminder for event '%s' at %s (in %s)", event.Name,
-			cetTime.Format("02.01. 15:04"), utils.FormatDuration(event.ScheduledStartTime.Sub(time.Now()), utils.English)))
+			localTime.Format("02.01. 15:04"), utils.FormatDuration(event.ScheduledStartTime.Sub(now), utils.English)))
+	}
+}
+
+func (ev *Eventer) queue(r Reminder) {
+	if err := ev.store.Add(r); err != nil {
+		slog.Error(fmt.Sprintf("Failed to queue reminder for event '%s': %s", r.EventName, err))
+		return
 	}
+
+	ev.wakeUp()
 }
 
-func syncExistingEvents(s *discordgo.Session) {
+func (ev *Eventer) syncExistingEvents(s *discordgo.Session) {
 	for _, guild := range s.State.Guilds {
-		events, err := s.GuildScheduledEvents(guild.ID, false)
+		events, err := ev.out.ScheduledEvents(guild.ID)
 
 		if err != nil {
 			continue
 		}
 
 		for _, event := range events {
-			cetTime := event.ScheduledStartTime.In(cetLocation)
+			if ev.config().AutoManage && event.Status == discordgo.GuildScheduledEventStatusActive {
+				ev.trackRunning(event)
+			}
+
+			localTime := event.ScheduledStartTime.In(ev.location(event.GuildID))
+
+			slog.Info(fmt.Sprintf("Found pending event '%s' at %s", event.Name, localTime.Format("02.01. 15:04")))
+
+			ev.queueReminders(event, reminderGracePeriod)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Sync complete. %d reminders in queue", ev.PendingCount()))
+}
+
+// the event queued in simulate mode, which discord doesn't know about
+const simulatedEventID = "simulated"
+
+func (ev *Eventer) queueSimulatedEvent(s *discordgo.Session) {
+	// start the fake event shortly after the smallest reminder offset, so at least
+	// one regular reminder and the "starts now" reminder show up within minutes
 
-			slog.Info(fmt.Sprintf("Found pending event '%s' at %s", event.Name, cetTime.Format("02.01. 15:04")))
+	var smallest time.Duration
 
-			queueReminders(event)
+	for i, offset := range ev.config().ReminderOffsets {
+		if i == 0 || offset < smallest {
+			smallest = offset
 		}
 	}
 
-	slog.Info(fmt.Sprintf("Sync complete. %d reminders in queue", len(store.Pending)))
+	guildID := "0"
+
+	if len(s.State.Guilds) > 0 {
+		guildID = s.State.Guilds[0].ID
+	}
+
+	event := &discordgo.GuildScheduledEvent{
+		ID:                 simulatedEventID,
+		GuildID:            guildID,
+		Name:               "Simulated event",
+		ScheduledStartTime: ev.clock.Now().Add(smallest + time.Minute),
+	}
+
+	ev.CreateRemindersForEvent(s, &discordgo.GuildScheduledEventCreate{GuildScheduledEvent: event})
+}
+
+func (ev *Eventer) deliveredReminders() map[string]time.Time {
+	cacheData, err := ev.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load delivered reminders from cache: %s", err))
+		return nil
+	}
+
+	return cacheData.DeliveredReminders
+}
+
+func (ev *Eventer) markDelivered(r Reminder) {
+	err := ev.cache.Update(func(k *cache.CacheData) {
+		if k.DeliveredReminders == nil {
+			k.DeliveredReminders = make(map[string]time.Time)
+		}
+
+		// forget about reminders of events which are long over
+
+		for key, startTime := range k.DeliveredReminders {
+			if ev.clock.Now().Sub(startTime) > 24*time.Hour {
+				delete(k.DeliveredReminders, key)
+			}
+		}
+
+		k.DeliveredReminders[r.key()] = r.StartTime
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store delivered reminder for event '%s' in cache: %s", r.EventName, err))
+	}
 }
@@ -0,0 +1,83 @@
+package eventer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+func TestStatusChangeKeepsSnoozedReminders(t *testing.T) {
+	now := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Minute)
+
+	ev, _, _ := testEventer(&cfg.ConfigEventer{ChannelID: "1"}, now)
+
+	channel := Reminder{EventID: "10", EventName: "Boss fight", StartTime: start, RemindAt: start}
+	snoozed := Reminder{EventID: "10", EventName: "Boss fight", StartTime: start, RemindAt: now.Add(9 * time.Minute), UserID: "5"}
+	other := Reminder{EventID: "11", EventName: "Race", StartTime: now.Add(time.Hour), RemindAt: now.Add(50 * time.Minute)}
+
+	for _, r := range []Reminder{channel, snoozed, other} {
+		if err := ev.store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := &discordgo.GuildScheduledEvent{ID: "10", GuildID: "2", Name: "Boss fight", ScheduledStartTime: start}
+
+	event.Status = discordgo.GuildScheduledEventStatusActive
+	ev.UpdateRemindersForEvent(nil, &discordgo.GuildScheduledEventUpdate{GuildScheduledEvent: event})
+
+	pending, _ := ev.store.All()
+
+	if len(pending) != 2 || !containsReminder(pending, snoozed) || !containsReminder(pending, other) {
+		t.Fatalf("expected the snoozed reminder and the other event's to be left after the start, got %v", pending)
+	}
+
+	event.Status = discordgo.GuildScheduledEventStatusCompleted
+	ev.UpdateRemindersForEvent(nil, &discordgo.GuildScheduledEventUpdate{GuildScheduledEvent: event})
+
+	pending, _ = ev.store.All()
+
+	if len(pending) != 1 || !containsReminder(pending, other) {
+		t.Fatalf("expected only the other event's reminder to be left after the end, got %v", pending)
+	}
+}
+
+func containsReminder(reminders []Reminder, r Reminder) bool {
+	for _, p := range reminders {
+		if p.key() == r.key() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestResyncKeepsSimulatedEvent(t *testing.T) {
+	now := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+	start := now.Add(time.Hour)
+
+	ev, _, _ := testEventer(&cfg.ConfigEventer{ChannelID: "1"}, now)
+
+	simulated := Reminder{EventID: simulatedEventID, EventName: "Simulated event", StartTime: start, RemindAt: start}
+	deleted := Reminder{EventID: "10", EventName: "Boss fight", StartTime: start, RemindAt: start.Add(-10 * time.Minute)}
+
+	for _, r := range []Reminder{simulated, deleted, {EventID: "10", EventName: "Boss fight", StartTime: start, RemindAt: start}} {
+		if err := ev.store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &discordgo.Session{State: discordgo.NewState()}
+	s.State.Guilds = []*discordgo.Guild{{ID: "2"}}
+
+	ev.resync(s)
+
+	pending, _ := ev.store.All()
+
+	if len(pending) != 1 || !containsReminder(pending, simulated) {
+		t.Fatalf("expected only the simulated event's reminder to be left, got %v", pending)
+	}
+}
@@ -0,0 +1,47 @@
+package eventer
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// NextEventSummary returns when the next scheduled event of all guilds starts, the reply of the
+// in-game "!nextevent" command
+func (ev *Eventer) NextEventSummary(s *discordgo.Session) string {
+	var next *discordgo.GuildScheduledEvent
+
+	for _, guild := range s.State.Guilds {
+		events, err := ev.out.ScheduledEvents(guild.ID)
+
+		if err != nil {
+			continue
+		}
+
+		for _, event := range events {
+			if event.Status != discordgo.GuildScheduledEventStatusScheduled {
+				continue
+			}
+
+			if next == nil || event.ScheduledStartTime.Before(next.ScheduledStartTime) {
+				next = event
+			}
+		}
+	}
+
+	lang := ev.gameLanguage()
+
+	if next == nil {
+		return i18n.T(lang, "event.game.none")
+	}
+
+	start := next.ScheduledStartTime.In(ev.location(next.GuildID))
+	msg := i18n.T(lang, "event.game.next", next.Name, start.Format("02.01."), start.Format("15:04"),
+		utils.FormatDuration(start.Sub(ev.clock.Now()), lang))
+
+	if location := eventLocation(next); location != "" {
+		msg += " " + i18n.T(lang, "event.game.location", location)
+	}
+
+	return msg
+}
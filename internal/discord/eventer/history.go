@@ -0,0 +1,131 @@
+package eventer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// discord limits the description of an embed to 4096 characters
+const maxHistoryLength = 4000
+
+func (ev *Eventer) registerHistory(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "events",
+		Description: "Review the events of this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "history",
+				Description: "List the past events of a month with their attendance",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "month",
+						Description: "The month as YYYY-MM (default this month)",
+					},
+				},
+			},
+		},
+	}, ev.handleHistory)
+}
+
+// handleHistory lists the completed events of the guild which started in the given month
+func (ev *Eventer) handleHistory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	lang := ev.subs.Language(interactions.UserID(i), ev.language(i.GuildID))
+
+	if i.GuildID == "" {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "event.history.guild")})
+		return
+	}
+
+	loc := ev.location(i.GuildID)
+	now := ev.clock.Now().In(loc)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+
+	for _, o := range i.ApplicationCommandData().Options[0].Options {
+		if o.Name != "month" {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01", strings.TrimSpace(o.StringValue()), loc)
+
+		if err != nil {
+			interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+				Content: i18n.T(lang, "event.history.invalid", o.StringValue()),
+			})
+			return
+		}
+
+		month = t
+	}
+
+	var lines []string
+	var interested, players, counted int
+
+	for _, event := range ev.PastEvents() {
+		start := event.Start.In(loc)
+
+		if event.GuildID != i.GuildID || start.Before(month) || !start.Before(month.AddDate(0, 1, 0)) {
+			continue
+		}
+
+		line := fmt.Sprintf("`%s` **%s**", start.Format("02.01. 15:04"), event.Name)
+
+		if event.Location != "" {
+			line += " (" + event.Location + ")"
+		}
+
+		line += " - " + i18n.T(lang, "event.history.interested", event.Interested)
+		interested += event.Interested
+
+		if event.Players >= 0 {
+			line += ", " + i18n.T(lang, "event.history.players", event.Players)
+			players += event.Players
+			counted++
+		}
+
+		lines = append(lines, line)
+	}
+
+	title := i18n.T(lang, "event.history.title", month.Format("01/2006"))
+
+	if len(lines) == 0 {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+			Content: i18n.T(lang, "event.history.none", month.Format("01/2006")),
+		})
+		return
+	}
+
+	// newest events are dropped if the list is too long for the embed
+
+	description := ""
+
+	for n, line := range lines {
+		if len(description)+len(line) > maxHistoryLength {
+			description += i18n.T(lang, "event.history.more", len(lines)-n)
+			break
+		}
+
+		description += line + "\n"
+	}
+
+	footer := i18n.T(lang, "event.history.total", len(lines), interested)
+
+	if counted > 0 {
+		footer += ", " + i18n.T(lang, "event.history.players", players)
+	}
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       title,
+			Description: description,
+			Color:       0x5865F2, // Discord blurple
+			Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+		}},
+	})
+}
@@ -0,0 +1,88 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+)
+
+// Running are the active events the bot completes once their duration is over
+type Running struct {
+	sync.Mutex
+	Events map[string]RunningEvent
+}
+
+type RunningEvent struct {
+	GuildID string
+	Name    string
+	End     time.Time
+}
+
+// startEvent sets the event to active at its start time, unless the organizer already did
+func (ev *Eventer) startEvent(s *discordgo.Session, r Reminder) {
+	slog.Info(fmt.Sprintf("Starting event '%s'", r.EventName))
+
+	_, err := s.GuildScheduledEventEdit(r.GuildID, r.EventID, &discordgo.GuildScheduledEventParams{
+		Status: discordgo.GuildScheduledEventStatusActive,
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to start event '%s': %s", r.EventName, err))
+	}
+}
+
+// trackRunning remembers when the active event is to be completed: at its scheduled end, or after
+// the default duration for events without one
+func (ev *Eventer) trackRunning(event *discordgo.GuildScheduledEvent) {
+	end := event.ScheduledStartTime.Add(ev.config().DefaultDuration)
+
+	if event.ScheduledEndTime != nil {
+		end = *event.ScheduledEndTime
+	}
+
+	ev.running.Lock()
+	ev.running.Events[event.ID] = RunningEvent{GuildID: event.GuildID, Name: event.Name, End: end}
+	ev.running.Unlock()
+
+	ev.wakeUp()
+
+	slog.Info(fmt.Sprintf("Event '%s' will be completed at %s", event.Name, end.In(ev.location(event.GuildID)).Format("02.01. 15:04")))
+}
+
+func (ev *Eventer) untrackRunning(eventID string) {
+	ev.running.Lock()
+	delete(ev.running.Events, eventID)
+	ev.running.Unlock()
+}
+
+// completeDueEvents sets all running events whose end passed to completed
+func (ev *Eventer) completeDueEvents(s *discordgo.Session) {
+	now := ev.clock.Now()
+
+	ev.running.Lock()
+	defer ev.running.Unlock()
+
+	for eventID, event := range ev.running.Events {
+		if now.Before(event.End) {
+			continue
+		}
+
+		delete(ev.running.Events, eventID)
+
+		slog.Info(fmt.Sprintf("Completing event '%s'", event.Name))
+
+		errorreport.Go("eventer", func() {
+			_, err := s.GuildScheduledEventEdit(event.GuildID, eventID, &discordgo.GuildScheduledEventParams{
+				Status: discordgo.GuildScheduledEventStatusCompleted,
+			})
+
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to complete event '%s': %s", event.Name, err))
+			}
+		})
+	}
+}
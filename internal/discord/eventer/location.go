@@ -0,0 +1,62 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// eventLocation returns the location of external events, which have no channel
+func eventLocation(event *discordgo.GuildScheduledEvent) string {
+	if event.EntityType != discordgo.GuildScheduledEventEntityTypeExternal {
+		return ""
+	}
+
+	return strings.TrimSpace(event.EntityMetadata.Location)
+}
+
+func locationLine(location string, lang utils.Language) string {
+	if location == "" {
+		return ""
+	}
+
+	return i18n.T(lang, "event.location", location)
+}
+
+// locationServer returns the server configured for the keyword contained in the location
+func (ev *Eventer) locationServer(location string) string {
+	if location == "" || ev.rcon() == nil {
+		return ""
+	}
+
+	location = strings.ToLower(location)
+
+	// longest keyword first, so "island 2" beats "island"
+
+	var best string
+
+	for keyword := range ev.config().LocationServers {
+		if strings.Contains(location, strings.ToLower(keyword)) && len(keyword) > len(best) {
+			best = keyword
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return ev.config().LocationServers[best]
+}
+
+func (ev *Eventer) broadcast(server string, message string) {
+	slog.Info(fmt.Sprintf("Broadcasting event reminder on %s", server))
+
+	if err := rcon.Broadcast(*ev.rcon(), server, message); err != nil {
+		slog.Error(fmt.Sprintf("Failed to broadcast event reminder on %s: %s", server, err))
+	}
+}
@@ -0,0 +1,134 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+type MentionLimiter struct {
+	sync.Mutex
+	LastMention time.Time
+	Digest      []*discordgo.GuildScheduledEvent
+}
+
+// mention returns the @everyone prefix for a message, or an empty string while the
+// configured mention cooldown is still running.
+func (ev *Eventer) mention() string {
+	if ev.allowMention() {
+		return "@everyone\n\n"
+	}
+
+	return ""
+}
+
+// allowMention starts the cooldown if it isn't running, the caller must send the mention then
+func (ev *Eventer) allowMention() bool {
+	ev.mentions.Lock()
+	defer ev.mentions.Unlock()
+
+	if !ev.mentionAllowedLocked() {
+		return false
+	}
+
+	ev.mentions.LastMention = ev.clock.Now()
+
+	return true
+}
+
+// mentionAllowedLocked returns false while the cooldown is running, without starting it
+func (ev *Eventer) mentionAllowedLocked() bool {
+	return ev.config().MentionCooldown == 0 || ev.clock.Now().Sub(ev.mentions.LastMention) >= ev.config().MentionCooldown
+}
+
+// deferToDigest queues a new-event notification for the digest if digest mode is enabled
+// and the mention cooldown is currently running. Returns false if the notification should be
+// sent right away.
+func (ev *Eventer) deferToDigest(event *discordgo.GuildScheduledEvent) bool {
+	if !ev.config().MentionDigest || ev.config().MentionCooldown == 0 {
+		return false
+	}
+
+	ev.mentions.Lock()
+	defer ev.mentions.Unlock()
+
+	if len(ev.mentions.Digest) == 0 && ev.mentionAllowedLocked() {
+		return false
+	}
+
+	ev.mentions.Digest = append(ev.mentions.Digest, event)
+	ev.wakeUp()
+
+	slog.Info(fmt.Sprintf("Mention cooldown active, adding event '%s' to digest (%d events pending)",
+		event.Name, len(ev.mentions.Digest)))
+
+	return true
+}
+
+func (ev *Eventer) sendDigest(s *discordgo.Session) {
+	ev.mentions.Lock()
+
+	if len(ev.mentions.Digest) == 0 || !ev.mentionAllowedLocked() {
+		ev.mentions.Unlock()
+		return
+	}
+
+	events := ev.mentions.Digest
+	ev.mentions.Digest = nil
+	ev.mentions.Unlock()
+
+	var lines []string
+
+	for _, event := range events {
+		localTime := event.ScheduledStartTime.In(ev.location(event.GuildID))
+
+		lines = append(lines, fmt.Sprintf("- %s (%s)\n  https://discord.com/events/%s/%s",
+			event.Name, localTime.Format("02.01. 15:04"), event.GuildID, event.ID))
+	}
+
+	// the events of the digest are posted to the same channel, in the language of the first event's guild
+
+	lang := ev.language(events[0].GuildID)
+	title := i18n.T(lang, "event.created")
+
+	if len(events) > 1 {
+		title = i18n.T(lang, "event.digest", len(events))
+	}
+
+	msg := fmt.Sprintf("%s \n\n@everyone\n\n%s", title, strings.Join(lines, "\n"))
+
+	slog.Info(fmt.Sprintf("Sending digest for %d new events", len(events)))
+
+	m, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{Content: msg})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send discord digest for %d new events: %s", len(events), err))
+		return
+	}
+
+	ev.mentions.Lock()
+	ev.mentions.LastMention = ev.clock.Now()
+	ev.mentions.Unlock()
+
+	ev.crosspost(m)
+}
+
+func (ev *Eventer) dropFromDigest(eventID string) {
+	ev.mentions.Lock()
+	defer ev.mentions.Unlock()
+
+	var remaining []*discordgo.GuildScheduledEvent
+
+	for _, event := range ev.mentions.Digest {
+		if event.ID != eventID {
+			remaining = append(remaining, event)
+		}
+	}
+
+	ev.mentions.Digest = remaining
+}
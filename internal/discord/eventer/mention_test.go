@@ -0,0 +1,69 @@
+package eventer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output/outputtest"
+)
+
+// testEventer creates an eventer posting to the fake output, with the clock at now
+func testEventer(config *cfg.ConfigEventer, now time.Time) (*Eventer, *outputtest.Fake, *clock.Fake) {
+	out := &outputtest.Fake{}
+	fake := clock.NewFake(now)
+
+	ev := &Eventer{
+		clock:    fake,
+		out:      out,
+		live:     cfg.NewLive(&cfg.ConfigBot{Eventer: config}),
+		store:    &memoryStore{},
+		wake:     make(chan struct{}, 1),
+		mentions: &MentionLimiter{},
+
+		attendance: &Attendance{Events: make(map[string]*EventAttendance)},
+		running:    &Running{Events: make(map[string]RunningEvent)},
+	}
+
+	return ev, out, fake
+}
+
+func TestDigestWaitsForCooldown(t *testing.T) {
+	now := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+
+	ev, out, fake := testEventer(&cfg.ConfigEventer{ChannelID: "1", MentionCooldown: time.Hour, MentionDigest: true}, now)
+
+	ev.mentions.LastMention = now.Add(-10 * time.Minute)
+	ev.mentions.Digest = []*discordgo.GuildScheduledEvent{
+		{ID: "10", GuildID: "2", Name: "Boss fight", ScheduledStartTime: now.Add(24 * time.Hour)},
+		{ID: "11", GuildID: "2", Name: "Race", ScheduledStartTime: now.Add(48 * time.Hour)},
+	}
+
+	ev.sendDigest(nil)
+
+	if len(out.Sent) != 0 {
+		t.Fatalf("digest was sent during the cooldown: %q", out.Sent[0].Content)
+	}
+
+	fake.Advance(50 * time.Minute)
+	ev.sendDigest(nil)
+
+	if len(out.Sent) != 1 {
+		t.Fatalf("expected the digest to be sent once the cooldown ran out, got %d messages", len(out.Sent))
+	}
+
+	if msg := out.Sent[0].Content; !strings.Contains(msg, "@everyone") || !strings.Contains(msg, "Boss fight") || !strings.Contains(msg, "Race") {
+		t.Errorf("unexpected digest %q", msg)
+	}
+
+	if !ev.mentions.LastMention.Equal(fake.Now()) {
+		t.Errorf("cooldown started at %s, want %s", ev.mentions.LastMention, fake.Now())
+	}
+
+	if len(ev.mentions.Digest) != 0 {
+		t.Errorf("%d events left in the digest", len(ev.mentions.Digest))
+	}
+}
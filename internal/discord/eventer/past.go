@@ -0,0 +1,61 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+)
+
+// max. number of completed events kept, the oldest are dropped first
+const maxPastEvents = 1000
+
+// eventCompleted records the completed event with its attendance and posts the recap, if enabled
+func (ev *Eventer) eventCompleted(s *discordgo.Session, event *discordgo.GuildScheduledEvent) {
+	interested, players := ev.attendees(s, event)
+
+	past := cache.PastEvent{
+		ID:         event.ID,
+		GuildID:    event.GuildID,
+		Name:       event.Name,
+		Location:   eventLocation(event),
+		Start:      event.ScheduledStartTime,
+		End:        ev.clock.Now(),
+		Interested: len(interested),
+		Players:    len(players),
+	}
+
+	if ev.rcon() == nil {
+		past.Players = -1
+	}
+
+	err := ev.cache.Update(func(k *cache.CacheData) {
+		k.PastEvents = append(k.PastEvents, past)
+
+		if len(k.PastEvents) > maxPastEvents {
+			k.PastEvents = k.PastEvents[len(k.PastEvents)-maxPastEvents:]
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store completed event '%s': %s", event.Name, err))
+	}
+
+	if ev.config().AttendanceRecap {
+		ev.postRecap(s, event, interested, players)
+	}
+}
+
+// PastEvents returns the completed events, oldest first
+func (ev *Eventer) PastEvents() []cache.PastEvent {
+	cacheData, err := ev.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load completed events from cache: %s", err))
+		return nil
+	}
+
+	return slices.Clone(cacheData.PastEvents)
+}
@@ -0,0 +1,86 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// max. number of names listed per group in the recap
+const maxRecapNames = 50
+
+// attendees returns who was interested in the event and which players were online while it ran, from its
+// scheduled start until now. Without server status nobody is seen in game.
+func (ev *Eventer) attendees(s *discordgo.Session, event *discordgo.GuildScheduledEvent) ([]string, []string) {
+	users, err := s.GuildScheduledEventUsers(event.GuildID, event.ID, 100, false, "", "")
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to fetch interested users of event '%s': %s", event.Name, err))
+	}
+
+	var interested []string
+
+	for _, user := range users {
+		if user.User != nil {
+			interested = append(interested, user.User.Mention())
+		}
+	}
+
+	if ev.rcon() == nil {
+		return interested, nil
+	}
+
+	var servers []string
+
+	if server := ev.locationServer(eventLocation(event)); server != "" {
+		servers = []string{server}
+	}
+
+	var players []string
+
+	for _, name := range history.PlayersSeen(servers, event.ScheduledStartTime, ev.clock.Now()) {
+		players = append(players, utils.SanitizeName(name))
+	}
+
+	return interested, players
+}
+
+// postRecap posts the attendees of the completed event
+func (ev *Eventer) postRecap(s *discordgo.Session, event *discordgo.GuildScheduledEvent, interested []string, players []string) {
+	lang := ev.language(event.GuildID)
+
+	msg := i18n.T(lang, "event.recap", event.Name) + "\n\n" +
+		i18n.T(lang, "event.recap.interested", len(interested), recapList(interested, lang))
+
+	if ev.rcon() != nil {
+		msg += "\n" + i18n.T(lang, "event.recap.players", len(players), recapList(players, lang))
+	}
+
+	slog.Info(fmt.Sprintf("Posting recap of event '%s'", event.Name))
+
+	_, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{
+		Content:         msg,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send recap of event '%s': %s", event.Name, err))
+	}
+}
+
+func recapList(names []string, lang utils.Language) string {
+	if len(names) == 0 {
+		return i18n.T(lang, "event.recap.nobody")
+	}
+
+	if len(names) > maxRecapNames {
+		return i18n.T(lang, "event.recap.more", strings.Join(names[:maxRecapNames], ", "), len(names)-maxRecapNames)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,92 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ev *Eventer) resyncLoop(s *discordgo.Session) {
+	ticker := ev.clock.NewTicker(ev.config().ResyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		ev.resync(s)
+	}
+}
+
+// resync reconciles the reminder queue with the scheduled events of all guilds: reminders of missing
+// events are queued, those of deleted or ended events dropped and those of moved events rescheduled
+func (ev *Eventer) resync(s *discordgo.Session) {
+	events := make(map[string]*discordgo.GuildScheduledEvent)
+
+	for _, guild := range s.State.Guilds {
+		guildEvents, err := ev.out.ScheduledEvents(guild.ID)
+
+		// without the full list, reminders can't be told orphaned
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to fetch scheduled events of guild %s for resync: %s", guild.ID, err))
+			return
+		}
+
+		for _, event := range guildEvents {
+			if event.Status == discordgo.GuildScheduledEventStatusScheduled {
+				events[event.ID] = event
+			}
+		}
+	}
+
+	pending, err := ev.store.All()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load reminders for resync: %s", err))
+		return
+	}
+
+	orphaned := make(map[string]bool)
+	queued := make(map[string]time.Time)
+
+	for _, r := range pending {
+		// snoozed reminders are due within minutes and may belong to events which already started,
+		// the simulated event isn't known to discord
+
+		if r.UserID != "" || r.EventID == simulatedEventID {
+			continue
+		}
+
+		if _, ok := events[r.EventID]; !ok {
+			orphaned[r.EventID] = true
+		}
+
+		queued[r.EventID] = r.StartTime
+	}
+
+	for eventID := range orphaned {
+		slog.Info(fmt.Sprintf("Resync: event %s no longer exists, dropping its reminders", eventID))
+
+		ev.removeRemindersForEvent(eventID)
+		ev.dropFromDigest(eventID)
+	}
+
+	for _, event := range events {
+		startTime, ok := queued[event.ID]
+
+		if ok && startTime.Equal(event.ScheduledStartTime) {
+			continue
+		}
+
+		// already delivered reminders are skipped, so requeueing events without pending reminders is safe
+
+		if ok {
+			slog.Info(fmt.Sprintf("Resync: event '%s' was moved, rescheduling reminders", event.Name))
+		}
+
+		ev.removeRemindersForEvent(event.ID)
+		ev.queueReminders(event, 0)
+	}
+
+	slog.Debug(fmt.Sprintf("Resync complete. %d reminders in queue", ev.PendingCount()))
+}
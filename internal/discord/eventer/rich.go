@@ -0,0 +1,80 @@
+package eventer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+func eventEnd(event *discordgo.GuildScheduledEvent) time.Time {
+	if event.ScheduledEndTime == nil {
+		return time.Time{}
+	}
+
+	return *event.ScheduledEndTime
+}
+
+func eventImage(event *discordgo.GuildScheduledEvent) string {
+	if event.Image == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("https://cdn.discordapp.com/guild-events/%s/%s.png?size=1024", event.ID, event.Image)
+}
+
+// richReminder builds the reminder as embed with the configured fields, the event's cover image
+// and a button linking the event. Mentions only work in the message content, not in embeds.
+func (ev *Eventer) richReminder(r Reminder, lang utils.Language, headline string, mention string) *discordgo.MessageSend {
+	rich := ev.config().RichReminders
+
+	embed := &discordgo.MessageEmbed{
+		Title:       r.EventName,
+		URL:         r.EventURL,
+		Description: headline,
+		Color:       rich.Color,
+	}
+
+	for _, field := range rich.Fields {
+		switch field {
+		case cfg.RichReminderFieldStart:
+			// discord renders the timestamp in the reader's timezone
+
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   i18n.T(lang, "event.rich.start"),
+				Value:  fmt.Sprintf("<t:%d:F> (<t:%d:R>)", r.StartTime.Unix(), r.StartTime.Unix()),
+				Inline: true,
+			})
+		case cfg.RichReminderFieldDuration:
+			if r.EndTime.After(r.StartTime) {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+					Name:   i18n.T(lang, "event.rich.duration"),
+					Value:  utils.FormatDuration(r.EndTime.Sub(r.StartTime), lang),
+					Inline: true,
+				})
+			}
+		case cfg.RichReminderFieldLocation:
+			if r.Location != "" {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: i18n.T(lang, "event.rich.location"), Value: r.Location, Inline: true})
+			}
+		}
+	}
+
+	if r.Image != "" && !rich.HideImage {
+		embed.Image = &discordgo.MessageEmbedImage{URL: r.Image}
+	}
+
+	return &discordgo.MessageSend{
+		Content: strings.TrimSpace("**Reminder** \n\n" + mention),
+		Embeds:  []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: rich.ButtonLabel, Style: discordgo.LinkButton, URL: r.EventURL},
+			}},
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// the worker sleeps until the next reminder, digest or event completion is due, but wakes at least
+// this often to report to the health check and to pick up reminders queued by other shards
+const maxIdle = 30 * time.Second
+
+// wakeUp interrupts the worker's sleep, so it recomputes when it is due next
+func (ev *Eventer) wakeUp() {
+	select {
+	case ev.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextWakeup returns how long the worker may sleep until something needs to be sent
+func (ev *Eventer) nextWakeup(now time.Time) time.Duration {
+	next := now.Add(maxIdle)
+
+	due, ok, err := ev.store.Next()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load next reminder from the queue: %s", err))
+	} else if ok && due.Before(next) {
+		next = due
+	}
+
+	ev.mentions.Lock()
+
+	if len(ev.mentions.Digest) > 0 {
+		if digest := ev.mentions.LastMention.Add(ev.config().MentionCooldown); digest.Before(next) {
+			next = digest
+		}
+	}
+
+	ev.mentions.Unlock()
+
+	ev.running.Lock()
+
+	for _, event := range ev.running.Events {
+		if event.End.Before(next) {
+			next = event.End
+		}
+	}
+
+	ev.running.Unlock()
+
+	return next.Sub(now)
+}
@@ -0,0 +1,96 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+const buttonSnooze = "reminder-snooze"
+const snoozeDelay = 10 * time.Minute
+
+func (ev *Eventer) RegisterInteractions(d *interactions.Dispatcher) {
+	d.HandleComponent(buttonSnooze, ev.handleSnooze)
+	ev.registerHistory(d)
+}
+
+func addSnoozeButton(msg *discordgo.MessageSend, r Reminder) {
+	button := discordgo.Button{
+		Label:    "In 10 Minuten erinnern",
+		Style:    discordgo.SecondaryButton,
+		CustomID: buttonSnooze + ":" + r.EventID,
+		Emoji:    &discordgo.ComponentEmoji{Name: "⏰"},
+	}
+
+	// rich reminders already have a row with the event link
+
+	if len(msg.Components) > 0 {
+		if row, ok := msg.Components[0].(discordgo.ActionsRow); ok {
+			row.Components = append(row.Components, button)
+			msg.Components[0] = row
+			return
+		}
+	}
+
+	msg.Components = append(msg.Components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{button}})
+}
+
+// handleSnooze queues a personal reminder for the clicking user, sent as DM after the snooze delay
+func (ev *Eventer) handleSnooze(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_, eventID, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	userID := interactions.UserID(i)
+	lang := ev.subs.Language(userID, ev.language(i.GuildID))
+
+	event, err := s.GuildScheduledEvent(i.GuildID, eventID, false)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to fetch event %s for snoozed reminder: %s", eventID, err))
+	}
+
+	if err != nil || (event.Status != discordgo.GuildScheduledEventStatusScheduled && event.Status != discordgo.GuildScheduledEventStatusActive) {
+		interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "event.gone")})
+		return
+	}
+
+	slog.Info(fmt.Sprintf("User %s snoozed the reminder of event '%s'", userID, event.Name))
+
+	ev.queue(Reminder{
+		GuildID:   event.GuildID,
+		EventID:   event.ID,
+		EventName: event.Name,
+		EventURL:  fmt.Sprintf("https://discord.com/events/%s/%s", event.GuildID, event.ID),
+		StartTime: event.ScheduledStartTime,
+		RemindAt:  ev.clock.Now().Add(snoozeDelay),
+		Location:  eventLocation(event),
+		EndTime:   eventEnd(event),
+		Image:     eventImage(event),
+		UserID:    userID,
+	})
+
+	interactions.RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Content: i18n.T(lang, "event.snoozed", event.Name),
+	})
+}
+
+func (ev *Eventer) sendPersonalReminder(s *discordgo.Session, r Reminder) {
+	channel, err := s.UserChannelCreate(r.UserID)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to open DM channel to user %s: %s", r.UserID, err))
+		return
+	}
+
+	msg := "**Reminder** \n\n" + ev.reminderBody(r, ev.subs.Language(r.UserID, ev.language(r.GuildID)))
+
+	slog.Info(fmt.Sprintf("Sending snoozed reminder of event '%s' to user %s", r.EventName, r.UserID))
+
+	if err := retry.Send(ev.out, channel.ID, msg, nil); err != nil {
+		slog.Error(fmt.Sprintf("Failed to send snoozed reminder to user %s: %s", r.UserID, err))
+	}
+}
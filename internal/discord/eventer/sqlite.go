@@ -0,0 +1,178 @@
+package eventer
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore keeps the queue in a table of a SQLite database, which can be shared by multiple shards.
+// TakeDue runs in a transaction, so every reminder is taken (and sent) only once.
+type sqliteStore struct {
+	db    *sql.DB
+	table string
+}
+
+func openSqliteStore(file string, table string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", file+"?_pragma=busy_timeout(5000)")
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (
+		reminder_key TEXT PRIMARY KEY,
+		guild_id     TEXT NOT NULL,
+		event_id     TEXT NOT NULL,
+		event_name   TEXT NOT NULL,
+		event_url    TEXT NOT NULL,
+		start_time   INTEGER NOT NULL,
+		remind_at    INTEGER NOT NULL,
+		now          INTEGER NOT NULL,
+		location     TEXT NOT NULL,
+		end_time     INTEGER NOT NULL DEFAULT 0,
+		image        TEXT NOT NULL DEFAULT '',
+		user_id      TEXT NOT NULL DEFAULT ''
+	)`)
+
+	if err == nil {
+		err = migrateSqliteStore(db, table)
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db, table: table}, nil
+}
+
+// migrateSqliteStore adds the columns missing in databases created by older versions
+func migrateSqliteStore(db *sql.DB, table string) error {
+	for _, column := range []string{"end_time INTEGER NOT NULL DEFAULT 0", "image TEXT NOT NULL DEFAULT ''", "user_id TEXT NOT NULL DEFAULT ''"} {
+		var count int
+
+		name := strings.Fields(column)[0]
+
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('"+table+"') WHERE name = ?", name).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q *sqliteStore) All() ([]Reminder, error) {
+	return q.query(q.db, "SELECT guild_id, event_id, event_name, event_url, start_time, remind_at, now, location, end_time, image, user_id FROM "+
+		q.table+" ORDER BY remind_at")
+}
+
+func (q *sqliteStore) Add(r Reminder) error {
+	var endTime int64
+
+	if !r.EndTime.IsZero() {
+		endTime = r.EndTime.UnixMilli()
+	}
+
+	_, err := q.db.Exec("INSERT OR IGNORE INTO "+q.table+
+		" (reminder_key, guild_id, event_id, event_name, event_url, start_time, remind_at, now, location, end_time, image, user_id)"+
+		" VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		r.key(), r.GuildID, r.EventID, r.EventName, r.EventURL, r.StartTime.UnixMilli(), r.RemindAt.UnixMilli(), r.Now, r.Location,
+		endTime, r.Image, r.UserID)
+
+	return err
+}
+
+func (q *sqliteStore) RemoveEvent(eventID string) error {
+	_, err := q.db.Exec("DELETE FROM "+q.table+" WHERE event_id = ?", eventID)
+
+	return err
+}
+
+func (q *sqliteStore) Next() (time.Time, bool, error) {
+	var next sql.NullInt64
+
+	if err := q.db.QueryRow("SELECT MIN(remind_at) FROM " + q.table).Scan(&next); err != nil {
+		return time.Time{}, false, err
+	}
+
+	return time.UnixMilli(next.Int64), next.Valid, nil
+}
+
+func (q *sqliteStore) TakeDue(now time.Time) ([]Reminder, error) {
+	tx, err := q.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	due, err := q.query(tx, "SELECT guild_id, event_id, event_name, event_url, start_time, remind_at, now, location, end_time, image, user_id FROM "+
+		q.table+" WHERE remind_at < ? ORDER BY remind_at", now.UnixMilli())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM "+q.table+" WHERE remind_at < ?", now.UnixMilli()); err != nil {
+		return nil, err
+	}
+
+	return due, tx.Commit()
+}
+
+func (q *sqliteStore) Clear() error {
+	_, err := q.db.Exec("DELETE FROM " + q.table)
+
+	return err
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (q *sqliteStore) query(db queryer, query string, args ...any) ([]Reminder, error) {
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res []Reminder
+
+	for rows.Next() {
+		var r Reminder
+		var startTime, remindAt, endTime int64
+
+		if err := rows.Scan(&r.GuildID, &r.EventID, &r.EventName, &r.EventURL, &startTime, &remindAt, &r.Now, &r.Location,
+			&endTime, &r.Image, &r.UserID); err != nil {
+			return nil, err
+		}
+
+		r.StartTime = time.UnixMilli(startTime)
+		r.RemindAt = time.UnixMilli(remindAt)
+
+		if endTime != 0 {
+			r.EndTime = time.UnixMilli(endTime)
+		}
+
+		res = append(res, r)
+	}
+
+	return res, rows.Err()
+}
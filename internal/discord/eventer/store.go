@@ -0,0 +1,226 @@
+package eventer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// ReminderStore is the queue of pending reminders. Adding a reminder which is already queued
+// (same event, start time and offset) is a no-op, so events can be synced repeatedly.
+type ReminderStore interface {
+	// All returns all pending reminders
+	All() ([]Reminder, error)
+
+	// Add queues the reminder
+	Add(r Reminder) error
+
+	// RemoveEvent drops all reminders of the event
+	RemoveEvent(eventID string) error
+
+	// Next returns when the earliest pending reminder is due, false if the queue is empty
+	Next() (time.Time, bool, error)
+
+	// TakeDue removes and returns all reminders due at the given time
+	TakeDue(now time.Time) ([]Reminder, error)
+
+	// Clear drops all reminders
+	Clear() error
+}
+
+// NewReminderStore opens the store selected in the config
+func NewReminderStore(config *cfg.ConfigEventer) (ReminderStore, error) {
+	switch config.ReminderStore {
+	case "", cfg.ReminderStoreMemory:
+		return &memoryStore{}, nil
+	case cfg.ReminderStoreFile:
+		return openFileStore(config.ReminderStorePath)
+	case cfg.ReminderStoreSqlite, cfg.ReminderStoreDatabase:
+		return openSqliteStore(config.ReminderStorePath, config.ReminderStoreTable)
+	}
+
+	return nil, fmt.Errorf("unknown reminder store '%s'", config.ReminderStore)
+}
+
+// memoryStore keeps the reminders in memory only, they are rebuilt from the scheduled events on startup
+type memoryStore struct {
+	sync.Mutex
+	pending []Reminder
+}
+
+func (m *memoryStore) All() ([]Reminder, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return append([]Reminder{}, m.pending...), nil
+}
+
+func (m *memoryStore) Add(r Reminder) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.add(r)
+
+	return nil
+}
+
+func (m *memoryStore) add(r Reminder) {
+	for _, p := range m.pending {
+		if p.key() == r.key() {
+			return
+		}
+	}
+
+	m.pending = append(m.pending, r)
+}
+
+func (m *memoryStore) RemoveEvent(eventID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	var remaining []Reminder
+
+	for _, r := range m.pending {
+		if r.EventID != eventID {
+			remaining = append(remaining, r)
+		}
+	}
+
+	m.pending = remaining
+
+	return nil
+}
+
+func (m *memoryStore) Next() (time.Time, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var next time.Time
+
+	for i, r := range m.pending {
+		if i == 0 || r.RemindAt.Before(next) {
+			next = r.RemindAt
+		}
+	}
+
+	return next, len(m.pending) > 0, nil
+}
+
+func (m *memoryStore) TakeDue(now time.Time) ([]Reminder, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var due []Reminder
+	var remaining []Reminder
+
+	for _, r := range m.pending {
+		if now.After(r.RemindAt) {
+			due = append(due, r)
+		} else {
+			remaining = append(remaining, r)
+		}
+	}
+
+	m.pending = remaining
+
+	return due, nil
+}
+
+func (m *memoryStore) Clear() error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.pending = nil
+
+	return nil
+}
+
+// fileStore is a memoryStore which writes the queue to a JSON file on every change
+type fileStore struct {
+	memoryStore
+	file string
+}
+
+func openFileStore(file string) (*fileStore, error) {
+	f := &fileStore{file: file}
+
+	dat, err := os.ReadFile(file)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(dat, &f.pending); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders file %s: %w", file, err)
+	}
+
+	return f, nil
+}
+
+func (f *fileStore) Add(r Reminder) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.add(r)
+
+	return f.save()
+}
+
+func (f *fileStore) RemoveEvent(eventID string) error {
+	if err := f.memoryStore.RemoveEvent(eventID); err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	return f.save()
+}
+
+func (f *fileStore) TakeDue(now time.Time) ([]Reminder, error) {
+	due, err := f.memoryStore.TakeDue(now)
+
+	if err != nil || len(due) == 0 {
+		return due, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	return due, f.save()
+}
+
+func (f *fileStore) Clear() error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.pending = nil
+
+	return f.save()
+}
+
+// save writes the queue atomically, must be called with the lock held
+func (f *fileStore) save() error {
+	dat, err := json.Marshal(f.pending)
+
+	if err != nil {
+		return err
+	}
+
+	tmp := f.file + ".tmp"
+
+	if err := os.WriteFile(tmp, dat, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.file)
+}
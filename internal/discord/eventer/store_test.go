@@ -0,0 +1,92 @@
+package eventer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// openStores opens a store of every kind, each in its own directory
+func openStores(t *testing.T) map[string]func() ReminderStore {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	open := func(config *cfg.ConfigEventer) func() ReminderStore {
+		return func() ReminderStore {
+			store, err := NewReminderStore(config)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return store
+		}
+	}
+
+	return map[string]func() ReminderStore{
+		cfg.ReminderStoreMemory: open(&cfg.ConfigEventer{ReminderStore: cfg.ReminderStoreMemory}),
+		cfg.ReminderStoreFile:   open(&cfg.ConfigEventer{ReminderStore: cfg.ReminderStoreFile, ReminderStorePath: filepath.Join(dir, "reminders.json")}),
+		cfg.ReminderStoreSqlite: open(&cfg.ConfigEventer{ReminderStore: cfg.ReminderStoreSqlite, ReminderStorePath: filepath.Join(dir, "reminders.db"), ReminderStoreTable: "reminders"}),
+	}
+}
+
+func TestReminderStores(t *testing.T) {
+	start := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+
+	early := Reminder{EventID: "1", EventName: "Boss fight", StartTime: start, RemindAt: start.Add(-time.Hour)}
+	late := Reminder{EventID: "1", EventName: "Boss fight", StartTime: start, RemindAt: start.Add(-10 * time.Minute)}
+	other := Reminder{EventID: "2", EventName: "Race", StartTime: start.Add(time.Hour), RemindAt: start}
+
+	for kind, open := range openStores(t) {
+		t.Run(kind, func(t *testing.T) {
+			store := open()
+
+			for _, r := range []Reminder{late, early, other, early} {
+				if err := store.Add(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if all, _ := store.All(); len(all) != 3 {
+				t.Fatalf("expected the duplicate to be skipped, got %d reminders", len(all))
+			}
+
+			if next, ok, _ := store.Next(); !ok || !next.Equal(early.RemindAt) {
+				t.Errorf("next reminder at %s, want %s", next, early.RemindAt)
+			}
+
+			due, err := store.TakeDue(start.Add(-30 * time.Minute))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(due) != 1 || due[0].key() != early.key() {
+				t.Fatalf("expected only the early reminder to be due, got %v", due)
+			}
+
+			if err := store.RemoveEvent("1"); err != nil {
+				t.Fatal(err)
+			}
+
+			all, _ := store.All()
+
+			if len(all) != 1 || all[0].EventID != "2" {
+				t.Fatalf("expected only the reminder of the other event to be left, got %v", all)
+			}
+
+			// the persistent stores keep the queue when opened again
+
+			if kind == cfg.ReminderStoreMemory {
+				return
+			}
+
+			if all, _ := open().All(); len(all) != 1 || all[0].key() != other.key() {
+				t.Errorf("reopened store holds %v", all)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package eventer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+// day words of the in-game trigger, english and german
+var triggerWeekdays = map[string]time.Weekday{
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+	"montag": time.Monday, "dienstag": time.Tuesday, "mittwoch": time.Wednesday, "donnerstag": time.Thursday,
+	"freitag": time.Friday, "samstag": time.Saturday, "sonntag": time.Sunday,
+}
+
+var triggerDayOffsets = map[string]int{"today": 0, "heute": 0, "tomorrow": 1, "morgen": 1}
+
+// how often the game chat is read for triggers, if no other feature reads it
+const triggerPollInterval = 5 * time.Second
+
+// sender of the replies in the game chat
+const triggerSender = "LazyDodoBot"
+
+var errTriggerSyntax = errors.New("expected <name> [day] <HH:MM>")
+
+// HandleGameChat creates a scheduled event when an organizer posts the trigger keyword in the game chat.
+// The location is the server (or map) the message was posted on. Returns the reply for the game chat,
+// which is empty if the message is no trigger.
+func (ev *Eventer) HandleGameChat(s *discordgo.Session, location string, sender string, senderID string, message string) string {
+	trigger := ev.config().GameTrigger
+
+	if trigger == nil {
+		return ""
+	}
+
+	text, ok := strings.CutPrefix(strings.TrimSpace(message), trigger.Keyword)
+
+	// "!eventful" is no trigger
+
+	if !ok || text != "" && !unicode.IsSpace([]rune(text)[0]) {
+		return ""
+	}
+
+	// names can be taken by anyone, they are only trusted for chat without platform IDs
+
+	organizer := slices.ContainsFunc(trigger.Organizers, func(o string) bool {
+		if senderID != "" {
+			return o == senderID
+		}
+
+		return strings.EqualFold(o, sender)
+	})
+
+	if !organizer {
+		slog.Info(fmt.Sprintf("Ignoring in-game event trigger of %s, who is no organizer", sender))
+		return ""
+	}
+
+	name, start, err := parseTrigger(text, ev.clock.Now(), ev.location(trigger.GuildID))
+
+	if err != nil {
+		return i18n.T(ev.gameLanguage(), "event.trigger.usage", trigger.Keyword)
+	}
+
+	end := start.Add(ev.config().DefaultDuration)
+
+	event, err := s.GuildScheduledEventCreate(trigger.GuildID, &discordgo.GuildScheduledEventParams{
+		Name:               name,
+		Description:        i18n.T(ev.language(trigger.GuildID), "event.trigger.description", sender),
+		ScheduledStartTime: &start,
+		ScheduledEndTime:   &end,
+		PrivacyLevel:       discordgo.GuildScheduledEventPrivacyLevelGuildOnly,
+		EntityType:         discordgo.GuildScheduledEventEntityTypeExternal,
+		EntityMetadata:     &discordgo.GuildScheduledEventEntityMetadata{Location: ev.serverForMap(location)},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to create event '%s' triggered in game by %s: %s", name, sender, err))
+		return i18n.T(ev.gameLanguage(), "event.trigger.failed", name)
+	}
+
+	slog.Info(fmt.Sprintf("Created event '%s' (%s) triggered in game by %s", event.Name, event.ID, sender))
+
+	return i18n.T(ev.gameLanguage(), "event.trigger.created", event.Name, start.Format("02.01."), start.Format("15:04"))
+}
+
+// RunGameTrigger reads the game chat of the RCON servers for event triggers, for bots which don't read the
+// game chat otherwise
+func (ev *Eventer) RunGameTrigger(s *discordgo.Session) {
+	if ev.config().GameTrigger == nil || ev.rcon() == nil {
+		return
+	}
+
+	var servers []cfg.ConfigRconServer
+
+	for _, server := range ev.rcon().Servers {
+		if rcon.SupportsChat(server) {
+			servers = append(servers, server)
+		}
+	}
+
+	if len(servers) == 0 {
+		slog.Warn("No server supports reading the game chat, in-game event triggers are not read")
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Reading in-game event triggers of %d servers", len(servers)))
+
+	feed := rcon.NewChatFeed(servers)
+
+	ticker := time.NewTicker(triggerPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, server := range servers {
+			messages, err := feed.Read(server)
+
+			if err != nil {
+				// unreachable servers are reported by the RCON alerts already
+
+				slog.Debug(fmt.Sprintf("Failed to read chat of %s: %s", server.Name, err))
+				continue
+			}
+
+			for _, m := range messages {
+				reply := ev.HandleGameChat(s, server.Name, m.Sender, m.SenderID, m.Message)
+
+				if reply == "" {
+					continue
+				}
+
+				if err := rcon.SendChat(server, triggerSender, reply); err != nil {
+					slog.Error(fmt.Sprintf("Failed to send reply to %s: %s", server.Name, err))
+				}
+			}
+		}
+	}
+}
+
+// serverForMap returns the name of the server running the given map, chat read from the database
+// only knows the map. Anything else is returned as is.
+func (ev *Eventer) serverForMap(location string) string {
+	if ev.rcon() == nil {
+		return location
+	}
+
+	for _, server := range ev.rcon().Servers {
+		if server.Map != "" && server.Map == location {
+			return server.Name
+		}
+	}
+
+	return location
+}
+
+// parseTrigger splits e.g. "Boss fight Saturday 20:00" into the event name and its start in loc. The day is
+// optional: a weekday, today/tomorrow or a date like 24.12., without one the next occurrence of the time is used.
+func parseTrigger(text string, now time.Time, loc *time.Location) (string, time.Time, error) {
+	fields := strings.Fields(text)
+
+	if len(fields) < 2 {
+		return "", time.Time{}, errTriggerSyntax
+	}
+
+	at, err := time.Parse("15:04", fields[len(fields)-1])
+
+	if err != nil {
+		return "", time.Time{}, errTriggerSyntax
+	}
+
+	fields = fields[:len(fields)-1]
+	now = now.In(loc)
+
+	date := now
+	day := strings.ToLower(fields[len(fields)-1])
+	explicit := true
+	weekday, isWeekday := triggerWeekdays[day]
+
+	if isWeekday {
+		date = now.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7)
+	} else if offset, ok := triggerDayOffsets[day]; ok {
+		date = now.AddDate(0, 0, offset)
+	} else if d, err := time.Parse("2.1.", day); err == nil {
+		date = time.Date(now.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+	} else if d, err := time.Parse("2.1.2006", day); err == nil {
+		date = d
+	} else {
+		explicit = false
+	}
+
+	if explicit {
+		fields = fields[:len(fields)-1]
+	}
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+
+	// a time which already passed today means the next day, or next week for the weekday of today
+
+	if !start.After(now) {
+		switch {
+		case !explicit:
+			start = start.AddDate(0, 0, 1)
+		case isWeekday:
+			start = start.AddDate(0, 0, 7)
+		default:
+			return "", time.Time{}, fmt.Errorf("%s is in the past", start.Format("02.01.2006 15:04"))
+		}
+	}
+
+	if len(fields) == 0 {
+		return "", time.Time{}, errTriggerSyntax
+	}
+
+	return strings.Join(fields, " "), start, nil
+}
@@ -0,0 +1,135 @@
+package eventer
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// max. number of interested users mentioned when a voice event starts
+const maxInterestedMentions = 50
+
+// Attendance keeps track of who joined the channel of a running voice or stage event
+type Attendance struct {
+	sync.Mutex
+	Events map[string]*EventAttendance
+}
+
+type EventAttendance struct {
+	Name      string
+	GuildID   string
+	ChannelID string
+	Users     map[string]bool
+}
+
+func isVoiceEvent(event *discordgo.GuildScheduledEvent) bool {
+	return event.ChannelID != "" && (event.EntityType == discordgo.GuildScheduledEventEntityTypeVoice ||
+		event.EntityType == discordgo.GuildScheduledEventEntityTypeStageInstance)
+}
+
+// voiceEventStarted posts the link to the channel of the started event, optionally mentioning everyone
+// interested in the event, and starts tracking who joins the channel
+func (ev *Eventer) voiceEventStarted(s *discordgo.Session, event *discordgo.GuildScheduledEvent) {
+	attendance := &EventAttendance{Name: event.Name, GuildID: event.GuildID, ChannelID: event.ChannelID, Users: make(map[string]bool)}
+
+	// members which are already in the channel
+
+	if guild, err := s.State.Guild(event.GuildID); err == nil {
+		for _, state := range guild.VoiceStates {
+			if state.ChannelID == event.ChannelID {
+				attendance.Users[state.UserID] = true
+			}
+		}
+	}
+
+	ev.attendance.Lock()
+	ev.attendance.Events[event.ID] = attendance
+	ev.attendance.Unlock()
+
+	slog.Info(fmt.Sprintf("Voice event '%s' started, tracking attendance in channel %s", event.Name, event.ChannelID))
+
+	msg := i18n.T(ev.language(event.GuildID), "event.voice.started", event.Name, event.ChannelID)
+
+	if ev.config().MentionInterested {
+		users, err := s.GuildScheduledEventUsers(event.GuildID, event.ID, maxInterestedMentions, false, "", "")
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to fetch interested users of event '%s': %s", event.Name, err))
+		}
+
+		var mentions []string
+
+		for _, user := range users {
+			if user.User != nil {
+				mentions = append(mentions, user.User.Mention())
+			}
+		}
+
+		if len(mentions) > 0 {
+			msg += "\n\n" + strings.Join(mentions, " ")
+		}
+	}
+
+	if _, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{Content: msg}); err != nil {
+		slog.Error(fmt.Sprintf("Failed to send start notification for event '%s': %s", event.Name, err))
+	}
+}
+
+// voiceEventEnded stops tracking the event and posts who attended
+func (ev *Eventer) voiceEventEnded(s *discordgo.Session, eventID string) {
+	ev.attendance.Lock()
+	attendance, ok := ev.attendance.Events[eventID]
+	delete(ev.attendance.Events, eventID)
+	ev.attendance.Unlock()
+
+	if !ok {
+		return
+	}
+
+	var users []string
+
+	for userID := range attendance.Users {
+		users = append(users, fmt.Sprintf("<@%s>", userID))
+	}
+
+	sort.Strings(users)
+
+	slog.Info(fmt.Sprintf("Voice event '%s' ended with %d attendees", attendance.Name, len(users)))
+
+	lang := ev.language(attendance.GuildID)
+	msg := i18n.T(lang, "event.voice.ended", attendance.Name, len(users), strings.Join(users, ", "))
+
+	if len(users) == 0 {
+		msg = i18n.T(lang, "event.voice.nobody", attendance.Name)
+	}
+
+	_, err := ev.out.SendMessage(ev.config().ChannelID, &discordgo.MessageSend{
+		Content:         msg,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send attendance of event '%s': %s", attendance.Name, err))
+	}
+}
+
+// TrackVoice records members joining the channel of a running voice event
+func (ev *Eventer) TrackVoice(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if v.VoiceState == nil || v.ChannelID == "" {
+		return
+	}
+
+	ev.attendance.Lock()
+	defer ev.attendance.Unlock()
+
+	for _, attendance := range ev.attendance.Events {
+		if attendance.ChannelID == v.ChannelID {
+			attendance.Users[v.UserID] = true
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// an in-game command is answered at most once per server within this time, so a spamming player
+// can't flood the chat of everyone else
+const gameCommandCooldown = 30 * time.Second
+
+// gameChat answers the in-game chat commands "!players" and "!nextevent" if enabled and the
+// "!privacy" confirmations, and passes all other messages on to the in-game event trigger. It is only called from the crosschat loop.
+func (bot *DiscordBot) gameChat(s *discordgo.Session, location string, sender string, senderID string, message string) string {
+	command, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(message)), " ")
+
+	if bot.config().Crosschat.GameCommands && (command == "!players" || command == "!nextevent") {
+		key := location + "/" + command
+
+		// crosschat and the chat relay read the chat of different servers at the same time
+
+		bot.gameCommandMu.Lock()
+		defer bot.gameCommandMu.Unlock()
+
+		if used, ok := bot.gameCommandUses[key]; ok && bot.clock.Now().Sub(used) < gameCommandCooldown {
+			return ""
+		}
+
+		var reply string
+
+		switch {
+		case command == "!players" && bot.serverStatus != nil:
+			reply = bot.serverStatus.PlayersSummary()
+		case command == "!nextevent" && bot.eventer != nil:
+			reply = bot.eventer.NextEventSummary(s)
+		}
+
+		if reply != "" {
+			slog.Info(fmt.Sprintf("Answering in-game command %s of %s on %s", command, sender, location))
+			bot.gameCommandUses[key] = bot.clock.Now()
+		}
+
+		return reply
+	}
+
+	// players confirm in-game that their statistics should not be recorded, see /privacy
+
+	if command == "!privacy" && bot.serverStatus != nil {
+		_, code, _ := strings.Cut(strings.TrimSpace(message), " ")
+
+		return bot.serverStatus.ConfirmPrivacy(sender, strings.TrimSpace(code))
+	}
+
+	if bot.eventer != nil {
+		return bot.eventer.HandleGameChat(s, location, sender, senderID, message)
+	}
+
+	return ""
+}
+
+// relayGameChat passes the game chat read by the chat relay on to gameChat, except the one of servers
+// crosschat reads too, which answers it already
+func (bot *DiscordBot) relayGameChat(s *discordgo.Session, location string, sender string, senderID string, message string) string {
+	if bot.config().Crosschat != nil && slices.ContainsFunc(bot.config().Crosschat.RconServers, func(server cfg.ConfigRconServer) bool { return server.Name == location }) {
+		return ""
+	}
+
+	return bot.gameChat(s, location, sender, senderID, message)
+}
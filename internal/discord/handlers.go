@@ -0,0 +1,16 @@
+package discord
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+)
+
+// safe wraps a gateway event handler, so a panic while handling a malformed event is recovered
+// and reported instead of crashing the bot
+func safe[T any](subsystem string, h func(s *discordgo.Session, e T)) func(s *discordgo.Session, e T) {
+	return func(s *discordgo.Session, e T) {
+		defer errorreport.Catch(subsystem)
+
+		h(s, e)
+	}
+}
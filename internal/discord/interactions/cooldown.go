@@ -0,0 +1,139 @@
+package interactions
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// expired uses are dropped once this many are remembered
+const maxRememberedUses = 1000
+
+// SetCooldown limits the public commands and components to the configured cooldowns and concurrency
+func (d *Dispatcher) SetCooldown(config *cfg.ConfigCooldown) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.cooldown = config
+	d.busy = nil
+
+	if config != nil && config.MaxConcurrent > 0 {
+		d.busy = make(chan struct{}, config.MaxConcurrent)
+	}
+}
+
+// public returns if the command or component is usable by everyone, those are limited by the cooldown.
+// Components are public unless restricted. Must be called with the lock held.
+func (d *Dispatcher) public(name string) bool {
+	if d.guards[name] != nil {
+		return false
+	}
+
+	for _, cmd := range d.definitions {
+		if cmd.Name == name {
+			return cmd.DefaultMemberPermissions == nil
+		}
+	}
+
+	return true
+}
+
+// throttle checks the cooldowns of the invoking user and channel, and remembers the use. If the command
+// or component may not be used yet, the remaining time is returned.
+func (d *Dispatcher) throttle(name string, i *discordgo.InteractionCreate) time.Duration {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.cooldown == nil || !d.public(name) {
+		return 0
+	}
+
+	now := time.Now()
+
+	limits := map[string]time.Duration{
+		"user/" + name + "/" + UserID(i):      d.cooldown.PerUser,
+		"channel/" + name + "/" + i.ChannelID: d.cooldown.PerChannel,
+	}
+
+	var wait time.Duration
+
+	for key, cooldown := range limits {
+		if until := d.uses[key].Add(cooldown); now.Before(until) {
+			wait = max(wait, until.Sub(now))
+		}
+	}
+
+	if wait > 0 {
+		return wait
+	}
+
+	if len(d.uses) >= maxRememberedUses {
+		for key, used := range d.uses {
+			if now.Sub(used) > max(d.cooldown.PerUser, d.cooldown.PerChannel) {
+				delete(d.uses, key)
+			}
+		}
+	}
+
+	for key := range limits {
+		d.uses[key] = now
+	}
+
+	return 0
+}
+
+// acquire takes a slot of the concurrency limit, false if all are in use. Commands and components which
+// are not public always get one.
+func (d *Dispatcher) acquire(name string) (release func(), ok bool) {
+	d.RLock()
+	busy := d.busy
+	public := d.public(name)
+	d.RUnlock()
+
+	if busy == nil || !public {
+		return func() {}, true
+	}
+
+	select {
+	case busy <- struct{}{}:
+		return func() { <-busy }, true
+	default:
+		return nil, false
+	}
+}
+
+// limit replies to interactions exceeding the cooldown or concurrency limit, false if the command or
+// component must not be handled
+func (d *Dispatcher) limit(s *discordgo.Session, i *discordgo.InteractionCreate, name string) (release func(), ok bool) {
+	release, ok = d.acquire(name)
+
+	if !ok {
+		slog.Warn(fmt.Sprintf("Rejecting interaction '%s', too many interactions running", name))
+
+		RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+			Content: i18n.T(d.language(i), "cooldown.busy"),
+		})
+
+		return nil, false
+	}
+
+	if wait := d.throttle(name, i); wait > 0 {
+		release()
+
+		lang := d.language(i)
+		wait = wait.Truncate(time.Second) + time.Second
+
+		RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+			Content: i18n.T(lang, "cooldown.wait", utils.FormatDurationStyle(wait, lang, utils.DurationStyle{Seconds: true})),
+		})
+
+		return nil, false
+	}
+
+	return release, true
+}
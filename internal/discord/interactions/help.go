@@ -0,0 +1,90 @@
+package interactions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// AddHelp adds the /help command, which lists all added commands the invoking member may use.
+// It must be called after all other commands were added.
+func (d *Dispatcher) AddHelp() {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "help",
+		Description: "List the available commands",
+	}, d.handleHelp)
+}
+
+func (d *Dispatcher) handleHelp(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var lines []string
+
+	lang := d.language(i)
+
+	d.RLock()
+
+	for _, cmd := range d.definitions {
+		if allowed := d.guards[cmd.Name]; allowed != nil && !allowed(i) {
+			continue
+		}
+
+		if !hasPermissions(i, cmd.DefaultMemberPermissions) {
+			continue
+		}
+
+		var subcommands []*discordgo.ApplicationCommandOption
+
+		for _, o := range cmd.Options {
+			if o.Type == discordgo.ApplicationCommandOptionSubCommand {
+				subcommands = append(subcommands, o)
+			}
+		}
+
+		if len(subcommands) == 0 {
+			lines = append(lines, fmt.Sprintf("`/%s` - %s", cmd.Name, description(lang, cmd.Name, cmd.Description)))
+		}
+
+		for _, sub := range subcommands {
+			lines = append(lines, fmt.Sprintf("`/%s %s` - %s", cmd.Name, sub.Name,
+				description(lang, cmd.Name+" "+sub.Name, sub.Description)))
+		}
+	}
+
+	d.RUnlock()
+
+	sort.Strings(lines)
+
+	RespondEphemeral(s, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       i18n.T(lang, "help.title"),
+			Description: strings.Join(lines, "\n"),
+			Color:       0x5865F2, // Discord blurple
+		}},
+	})
+}
+
+// description returns the translated description of the command path, or the english one of its definition
+func description(lang utils.Language, path string, english string) string {
+	if text, ok := i18n.Lookup(lang, "command."+strings.ReplaceAll(path, " ", ".")+".description"); ok {
+		return text
+	}
+
+	return english
+}
+
+func hasPermissions(i *discordgo.InteractionCreate, required *int64) bool {
+	if required == nil {
+		return true
+	}
+
+	// commands with permissions are not usable in DMs
+
+	if i.Member == nil {
+		return false
+	}
+
+	return i.Member.Permissions&*required == *required
+}
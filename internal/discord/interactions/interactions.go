@@ -0,0 +1,249 @@
+package interactions
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+type Handler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+type Dispatcher struct {
+	sync.RWMutex
+	commands    map[string]Handler
+	components  map[string]Handler
+	definitions []*discordgo.ApplicationCommand
+	guards      map[string]func(i *discordgo.InteractionCreate) bool
+	languages   func(userID string, fallback utils.Language) utils.Language
+	cooldown    *cfg.ConfigCooldown
+	uses        map[string]time.Time
+	busy        chan struct{}
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		commands:   make(map[string]Handler),
+		components: make(map[string]Handler),
+		guards:     make(map[string]func(i *discordgo.InteractionCreate) bool),
+		uses:       make(map[string]time.Time),
+	}
+}
+
+// HandleCommand registers the handler for the slash command with the given name.
+func (d *Dispatcher) HandleCommand(name string, h Handler) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.commands[name] = h
+}
+
+// AddCommand registers a slash command definition along with its handler. All added commands
+// are created in discord by RegisterCommands.
+func (d *Dispatcher) AddCommand(cmd *discordgo.ApplicationCommand, h Handler) {
+	d.HandleCommand(cmd.Name, h)
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.definitions = append(d.definitions, cmd)
+}
+
+// Restrict marks the command or component as only usable by members passing allowed, so /help hides it
+// from everyone else and the cooldown doesn't apply. The handler itself still has to check the permission.
+func (d *Dispatcher) Restrict(name string, allowed func(i *discordgo.InteractionCreate) bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.guards[name] = allowed
+}
+
+// SetLanguages sets the lookup of the users' languages, used for the replies of the dispatcher's own commands
+func (d *Dispatcher) SetLanguages(languages func(userID string, fallback utils.Language) utils.Language) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.languages = languages
+}
+
+// language returns the language of the invoking user, english if unknown
+func (d *Dispatcher) language(i *discordgo.InteractionCreate) utils.Language {
+	d.RLock()
+	languages := d.languages
+	d.RUnlock()
+
+	if languages == nil {
+		return utils.English
+	}
+
+	return languages(UserID(i), utils.English)
+}
+
+// RegisterCommands creates/updates all added slash commands in discord, replacing any
+// previously registered commands of the application.
+func (d *Dispatcher) RegisterCommands(s *discordgo.Session, appID string) error {
+	d.RLock()
+	defer d.RUnlock()
+
+	definitions := d.definitions
+
+	if definitions == nil {
+		definitions = []*discordgo.ApplicationCommand{}
+	}
+
+	for _, cmd := range definitions {
+		localize(cmd)
+	}
+
+	_, err := s.ApplicationCommandBulkOverwrite(appID, "", definitions)
+
+	if err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("Registered %d slash commands", len(d.definitions)))
+
+	return nil
+}
+
+// HandleComponent registers the handler for message components (buttons etc). Components are
+// matched by the part of their custom ID before the first ':', so the remainder can carry
+// arguments (e.g. "approve:1234").
+func (d *Dispatcher) HandleComponent(name string, h Handler) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.components[name] = h
+}
+
+// Dispatch routes an incoming interaction to its registered handler, to be added via session.AddHandler.
+func (d *Dispatcher) Dispatch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var h Handler
+	var name string
+
+	d.RLock()
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		name = i.ApplicationCommandData().Name
+		h = d.commands[name]
+	case discordgo.InteractionMessageComponent:
+		name, _, _ = strings.Cut(i.MessageComponentData().CustomID, ":")
+		h = d.components[name]
+	}
+
+	d.RUnlock()
+
+	if h == nil {
+		slog.Warn(fmt.Sprintf("Ignoring unknown interaction '%s' (type %d)", name, i.Type))
+		return
+	}
+
+	release, ok := d.limit(s, i, name)
+
+	if !ok {
+		return
+	}
+
+	defer release()
+
+	h(s, i)
+}
+
+// RespondEphemeral replies to an interaction with a message only visible to the invoking user.
+func RespondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	data.Flags |= discordgo.MessageFlagsEphemeral
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to respond to interaction: %s", err))
+	}
+}
+
+// Acknowledge confirms a component interaction without changing the message.
+func Acknowledge(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to acknowledge interaction: %s", err))
+	}
+}
+
+// ServerChoices returns the configured RCON servers as choices for a slash command option. The value
+// is the server key, servers with the same name are told apart by their key.
+func ServerChoices(servers []cfg.ConfigRconServer) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+
+	for _, server := range servers {
+		// discord allows at most 25 choices
+
+		if len(choices) == 25 {
+			break
+		}
+
+		name := server.Name
+
+		if slices.ContainsFunc(servers, func(s cfg.ConfigRconServer) bool { return s.Name == server.Name && s.Key != server.Key }) {
+			name = fmt.Sprintf("%s (%s)", server.Name, server.Key)
+		}
+
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: server.Key})
+	}
+
+	return choices
+}
+
+// TagChoices returns the tags of the configured RCON servers as choices for a slash command option,
+// nil if no server is tagged.
+func TagChoices(servers []cfg.ConfigRconServer) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	var tags []string
+
+	for _, server := range servers {
+		for _, tag := range server.Tags {
+			tag = strings.ToLower(tag)
+
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		if len(choices) == 25 {
+			break
+		}
+
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: tag, Value: tag})
+	}
+
+	return choices
+}
+
+// UserID returns the ID of the user who triggered the interaction.
+func UserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+
+	if i.User != nil {
+		return i.User.ID
+	}
+
+	return ""
+}
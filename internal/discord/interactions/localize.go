@@ -0,0 +1,61 @@
+package interactions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// localize adds the translations of the i18n catalog to the command, its options and choices, so
+// discord shows them in the client's language
+func localize(cmd *discordgo.ApplicationCommand) {
+	names, descriptions := localizations(cmd.Name)
+
+	if len(names) > 0 {
+		cmd.NameLocalizations = &names
+	}
+
+	if len(descriptions) > 0 {
+		cmd.DescriptionLocalizations = &descriptions
+	}
+
+	localizeOptions(cmd.Name, cmd.Options)
+}
+
+func localizeOptions(path string, options []*discordgo.ApplicationCommandOption) {
+	for _, o := range options {
+		optionPath := path + " " + o.Name
+
+		o.NameLocalizations, o.DescriptionLocalizations = localizations(optionPath)
+
+		for _, choice := range o.Choices {
+			choice.NameLocalizations = translations(optionPath + " " + fmt.Sprint(choice.Value))
+		}
+
+		localizeOptions(optionPath, o.Options)
+	}
+}
+
+func localizations(path string) (map[discordgo.Locale]string, map[discordgo.Locale]string) {
+	return translations(path + " name"), translations(path + " description")
+}
+
+// translations returns the catalog entries of the command path in all languages, keyed by locale
+func translations(path string) map[discordgo.Locale]string {
+	key := "command." + strings.ReplaceAll(path, " ", ".")
+	res := make(map[discordgo.Locale]string)
+
+	for lang, locale := range i18n.Locales {
+		if text, ok := i18n.Lookup(lang, key); ok {
+			res[locale] = text
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
+}
@@ -0,0 +1,109 @@
+package lifetime
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+)
+
+// how often expired messages are deleted
+const checkInterval = time.Minute
+
+// Expire returns the onSent callback of retry.SendMessage which deletes the message after the lifetime,
+// nil without lifetime
+func Expire(c clock.Clock, store *cache.Store, lifetime time.Duration) func(m *discordgo.Message) {
+	if lifetime <= 0 {
+		return nil
+	}
+
+	return func(m *discordgo.Message) {
+		if m == nil {
+			return
+		}
+
+		err := store.Update(func(k *cache.CacheData) {
+			k.Expiring = append(k.Expiring, cache.ExpiringMessage{ChannelID: m.ChannelID, MessageID: m.ID, DeleteAt: c.Now().Add(lifetime)})
+		})
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to store lifetime of message %s in cache: %s", m.ID, err))
+		}
+	}
+}
+
+// Run deletes the messages stored in the cache once their lifetime is over, also the ones which expired while
+// the bot was down
+func Run(s *discordgo.Session, store *cache.Store, c clock.Clock) {
+	out := output.FromDiscord(s)
+
+	ticker := c.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		deleteExpired(out, store, c.Now())
+
+		<-ticker.C()
+	}
+}
+
+func deleteExpired(out output.Session, store *cache.Store, now time.Time) {
+	cacheData, err := store.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load expiring messages from cache: %s", err))
+		return
+	}
+
+	done := make(map[string]bool)
+
+	for _, m := range cacheData.Expiring {
+		if now.Before(m.DeleteAt) {
+			continue
+		}
+
+		// messages which are gone, or can't be deleted anymore, are dropped. Other errors are tried again.
+
+		err := out.DeleteMessage(m.ChannelID, m.MessageID)
+
+		if err != nil {
+			var restErr *discordgo.RESTError
+
+			if !errors.As(err, &restErr) || restErr.Message == nil {
+				slog.Warn(fmt.Sprintf("Failed to delete expired message %s, trying again later: %s", m.MessageID, err))
+				continue
+			}
+
+			if restErr.Message.Code != discordgo.ErrCodeUnknownMessage {
+				slog.Error(fmt.Sprintf("Failed to delete expired message %s: %s", m.MessageID, err))
+			}
+		}
+
+		done[m.MessageID] = true
+	}
+
+	if len(done) == 0 {
+		return
+	}
+
+	err = store.Update(func(k *cache.CacheData) {
+		var remaining []cache.ExpiringMessage
+
+		for _, m := range k.Expiring {
+			if !done[m.MessageID] {
+				remaining = append(remaining, m)
+			}
+		}
+
+		k.Expiring = remaining
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to remove deleted messages from cache: %s", err))
+	}
+}
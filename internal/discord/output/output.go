@@ -0,0 +1,75 @@
+package output
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Session is the narrow set of Discord calls the bot posts its output through. It is implemented
+// by the gateway session, and can be mocked in tests or backed by another transport (e.g. webhooks).
+type Session interface {
+	// SendMessage posts a new message to the channel
+	SendMessage(channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error)
+
+	// EditMessage replaces an existing message
+	EditMessage(edit *discordgo.MessageEdit) (*discordgo.Message, error)
+
+	// DeleteMessage deletes a message
+	DeleteMessage(channelID string, messageID string) error
+
+	// Pin pins a message in its channel
+	Pin(channelID string, messageID string) error
+
+	// Crosspost publishes a message of an announcement channel to the channels following it
+	Crosspost(channelID string, messageID string) error
+
+	// FetchMessage returns a single message
+	FetchMessage(channelID string, messageID string) (*discordgo.Message, error)
+
+	// FetchMessages returns up to limit messages of the channel, before the given message id if set
+	FetchMessages(channelID string, limit int, beforeID string) ([]*discordgo.Message, error)
+
+	// ScheduledEvents returns the scheduled events of the guild
+	ScheduledEvents(guildID string) ([]*discordgo.GuildScheduledEvent, error)
+}
+
+// FromDiscord returns the output session backed by the discord gateway session
+func FromDiscord(s *discordgo.Session) Session {
+	return &discordSession{s: s}
+}
+
+type discordSession struct {
+	s *discordgo.Session
+}
+
+func (d *discordSession) SendMessage(channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	return d.s.ChannelMessageSendComplex(channelID, msg)
+}
+
+func (d *discordSession) EditMessage(edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	return d.s.ChannelMessageEditComplex(edit)
+}
+
+func (d *discordSession) DeleteMessage(channelID string, messageID string) error {
+	return d.s.ChannelMessageDelete(channelID, messageID)
+}
+
+func (d *discordSession) Pin(channelID string, messageID string) error {
+	return d.s.ChannelMessagePin(channelID, messageID)
+}
+
+func (d *discordSession) Crosspost(channelID string, messageID string) error {
+	_, err := d.s.ChannelMessageCrosspost(channelID, messageID)
+	return err
+}
+
+func (d *discordSession) FetchMessage(channelID string, messageID string) (*discordgo.Message, error) {
+	return d.s.ChannelMessage(channelID, messageID)
+}
+
+func (d *discordSession) FetchMessages(channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	return d.s.ChannelMessages(channelID, limit, beforeID, "", "")
+}
+
+func (d *discordSession) ScheduledEvents(guildID string) ([]*discordgo.GuildScheduledEvent, error) {
+	return d.s.GuildScheduledEvents(guildID, false)
+}
@@ -0,0 +1,58 @@
+// Package outputtest provides a fake output.Session for the tests of the features posting to discord.
+package outputtest
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+)
+
+// Fake records the messages sent, all other calls do nothing
+type Fake struct {
+	Sent []*discordgo.MessageSend
+}
+
+var _ output.Session = (*Fake)(nil)
+
+// Contents returns the content of each message sent
+func (f *Fake) Contents() []string {
+	var res []string
+
+	for _, msg := range f.Sent {
+		res = append(res, msg.Content)
+	}
+
+	return res
+}
+
+func (f *Fake) SendMessage(channelID string, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	f.Sent = append(f.Sent, msg)
+	return &discordgo.Message{ID: "1", ChannelID: channelID, Content: msg.Content}, nil
+}
+
+func (f *Fake) EditMessage(edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	return &discordgo.Message{ID: edit.ID, ChannelID: edit.Channel}, nil
+}
+
+func (f *Fake) DeleteMessage(channelID string, messageID string) error {
+	return nil
+}
+
+func (f *Fake) Pin(channelID string, messageID string) error {
+	return nil
+}
+
+func (f *Fake) Crosspost(channelID string, messageID string) error {
+	return nil
+}
+
+func (f *Fake) FetchMessage(channelID string, messageID string) (*discordgo.Message, error) {
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func (f *Fake) FetchMessages(channelID string, limit int, beforeID string) ([]*discordgo.Message, error) {
+	return nil, nil
+}
+
+func (f *Fake) ScheduledEvents(guildID string) ([]*discordgo.GuildScheduledEvent, error) {
+	return nil, nil
+}
@@ -0,0 +1,182 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+)
+
+const permsText int64 = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+const permsEmbed int64 = permsText | discordgo.PermissionEmbedLinks
+const permsThreads int64 = discordgo.PermissionCreatePublicThreads | discordgo.PermissionSendMessagesInThreads | discordgo.PermissionManageThreads
+
+var permissionNames = []struct {
+	perm int64
+	name string
+}{
+	{discordgo.PermissionViewChannel, "View Channel"},
+	{discordgo.PermissionSendMessages, "Send Messages"},
+	{discordgo.PermissionEmbedLinks, "Embed Links"},
+	{discordgo.PermissionReadMessageHistory, "Read Message History"},
+	{discordgo.PermissionManageMessages, "Manage Messages"},
+	{discordgo.PermissionCreatePublicThreads, "Create Public Threads"},
+	{discordgo.PermissionSendMessagesInThreads, "Send Messages in Threads"},
+	{discordgo.PermissionManageThreads, "Manage Threads"},
+	{discordgo.PermissionManageEvents, "Manage Events"},
+}
+
+type channelRequirement struct {
+	channelID string
+	purposes  []string
+	perms     int64
+
+	// purposes crossposting their messages, which needs an announcement channel
+	crossposts []string
+}
+
+// requiredPermissions lists the permissions the configured features need, by channel
+func (bot *DiscordBot) requiredPermissions() []*channelRequirement {
+	var res []*channelRequirement
+
+	require := func(channelID string, purpose string, perms int64) {
+		if channelID == "" {
+			return
+		}
+
+		for _, r := range res {
+			if r.channelID == channelID {
+				r.purposes = append(r.purposes, purpose)
+				r.perms |= perms
+				return
+			}
+		}
+
+		res = append(res, &channelRequirement{channelID: channelID, purposes: []string{purpose}, perms: perms})
+	}
+
+	crosspost := func(channelID string, purpose string) {
+		for _, r := range res {
+			if r.channelID == channelID {
+				r.crossposts = append(r.crossposts, purpose)
+			}
+		}
+	}
+
+	if status := bot.config().ServerStatus; status != nil {
+		// the status message is searched in the channel history if not cached
+
+		perms := permsEmbed | discordgo.PermissionReadMessageHistory
+
+		if status.StatusThreads {
+			perms |= permsThreads
+		}
+
+		if len(status.Guilds) == 0 {
+			require(status.ChannelID, "status message", perms)
+		}
+
+		for _, guild := range status.Guilds {
+			if guild.Pin {
+				require(guild.ChannelID, "status message", perms|discordgo.PermissionManageMessages)
+			} else {
+				require(guild.ChannelID, "status message", perms)
+			}
+		}
+
+		if status.ShowJoinLeave {
+			perms := permsText
+
+			if status.ArchiveJoinLeave || status.PurgeJoinLeave {
+				perms |= discordgo.PermissionReadMessageHistory | discordgo.PermissionManageMessages
+			}
+
+			require(status.ChannelIDJoinLeave, "join/leave messages", perms)
+		}
+
+		if status.SnapshotTime != "" {
+			require(status.ChannelIDSnapshot, "daily snapshot", permsEmbed)
+		}
+
+		require(status.ChannelIDSla, "uptime report", permsEmbed)
+		require(status.ChannelIDIncidents, "incidents", permsText|permsThreads)
+
+		if status.CrosspostOutages {
+			crosspost(status.ChannelIDIncidents, "outages")
+		}
+		require(status.ChannelIDFull, "fill alerts", permsEmbed)
+		require(status.ChannelIDReports, "player reports", permsEmbed)
+		require(status.ChannelIDPlaytime, "playtime leaderboard", permsEmbed)
+	}
+
+	if ev := bot.config().Eventer; ev != nil {
+		perms := permsEmbed
+
+		if ev.AutoManage || ev.GameTrigger != nil {
+			perms |= discordgo.PermissionManageEvents
+		}
+
+		require(ev.ChannelID, "event notifications", perms)
+
+		if ev.CrosspostEvents {
+			crosspost(ev.ChannelID, "new events")
+		}
+	}
+
+	if bot.config().Admin != nil {
+		require(bot.config().Admin.ChannelID, "admin alerts", permsText)
+	}
+
+	if bot.config().Crosschat != nil {
+		require(bot.config().Crosschat.ChannelID, "cross chat", permsText)
+	}
+
+	return res
+}
+
+// checkPermissions verifies the bot has the permissions of all configured features in their channels,
+// missing ones are logged and reported to the admin channel
+func (bot *DiscordBot) checkPermissions(userID string) {
+	var problems []string
+
+	requirements := bot.requiredPermissions()
+
+	for _, r := range requirements {
+		purposes := strings.Join(r.purposes, ", ")
+		perms, err := bot.session.UserChannelPermissions(userID, r.channelID)
+
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("<#%s> (%s): channel not accessible, %s", r.channelID, purposes, err))
+			continue
+		}
+
+		var missing []string
+
+		for _, p := range permissionNames {
+			if r.perms&p.perm != 0 && perms&p.perm == 0 && perms&discordgo.PermissionAdministrator == 0 {
+				missing = append(missing, p.name)
+			}
+		}
+
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("<#%s> (%s): missing %s", r.channelID, purposes, strings.Join(missing, ", ")))
+		}
+
+		if len(r.crossposts) == 0 {
+			continue
+		}
+
+		if channel, err := bot.session.Channel(r.channelID); err == nil && channel.Type != discordgo.ChannelTypeGuildNews {
+			problems = append(problems, fmt.Sprintf("<#%s> (%s): not an announcement channel, messages can't be crossposted", r.channelID, strings.Join(r.crossposts, ", ")))
+		}
+	}
+
+	if len(problems) == 0 {
+		slog.Info(fmt.Sprintf("[%s] Permissions check passed for %d channels", bot.config().Name, len(requirements)))
+		return
+	}
+
+	alerts.MissingPermissions(bot.session, bot.config().Admin, bot.config().Name, problems)
+}
@@ -0,0 +1,46 @@
+package discord
+
+import (
+	"fmt"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+// addReadiness registers the readiness checks of the bot for /readyz
+func (bot *DiscordBot) addReadiness() {
+	health.AddReadiness(bot.config().Name+"/gateway", bot.gatewayConnected)
+
+	if bot.config().ServerStatus != nil && len(bot.config().ServerStatus.Rcon.Servers) > 0 {
+		health.AddReadiness(bot.config().Name+"/rcon", bot.rconReachable)
+	}
+}
+
+// gatewayConnected fails while the gateway session is closed or reconnecting
+func (bot *DiscordBot) gatewayConnected() error {
+	bot.session.RLock()
+	defer bot.session.RUnlock()
+
+	if !bot.session.DataReady {
+		return fmt.Errorf("not connected to the discord gateway")
+	}
+
+	return nil
+}
+
+// rconReachable fails if none of the RCON servers was polled successfully within the last polls, a single
+// server being down doesn't make the bot useless
+func (bot *DiscordBot) rconReachable() error {
+	rconConfig := bot.config().ServerStatus.Rcon
+	within := time.Duration(cfg.Config.ReadyPolls*rconConfig.QueryEverySeconds) * time.Second
+
+	for _, server := range rconConfig.Servers {
+		if bot.clock.Now().Sub(rcon.LastSuccess(server.Key)) <= within {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no RCON server reachable within the last %d polls", cfg.Config.ReadyPolls)
+}
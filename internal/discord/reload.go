@@ -0,0 +1,126 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/patrickjane/lazydodo-bot/internal/api"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// reloads are triggered by SIGHUP and by changes of the config file, possibly at the same time
+var reloadLock sync.Mutex
+
+// Reload parses the config file again and applies the changed settings to the running bots
+func Reload(bots []*DiscordBot) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	slog.Info(fmt.Sprintf("Reloading config file %s", cfg.ConfigFile()))
+
+	root, err := cfg.Reload()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Ignoring changed config file, it is invalid: %s", err))
+		return
+	}
+
+	// API tokens removed from the config are invalid right away
+
+	api.SetConfigTokens(root.Api)
+
+	running := make(map[string]bool)
+
+	for _, bot := range bots {
+		running[bot.config().Name] = true
+	}
+
+	for _, next := range root.AllBots() {
+		if !running[next.Name] {
+			slog.Warn(fmt.Sprintf("Bot '%s' was added to the config file, it is started on the next restart", next.Name))
+		}
+	}
+
+	for _, bot := range bots {
+		if bot.standingBy() {
+			continue
+		}
+
+		i := slices.IndexFunc(root.AllBots(), func(next *cfg.ConfigBot) bool { return next.Name == bot.config().Name })
+
+		if i < 0 {
+			slog.Warn(fmt.Sprintf("[%s] Bot was removed from the config file, it is stopped on the next restart", bot.config().Name))
+			cfg.SetPending(bot.config().Name, []string{"bots"})
+			continue
+		}
+
+		bot.reload(root.AllBots()[i])
+	}
+}
+
+// reload applies the settings which can be changed at runtime, all other changes wait for /config apply
+func (bot *DiscordBot) reload(next *cfg.ConfigBot) {
+	// settings changed with /config set take precedence, as on startup
+
+	bot.applySettings(next)
+
+	changes, err := bot.config().Changes(next)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("[%s] Failed to compare reloaded config: %s", bot.config().Name, err))
+		return
+	}
+
+	if len(changes.Live) > 0 {
+		apply := func() error {
+			return bot.live.Update(func(b *cfg.ConfigBot) error {
+				b.ApplyLive(next, changes.Live)
+				return nil
+			})
+		}
+
+		if bot.eventer != nil && slices.Contains(changes.Live, cfg.KeyReminderOffsets) {
+			err = bot.eventer.Reschedule(bot.session, apply)
+		} else {
+			err = apply()
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("[%s] Failed to apply reloaded config: %s", bot.config().Name, err))
+		} else {
+			slog.Info(fmt.Sprintf("[%s] Applied changed settings: %s", bot.config().Name, strings.Join(changes.Live, ", ")))
+		}
+
+		if slices.Contains(changes.Live, cfg.KeyPollInterval) {
+			select {
+			case bot.pollInterval <- bot.config().ServerStatus.Rcon.QueryEverySeconds:
+			default:
+			}
+		}
+
+		// the poll loop is replaced by one polling the new servers, the other features read them on their
+		// next use. The server choices of the slash commands are registered on startup only.
+
+		if slices.Contains(changes.Live, cfg.KeyRconServers) {
+			select {
+			case bot.rconRestart <- struct{}{}:
+			default:
+			}
+
+			slog.Warn(fmt.Sprintf("[%s] Servers changed, the server choices of the slash commands change on the next restart", bot.config().Name))
+		}
+
+		if slices.Contains(changes.Live, cfg.KeyCooldown) {
+			bot.dispatcher.SetCooldown(bot.config().Cooldown)
+		}
+	}
+
+	cfg.SetPending(bot.config().Name, changes.Restart)
+
+	if len(changes.Restart) > 0 {
+		slog.Warn(fmt.Sprintf("[%s] Changed settings need a restart, use /config apply: %s", bot.config().Name, strings.Join(changes.Restart, ", ")))
+	}
+}
@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+)
+
+// discord allows about 10 crossposts per channel and hour, more are dropped instead of waiting for the limit
+const crosspostLimit = 10
+const crosspostWindow = time.Hour
+
+type crosspost struct {
+	out         output.Session
+	channelID   string
+	messageID   string
+	attempts    int
+	nextAttempt time.Time
+}
+
+type crossposts struct {
+	mu      sync.Mutex
+	pending []*crosspost
+
+	// per channel the times of the crossposts of the last window
+	sent map[string][]time.Time
+}
+
+var singletonCrossposts = &crossposts{sent: make(map[string][]time.Time)}
+
+// Crosspost publishes the message of an announcement channel to the channels following it. The message is
+// crossposted by the retry loop, failures are retried with backoff like messages.
+func Crosspost(out output.Session, channelID string, messageID string) {
+	singletonCrossposts.mu.Lock()
+	defer singletonCrossposts.mu.Unlock()
+
+	singletonCrossposts.pending = append(singletonCrossposts.pending, &crosspost{out: out, channelID: channelID, messageID: messageID})
+}
+
+func (c *crossposts) process() {
+	c.mu.Lock()
+
+	var due []*crosspost
+	var remaining []*crosspost
+
+	now := time.Now()
+
+	for _, it := range c.pending {
+		if now.After(it.nextAttempt) {
+			due = append(due, it)
+		} else {
+			remaining = append(remaining, it)
+		}
+	}
+
+	c.pending = remaining
+	c.mu.Unlock()
+
+	for _, it := range due {
+		if !c.allow(it.channelID, now) {
+			slog.Warn(fmt.Sprintf("Not crossposting message %s of channel %s, the limit of %d crossposts per hour is reached", it.messageID, it.channelID, crosspostLimit))
+			continue
+		}
+
+		err := it.out.Crosspost(it.channelID, it.messageID)
+
+		if err == nil {
+			continue
+		}
+
+		it.attempts++
+
+		if !retryable(err) || it.attempts >= maxAttempts {
+			slog.Error(fmt.Sprintf("Giving up on crossposting message %s of channel %s after %d attempts: %s", it.messageID, it.channelID, it.attempts, err))
+			continue
+		}
+
+		it.nextAttempt = time.Now().Add(min(baseBackoff<<(it.attempts-1), maxBackoff))
+
+		c.mu.Lock()
+		c.pending = append(c.pending, it)
+		c.mu.Unlock()
+	}
+}
+
+// allow takes one crosspost of the channel's limit, false if it is used up
+func (c *crossposts) allow(channelID string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var recent []time.Time
+
+	for _, t := range c.sent[channelID] {
+		if now.Sub(t) < crosspostWindow {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= crosspostLimit {
+		c.sent[channelID] = recent
+		return false
+	}
+
+	c.sent[channelID] = append(recent, now)
+
+	return true
+}
@@ -0,0 +1,207 @@
+package retry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+)
+
+const maxAttempts = 6
+const baseBackoff = 2 * time.Second
+const maxBackoff = 5 * time.Minute
+
+type item struct {
+	ChannelID   string                    `json:"channelID"`
+	Content     string                    `json:"content"`
+	Embeds      []*discordgo.MessageEmbed `json:"embeds,omitempty"`
+	Attempts    int                       `json:"attempts"`
+	LastError   string                    `json:"lastError"`
+	FailedAt    time.Time                 `json:"failedAt"`
+	out         output.Session
+	msg         *discordgo.MessageSend
+	nextAttempt time.Time
+	onSent      func(m *discordgo.Message)
+}
+
+type Queue struct {
+	mu    sync.Mutex
+	items []*item
+}
+
+var singletonQueue = &Queue{}
+
+// Send posts a message to the given channel. If sending fails with a transient error, the
+// message is put into the retry queue and sent again later with exponential backoff.
+// onSent (optional) is called once the message was delivered. Returns an error only if the
+// message could neither be sent nor queued.
+func Send(out output.Session, channelID string, content string, onSent func()) error {
+	return SendComplex(out, channelID, &discordgo.MessageSend{Content: content}, onSent)
+}
+
+// SendComplex is Send for messages with embeds or components
+func SendComplex(out output.Session, channelID string, msg *discordgo.MessageSend, onSent func()) error {
+	var sent func(m *discordgo.Message)
+
+	if onSent != nil {
+		sent = func(*discordgo.Message) { onSent() }
+	}
+
+	return SendMessage(out, channelID, msg, sent)
+}
+
+// SendMessage is SendComplex, onSent gets the message as sent, e.g. to delete it later
+func SendMessage(out output.Session, channelID string, msg *discordgo.MessageSend, onSent func(m *discordgo.Message)) error {
+	m, err := out.SendMessage(channelID, msg)
+
+	if err == nil {
+		if onSent != nil {
+			onSent(m)
+		}
+
+		return nil
+	}
+
+	if !retryable(err) {
+		return err
+	}
+
+	singletonQueue.mu.Lock()
+	defer singletonQueue.mu.Unlock()
+
+	if len(singletonQueue.items) >= cfg.Config.RetryQueueSize {
+		deadLetter(&item{ChannelID: channelID, Content: msg.Content, Embeds: msg.Embeds, Attempts: 1, LastError: err.Error()})
+		return fmt.Errorf("retry queue full: %s", err)
+	}
+
+	slog.Warn(fmt.Sprintf("Failed to send message to channel %s, queueing for retry: %s", channelID, err))
+
+	singletonQueue.items = append(singletonQueue.items, &item{
+		out:         out,
+		msg:         msg,
+		ChannelID:   channelID,
+		Content:     msg.Content,
+		Embeds:      msg.Embeds,
+		Attempts:    1,
+		LastError:   err.Error(),
+		nextAttempt: time.Now().Add(baseBackoff),
+		onSent:      onSent,
+	})
+
+	return nil
+}
+
+func Run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		singletonQueue.process()
+		singletonCrossposts.process()
+	}
+}
+
+func (q *Queue) process() {
+	q.mu.Lock()
+
+	var due []*item
+	var remaining []*item
+
+	now := time.Now()
+
+	for _, it := range q.items {
+		if now.After(it.nextAttempt) {
+			due = append(due, it)
+		} else {
+			remaining = append(remaining, it)
+		}
+	}
+
+	q.items = remaining
+	q.mu.Unlock()
+
+	// send without holding the lock, so new messages can be queued meanwhile
+
+	for _, it := range due {
+		m, err := it.out.SendMessage(it.ChannelID, it.msg)
+
+		if err == nil {
+			slog.Info(fmt.Sprintf("Sent queued message to channel %s after %d attempts", it.ChannelID, it.Attempts+1))
+
+			if it.onSent != nil {
+				it.onSent(m)
+			}
+
+			continue
+		}
+
+		it.Attempts++
+		it.LastError = err.Error()
+
+		if !retryable(err) || it.Attempts >= maxAttempts {
+			deadLetter(it)
+			continue
+		}
+
+		backoff := baseBackoff << (it.Attempts - 1)
+
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		it.nextAttempt = time.Now().Add(backoff)
+
+		q.mu.Lock()
+		q.items = append(q.items, it)
+		q.mu.Unlock()
+	}
+}
+
+func retryable(err error) bool {
+	var restErr *discordgo.RESTError
+
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		return restErr.Response.StatusCode >= http.StatusInternalServerError ||
+			restErr.Response.StatusCode == http.StatusTooManyRequests
+	}
+
+	// network errors and the like
+
+	return true
+}
+
+func deadLetter(it *item) {
+	it.FailedAt = time.Now()
+
+	slog.Error(fmt.Sprintf("Giving up on message to channel %s after %d attempts (%s): %s",
+		it.ChannelID, it.Attempts, it.LastError, it.Content))
+
+	errorreport.Repeated("discord", "", "dead letter", fmt.Errorf("giving up on message to channel %s after %d attempts: %s",
+		it.ChannelID, it.Attempts, it.LastError))
+
+	if cfg.Config.DeadLetterFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(cfg.Config.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to open dead letter file: %s", err))
+		return
+	}
+
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(it); err != nil {
+		slog.Error(fmt.Sprintf("Failed to write to dead letter file: %s", err))
+	}
+}
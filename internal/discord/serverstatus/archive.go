@@ -0,0 +1,177 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+)
+
+// the lines may start with the emoji of the server
+var (
+	reJoinLeave = regexp.MustCompile(`^(?:\S+ )?\[(.+?)\] (.+) (joined|left) the server$`)
+	reMove      = regexp.MustCompile(`^(?:\S+ )?\[(.+?) -> (.+?)\] (.+) moved servers$`)
+)
+
+type activityStats struct {
+	joins   int
+	leaves  int
+	players map[string]bool
+}
+
+func (s *ServerStatus) archiveIfDue() {
+	if s.archiving.Load() {
+		return
+	}
+
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load last archive time from cache: %s", err))
+		return
+	}
+
+	now := s.clock.Now().In(s.channelLocation(s.config().ChannelIDJoinLeave))
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	// first run only establishes the baseline, the first archive happens at the next month end
+
+	if cacheData.LastArchive.IsZero() || !cacheData.LastArchive.Before(monthStart) {
+		if cacheData.LastArchive.IsZero() {
+			s.storeLastArchive(now)
+		}
+
+		return
+	}
+
+	s.archiving.Store(true)
+
+	errorreport.Go("serverstatus", func() {
+		defer s.archiving.Store(false)
+
+		if err := s.archiveMonth(monthStart.AddDate(0, -1, 0), monthStart); err != nil {
+			slog.Error(fmt.Sprintf("Failed to archive join/leave messages: %s", err))
+			return
+		}
+
+		s.storeLastArchive(now)
+	})
+}
+
+func (s *ServerStatus) storeLastArchive(t time.Time) {
+	err := s.cache.Update(func(k *cache.CacheData) {
+		k.LastArchive = t
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store last archive time in cache: %s", err))
+	}
+}
+
+// archiveMonth posts a summary of all join/leave messages the bot posted between from and to,
+// and deletes them afterwards if purging is enabled.
+func (s *ServerStatus) archiveMonth(from time.Time, to time.Time) error {
+	channelID := s.config().ChannelIDJoinLeave
+	stats := make(map[string]*activityStats)
+	statsFor := func(server string) *activityStats {
+		if _, ok := stats[server]; !ok {
+			stats[server] = &activityStats{players: make(map[string]bool)}
+		}
+
+		return stats[server]
+	}
+
+	var toDelete []string
+	before := ""
+
+	slog.Info(fmt.Sprintf("Archiving join/leave messages of %s", from.Format("January 2006")))
+
+	for {
+		msgs, err := s.out.FetchMessages(channelID, 100, before)
+
+		if err != nil {
+			return err
+		}
+
+		if len(msgs) == 0 {
+			break
+		}
+
+		before = msgs[len(msgs)-1].ID
+
+		for _, m := range msgs {
+			if m.Author == nil || m.Author.ID != s.UserID || !m.Timestamp.Before(to) || m.Timestamp.Before(from) {
+				continue
+			}
+
+			if match := reJoinLeave.FindStringSubmatch(m.Content); match != nil {
+				st := statsFor(match[1])
+				st.players[match[2]] = true
+
+				if match[3] == "joined" {
+					st.joins++
+				} else {
+					st.leaves++
+				}
+			} else if match := reMove.FindStringSubmatch(m.Content); match != nil {
+				statsFor(match[1]).leaves++
+				statsFor(match[2]).joins++
+				statsFor(match[2]).players[match[3]] = true
+			} else {
+				continue
+			}
+
+			toDelete = append(toDelete, m.ID)
+		}
+
+		// messages are returned newest first, so we can stop once we are past the archived month
+
+		if msgs[len(msgs)-1].Timestamp.Before(from) {
+			break
+		}
+	}
+
+	servers := make([]string, 0, len(stats))
+
+	for server := range stats {
+		servers = append(servers, server)
+	}
+
+	sort.Strings(servers)
+
+	lines := []string{fmt.Sprintf("## Activity in %s", from.Format("January 2006"))}
+
+	if len(servers) == 0 {
+		lines = append(lines, "No player activity")
+	}
+
+	for _, server := range servers {
+		st := stats[server]
+
+		lines = append(lines, fmt.Sprintf("- **%s**: %d joins, %d leaves, %d unique players", server, st.joins, st.leaves, len(st.players)))
+	}
+
+	if _, err := s.out.SendMessage(channelID, &discordgo.MessageSend{Content: strings.Join(lines, "\n")}); err != nil {
+		return err
+	}
+
+	if !s.config().PurgeJoinLeave {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Purging %d archived join/leave messages", len(toDelete)))
+
+	for _, id := range toDelete {
+		if err := s.out.DeleteMessage(channelID, id); err != nil {
+			slog.Error(fmt.Sprintf("Failed to delete archived join/leave message %s: %s", id, err))
+		}
+	}
+
+	return nil
+}
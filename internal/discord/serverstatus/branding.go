@@ -0,0 +1,30 @@
+package serverstatus
+
+import "github.com/bwmarrin/discordgo"
+
+// title is the heading of the status message in the target channel
+func (t statusTarget) title() string {
+	if t.Branding != nil && t.Branding.Title != "" {
+		return t.Branding.Title
+	}
+
+	return discordMessageTitle
+}
+
+// brand adds the configured author line to the first embed, unless it shows a server icon, and the footer
+// to the last one
+func (t statusTarget) brand(embeds []*discordgo.MessageEmbed) {
+	b := t.Branding
+
+	if b == nil || len(embeds) == 0 {
+		return
+	}
+
+	if b.AuthorName != "" && embeds[0].Author == nil {
+		embeds[0].Author = &discordgo.MessageEmbedAuthor{Name: b.AuthorName, IconURL: b.AuthorIconURL, URL: b.AuthorURL}
+	}
+
+	if b.Footer != "" {
+		embeds[len(embeds)-1].Footer = &discordgo.MessageEmbedFooter{Text: b.Footer, IconURL: b.FooterIconURL}
+	}
+}
@@ -0,0 +1,104 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/chart"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+const buttonRefresh = "status-refresh"
+const buttonHistory = "status-history"
+const historyWindow = 24 * time.Hour
+
+func (s *ServerStatus) buildComponents() []discordgo.MessageComponent {
+	if !s.config().ShowButtons {
+		return []discordgo.MessageComponent{}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Refresh now",
+					Style:    discordgo.SecondaryButton,
+					CustomID: buttonRefresh,
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔄"},
+				},
+				discordgo.Button{
+					Label:    "History",
+					Style:    discordgo.SecondaryButton,
+					CustomID: buttonHistory,
+					Emoji:    &discordgo.ComponentEmoji{Name: "📈"},
+				},
+			},
+		},
+	}
+}
+
+func (s *ServerStatus) handleRefresh(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	slog.Info("Refresh of server status requested via button")
+
+	// the status message itself is updated by the regular loop once the poll has finished
+
+	rcon.RequestRefresh(s.refresh)
+	interactions.Acknowledge(session, i)
+}
+
+func (s *ServerStatus) handleHistory(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	servers := slices.Clone(s.config().Rcon.Servers)
+	slices.SortStableFunc(servers, func(a, b cfg.ConfigRconServer) int { return strings.Compare(a.Name, b.Name) })
+
+	since := s.clock.Now().Add(-historyWindow).Truncate(history.Resolution)
+	buckets := int(historyWindow / history.Resolution)
+
+	var panels []chart.Panel
+	lines := []string{"**Players during the last 24 hours**"}
+
+	for n, server := range servers {
+		panel := chart.Panel{Values: make([]float64, buckets), Down: make([]bool, buckets)}
+		peak := 0
+
+		for _, sample := range history.Samples(server.Key, since) {
+			idx := int(sample.Time.Sub(since) / history.Resolution)
+
+			if idx < 0 || idx >= buckets {
+				continue
+			}
+
+			panel.Values[idx] = float64(sample.Players)
+			panel.Down[idx] = !sample.Reachable()
+
+			if sample.Players > peak {
+				peak = sample.Players
+			}
+		}
+
+		panel.Max = float64(peak)
+		panels = append(panels, panel)
+		lines = append(lines, fmt.Sprintf("%d. %s (peak: %d)", n+1, server.Name, peak))
+	}
+
+	img, err := chart.Render(panels)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to render history chart: %s", err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Failed to render history chart"})
+		return
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Content: strings.Join(lines, "\n"),
+		Files: []*discordgo.File{
+			{Name: "history.png", ContentType: "image/png", Reader: img},
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package serverstatus
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+func (s *ServerStatus) RegisterInteractions(d *interactions.Dispatcher) {
+	d.HandleComponent(buttonRefresh, s.handleRefresh)
+	d.HandleComponent(buttonHistory, s.handleHistory)
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "serverinfo",
+		Description: "Show detailed information about a server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(s.config().Rcon.Servers),
+			},
+		},
+	}, s.handleServerInfo)
+
+	s.registerPlayers(d)
+	s.registerUptime(d)
+	s.registerHeatmap(d)
+	s.registerPrivacy(d)
+	s.registerPlaytime(d)
+
+	if s.config().ChannelIDReports != "" {
+		s.registerReport(d)
+	}
+}
+
+func (s *ServerStatus) handleServerInfo(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	serverKey := i.ApplicationCommandData().Options[0].StringValue()
+
+	var serverConfig *cfg.ConfigRconServer
+
+	for _, server := range s.config().Rcon.Servers {
+		if server.Key == serverKey {
+			serverConfig = &server
+		}
+	}
+
+	ifo, ok := s.Current(serverKey)
+
+	if serverConfig == nil || !ok {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("No information available for server '%s' yet.", s.serverName(serverKey)),
+		})
+		return
+	}
+
+	now := s.clock.Now().In(s.location(i.GuildID))
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	status := "Online"
+	color := 0x57F287 // Discord green
+
+	if !ifo.Reachable {
+		status = "Unreachable"
+		color = 0xc1121f
+	}
+
+	mods := "None"
+
+	if len(serverConfig.Mods) > 0 {
+		mods = strings.Join(serverConfig.Mods, ", ")
+	}
+
+	peak := history.Peak(serverKey, midnight)
+
+	if len(ifo.Players) > peak {
+		peak = len(ifo.Players)
+	}
+
+	uptime := "Unknown"
+	lastRestart := "Unknown"
+
+	if t, ok := history.LastRestart(serverKey); ok {
+		lastRestart = fmt.Sprintf("<t:%d:f>", t.Unix())
+
+		if ifo.Reachable {
+			uptime = utils.FormatDuration(now.Sub(t), utils.English)
+		}
+	} else if samples := history.Samples(serverKey, time.Time{}); len(samples) > 0 && ifo.Reachable {
+		uptime = fmt.Sprintf("at least %s", utils.FormatDuration(now.Sub(samples[0].Time), utils.English))
+	}
+
+	inline := func(name string, value string) *discordgo.MessageEmbedField {
+		// discord rejects empty field values
+
+		if value == "" {
+			value = "-"
+		}
+
+		return &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true}
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: serverConfig.Name,
+			Color: color,
+			Fields: []*discordgo.MessageEmbedField{
+				inline("Status", status),
+				inline("Address", serverConfig.Address),
+				inline("Map", serverConfig.Map),
+				inline("Version", ifo.ServerVersion),
+				inline("Day", fmt.Sprintf("%d", ifo.Day)),
+				inline("Players", fmt.Sprintf("%d (peak today: %d)", len(ifo.Players), peak)),
+				inline("Uptime", uptime),
+				inline("Last restart", lastRestart),
+				{Name: "Mods", Value: mods},
+			},
+		}},
+	})
+}
@@ -0,0 +1,90 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+type fillSample struct {
+	at      time.Time
+	players int
+}
+
+// checkFillRate alerts the full channel when a server is projected to reach its player slots within the
+// configured time, at the join rate of the same time span. The alert is sent once, until the projection
+// is more than twice the time away or the server stops filling up.
+func (s *ServerStatus) checkFillRate(serverInfos map[string]*model.ServerInfo, now time.Time) {
+	window := s.config().FullWithin
+
+	if s.fill == nil {
+		s.fill = make(map[string][]fillSample)
+		s.fullAlerted = make(map[string]bool)
+	}
+
+	for _, server := range s.config().Rcon.Servers {
+		ifo, ok := serverInfos[server.Key]
+
+		if server.MaxPlayers == 0 || !ok || !ifo.Reachable {
+			delete(s.fill, server.Key)
+			continue
+		}
+
+		players := len(ifo.Players)
+		samples := append(s.fill[server.Key], fillSample{at: now, players: players})
+
+		for len(samples) > 1 && now.Sub(samples[0].at) > window {
+			samples = samples[1:]
+		}
+
+		s.fill[server.Key] = samples
+
+		// the rate of the first minutes after startup or an outage is not meaningful
+
+		elapsed := now.Sub(samples[0].at)
+
+		if elapsed < window/2 || players >= server.MaxPlayers {
+			continue
+		}
+
+		rate := float64(players-samples[0].players) / elapsed.Minutes()
+
+		if rate <= 0 {
+			s.fullAlerted[server.Key] = false
+			continue
+		}
+
+		eta := time.Duration(float64(server.MaxPlayers-players) / rate * float64(time.Minute))
+
+		if eta > 2*window {
+			s.fullAlerted[server.Key] = false
+		}
+
+		if eta > window || s.fullAlerted[server.Key] {
+			continue
+		}
+
+		s.fullAlerted[server.Key] = true
+
+		slog.Info(fmt.Sprintf("Server %s is projected to be full in %s", server.Name, eta.Round(time.Minute)))
+
+		embed := &discordgo.MessageEmbed{
+			Title: fmt.Sprintf("%s%s is filling up", s.emojiPrefix(server.Key), server.Name),
+			Description: fmt.Sprintf("%d/%d players, +%.1f per minute over the last %d minutes.\nExpected to be full in about **%d minutes**.",
+				players, server.MaxPlayers, rate, int(math.Round(elapsed.Minutes())), int(math.Ceil(eta.Minutes()))),
+			Color:     0xfee75c,
+			Timestamp: now.Format(time.RFC3339),
+		}
+
+		err := retry.SendComplex(s.out, s.config().ChannelIDFull, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}, nil)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to send fill alert for server %s: %s", server.Name, err))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package serverstatus
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+)
+
+// the forecast warns when the expected peak fills this share of the player slots
+const forecastWarnRatio = 0.9
+
+// forecast lists the expected peak of every server for the rest of the day, empty without any history
+func (s *ServerStatus) forecast(now time.Time) string {
+	var lines []string
+
+	for _, server := range s.config().Rcon.Servers {
+		f, ok := history.ForecastPeak(server.Key, now)
+
+		if !ok {
+			continue
+		}
+
+		peak := int(math.Round(f.Peak))
+		line := fmt.Sprintf("- %s%s: ~%d players around %02d:00", s.emojiPrefix(server.Key), server.Name, peak, f.Hour)
+
+		if server.MaxPlayers > 0 {
+			line = fmt.Sprintf("- %s%s: ~%d/%d players around %02d:00", s.emojiPrefix(server.Key), server.Name, peak, server.MaxPlayers, f.Hour)
+
+			if f.Peak >= forecastWarnRatio*float64(server.MaxPlayers) {
+				line += " :warning: close to full"
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n### Expected peak today\n%s\n-# Average of the same weekday of the past weeks", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,72 @@
+package serverstatus
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// min. number of followed players on the same server for a friends DM
+const minFriends = 2
+
+// notifyFriends sends users subscribed to friends a single DM when two or more of the players they follow
+// are on the same server. Another DM is only sent when someone new joins the group, the group is forgotten
+// once less than two of them are left.
+func (s *ServerStatus) notifyFriends() {
+	// first update after startup only establishes the baseline
+
+	baseline := s.friendGroups == nil
+	groups := make(map[string][]string)
+
+	for userID, followed := range s.subs.Friends() {
+		for _, serverKey := range slices.Sorted(maps.Keys(s.lastPlayers)) {
+			var online []string
+
+			for _, player := range slices.Sorted(maps.Keys(s.lastPlayers[serverKey])) {
+				if _, hidden := s.optOut(player); hidden {
+					continue
+				}
+
+				if slices.ContainsFunc(followed, func(f string) bool { return strings.EqualFold(f, player) }) {
+					online = append(online, player)
+				}
+			}
+
+			if len(online) < minFriends {
+				continue
+			}
+
+			key := userID + "/" + serverKey
+			notified := s.friendGroups[key]
+			groups[key] = union(notified, online)
+
+			if baseline || !slices.ContainsFunc(online, func(p string) bool { return !slices.Contains(notified, p) }) {
+				continue
+			}
+
+			var names []string
+
+			for _, player := range online {
+				names = append(names, utils.SanitizeName(player))
+			}
+
+			s.subs.Notify(s.Session, []string{userID}, s.config().Languages.Language(""), func(lang utils.Language) string {
+				return s.emojiPrefix(serverKey) + i18n.T(lang, "status.friends", joinNames(lang, names), s.serverName(serverKey))
+			})
+		}
+	}
+
+	s.friendGroups = groups
+}
+
+// joinNames lists the names as "a, b and c"
+func joinNames(lang utils.Language, names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+
+	return i18n.T(lang, "status.friends.and", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
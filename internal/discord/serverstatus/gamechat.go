@@ -0,0 +1,30 @@
+package serverstatus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// PlayersSummary returns the player counts of all servers, the reply of the in-game "!players" command
+func (s *ServerStatus) PlayersSummary() string {
+	var parts []string
+	var total int
+
+	lang := s.live.Load().Languages.Language("")
+
+	for _, server := range s.config().Rcon.Servers {
+		ifo, ok := s.Current(server.Key)
+
+		if !ok || !ifo.Reachable {
+			parts = append(parts, i18n.T(lang, "status.game.offline", server.Name))
+			continue
+		}
+
+		total += len(ifo.Players)
+		parts = append(parts, fmt.Sprintf("%s %d", server.Name, len(ifo.Players)))
+	}
+
+	return i18n.T(lang, "status.game.players", total, strings.Join(parts, ", "))
+}
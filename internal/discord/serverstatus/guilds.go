@@ -0,0 +1,47 @@
+package serverstatus
+
+import (
+	"slices"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// statusTarget is a channel with a status message of its own
+type statusTarget struct {
+	ChannelID string
+	Servers   []string // empty for all servers
+	Pin       bool
+	Branding  *cfg.ConfigBranding
+}
+
+// statusTargets returns one target per configured guild, or the status channel if no guilds are configured
+func (s *ServerStatus) statusTargets() []statusTarget {
+	if len(s.config().Guilds) == 0 {
+		return []statusTarget{{ChannelID: s.config().ChannelID, Branding: s.config().Branding}}
+	}
+
+	var targets []statusTarget
+
+	for _, guild := range s.config().Guilds {
+		targets = append(targets, statusTarget{ChannelID: guild.ChannelID, Servers: guild.Servers, Pin: guild.Pin, Branding: guild.Branding})
+	}
+
+	return targets
+}
+
+func (t statusTarget) filter(serverStatusMap map[string]*model.ServerInfo) map[string]*model.ServerInfo {
+	if len(t.Servers) == 0 {
+		return serverStatusMap
+	}
+
+	res := make(map[string]*model.ServerInfo)
+
+	for serverKey, serverInfo := range serverStatusMap {
+		if slices.Contains(t.Servers, serverKey) {
+			res[serverKey] = serverInfo
+		}
+	}
+
+	return res
+}
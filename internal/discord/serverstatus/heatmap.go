@@ -0,0 +1,109 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/chart"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+)
+
+// rows of the heatmap, starting the week on monday
+var heatmapDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+func (s *ServerStatus) registerHeatmap(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "heatmap",
+		Description: "Show the average player count of a server by weekday and hour",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(s.config().Rcon.Servers),
+			},
+		},
+	}, s.handleHeatmap)
+}
+
+func (s *ServerStatus) handleHeatmap(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	serverKey := i.ApplicationCommandData().Options[0].StringValue()
+
+	// average of all reachable samples per weekday and hour, in the guild's time zone
+
+	loc := s.location(i.GuildID)
+
+	var sums [7][24]float64
+	var counts [7][24]int
+
+	since := s.clock.Now().Add(-history.Retention)
+	samples := history.Samples(serverKey, since)
+
+	if len(samples) == 0 {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("No history available for server '%s' yet.", s.serverName(serverKey)),
+		})
+		return
+	}
+
+	for _, sample := range samples {
+		if !sample.Reachable() {
+			continue
+		}
+
+		t := sample.Time.In(loc)
+		day := (int(t.Weekday()) + 6) % 7
+
+		sums[day][t.Hour()] += float64(sample.Players)
+		counts[day][t.Hour()]++
+	}
+
+	heatmap := chart.Heatmap{Values: make([][]float64, 7), Empty: make([][]bool, 7)}
+	busiestDay, busiestHour := -1, 0
+
+	for day := range 7 {
+		heatmap.Values[day] = make([]float64, 24)
+		heatmap.Empty[day] = make([]bool, 24)
+
+		for hour := range 24 {
+			if counts[day][hour] == 0 {
+				heatmap.Empty[day][hour] = true
+				continue
+			}
+
+			avg := sums[day][hour] / float64(counts[day][hour])
+			heatmap.Values[day][hour] = avg
+
+			if avg > heatmap.Max {
+				heatmap.Max = avg
+				busiestDay, busiestHour = day, hour
+			}
+		}
+	}
+
+	img, err := chart.RenderHeatmap(heatmap)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to render heatmap: %s", err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Failed to render heatmap"})
+		return
+	}
+
+	content := fmt.Sprintf("**Average players on %s by weekday and hour**\nRows: Monday to Sunday • Columns: 00:00 to 23:00 (%s)",
+		s.serverName(serverKey), s.clock.Now().In(loc).Format("MST"))
+
+	if busiestDay >= 0 {
+		content += fmt.Sprintf("\nBusiest: %s %02d:00 (%.1f players on average)", heatmapDays[busiestDay], busiestHour, heatmap.Max)
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Content: content,
+		Files: []*discordgo.File{
+			{Name: "heatmap.png", ContentType: "image/png", Reader: img},
+		},
+	})
+}
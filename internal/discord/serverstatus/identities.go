@@ -0,0 +1,123 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+type onlinePlayer struct {
+	name   string
+	server string
+}
+
+type rename struct {
+	server  string
+	oldName string
+	newName string
+}
+
+// trackIdentities adds the time since the last poll to the playtime of every online player with a platform ID,
+// tracks their sessions and detects players which show up with a new name
+func (s *ServerStatus) trackIdentities(serverStatusMap map[string]*model.ServerInfo) {
+	serverStatusMap = s.withoutUntracked(serverStatusMap)
+	now := s.clock.Now()
+	elapsed := now.Sub(s.lastPlaytime)
+	s.lastPlaytime = now
+
+	// after a gap (startup, stalled polling) nobody knows who played, so it isn't counted
+
+	if elapsed > 2*time.Duration(s.config().Rcon.QueryEverySeconds)*time.Second {
+		elapsed = 0
+	}
+
+	// platform ID -> name and server of the online players
+
+	online := make(map[string]onlinePlayer)
+
+	for serverKey, serverInfo := range serverStatusMap {
+		if !serverInfo.Reachable {
+			continue
+		}
+
+		for _, player := range serverInfo.Players {
+			if player.ID != "" && player.Name != "" {
+				online[cfg.Config.Privacy.PlatformID(player.ID)] = onlinePlayer{name: player.Name, server: serverKey}
+			}
+		}
+	}
+
+	// sessions of the players who left must still be ended
+
+	if len(online) == 0 && !s.sessionsOpen() {
+		return
+	}
+
+	week := weekStart(now.In(s.channelLocation(s.config().ChannelIDPlaytime)))
+
+	var renames []rename
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		if k.Players == nil {
+			k.Players = make(map[string]cache.PlayerIdentity)
+		}
+
+		for id, player := range online {
+			identity, known := k.Players[id]
+
+			if known && identity.Name != player.name {
+				renames = append(renames, rename{server: player.server, oldName: identity.Name, newName: player.name})
+				identity.Previous = append(identity.Previous, identity.Name)
+			}
+
+			identity.Name = player.name
+			identity.Playtime += elapsed
+			identity.LastSeen = now
+
+			if !slices.Contains(identity.Servers, player.server) {
+				identity.Servers = append(identity.Servers, player.server)
+			}
+
+			trackSessions(&identity, player.server, elapsed, now, week)
+
+			k.Players[id] = identity
+		}
+
+		for id, identity := range k.Players {
+			if len(identity.Sessions) > 0 && endSessions(&identity, online[id].server, serverStatusMap, now) {
+				k.Players[id] = identity
+			}
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store player identities in cache: %s", err))
+	}
+
+	sort.Slice(renames, func(i, j int) bool { return strings.ToLower(renames[i].newName) < strings.ToLower(renames[j].newName) })
+
+	for _, r := range renames {
+		slog.Info(fmt.Sprintf("Player %s is now known as %s", r.oldName, r.newName))
+
+		if err := s.subs.RenamePlayer(r.oldName, r.newName); err != nil {
+			slog.Error(fmt.Sprintf("Failed to move subscriptions of player %s to %s: %s", r.oldName, r.newName, err))
+		}
+
+		if !s.config().ShowRenames {
+			continue
+		}
+
+		if err := s.sendJoinLeave(s.emojiPrefix(r.server) + i18n.T(s.channelLanguage(s.config().ChannelIDJoinLeave), "status.renamed", s.serverName(r.server), utils.SanitizeName(r.oldName), utils.SanitizeName(r.newName))); err != nil {
+			slog.Error(fmt.Sprintf("Failed to send rename notification for player %s: %s", r.newName, err))
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// post a status update into an open incident thread this often
+const incidentUpdateInterval = 30 * time.Minute
+
+// handleIncidents opens a thread for every server which is unreachable for longer than the configured
+// threshold, posts updates while the outage lasts and closes the thread once the server is back.
+func (s *ServerStatus) handleIncidents(serverStatusMap map[string]*model.ServerInfo) {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load open incidents from cache: %s", err))
+		return
+	}
+
+	if s.downSince == nil {
+		s.downSince = make(map[string]time.Time)
+	}
+
+	now := s.clock.Now()
+
+	for serverKey, serverInfo := range serverStatusMap {
+		incident, open := cacheData.Incidents[serverKey]
+
+		// the outage only counts once the restart window is over
+
+		if !serverInfo.Reachable && !open && s.restarting(serverKey) {
+			continue
+		}
+
+		if serverInfo.Reachable {
+			delete(s.downSince, serverKey)
+
+			if open {
+				s.closeIncident(serverKey, incident)
+			}
+
+			continue
+		}
+
+		if _, ok := s.downSince[serverKey]; !ok {
+			s.downSince[serverKey] = now
+		}
+
+		switch {
+		case !open && now.Sub(s.downSince[serverKey]) >= s.config().IncidentThreshold:
+			s.openIncident(serverKey, s.downSince[serverKey])
+		case open && now.Sub(incident.LastUpdate) >= incidentUpdateInterval:
+			s.postIncidentMessage(incident.ThreadID, fmt.Sprintf("Still unreachable after %s.\n\n%s",
+				utils.FormatDuration(now.Sub(incident.Since).Round(time.Minute), utils.English), s.diagnostics(serverKey)))
+
+			incident.LastUpdate = now
+			s.storeIncident(serverKey, &incident)
+		}
+	}
+}
+
+func (s *ServerStatus) openIncident(serverKey string, since time.Time) {
+	name := fmt.Sprintf("Incident: %s %s", s.serverName(serverKey), since.In(s.channelLocation(s.config().ChannelIDIncidents)).Format("02.01.2006"))
+
+	msg := fmt.Sprintf("**%s is unreachable** since <t:%d:f>.\n\n%s", s.serverName(serverKey), since.Unix(), s.diagnostics(serverKey))
+
+	// announcement channels only have threads of messages, the thread is started from the crossposted message
+
+	var thread *discordgo.Channel
+	var err error
+
+	if s.config().CrosspostOutages {
+		var m *discordgo.Message
+
+		if m, err = s.crosspostIncidentMessage(msg); err == nil {
+			thread, err = s.Session.MessageThreadStart(s.config().ChannelIDIncidents, m.ID, name, 1440)
+		}
+	} else {
+		thread, err = s.Session.ThreadStart(s.config().ChannelIDIncidents, name, discordgo.ChannelTypeGuildPublicThread, 1440)
+	}
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to open incident thread for %s: %s", serverKey, err))
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Opened incident thread for %s", serverKey))
+
+	if !s.config().CrosspostOutages {
+		s.postIncidentMessage(thread.ID, msg)
+	}
+
+	s.storeIncident(serverKey, &cache.Incident{ThreadID: thread.ID, Since: since, LastUpdate: s.clock.Now()})
+}
+
+func (s *ServerStatus) closeIncident(serverKey string, incident cache.Incident) {
+	slog.Info(fmt.Sprintf("Closing incident thread for %s", serverKey))
+
+	msg := fmt.Sprintf("**%s is reachable again.**\n\nOutage from <t:%d:f> to <t:%d:f> (%s).",
+		s.serverName(serverKey), incident.Since.Unix(), s.clock.Now().Unix(),
+		utils.FormatDuration(s.clock.Now().Sub(incident.Since).Round(time.Minute), utils.English))
+
+	s.postIncidentMessage(incident.ThreadID, msg)
+
+	if s.config().CrosspostOutages {
+		if _, err := s.crosspostIncidentMessage(msg); err != nil {
+			slog.Error(fmt.Sprintf("Failed to announce end of incident for %s: %s", serverKey, err))
+		}
+	}
+
+	archived := true
+
+	_, err := s.Session.ChannelEditComplex(incident.ThreadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &archived})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to close incident thread for %s: %s", serverKey, err))
+	}
+
+	s.storeIncident(serverKey, nil)
+}
+
+func (s *ServerStatus) diagnostics(serverKey string) string {
+	address := "-"
+
+	for _, server := range s.config().Rcon.Servers {
+		if server.Key == serverKey {
+			address = server.Address
+		}
+	}
+
+	lastPoll := "never"
+
+	if last := rcon.LastSuccess(serverKey); !last.IsZero() {
+		lastPoll = fmt.Sprintf("<t:%d:R>", last.Unix())
+	}
+
+	errorCounts := "-"
+
+	if counts, ok := alerts.Counts()[serverKey]; ok {
+		errorCounts = counts
+	}
+
+	return fmt.Sprintf("RCON address: %s\nLast successful poll: %s\nErrors since start: %s", address, lastPoll, errorCounts)
+}
+
+func (s *ServerStatus) postIncidentMessage(threadID string, msg string) {
+	if _, err := s.out.SendMessage(threadID, &discordgo.MessageSend{Content: msg}); err != nil {
+		slog.Error(fmt.Sprintf("Failed to post to incident thread: %s", err))
+	}
+}
+
+// crosspostIncidentMessage posts the message to the incidents channel and queues crossposting it to its followers
+func (s *ServerStatus) crosspostIncidentMessage(msg string) (*discordgo.Message, error) {
+	m, err := s.out.SendMessage(s.config().ChannelIDIncidents, &discordgo.MessageSend{Content: msg})
+
+	if err != nil {
+		return nil, err
+	}
+
+	retry.Crosspost(s.out, m.ChannelID, m.ID)
+
+	return m, nil
+}
+
+// storeIncident stores the open incident of a server, or removes it if incident is nil
+func (s *ServerStatus) storeIncident(serverKey string, incident *cache.Incident) {
+	err := s.cache.Update(func(k *cache.CacheData) {
+		if k.Incidents == nil {
+			k.Incidents = make(map[string]cache.Incident)
+		}
+
+		if incident == nil {
+			delete(k.Incidents, serverKey)
+		} else {
+			k.Incidents[serverKey] = *incident
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store incident of %s in cache: %s", serverKey, err))
+	}
+}
@@ -0,0 +1,83 @@
+package serverstatus
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// discord allows at most 10 embeds per message
+const maxEmbeds = 10
+
+func (s *ServerStatus) registerPlayers(d *interactions.Dispatcher) {
+	options := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "server",
+			Description: "Only this server (default all servers)",
+			Choices:     interactions.ServerChoices(s.config().Rcon.Servers),
+		},
+	}
+
+	if tags := interactions.TagChoices(s.config().Rcon.Servers); tags != nil {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "tag",
+			Description: "Only the servers with this tag",
+			Choices:     tags,
+		})
+	}
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "players",
+		Description: "Show who is online right now",
+		Options:     options,
+	}, s.handlePlayers)
+}
+
+// handlePlayers replies with the current player lists, like the status message
+func (s *ServerStatus) handlePlayers(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	servers := s.config().Rcon.Servers
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "server":
+			server, _ := cfg.ServerByKey(s.config().Rcon.Servers, o.StringValue())
+			servers = []cfg.ConfigRconServer{server}
+		case "tag":
+			servers = cfg.Tagged(servers, o.StringValue())
+		}
+	}
+
+	infos := s.currentInfos()
+	shown := make(map[string]*model.ServerInfo)
+
+	for _, server := range servers {
+		if ifo, ok := infos[server.Key]; ok {
+			shown[server.Key] = ifo
+		}
+	}
+
+	if len(shown) == 0 {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+			Content: "No information available for these servers yet.",
+		})
+		return
+	}
+
+	embeds := s.buildEmbeds(shown)
+	content := ""
+
+	if len(embeds) > maxEmbeds {
+		content = fmt.Sprintf("Showing %d of %d servers, pick a server or tag to see the others.", maxEmbeds, len(embeds))
+		embeds = embeds[:maxEmbeds]
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Content: content,
+		Embeds:  embeds,
+	})
+}
@@ -0,0 +1,315 @@
+package serverstatus
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// number of players in the weekly leaderboard
+const playtimeTop = 10
+
+// discord allows at most 25 fields per embed
+const maxEmbedFields = 25
+
+type playtimeRank struct {
+	name     string
+	playtime time.Duration
+}
+
+func (s *ServerStatus) registerPlaytime(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "playtime",
+		Description: "Show how long a player played on the servers",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "Name of the player",
+				Required:    true,
+			},
+		},
+	}, s.handlePlaytime)
+}
+
+func (s *ServerStatus) handlePlaytime(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	player := i.ApplicationCommandData().Options[0].StringValue()
+
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load playtime from cache: %s", err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Failed to load the playtime, please try again later."})
+		return
+	}
+
+	playtime, ok := findIdentity(cacheData.Players, player)
+
+	if !ok {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+			Content:         fmt.Sprintf("No playtime recorded for %s.", utils.SanitizeName(player)),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		return
+	}
+
+	week := weekStart(s.clock.Now().In(s.channelLocation(s.config().ChannelIDPlaytime)))
+
+	var thisWeek time.Duration
+
+	if playtime.WeekStart.Equal(week) {
+		for _, d := range playtime.Week {
+			thisWeek += d
+		}
+	}
+
+	lines := []string{fmt.Sprintf("Total: **%s**", formatPlaytime(playtime.Playtime)), fmt.Sprintf("This week: **%s**", formatPlaytime(thisWeek))}
+
+	// members who opted out of join/leave announcements don't reveal when they are online, except to themselves
+
+	optOut, hidden := s.optOut(playtime.Name)
+
+	if !hidden || optOut.UserID == interactions.UserID(i) {
+		for _, server := range slices.Sorted(maps.Keys(playtime.Sessions)) {
+			lines = append(lines, fmt.Sprintf("Online on %s since <t:%d:R>", s.serverName(server), playtime.Sessions[server].Unix()))
+		}
+
+		if len(playtime.Sessions) == 0 && !playtime.Last.End.IsZero() {
+			lines = append(lines, fmt.Sprintf("Last session: <t:%d:f> on %s (%s)", playtime.Last.Start.Unix(), s.serverName(playtime.Last.Server),
+				formatPlaytime(playtime.Last.End.Sub(playtime.Last.Start))))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Playtime of %s", utils.SanitizeName(playtime.Name)),
+		Description: strings.Join(lines, "\n"),
+	}
+
+	servers := slices.SortedFunc(maps.Keys(playtime.ServerPlaytime), func(a, b string) int {
+		return cmp.Compare(playtime.ServerPlaytime[b], playtime.ServerPlaytime[a])
+	})
+
+	for _, server := range servers[:min(len(servers), maxEmbedFields)] {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: s.serverName(server), Value: formatPlaytime(playtime.ServerPlaytime[server]), Inline: true})
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// findIdentity finds the player by its current name, ignoring case and the characters removed by SanitizeName.
+// Of several players with the name, the one seen last is returned.
+func findIdentity(players map[string]cache.PlayerIdentity, player string) (cache.PlayerIdentity, bool) {
+	var res cache.PlayerIdentity
+	found := false
+
+	for _, id := range slices.Sorted(maps.Keys(players)) {
+		identity := players[id]
+
+		if strings.EqualFold(utils.SanitizeName(identity.Name), utils.SanitizeName(player)) && (!found || identity.LastSeen.After(res.LastSeen)) {
+			res = identity
+			found = true
+		}
+	}
+
+	return res, found
+}
+
+// trackSessions adds the time since the last poll to the playtime by server of the online player, and starts
+// its session on the server
+func trackSessions(identity *cache.PlayerIdentity, server string, elapsed time.Duration, now time.Time, week time.Time) {
+	if identity.ServerPlaytime == nil {
+		identity.ServerPlaytime = make(map[string]time.Duration)
+	}
+
+	if identity.Sessions == nil {
+		identity.Sessions = make(map[string]time.Time)
+	}
+
+	rollWeek(identity, week)
+
+	identity.ServerPlaytime[server] += elapsed
+	identity.Week[server] += elapsed
+
+	if _, ok := identity.Sessions[server]; !ok {
+		identity.Sessions[server] = now
+	}
+}
+
+// endSessions ends the sessions of the player on the servers it is gone from, or which aren't polled anymore.
+// False if no session ended.
+func endSessions(identity *cache.PlayerIdentity, server string, serverStatusMap map[string]*model.ServerInfo, now time.Time) bool {
+	ended := false
+
+	for key, start := range identity.Sessions {
+		if serverInfo, polled := serverStatusMap[key]; polled && (!serverInfo.Reachable || key == server) {
+			continue
+		}
+
+		delete(identity.Sessions, key)
+		identity.Last = cache.Session{Server: key, Start: start, End: now}
+		ended = true
+	}
+
+	return ended
+}
+
+// sessionsOpen returns if any player has a session which wasn't ended yet
+func (s *ServerStatus) sessionsOpen() bool {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load player identities from cache: %s", err))
+		return false
+	}
+
+	for _, identity := range cacheData.Players {
+		if len(identity.Sessions) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rollWeek starts a new week of the playtime, the week before is kept for the weekly leaderboard
+func rollWeek(playtime *cache.PlayerIdentity, week time.Time) {
+	if !playtime.WeekStart.Before(week) {
+		return
+	}
+
+	playtime.LastWeek = nil
+
+	if playtime.WeekStart.Equal(week.AddDate(0, 0, -7)) {
+		playtime.LastWeek = playtime.Week
+	}
+
+	playtime.Week = make(map[string]time.Duration)
+	playtime.WeekStart = week
+}
+
+// lastWeek returns the playtime by server of the week before the given one
+func lastWeek(playtime cache.PlayerIdentity, week time.Time) map[string]time.Duration {
+	switch {
+	case playtime.WeekStart.Equal(week):
+		return playtime.LastWeek
+	case playtime.WeekStart.Equal(week.AddDate(0, 0, -7)):
+		return playtime.Week
+	}
+
+	return nil
+}
+
+// weekStart returns monday midnight of the week of t
+func weekStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+}
+
+// playtimeReportIfDue posts the top players by playtime of the past week once a new week started
+func (s *ServerStatus) playtimeReportIfDue() {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load last playtime report time from cache: %s", err))
+		return
+	}
+
+	now := s.clock.Now().In(s.channelLocation(s.config().ChannelIDPlaytime))
+	week := weekStart(now)
+
+	// like the SLA report, the first run only establishes the baseline
+
+	if cacheData.LastPlaytimeReport.IsZero() || !cacheData.LastPlaytimeReport.Before(week) {
+		if cacheData.LastPlaytimeReport.IsZero() {
+			s.storeLastPlaytimeReport(now)
+		}
+
+		return
+	}
+
+	// players who opted out of join/leave announcements are left out of the public leaderboard
+
+	var ranks []playtimeRank
+
+	for _, playtime := range cacheData.Players {
+		if _, hidden := s.optOut(playtime.Name); hidden {
+			continue
+		}
+
+		var total time.Duration
+
+		for _, d := range lastWeek(playtime, week) {
+			total += d
+		}
+
+		if total > 0 {
+			ranks = append(ranks, playtimeRank{name: playtime.Name, playtime: total})
+		}
+	}
+
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].playtime != ranks[j].playtime {
+			return ranks[i].playtime > ranks[j].playtime
+		}
+
+		return strings.ToLower(ranks[i].name) < strings.ToLower(ranks[j].name)
+	})
+
+	from := week.AddDate(0, 0, -7)
+
+	if len(ranks) == 0 {
+		slog.Info(fmt.Sprintf("No playtime recorded in the week of %s, skipping playtime report", from.Format("02.01.2006")))
+		s.storeLastPlaytimeReport(now)
+		return
+	}
+
+	var lines []string
+
+	for n, rank := range ranks[:min(len(ranks), playtimeTop)] {
+		lines = append(lines, fmt.Sprintf("**%d.** %s - %s", n+1, s.displayName(rank.name), formatPlaytime(rank.playtime)))
+	}
+
+	slog.Info(fmt.Sprintf("Posting playtime report for the week of %s", from.Format("02.01.2006")))
+
+	_, err = s.out.SendMessage(s.config().ChannelIDPlaytime, &discordgo.MessageSend{
+		Content: fmt.Sprintf("## Top players of the week %s - %s", from.Format("02.01."), week.AddDate(0, 0, -1).Format("02.01.2006")),
+		Embeds: []*discordgo.MessageEmbed{{
+			Description: strings.Join(lines, "\n"),
+		}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to post playtime report: %s", err))
+		return
+	}
+
+	s.storeLastPlaytimeReport(now)
+}
+
+func (s *ServerStatus) storeLastPlaytimeReport(t time.Time) {
+	err := s.cache.Update(func(k *cache.CacheData) {
+		k.LastPlaytimeReport = t
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store last playtime report time in cache: %s", err))
+	}
+}
+
+func formatPlaytime(d time.Duration) string {
+	return utils.FormatDurationStyle(d, utils.English, utils.DurationStyle{Compact: true})
+}
@@ -0,0 +1,295 @@
+package serverstatus
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// a player has this long to confirm in the game chat that its statistics should not be recorded
+const privacyConfirmTimeout = 10 * time.Minute
+
+// privacyConfirmation is a stats:false opt-out waiting for the player to post the code in the game chat
+type privacyConfirmation struct {
+	userID  string
+	code    string
+	expires time.Time
+}
+
+func (s *ServerStatus) registerPrivacy(d *interactions.Dispatcher) {
+	player := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "player",
+		Description: "Your player name",
+		Required:    true,
+	}
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "privacy",
+		Description: "Choose whether your joins and leaves are announced",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "hide",
+				Description: "Stop announcing the joins and leaves of your player",
+				Options: []*discordgo.ApplicationCommandOption{player, {
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "stats",
+					Description: "Keep counting your playtime and sessions (default yes, turning it off is confirmed in-game)",
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Announce the joins and leaves of your player again",
+				Options:     []*discordgo.ApplicationCommandOption{player},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show your hidden players",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Announce the joins and leaves of a player hidden by another member (admins only)",
+				Options:     []*discordgo.ApplicationCommandOption{player},
+			},
+		},
+	}, s.handlePrivacy)
+}
+
+func (s *ServerStatus) handlePrivacy(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactions.UserID(i)
+	sub := i.ApplicationCommandData().Options[0]
+
+	var name string
+	stats := true
+
+	for _, o := range sub.Options {
+		switch o.Name {
+		case "player":
+			name = utils.SanitizeName(o.StringValue())
+		case "stats":
+			stats = o.BoolValue()
+		}
+	}
+
+	if sub.Name == "remove" && !s.isAdmin(i) {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Only admins can remove the privacy settings of other members."})
+		return
+	}
+
+	key := strings.ToLower(name)
+	reply := ""
+	confirm := false
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		optOut, exists := k.OptOuts[key]
+
+		if exists && optOut.UserID != userID && sub.Name != "list" && sub.Name != "remove" {
+			reply = fmt.Sprintf("%s was linked by another member, ask an admin to remove it if it is yours.", name)
+			return
+		}
+
+		switch sub.Name {
+		case "hide":
+			if k.OptOuts == nil {
+				k.OptOuts = make(map[string]cache.OptOut)
+			}
+
+			// not recording the statistics needs the confirmation of the player, a confirmed opt-out is kept
+
+			k.OptOuts[key] = cache.OptOut{UserID: userID, Name: name, NoStats: !stats && optOut.NoStats}
+			reply = fmt.Sprintf("The joins and leaves of %s are not announced anymore.", name)
+			confirm = !stats && !optOut.NoStats
+
+			if !stats && optOut.NoStats {
+				reply += " Its sessions and playtime are not recorded either."
+			}
+		case "show", "remove":
+			delete(k.OptOuts, key)
+			reply = fmt.Sprintf("The joins and leaves of %s are announced again.", name)
+		case "list":
+			var names []string
+
+			for _, optOut := range k.OptOuts {
+				if optOut.UserID == userID {
+					names = append(names, optOut.Name)
+				}
+			}
+
+			sort.Strings(names)
+
+			reply = "No hidden players."
+
+			if len(names) > 0 {
+				reply = "Hidden players: " + strings.Join(names, ", ")
+			}
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store privacy settings of user %s: %s", userID, err))
+		reply = "Failed to store your privacy settings."
+	} else if sub.Name == "show" || sub.Name == "remove" {
+		s.dropConfirmation(key)
+	} else if confirm && !s.gameChat.Load() {
+		reply += " Not recording its sessions and playtime must be confirmed in the game chat, which this bot doesn't read."
+	} else if confirm {
+		code := s.requestConfirmation(key, userID)
+		reply += fmt.Sprintf(" To stop recording its sessions and playtime, write `!privacy %s` in the game chat as %s within %d minutes.",
+			code, name, int(privacyConfirmTimeout.Minutes()))
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Content:         reply,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// EnableGameChat tells that the game chat is read, so players can confirm in-game that their statistics
+// should not be recorded
+func (s *ServerStatus) EnableGameChat() {
+	s.gameChat.Store(true)
+}
+
+// ConfirmPrivacy handles the in-game "!privacy <code>" of the sender, which stops recording its statistics if the
+// code matches the one given to the member who hid the player. Returns the reply for the game chat.
+func (s *ServerStatus) ConfirmPrivacy(sender string, code string) string {
+	name := utils.SanitizeName(sender)
+	key := strings.ToLower(name)
+
+	s.confirmMu.Lock()
+	pending, ok := s.confirmations[key]
+
+	if ok && strings.EqualFold(pending.code, code) && s.clock.Now().Before(pending.expires) {
+		delete(s.confirmations, key)
+	} else {
+		ok = false
+	}
+
+	s.confirmMu.Unlock()
+
+	if !ok {
+		return ""
+	}
+
+	confirmed := false
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		optOut, exists := k.OptOuts[key]
+
+		// the player was shown again, or hidden by someone else meanwhile
+
+		if !exists || optOut.UserID != pending.userID {
+			return
+		}
+
+		optOut.NoStats = true
+		k.OptOuts[key] = optOut
+		confirmed = true
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store privacy settings of user %s: %s", pending.userID, err))
+		return ""
+	}
+
+	if !confirmed {
+		return ""
+	}
+
+	slog.Info(fmt.Sprintf("Player %s confirmed not recording its statistics for user %s", name, pending.userID))
+
+	return fmt.Sprintf("The sessions and playtime of %s are not recorded anymore.", name)
+}
+
+// requestConfirmation returns a new code the player must post in the game chat, replacing a pending one
+func (s *ServerStatus) requestConfirmation(key string, userID string) string {
+	code := strings.ToUpper(rand.Text()[:6])
+
+	s.confirmMu.Lock()
+	defer s.confirmMu.Unlock()
+
+	if s.confirmations == nil {
+		s.confirmations = make(map[string]privacyConfirmation)
+	}
+
+	now := s.clock.Now()
+
+	maps.DeleteFunc(s.confirmations, func(_ string, c privacyConfirmation) bool { return !now.Before(c.expires) })
+
+	s.confirmations[key] = privacyConfirmation{userID: userID, code: code, expires: now.Add(privacyConfirmTimeout)}
+
+	return code
+}
+
+func (s *ServerStatus) dropConfirmation(key string) {
+	s.confirmMu.Lock()
+	defer s.confirmMu.Unlock()
+
+	delete(s.confirmations, key)
+}
+
+// isAdmin returns true if the invoking member has one of the admin roles
+func (s *ServerStatus) isAdmin(i *discordgo.InteractionCreate) bool {
+	admin := s.live.Load().Admin
+
+	if admin == nil || i.Member == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(i.Member.Roles, func(role string) bool { return slices.Contains(admin.RoleIDs, role) })
+}
+
+// optOut returns the opt-out of the player, false if its joins and leaves are announced
+func (s *ServerStatus) optOut(player string) (cache.OptOut, bool) {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load privacy settings from cache: %s", err))
+		return cache.OptOut{}, false
+	}
+
+	optOut, ok := cacheData.OptOuts[strings.ToLower(utils.SanitizeName(player))]
+
+	return optOut, ok
+}
+
+// withoutUntracked returns the server infos without the names of players which opted out of the statistics,
+// they are still counted
+func (s *ServerStatus) withoutUntracked(serverInfos map[string]*model.ServerInfo) map[string]*model.ServerInfo {
+	res := make(map[string]*model.ServerInfo, len(serverInfos))
+
+	for serverKey, serverInfo := range serverInfos {
+		res[serverKey] = serverInfo
+
+		for n, player := range serverInfo.Players {
+			if optOut, ok := s.optOut(player.Name); !ok || !optOut.NoStats {
+				continue
+			}
+
+			if res[serverKey] == serverInfo {
+				copied := *serverInfo
+				copied.Players = append([]model.PlayerInfo{}, serverInfo.Players...)
+				res[serverKey] = &copied
+			}
+
+			res[serverKey].Players[n] = model.PlayerInfo{}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,179 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// discord limits the value of an embed field to 1024 characters and an embed to 6000 characters (and
+// maxEmbedFields fields)
+const maxFieldLength = 1024
+const maxEmbedLength = 6000
+
+// room kept for the field noting the player lists left out
+const omittedLength = 100
+
+func (s *ServerStatus) registerReport(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "report",
+		Description: "Report a player to the moderators",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "player",
+				Description: "Name of the player",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "What happened?",
+				Required:    true,
+				MaxLength:   1000,
+			},
+		},
+	}, s.handleReport)
+}
+
+// handleReport posts the report to the moderator channel, along with the server the player is on and
+// the player list of that server at the time of the report
+func (s *ServerStatus) handleReport(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	var player string
+	var reason string
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "player":
+			player = o.StringValue()
+		case "reason":
+			reason = o.StringValue()
+		}
+	}
+
+	reporter := interactions.UserID(i)
+	embed := s.reportEmbed(reporter, player, reason, s.currentInfos())
+
+	slog.Info(fmt.Sprintf("User %s reported player %s", reporter, player))
+
+	err := retry.SendComplex(s.out, s.config().ChannelIDReports, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, nil)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to post report of player %s: %s", player, err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Sorry, the report could not be filed, please try again later."})
+		return
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: "Thanks, the moderators received your report."})
+}
+
+// reportEmbed describes the report for the moderators. Player lists which don't fit into the embed are left out.
+func (s *ServerStatus) reportEmbed(reporter string, player string, reason string, infos map[string]*model.ServerInfo) *discordgo.MessageEmbed {
+	serverKey, target, online := findOnline(infos, player)
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Player report: %s", utils.SanitizeName(player)),
+		Color: 0xfee75c, // Discord yellow
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Reported by", Value: fmt.Sprintf("<@%s>", reporter), Inline: true},
+			{Name: "Server", Value: "Not online", Inline: true},
+			{Name: "Reason", Value: reason},
+		},
+		Timestamp: s.clock.Now().Format(time.RFC3339),
+	}
+
+	if online {
+		embed.Fields[1].Value = infos[serverKey].Name
+
+		if target.ID != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Platform ID", Value: fmt.Sprintf("`%s`", target.ID), Inline: true})
+		}
+	}
+
+	// without the player online, the player lists of all servers are attached
+
+	var servers []string
+
+	for key := range infos {
+		if !online || key == serverKey {
+			servers = append(servers, key)
+		}
+	}
+
+	sort.Strings(servers)
+
+	length := len(embed.Title)
+
+	for _, field := range embed.Fields {
+		length += len(field.Name) + len(field.Value)
+	}
+
+	for n, key := range servers {
+		field := &discordgo.MessageEmbedField{Name: fmt.Sprintf("Players on %s", infos[key].Name), Value: s.reportPlayerList(infos[key])}
+
+		// unless this is the last list, room is left for the note
+
+		fields, room := 1, len(field.Name)+len(field.Value)
+
+		if n < len(servers)-1 {
+			fields, room = 2, room+omittedLength
+		}
+
+		if len(embed.Fields)+fields > maxEmbedFields || length+room > maxEmbedLength {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "More servers",
+				Value: fmt.Sprintf("The player lists of %d more servers don't fit into the report.", len(servers)-n),
+			})
+			break
+		}
+
+		embed.Fields = append(embed.Fields, field)
+		length += len(field.Name) + len(field.Value)
+	}
+
+	return embed
+}
+
+// findOnline returns the server key and the player which matches the name, case insensitive and ignoring decorations
+func findOnline(infos map[string]*model.ServerInfo, name string) (string, model.PlayerInfo, bool) {
+	clean := utils.SanitizeName(name)
+
+	for serverKey, serverInfo := range infos {
+		for _, player := range serverInfo.Players {
+			if strings.EqualFold(utils.SanitizeName(player.Name), clean) {
+				return serverKey, player, true
+			}
+		}
+	}
+
+	return "", model.PlayerInfo{}, false
+}
+
+func (s *ServerStatus) reportPlayerList(serverInfo *model.ServerInfo) string {
+	if !serverInfo.Reachable {
+		return "Unreachable"
+	}
+
+	if len(serverInfo.Players) == 0 {
+		return "No players"
+	}
+
+	var lines []string
+
+	for _, player := range serverInfo.Players {
+		lines = append(lines, s.playerLine(player))
+	}
+
+	return utils.Truncate(strings.Join(lines, "\n"), maxFieldLength)
+}
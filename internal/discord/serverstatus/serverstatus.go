@@ -1,18 +1,36 @@
 package serverstatus
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/api"
 	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
 	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/lifetime"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/subscriptions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/metrics"
 	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/store"
+	"github.com/patrickjane/lazydodo-bot/internal/tracing"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
 )
 
 const tableServers = "crosschat_servers"
@@ -22,12 +40,46 @@ type ServerStatus struct {
 	Session *discordgo.Session
 	UserID  string
 
+	out          output.Session
+	clock        clock.Clock
+	live         *cfg.Live
+	cache        *cache.Store
+	refresh      chan<- struct{}
+	subs         *subscriptions.Subscriptions
 	db           *sql.DB
+	database     *store.Store
 	queryServers string
+	lastPlayers  map[string]map[string]bool
+	downSince    map[string]time.Time
+	reachable    map[string]bool
+	lastPlaytime time.Time
+	transfers    map[string]transfer
+	activity     map[string][]string
+	threadBodies map[string]string
+	friendGroups map[string][]string
+	fill         map[string][]fillSample
+	fullAlerted  map[string]bool
+	unavailable  map[string]time.Time
+	archiving    atomic.Bool
+	gameChat     atomic.Bool
+
+	confirmMu     sync.Mutex
+	confirmations map[string]privacyConfirmation
+
+	mu      sync.RWMutex
+	current map[string]model.ServerInfo
 }
 
-func NewServerStatus(s *discordgo.Session, userID string) *ServerStatus {
-	db, err := sql.Open("mysql", cfg.Config.ServerStatus.DbConnection)
+func NewServerStatus(s *discordgo.Session, userID string, live *cfg.Live, cache *cache.Store, refresh chan<- struct{},
+	subs *subscriptions.Subscriptions, database *store.Store) *ServerStatus {
+	config := live.Load().ServerStatus
+
+	if cfg.Config.Simulate || config.DbConnection == "" {
+		return &ServerStatus{Session: s, UserID: userID, out: output.FromDiscord(s), clock: clock.Real, live: live, cache: cache, refresh: refresh, subs: subs,
+			database: database}
+	}
+
+	db, err := sql.Open("mysql", config.DbConnection)
 
 	if err != nil {
 		panic(err)
@@ -41,78 +93,484 @@ func NewServerStatus(s *discordgo.Session, userID string) *ServerStatus {
 	return &ServerStatus{
 		Session:      s,
 		UserID:       userID,
+		out:          output.FromDiscord(s),
+		clock:        clock.Real,
+		live:         live,
+		cache:        cache,
+		refresh:      refresh,
+		subs:         subs,
 		db:           db,
+		database:     database,
 		queryServers: fmt.Sprintf("SELECT ServerName, ServerStatus FROM %s", tableServers),
 	}
 }
 
-func (s *ServerStatus) RunServerStatus(fromRcon <-chan map[string]*model.ServerInfo) error {
-	var existingMessageId string
+// config returns the current server status config, see cfg.Live
+func (s *ServerStatus) config() *cfg.ConfigServerStatus {
+	return s.live.Load().ServerStatus
+}
+
+func (s *ServerStatus) RunServerStatus(fromRcon <-chan model.ServerUpdate) error {
+	existingMessageIds := make(map[string]string)
 
-	cacheData, err := cache.Get()
+	cacheData, err := s.cache.Get()
 
 	if err != nil {
 		slog.Error(fmt.Sprintf("Failed to load server status message id from cache: %s", err))
 		return err
 	}
 
-	if len(cacheData.DiscordMessageIdStatus) > 0 {
-		existingMessageId = cacheData.DiscordMessageIdStatus
+	for channelID, messageID := range cacheData.DiscordMessageIdsStatus {
+		existingMessageIds[channelID] = messageID
 	}
 
+	if len(s.config().Guilds) == 0 && len(cacheData.DiscordMessageIdStatus) > 0 {
+		existingMessageIds[s.config().ChannelID] = cacheData.DiscordMessageIdStatus
+	}
+
+	// re-render the status message when polls stop arriving, so it gets marked stale
+
+	staleTicker := s.clock.NewTicker(time.Minute)
+	defer staleTicker.Stop()
+
+	markedStale := false
+
 	for {
 		select {
-		case ifos := <-fromRcon:
-			err := s.fetchPlayerInfosFromDb(ifos)
+		case <-staleTicker.C():
+			current := s.currentInfos()
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to retrieve server info from db: %s", err))
+			if markedStale || len(current) == 0 || !s.stale(current) {
+				continue
 			}
 
-			msgId, err := s.updatePlayerList(existingMessageId, ifos)
+			slog.Warn("No successful poll for a while, marking server status as stale")
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to send player list update to discord: %s", err))
+			markedStale = true
+			s.postStatus(context.Background(), current, existingMessageIds)
+
+		case update := <-fromRcon:
+			ifos := update.Servers
+			markedStale = false
+			ctx, span := tracing.Start(update.Ctx, "status.update")
+
+			if s.db != nil {
+				_, dbSpan := tracing.Start(ctx, "status.db")
+				err := s.fetchPlayerInfosFromDb(ifos)
+				tracing.End(dbSpan, err)
+
+				if err != nil {
+					slog.Error(fmt.Sprintf("Failed to retrieve server info from db: %s", err))
+				}
 			}
 
-			existingMessageId = msgId
+			s.storeCurrent(ifos)
+			metrics.RecordPoll(ifos)
+			api.RecordPoll(ifos)
 
-			err = cache.Update(func(k *cache.CacheData) {
-				k.DiscordMessageIdStatus = existingMessageId
-			})
+			if err := history.Record(s.withoutUntracked(ifos)); err != nil {
+				slog.Error(fmt.Sprintf("Failed to store server status history: %s", err))
+			}
 
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to store server status message id in cache: %s", err))
+			if err := s.database.RecordSnapshot(s.clock.Now(), ifos); err != nil {
+				slog.Error(fmt.Sprintf("Failed to store server status snapshot: %s", err))
+			}
+
+			// the diff also runs without join/leave messages in the channel, for the DM subscriptions
+
+			_, diffSpan := tracing.Start(ctx, "status.diff")
+			s.trackIdentities(ifos)
+			s.notifyJoinLeave(ifos)
+			s.notifyOutages(ifos)
+			s.notifyFriends()
+			diffSpan.End()
+
+			s.postStatus(ctx, ifos, existingMessageIds)
+
+			if s.config().SnapshotTime != "" {
+				s.postSnapshotIfDue(ifos)
+			}
+
+			if s.config().ChannelIDFull != "" {
+				s.checkFillRate(ifos, s.clock.Now())
+			}
+
+			if s.config().ArchiveJoinLeave {
+				s.archiveIfDue()
+			}
+
+			if s.config().ChannelIDSla != "" {
+				s.slaReportIfDue()
+			}
+
+			if s.config().ChannelIDPlaytime != "" {
+				s.playtimeReportIfDue()
+			}
+
+			if s.config().ChannelIDIncidents != "" {
+				s.handleIncidents(ifos)
+			}
+
+			span.End()
+		}
+	}
+}
+
+// postStatus updates the status messages of all targets and stores their message ids
+func (s *ServerStatus) postStatus(ctx context.Context, serverStatusMap map[string]*model.ServerInfo, existingMessageIds map[string]string) {
+	for _, target := range s.statusTargets() {
+		if s.skipUnavailable(target.ChannelID) {
+			continue
+		}
+
+		if s.config().StatusThreads {
+			s.updateThreads(target, target.filter(serverStatusMap))
+		}
+
+		msgId, err := s.updatePlayerList(ctx, target, existingMessageIds[target.ChannelID], target.filter(serverStatusMap))
+
+		if channelUnavailable(err) {
+			s.markUnavailable(target.ChannelID, err)
+
+			// a deleted channel takes the message with it, a missing permission may be fixed again
+
+			if discordErrorCode(err) == discordgo.ErrCodeUnknownChannel {
+				existingMessageIds[target.ChannelID] = ""
 			}
+
+			continue
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to send player list update to discord channel %s: %s", target.ChannelID, err))
+		} else {
+			s.markAvailable(target.ChannelID)
+		}
+
+		existingMessageIds[target.ChannelID] = msgId
+	}
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		if len(s.config().Guilds) == 0 {
+			k.DiscordMessageIdStatus = existingMessageIds[s.config().ChannelID]
+			return
 		}
+
+		k.DiscordMessageIdsStatus = existingMessageIds
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store server status message id in cache: %s", err))
 	}
 }
 
 func (s *ServerStatus) sendNotifyMessage(server string, player string, joined bool) error {
-	var err error
+	key := "status.left"
 
 	if joined {
-		_, err = s.Session.ChannelMessageSend(cfg.Config.ServerStatus.ChannelIDJoinLeave, fmt.Sprintf("[%s] %s joined the server", server, player))
-	} else {
-		_, err = s.Session.ChannelMessageSend(cfg.Config.ServerStatus.ChannelIDJoinLeave, fmt.Sprintf("[%s] %s left the server", server, player))
+		key = "status.joined"
+	}
+
+	if _, hidden := s.optOut(player); hidden {
+		return nil
+	}
+
+	name := utils.SanitizeName(player)
+	lang := s.channelLanguage(s.config().ChannelIDJoinLeave)
+	msg := s.emojiPrefix(server) + i18n.T(lang, key, s.serverName(server), name)
+
+	s.recordActivity(server, msg)
+
+	s.subs.Notify(s.Session, s.subs.Activity(server, player), lang, func(lang utils.Language) string {
+		return s.emojiPrefix(server) + i18n.T(lang, key, s.serverName(server), name)
+	})
+
+	if !s.config().ShowJoinLeave {
+		return nil
 	}
 
-	return err
+	return s.sendJoinLeave(msg)
+}
+
+// sendJoinLeave posts the message to the join/leave channel, it is deleted once its lifetime is over
+func (s *ServerStatus) sendJoinLeave(msg string) error {
+	return retry.SendMessage(s.out, s.config().ChannelIDJoinLeave, &discordgo.MessageSend{Content: msg}, lifetime.Expire(s.clock, s.cache, s.config().JoinLeaveLifetime))
 }
 
 func (s *ServerStatus) sendMoveMessage(player string, oldserver string, newserver string) error {
-	var err error
-	_, err = s.Session.ChannelMessageSend(cfg.Config.ServerStatus.ChannelIDJoinLeave, fmt.Sprintf("[%s -> %s] %s moved servers", oldserver, newserver, player))
-	return err
+	if _, hidden := s.optOut(player); hidden {
+		return nil
+	}
+
+	name := utils.SanitizeName(player)
+	lang := s.channelLanguage(s.config().ChannelIDJoinLeave)
+	msg := s.emojiPrefix(newserver) + i18n.T(lang, "status.moved", s.serverName(oldserver), s.serverName(newserver), name)
+
+	s.recordActivity(oldserver, msg)
+	s.recordActivity(newserver, msg)
+
+	s.subs.Notify(s.Session, union(s.subs.Activity(oldserver, player), s.subs.Activity(newserver, player)), lang,
+		func(lang utils.Language) string {
+			return s.emojiPrefix(newserver) + i18n.T(lang, "status.moved", s.serverName(oldserver), s.serverName(newserver), name)
+		})
+
+	if !s.config().ShowJoinLeave {
+		return nil
+	}
+
+	return s.sendJoinLeave(msg)
 }
 
-func (s *ServerStatus) updatePlayerList(existingMessageId string, serverStatusMap map[string]*model.ServerInfo) (string, error) {
-	// assemble message payload from server infos
+// notifyOutages sends a DM to the outage subscribers whenever a server becomes unreachable or reachable again
+func (s *ServerStatus) notifyOutages(serverStatusMap map[string]*model.ServerInfo) {
+	previous := s.reachable
+	s.reachable = make(map[string]bool)
 
-	payload := &discordgo.MessageSend{
-		Content: discordMessageTitle,
+	for serverKey, serverInfo := range serverStatusMap {
+		was, ok := previous[serverKey]
+
+		// outages during the restart window are expected
+
+		if s.restarting(serverKey) {
+			s.reachable[serverKey] = was || !ok
+			continue
+		}
+
+		s.reachable[serverKey] = serverInfo.Reachable
+
+		// first update after startup only establishes the baseline
+
+		if !ok || was == serverInfo.Reachable {
+			continue
+		}
+
+		key := "status.unreachable"
+
+		if serverInfo.Reachable {
+			key = "status.reachable"
+		}
+
+		s.subs.Notify(s.Session, s.subs.Outages(), s.config().Languages.Language(""), func(lang utils.Language) string {
+			return i18n.T(lang, key, serverInfo.Name)
+		})
+	}
+}
+
+// restarting reports whether the server is within one of its configured restart windows
+func (s *ServerStatus) restarting(serverKey string) bool {
+	for _, server := range s.config().Rcon.Servers {
+		if server.Key == serverKey {
+			return server.InRestartWindow(s.clock.Now().In(s.location("")))
+		}
+	}
+
+	return false
+}
+
+// storeCurrent keeps a copy of the latest server infos for slash commands and buttons
+func (s *ServerStatus) storeCurrent(serverStatusMap map[string]*model.ServerInfo) {
+	current := make(map[string]model.ServerInfo)
+
+	for serverKey, serverInfo := range serverStatusMap {
+		ifo := *serverInfo
+		ifo.Players = append([]model.PlayerInfo{}, serverInfo.Players...)
+		current[serverKey] = ifo
+	}
+
+	s.mu.Lock()
+	s.current = current
+	s.mu.Unlock()
+}
+
+// currentInfos returns a copy of the latest server infos
+func (s *ServerStatus) currentInfos() map[string]*model.ServerInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[string]*model.ServerInfo)
+
+	for serverKey, serverInfo := range s.current {
+		ifo := serverInfo
+		ifo.Players = append([]model.PlayerInfo{}, serverInfo.Players...)
+		res[serverKey] = &ifo
+	}
+
+	return res
+}
+
+// Current returns the latest known info of the server with the given key
+func (s *ServerStatus) Current(serverKey string) (model.ServerInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ifo, ok := s.current[serverKey]
+
+	return ifo, ok
+}
+
+func (s *ServerStatus) notifyJoinLeave(serverStatusMap map[string]*model.ServerInfo) {
+	current := make(map[string]map[string]bool)
+
+	for serverKey, serverInfo := range serverStatusMap {
+		// unreachable and restarting servers keep their last known players, so we don't spam leave messages
+
+		if !serverInfo.Reachable || s.restarting(serverKey) {
+			current[serverKey] = maps.Clone(s.lastPlayers[serverKey])
+			continue
+		}
+
+		current[serverKey] = make(map[string]bool)
+
+		// players without name (e.g. valheim without server log) can't be tracked
+
+		for _, player := range serverInfo.Players {
+			if player.Name != "" {
+				current[serverKey][player.Name] = true
+			}
+		}
+	}
+
+	previous := s.lastPlayers
+	mergeDuplicates(current, previous)
+	s.lastPlayers = current
+
+	// first update after startup only establishes the baseline
+
+	if previous == nil {
+		return
+	}
+
+	joined := make(map[string]string)
+	left := make(map[string]string)
+
+	// servers added or removed by a config reload don't announce their players
+
+	for serverKey, players := range current {
+		if _, known := previous[serverKey]; !known {
+			continue
+		}
+
+		for player := range players {
+			if !previous[serverKey][player] {
+				joined[player] = serverKey
+			}
+		}
+	}
+
+	for serverKey, players := range previous {
+		if _, polled := current[serverKey]; !polled {
+			continue
+		}
+
+		for player := range players {
+			if !current[serverKey][player] {
+				left[player] = serverKey
+			}
+		}
+	}
+
+	now := s.clock.Now()
+
+	s.storeEvents(now, joined, left)
+
+	if s.transfers == nil {
+		s.transfers = make(map[string]transfer)
+	}
+
+	for _, player := range sortedKeys(left) {
+		var err error
+
+		if newServer, ok := joined[player]; ok {
+			delete(joined, player)
+			err = s.sendMoveMessage(player, left[player], newServer)
+		} else if s.config().TransferWindow > 0 {
+			s.transfers[player] = transfer{server: left[player], since: now}
+		} else {
+			err = s.sendNotifyMessage(left[player], player, false)
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to send leave/move notification for player %s: %s", player, err))
+		}
+	}
+
+	for _, player := range sortedKeys(joined) {
+		var err error
+
+		if t, ok := s.transfers[player]; ok {
+			delete(s.transfers, player)
+
+			// back on the same server within the window, e.g. after a reconnect
+
+			if t.server == joined[player] {
+				continue
+			}
+
+			err = s.sendMoveMessage(player, t.server, joined[player])
+		} else {
+			err = s.sendNotifyMessage(joined[player], player, true)
+		}
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to send join/move notification for player %s: %s", player, err))
+		}
+	}
+
+	s.flushTransfers(now)
+}
+
+// storeEvents stores the joins and leaves as seen by the poll, before moves and transfers are told apart
+func (s *ServerStatus) storeEvents(now time.Time, joined map[string]string, left map[string]string) {
+	var events []store.Event
+
+	for _, player := range sortedKeys(left) {
+		if optOut, ok := s.optOut(player); ok && optOut.NoStats {
+			continue
+		}
+
+		events = append(events, store.Event{Time: now, Server: left[player], Player: player})
+	}
+
+	for _, player := range sortedKeys(joined) {
+		if optOut, ok := s.optOut(player); ok && optOut.NoStats {
+			continue
+		}
+
+		events = append(events, store.Event{Time: now, Server: joined[player], Player: player, Joined: true})
+	}
+
+	if err := s.database.RecordEvents(events); err != nil {
+		slog.Error(fmt.Sprintf("Failed to store join/leave events: %s", err))
+	}
+}
+
+func union(a []string, b []string) []string {
+	res := append([]string{}, a...)
+
+	for _, v := range b {
+		if !slices.Contains(res, v) {
+			res = append(res, v)
+		}
 	}
 
+	return res
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (s *ServerStatus) buildEmbeds(serverStatusMap map[string]*model.ServerInfo) []*discordgo.MessageEmbed {
+	var embeds []*discordgo.MessageEmbed
+
 	keys := make([]string, 0, len(serverStatusMap))
 
 	for k := range serverStatusMap {
@@ -121,23 +579,19 @@ func (s *ServerStatus) updatePlayerList(existingMessageId string, serverStatusMa
 
 	sort.Strings(keys)
 
-	for _, serverName := range keys {
-		serverInfo := serverStatusMap[serverName]
+	for _, serverKey := range keys {
+		serverInfo := serverStatusMap[serverKey]
 
 		body := "No players online"
 		color := 0x57F287 // Discord green
 
-		if len(serverInfo.Players) > 0 {
+		if len(serverInfo.Players) > 0 && serverInfo.Players[0].Team != "" {
+			body = s.groupedPlayerList(serverInfo.Players)
+		} else if len(serverInfo.Players) > 0 {
 			players := []string{}
 
 			for _, player := range serverInfo.Players {
-				playerNameString := fmt.Sprintf("- %s (%s)", player.Name, player.Tribe)
-
-				if len(player.Tribe) == 0 {
-					playerNameString = fmt.Sprintf("- %s", player.Name)
-				}
-
-				players = append(players, playerNameString)
+				players = append(players, s.playerLine(player))
 			}
 
 			body = strings.Join(players, "\n")
@@ -146,43 +600,253 @@ func (s *ServerStatus) updatePlayerList(existingMessageId string, serverStatusMa
 		if !serverInfo.Reachable {
 			color = 0xc1121f
 			body = "Server unreachable"
+
+			if s.restarting(serverKey) {
+				color = 0xfee75c // Discord yellow
+				body = "Server restarting"
+			}
 		}
 
-		payload.Embeds = append(payload.Embeds, &discordgo.MessageEmbed{
-			Title:       serverName,
-			Description: fmt.Sprintf("> Day: %d • Time: %s • Version: %s\n\n%s", serverInfo.Day, serverInfo.Time, serverInfo.ServerVersion, body),
+		embed := &discordgo.MessageEmbed{
+			Title:       s.emojiPrefix(serverKey) + serverInfo.Name,
+			Description: fmt.Sprintf("> Day: %d • Time: %s • Version: %s%s%s\n\n%s", serverInfo.Day, serverInfo.Time, serverInfo.ServerVersion, s.connectHint(serverKey), s.wipeHint(serverKey), body),
 			Color:       color,
-		})
+		}
+
+		// only the author line can show an icon in front of the text, it replaces the title then
+
+		if server, ok := s.serverConfig(serverKey); ok && server.IconURL != "" {
+			embed.Author = &discordgo.MessageEmbedAuthor{Name: embed.Title, IconURL: server.IconURL}
+			embed.Title = ""
+		}
+
+		embeds = append(embeds, embed)
+	}
+
+	return embeds
+}
+
+// displayName is the sanitized name of the player, optionally followed by the raw name
+func (s *ServerStatus) displayName(name string) string {
+	clean := utils.SanitizeName(name)
+
+	if clean == "" {
+		clean = "Unknown player"
+	}
+
+	if s.config().ShowRawNames && clean != name && name != "" {
+		return fmt.Sprintf("%s `%s`", clean, utils.EscapeName(name))
+	}
+
+	return clean
+}
+
+func (s *ServerStatus) playerLine(player model.PlayerInfo) string {
+	if len(player.Tribe) == 0 {
+		return fmt.Sprintf("- %s", s.displayName(player.Name))
+	}
+
+	return fmt.Sprintf("- %s (%s)", s.displayName(player.Name), utils.SanitizeName(player.Tribe))
+}
+
+// groupedPlayerList lists the players by team and squad
+func (s *ServerStatus) groupedPlayerList(players []model.PlayerInfo) string {
+	squads := make(map[string]map[string][]string)
+
+	for _, player := range players {
+		squad := player.Squad
+
+		if squad == "" {
+			squad = "Unassigned"
+		}
+
+		if squads[player.Team] == nil {
+			squads[player.Team] = make(map[string][]string)
+		}
+
+		squads[player.Team][squad] = append(squads[player.Team][squad], s.displayName(player.Name))
+	}
+
+	teams := make([]string, 0, len(squads))
+
+	for team := range squads {
+		teams = append(teams, team)
+	}
+
+	sort.Strings(teams)
+
+	var lines []string
+
+	for _, team := range teams {
+		lines = append(lines, fmt.Sprintf("**%s**", team))
+
+		names := make([]string, 0, len(squads[team]))
+
+		for squad := range squads[team] {
+			names = append(names, squad)
+		}
+
+		sort.Strings(names)
+
+		for _, squad := range names {
+			lines = append(lines, fmt.Sprintf("- %s: %s", squad, strings.Join(squads[team][squad], ", ")))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (s *ServerStatus) serverConfig(serverKey string) (cfg.ConfigRconServer, bool) {
+	for _, server := range s.config().Rcon.Servers {
+		if server.Key == serverKey {
+			return server, true
+		}
+	}
+
+	return cfg.ConfigRconServer{}, false
+}
+
+// serverName returns the display name of the server with the given key
+func (s *ServerStatus) serverName(serverKey string) string {
+	return cfg.ServerName(s.config().Rcon.Servers, serverKey)
+}
+
+// location returns the time zone of the guild's schedules and dates
+func (s *ServerStatus) location(guildID string) *time.Location {
+	return s.config().Timezones.Location(guildID)
+}
+
+// channelLocation returns the time zone of the guild the channel belongs to
+func (s *ServerStatus) channelLocation(channelID string) *time.Location {
+	if channel, err := s.Session.State.Channel(channelID); err == nil {
+		return s.location(channel.GuildID)
 	}
 
+	return s.location("")
+}
+
+// channelLanguage returns the language of the guild the channel belongs to
+func (s *ServerStatus) channelLanguage(channelID string) utils.Language {
+	if channel, err := s.Session.State.Channel(channelID); err == nil {
+		return s.config().Languages.Language(channel.GuildID)
+	}
+
+	return s.config().Languages.Language("")
+}
+
+// emojiPrefix returns the configured emoji of the server followed by a space, empty without emoji
+func (s *ServerStatus) emojiPrefix(serverKey string) string {
+	if server, ok := s.serverConfig(serverKey); ok && server.Emoji != "" {
+		return server.Emoji + " "
+	}
+
+	return ""
+}
+
+// connectHint returns the copyable connect link (and password) of a server, if enabled for the server
+func (s *ServerStatus) connectHint(serverKey string) string {
+	for _, server := range s.config().Rcon.Servers {
+		if server.Key != serverKey || !server.ShowConnect {
+			continue
+		}
+
+		// discord does not render steam:// links as clickable, so make them easy to copy instead
+
+		hint := fmt.Sprintf("\n> Connect: `steam://connect/%s`", server.ConnectAddress)
+
+		if server.ConnectPassword != "" {
+			hint += fmt.Sprintf(" • Password: `%s`", server.ConnectPassword)
+		}
+
+		return hint
+	}
+
+	return ""
+}
+
+// wipeHint counts down to the next wipe of the server, discord renders the relative time in the reader's client
+func (s *ServerStatus) wipeHint(serverKey string) string {
+	wipe, ok := s.config().NextWipe(serverKey, s.clock.Now())
+
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("\n> %s ends <t:%d:R> (wipe)", wipe.Name, wipe.Date.Unix())
+}
+
+func (s *ServerStatus) updatePlayerList(ctx context.Context, target statusTarget, existingMessageId string, serverStatusMap map[string]*model.ServerInfo) (res string, err error) {
+	// assemble message payload from server infos
+
+	_, renderSpan := tracing.Start(ctx, "status.render")
+
+	payload := &discordgo.MessageSend{
+		Content:    target.title() + s.lastUpdatedLine(serverStatusMap),
+		Embeds:     s.buildEmbeds(serverStatusMap),
+		Components: s.buildComponents(),
+	}
+
+	if s.config().StatusThreads {
+		payload.Embeds = []*discordgo.MessageEmbed{s.buildCompactEmbed(target, serverStatusMap)}
+	}
+
+	target.brand(payload.Embeds)
+
+	renderSpan.End()
+
+	_, apiSpan := tracing.Start(ctx, "discord.api")
+	defer func() { tracing.End(apiSpan, err) }()
+
 	// check if we already have the (pinned) message, then we edit it instead of send a new message
 
-	theMessage, err := s.fetchExistingMessage(existingMessageId)
+	theMessage, err := s.fetchExistingMessage(target, existingMessageId)
+
+	// the cached message was deleted, a new one is posted
+
+	if existingMessageId != "" && discordErrorCode(err) == discordgo.ErrCodeUnknownMessage {
+		slog.Warn(fmt.Sprintf("Status message %s in channel %s was deleted, posting a new one", existingMessageId, target.ChannelID))
+		theMessage, err = s.fetchExistingMessage(target, "")
+	}
 
 	if err != nil {
-		return "", fmt.Errorf("fetchExistingMessage: %s", err)
+		return "", fmt.Errorf("fetchExistingMessage: %w", err)
 	}
 
 	// actually send the updat to discord (edit or new)
 
 	if theMessage != nil {
 		edit := &discordgo.MessageEdit{
-			ID:      theMessage.ID,
-			Channel: cfg.Config.ServerStatus.ChannelID,
-			Content: &payload.Content, // replace content
-			Embeds:  &payload.Embeds,  // replace embeds array
+			ID:         theMessage.ID,
+			Channel:    target.ChannelID,
+			Content:    &payload.Content,    // replace content
+			Embeds:     &payload.Embeds,     // replace embeds array
+			Components: &payload.Components, // replace buttons
 		}
 
-		theMessage, err = s.Session.ChannelMessageEditComplex(edit)
+		theMessage, err = s.out.EditMessage(edit)
+
+		// deleted between fetching and editing
+
+		if discordErrorCode(err) == discordgo.ErrCodeUnknownMessage {
+			theMessage, err = nil, nil
+		}
 
 		if err != nil {
-			return "", fmt.Errorf("ChannelMessageEditComplex: %s", err)
+			return "", fmt.Errorf("ChannelMessageEditComplex: %w", err)
 		}
-	} else {
-		theMessage, err = s.Session.ChannelMessageSendComplex(cfg.Config.ServerStatus.ChannelID, payload)
+	}
+
+	if theMessage == nil {
+		theMessage, err = s.out.SendMessage(target.ChannelID, payload)
 
 		if err != nil {
-			return "", fmt.Errorf("ChannelMessageSendComplex: %s", err)
+			return "", fmt.Errorf("ChannelMessageSendComplex: %w", err)
+		}
+
+		if target.Pin {
+			if err := s.out.Pin(target.ChannelID, theMessage.ID); err != nil {
+				slog.Error(fmt.Sprintf("Failed to pin server status message in channel %s: %s", target.ChannelID, err))
+			}
 		}
 	}
 
@@ -191,19 +855,75 @@ func (s *ServerStatus) updatePlayerList(existingMessageId string, serverStatusMa
 	return theMessage.ID, nil
 }
 
-func (s *ServerStatus) fetchExistingMessage(existingMessageId string) (*discordgo.Message, error) {
+func (s *ServerStatus) postSnapshotIfDue(serverStatusMap map[string]*model.ServerInfo) {
+	// snapshot time was validated on startup
+
+	at, _ := time.Parse("15:04", s.config().SnapshotTime)
+
+	// the snapshot time is the wall clock time of the channel's guild, also across DST changes
+
+	now := s.clock.Now().In(s.channelLocation(s.config().ChannelIDSnapshot))
+	due := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+
+	// don't post a late snapshot when the bot was started long after the snapshot time
+
+	if now.Before(due) || now.Sub(due) > time.Hour {
+		return
+	}
+
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load last snapshot time from cache: %s", err))
+		return
+	}
+
+	if !cacheData.LastSnapshot.Before(due) {
+		return
+	}
+
+	payload := &discordgo.MessageSend{
+		Content: fmt.Sprintf("## Player list at %s (%s)", s.config().SnapshotTime, now.Format("02.01.2006")),
+		Embeds:  s.buildEmbeds(serverStatusMap),
+	}
+
+	if s.config().SnapshotForecast {
+		payload.Content += s.forecast(now)
+	}
+
+	slog.Info(fmt.Sprintf("Posting daily player list snapshot (%s)", s.config().SnapshotTime))
+
+	_, err = s.out.SendMessage(s.config().ChannelIDSnapshot, payload)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send player list snapshot to discord: %s", err))
+		return
+	}
+
+	err = s.cache.Update(func(k *cache.CacheData) {
+		k.LastSnapshot = now
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store last snapshot time in cache: %s", err))
+	}
+}
+
+func (s *ServerStatus) fetchExistingMessage(target statusTarget, existingMessageId string) (*discordgo.Message, error) {
 	if len(existingMessageId) > 0 {
-		return s.Session.ChannelMessage(cfg.Config.ServerStatus.ChannelID, existingMessageId)
+		return s.out.FetchMessage(target.ChannelID, existingMessageId)
 	}
 
-	msgs, err := s.Session.ChannelMessages(cfg.Config.ServerStatus.ChannelID, 100, "", "", "")
+	msgs, err := s.out.FetchMessages(target.ChannelID, 100, "")
 
 	if err != nil {
 		return nil, err
 	}
 
 	for _, m := range msgs {
-		if m.Author != nil && m.Author.ID == s.UserID && strings.Contains(m.Content, discordMessageTitle) {
+		// the title may have been changed since the message was posted
+
+		if m.Author != nil && m.Author.ID == s.UserID && (strings.Contains(m.Content, discordMessageTitle) || strings.Contains(m.Content, target.title())) {
 			return m, nil
 		}
 	}
@@ -260,3 +980,40 @@ func (s *ServerStatus) fetchPlayerInfosFromDb(serverInfos map[string]*model.Serv
 
 	return nil
 }
+
+// lastPoll returns the time of the latest successful poll of any of the servers
+func lastPoll(serverStatusMap map[string]*model.ServerInfo) time.Time {
+	var last time.Time
+
+	for serverKey := range serverStatusMap {
+		if t := rcon.LastSuccess(serverKey); t.After(last) {
+			last = t
+		}
+	}
+
+	return last
+}
+
+// stale reports whether none of the servers was polled successfully for the configured number of intervals
+func (s *ServerStatus) stale(serverStatusMap map[string]*model.ServerInfo) bool {
+	last := lastPoll(serverStatusMap)
+	staleAfter := time.Duration(s.config().StaleAfterPolls*s.config().Rcon.QueryEverySeconds) * time.Second
+
+	return !last.IsZero() && s.clock.Now().Sub(last) > staleAfter
+}
+
+// lastUpdatedLine is the subtext below the status title with the relative time of the last successful
+// poll. Embed footers can't render discord timestamps, so it's part of the message content.
+func (s *ServerStatus) lastUpdatedLine(serverStatusMap map[string]*model.ServerInfo) string {
+	last := lastPoll(serverStatusMap)
+
+	if last.IsZero() {
+		return ""
+	}
+
+	if s.stale(serverStatusMap) {
+		return fmt.Sprintf("\n-# ⚠️ stale, last updated <t:%d:R>", last.Unix())
+	}
+
+	return fmt.Sprintf("\n-# Last updated <t:%d:R>", last.Unix())
+}
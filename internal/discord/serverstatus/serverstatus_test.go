@@ -0,0 +1,132 @@
+package serverstatus
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/clock"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output/outputtest"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/subscriptions"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// testServerStatus creates a server status of the two servers island and center, posting to the fake output
+func testServerStatus(t *testing.T) (*ServerStatus, *outputtest.Fake) {
+	t.Helper()
+
+	store, err := cache.Open(filepath.Join(t.TempDir(), "cache.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &outputtest.Fake{}
+
+	config := &cfg.ConfigServerStatus{
+		ChannelIDJoinLeave: "1",
+		ShowJoinLeave:      true,
+		Rcon: cfg.ConfigRcon{Servers: []cfg.ConfigRconServer{
+			{Key: "island", Name: "The Island"},
+			{Key: "center", Name: "The Center"},
+		}},
+	}
+
+	s := &ServerStatus{
+		Session: &discordgo.Session{State: discordgo.NewState()},
+		out:     out,
+		clock:   clock.NewFake(time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)),
+		live:    cfg.NewLive(&cfg.ConfigBot{ServerStatus: config}),
+		cache:   store,
+		subs:    subscriptions.New(store),
+	}
+
+	return s, out
+}
+
+// poll returns the server infos of a poll, a nil list of players makes the server unreachable
+func poll(players map[string][]string) map[string]*model.ServerInfo {
+	res := make(map[string]*model.ServerInfo)
+
+	for serverKey, names := range players {
+		info := &model.ServerInfo{Reachable: names != nil}
+
+		for _, name := range names {
+			info.Players = append(info.Players, model.PlayerInfo{Name: name})
+		}
+
+		res[serverKey] = info
+	}
+
+	return res
+}
+
+func TestJoinLeave(t *testing.T) {
+	s, out := testServerStatus(t)
+
+	steps := []struct {
+		name    string
+		players map[string][]string
+		want    []string
+	}{
+		{"baseline", map[string][]string{"island": {"Alice", "Bob"}, "center": {"Carol"}}, nil},
+		{"move", map[string][]string{"island": {"Alice"}, "center": {"Bob", "Carol"}}, []string{"Bob"}},
+		{"join while a server is unreachable", map[string][]string{"island": {"Alice", "Dave"}, "center": nil}, []string{"Dave"}},
+		{"leave", map[string][]string{"island": {}, "center": nil}, []string{"Alice", "Dave"}},
+	}
+
+	for _, step := range steps {
+		out.Sent = nil
+
+		s.notifyJoinLeave(poll(step.players))
+
+		sent := out.Contents()
+
+		if len(sent) != len(step.want) {
+			t.Fatalf("%s: expected %d messages, got %q", step.name, len(step.want), sent)
+		}
+
+		for n, player := range step.want {
+			if !strings.Contains(sent[n], player) {
+				t.Errorf("%s: message %q is not about %s", step.name, sent[n], player)
+			}
+		}
+	}
+}
+
+func TestReportEmbedLimits(t *testing.T) {
+	s, _ := testServerStatus(t)
+
+	infos := make(map[string]*model.ServerInfo)
+
+	for n := range 40 {
+		info := &model.ServerInfo{Name: fmt.Sprintf("Server %02d", n), Reachable: true}
+
+		for p := range 30 {
+			info.Players = append(info.Players, model.PlayerInfo{Name: fmt.Sprintf("Player %02d with a rather long name %02d", p, n)})
+		}
+
+		infos[fmt.Sprintf("server%02d", n)] = info
+	}
+
+	embed := s.reportEmbed("1", "Nobody", "griefing", infos)
+
+	length := len(embed.Title)
+
+	for _, field := range embed.Fields {
+		length += len(field.Name) + len(field.Value)
+	}
+
+	if len(embed.Fields) > maxEmbedFields || length > maxEmbedLength {
+		t.Fatalf("report has %d fields and %d characters", len(embed.Fields), length)
+	}
+
+	if last := embed.Fields[len(embed.Fields)-1]; last.Name != "More servers" {
+		t.Errorf("left out player lists are not noted, last field is %q", last.Name)
+	}
+}
@@ -0,0 +1,231 @@
+package serverstatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// number of join/leave lines kept per server for its status thread
+const maxActivityLines = 10
+
+// threads are archived after a week without new messages, the bot only edits its message in there
+const threadArchiveMinutes = 10080
+
+func threadKey(channelID string, serverKey string) string {
+	return channelID + "/" + serverKey
+}
+
+// recordActivity remembers the join/leave line for the status thread of the server
+func (s *ServerStatus) recordActivity(serverKey string, line string) {
+	if !s.config().StatusThreads {
+		return
+	}
+
+	if s.activity == nil {
+		s.activity = make(map[string][]string)
+	}
+
+	line = fmt.Sprintf("<t:%d:t> %s", s.clock.Now().Unix(), line)
+	lines := append(s.activity[serverKey], line)
+
+	if len(lines) > maxActivityLines {
+		lines = lines[len(lines)-maxActivityLines:]
+	}
+
+	s.activity[serverKey] = lines
+}
+
+// buildCompactEmbed lists the player count of every server with a link to its thread
+func (s *ServerStatus) buildCompactEmbed(target statusTarget, serverStatusMap map[string]*model.ServerInfo) *discordgo.MessageEmbed {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load status threads from cache: %s", err))
+	}
+
+	keys := make([]string, 0, len(serverStatusMap))
+
+	for k := range serverStatusMap {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var lines []string
+	total := 0
+
+	for _, serverKey := range keys {
+		serverInfo := serverStatusMap[serverKey]
+		status := fmt.Sprintf("%d players", len(serverInfo.Players))
+
+		if !serverInfo.Reachable {
+			status = "unreachable"
+
+			if s.restarting(serverKey) {
+				status = "restarting"
+			}
+		} else {
+			total += len(serverInfo.Players)
+		}
+
+		line := fmt.Sprintf("%s**%s**: %s", s.emojiPrefix(serverKey), serverInfo.Name, status)
+
+		if thread, ok := cacheData.StatusThreads[threadKey(target.ChannelID, serverKey)]; ok {
+			line += fmt.Sprintf(" • <#%s>", thread.ThreadID)
+		}
+
+		lines = append(lines, line)
+	}
+
+	description := strings.Join(lines, "\n")
+
+	description = utils.Truncate(description, 4000)
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%d players online", total),
+		Description: description,
+		Color:       0x5865F2, // Discord blurple
+	}
+}
+
+// updateThreads updates the player list and recent activity in the thread of every server, threads
+// which don't exist (anymore) are created
+func (s *ServerStatus) updateThreads(target statusTarget, serverStatusMap map[string]*model.ServerInfo) {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load status threads from cache: %s", err))
+		return
+	}
+
+	updated := make(map[string]cache.StatusThread)
+
+	if s.threadBodies == nil {
+		s.threadBodies = make(map[string]string)
+	}
+
+	for serverKey, serverInfo := range serverStatusMap {
+		key := threadKey(target.ChannelID, serverKey)
+		thread := cacheData.StatusThreads[key]
+
+		embeds := s.buildEmbeds(map[string]*model.ServerInfo{serverKey: serverInfo})
+
+		if activity := s.activity[serverKey]; len(activity) > 0 {
+			embeds = append(embeds, &discordgo.MessageEmbed{Title: "Recent activity", Description: strings.Join(activity, "\n")})
+		}
+
+		// with dozens of servers, editing every thread on every poll would run into the rate limits. The
+		// in-game day and time change on every poll, they are updated along with the other changes only.
+
+		body, _ := json.Marshal(s.threadBody(serverKey, serverInfo, embeds))
+
+		if thread.MessageID != "" && s.threadBodies[key] == string(body) {
+			continue
+		}
+
+		next, err := s.updateThread(target.ChannelID, serverKey, thread, embeds)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to update status thread of %s: %s", serverKey, err))
+			continue
+		}
+
+		s.threadBodies[key] = string(body)
+
+		if next != thread {
+			updated[key] = next
+		}
+	}
+
+	if len(updated) == 0 {
+		return
+	}
+
+	err = s.cache.Update(func(k *cache.CacheData) {
+		if k.StatusThreads == nil {
+			k.StatusThreads = make(map[string]cache.StatusThread)
+		}
+
+		for key, thread := range updated {
+			k.StatusThreads[key] = thread
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store status threads in cache: %s", err))
+	}
+}
+
+// threadBody returns what is compared to tell if the thread must be edited, the embeds of the server without
+// its in-game day and time
+func (s *ServerStatus) threadBody(serverKey string, serverInfo *model.ServerInfo, embeds []*discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+	if serverInfo.Day == 0 && serverInfo.Time == "" {
+		return embeds
+	}
+
+	withoutTime := *serverInfo
+	withoutTime.Day = 0
+	withoutTime.Time = ""
+
+	return append(s.buildEmbeds(map[string]*model.ServerInfo{serverKey: &withoutTime}), embeds[1:]...)
+}
+
+// threadGone returns if the error tells that the thread or its message was deleted
+func threadGone(err error) bool {
+	code := discordErrorCode(err)
+
+	return code == discordgo.ErrCodeUnknownChannel || code == discordgo.ErrCodeUnknownMessage
+}
+
+// updateThread edits the message in the thread, reopening the thread if it was archived meanwhile.
+// If the thread or its message was deleted, a new thread is started.
+func (s *ServerStatus) updateThread(channelID string, serverKey string, thread cache.StatusThread, embeds []*discordgo.MessageEmbed) (cache.StatusThread, error) {
+	content := ""
+	edit := &discordgo.MessageEdit{ID: thread.MessageID, Channel: thread.ThreadID, Content: &content, Embeds: &embeds}
+
+	if thread.MessageID != "" {
+		_, err := s.out.EditMessage(edit)
+
+		if err != nil && !threadGone(err) {
+			archived := false
+
+			if _, err = s.Session.ChannelEditComplex(thread.ThreadID, &discordgo.ChannelEdit{Archived: &archived}); err == nil {
+				_, err = s.out.EditMessage(edit)
+			}
+		}
+
+		if err == nil {
+			return thread, nil
+		}
+
+		// other errors, like rate limits or outages, must not start another thread
+
+		if !threadGone(err) {
+			return thread, err
+		}
+
+		slog.Warn(fmt.Sprintf("Status thread of %s is gone, starting a new one", serverKey))
+	}
+
+	started, err := s.Session.ThreadStart(channelID, s.serverName(serverKey), discordgo.ChannelTypeGuildPublicThread, threadArchiveMinutes)
+
+	if err != nil {
+		return thread, err
+	}
+
+	msg, err := s.out.SendMessage(started.ID, &discordgo.MessageSend{Embeds: embeds})
+
+	if err != nil {
+		return thread, err
+	}
+
+	return cache.StatusThread{ThreadID: started.ID, MessageID: msg.ID}, nil
+}
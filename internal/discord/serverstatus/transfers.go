@@ -0,0 +1,72 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// transfer is a player who left a server and may show up on another one within the transfer window
+type transfer struct {
+	server string
+	since  time.Time
+}
+
+// mergeDuplicates keeps a player listed on several servers in the same poll (while transferring, the old
+// server may still list the player) on one of them only, so the diff reports a single move once the old
+// server drops the player. The player stays on the server of the previous poll, if it is one of them.
+func mergeDuplicates(current map[string]map[string]bool, previous map[string]map[string]bool) {
+	servers := make(map[string][]string)
+
+	for serverKey, players := range current {
+		for player := range players {
+			servers[player] = append(servers[player], serverKey)
+		}
+	}
+
+	for player, names := range servers {
+		if len(names) < 2 {
+			continue
+		}
+
+		sort.Strings(names)
+
+		keep := names[0]
+
+		for _, serverKey := range names {
+			if previous[serverKey][player] {
+				keep = serverKey
+				break
+			}
+		}
+
+		for _, serverKey := range names {
+			if serverKey != keep {
+				delete(current[serverKey], player)
+			}
+		}
+	}
+}
+
+// flushTransfers posts the leave messages of the players who did not show up on another server in time
+func (s *ServerStatus) flushTransfers(now time.Time) {
+	var expired []string
+
+	for player, t := range s.transfers {
+		if now.Sub(t.since) >= s.config().TransferWindow {
+			expired = append(expired, player)
+		}
+	}
+
+	sort.Strings(expired)
+
+	for _, player := range expired {
+		server := s.transfers[player].server
+		delete(s.transfers, player)
+
+		if err := s.sendNotifyMessage(server, player, false); err != nil {
+			slog.Error(fmt.Sprintf("Failed to send leave notification for player %s: %s", player, err))
+		}
+	}
+}
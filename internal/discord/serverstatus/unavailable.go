@@ -0,0 +1,77 @@
+package serverstatus
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+)
+
+// a status channel the bot can't post to is skipped for this long, instead of failing every poll
+const unavailableRetry = 10 * time.Minute
+
+// discordErrorCode returns the JSON error code of a failed discord API call, 0 for other errors
+func discordErrorCode(err error) int {
+	var restErr *discordgo.RESTError
+
+	if errors.As(err, &restErr) && restErr.Message != nil {
+		return restErr.Message.Code
+	}
+
+	return 0
+}
+
+// channelUnavailable reports whether the error means the channel is gone or the bot lacks access to it
+func channelUnavailable(err error) bool {
+	switch discordErrorCode(err) {
+	case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+		return true
+	}
+
+	return false
+}
+
+// skipUnavailable reports whether the channel is unavailable and not yet to be retried
+func (s *ServerStatus) skipUnavailable(channelID string) bool {
+	retryAt, ok := s.unavailable[channelID]
+
+	return ok && s.clock.Now().Before(retryAt)
+}
+
+// markUnavailable skips the channel until the next retry, the admins are told once
+func (s *ServerStatus) markUnavailable(channelID string, err error) {
+	if s.unavailable == nil {
+		s.unavailable = make(map[string]time.Time)
+	}
+
+	_, known := s.unavailable[channelID]
+	s.unavailable[channelID] = s.clock.Now().Add(unavailableRetry)
+
+	if known {
+		return
+	}
+
+	reason := err.Error()
+
+	var restErr *discordgo.RESTError
+
+	if errors.As(err, &restErr) && restErr.Message != nil {
+		reason = restErr.Message.Message
+	}
+
+	alerts.StatusChannelUnavailable(s.Session, s.live.Load().Admin, channelID, reason, unavailableRetry)
+}
+
+func (s *ServerStatus) markAvailable(channelID string) {
+	if _, ok := s.unavailable[channelID]; !ok {
+		return
+	}
+
+	delete(s.unavailable, channelID)
+
+	slog.Info(fmt.Sprintf("Status channel %s is available again", channelID))
+	alerts.StatusChannelAvailable(s.Session, s.live.Load().Admin, channelID)
+}
@@ -0,0 +1,165 @@
+package serverstatus
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+type uptimePeriod struct {
+	Label    string
+	Duration time.Duration
+}
+
+var uptimePeriods = map[string]uptimePeriod{
+	"7d":  {Label: "7 days", Duration: 7 * 24 * time.Hour},
+	"30d": {Label: "30 days", Duration: 30 * 24 * time.Hour},
+}
+
+func (s *ServerStatus) registerUptime(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "uptime",
+		Description: "Show the uptime of a server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "server",
+				Description: "The server",
+				Required:    true,
+				Choices:     interactions.ServerChoices(s.config().Rcon.Servers),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "period",
+				Description: "The period (default 7 days)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "7 days", Value: "7d"},
+					{Name: "30 days", Value: "30d"},
+				},
+			},
+		},
+	}, s.handleUptime)
+}
+
+func (s *ServerStatus) handleUptime(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	var serverKey string
+
+	period := "7d"
+
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "server":
+			serverKey = o.StringValue()
+		case "period":
+			period = o.StringValue()
+		}
+	}
+
+	now := s.clock.Now()
+	report := history.Uptime(serverKey, now.Add(-uptimePeriods[period].Duration), now)
+
+	if report.Covered == 0 {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("No history available for server '%s' yet.", s.serverName(serverKey)),
+		})
+		return
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       fmt.Sprintf("Uptime of %s (last %s)", s.serverName(serverKey), uptimePeriods[period].Label),
+			Description: formatUptime(report),
+			Color:       uptimeColor(report),
+		}},
+	})
+}
+
+// slaReportIfDue posts the uptime summary of all servers for the past month once a new month started
+func (s *ServerStatus) slaReportIfDue() {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load last SLA report time from cache: %s", err))
+		return
+	}
+
+	now := s.clock.Now().In(s.channelLocation(s.config().ChannelIDSla))
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	// like the archive, the first run only establishes the baseline
+
+	if cacheData.LastSlaReport.IsZero() || !cacheData.LastSlaReport.Before(monthStart) {
+		if cacheData.LastSlaReport.IsZero() {
+			s.storeLastSlaReport(now)
+		}
+
+		return
+	}
+
+	from := monthStart.AddDate(0, -1, 0)
+
+	var embeds []*discordgo.MessageEmbed
+
+	for _, server := range s.config().Rcon.Servers {
+		report := history.Uptime(server.Key, from, monthStart)
+
+		if report.Covered == 0 {
+			continue
+		}
+
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			Title:       server.Name,
+			Description: formatUptime(report),
+			Color:       uptimeColor(report),
+		})
+	}
+
+	slog.Info(fmt.Sprintf("Posting SLA report for %s", from.Format("01/2006")))
+
+	_, err = s.out.SendMessage(s.config().ChannelIDSla, &discordgo.MessageSend{
+		Content: fmt.Sprintf("## Uptime report %s", from.Format("01/2006")),
+		Embeds:  embeds,
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to post SLA report: %s", err))
+		return
+	}
+
+	s.storeLastSlaReport(now)
+}
+
+func (s *ServerStatus) storeLastSlaReport(t time.Time) {
+	err := s.cache.Update(func(k *cache.CacheData) {
+		k.LastSlaReport = t
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store last SLA report time in cache: %s", err))
+	}
+}
+
+func formatUptime(report history.UptimeReport) string {
+	longest := "-"
+
+	if report.Longest > 0 {
+		longest = utils.FormatDuration(report.Longest, utils.English)
+	}
+
+	return fmt.Sprintf("Uptime: **%.2f%%**\nOutages: %d\nLongest outage: %s\n\n-# Based on %s of recorded history",
+		report.Uptime, report.Outages, longest, utils.FormatDuration(report.Covered, utils.English))
+}
+
+func uptimeColor(report history.UptimeReport) int {
+	if report.Uptime < 99 {
+		return 0xc1121f
+	}
+
+	return 0x57F287 // Discord green
+}
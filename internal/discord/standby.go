@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/lease"
+)
+
+// standBy waits until the instance running the bot stops renewing its lease, and starts the bot then
+func (bot *DiscordBot) standBy() {
+	l := lease.Wait(bot.config().LockFile)
+
+	// reloads and shutdown wait until the bot is started
+
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	slog.Warn(fmt.Sprintf("[%s] Taking over, the other instance is gone", bot.config().Name))
+
+	bot.holdLease(l)
+
+	if err := bot.start(); err != nil {
+		slog.Error(fmt.Sprintf("Failed to start discord bot '%s': %s", bot.config().Name, err))
+		os.Exit(1)
+	}
+}
+
+// holdLease keeps the lease while the bot runs. The bot restarts once another instance took over the lease,
+// while this one was stalled: in standby mode to stand by, otherwise the restart refuses to start and exits.
+func (bot *DiscordBot) holdLease(l *lease.Lease) {
+	bot.lease = l
+
+	go func() {
+		<-l.Lost()
+
+		if cfg.Config.InstanceLock == cfg.InstanceLockStandby {
+			slog.Warn(fmt.Sprintf("[%s] Another instance took over, restarting to stand by", bot.config().Name))
+		} else {
+			slog.Error(fmt.Sprintf("[%s] Another instance took over, shutting down", bot.config().Name))
+		}
+
+		cfg.RequestRestart()
+	}()
+}
+
+// standingBy returns if the bot waits for another instance to disappear, must be called with the reload lock held
+func (bot *DiscordBot) standingBy() bool {
+	return bot.cache == nil
+}
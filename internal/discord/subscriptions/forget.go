@@ -0,0 +1,44 @@
+package subscriptions
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// registerForgetMe adds the /forgetme command, which deletes everything stored about the user
+func (s *Subscriptions) registerForgetMe(d *interactions.Dispatcher) {
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "forgetme",
+		Description: "Delete your subscriptions and settings",
+	}, s.handleForgetMe)
+}
+
+func (s *Subscriptions) handleForgetMe(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactions.UserID(i)
+
+	// the reply is still in the language chosen before
+
+	lang := s.Language(userID, utils.English)
+
+	found, err := s.cache.ForgetUser(userID)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to delete the data of user %s: %s", userID, err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "forgetme.failed")})
+		return
+	}
+
+	key := "forgetme.nothing"
+
+	if found {
+		slog.Info(fmt.Sprintf("Deleted the data of user %s", userID))
+		key = "forgetme.done"
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, key)})
+}
@@ -0,0 +1,65 @@
+package subscriptions
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// registerLanguage adds the /language command, which sets the language of the user's DMs and
+// replies. Channel messages are not affected.
+func (s *Subscriptions) registerLanguage(d *interactions.Dispatcher) {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+
+	for code, lang := range i18n.Codes {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: i18n.Name(lang), Value: code})
+	}
+
+	sort.Slice(choices, func(a, b int) bool { return choices[a].Name < choices[b].Name })
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "language",
+		Description: "Choose the language of the bot's DMs and replies to you",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "language",
+			Description: "The language",
+			Required:    true,
+			Choices:     choices,
+		}},
+	}, s.handleLanguage)
+}
+
+func (s *Subscriptions) handleLanguage(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactions.UserID(i)
+	code := i.ApplicationCommandData().Options[0].StringValue()
+
+	lang, ok := i18n.Parse(code)
+
+	if !ok {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(utils.English, "language.failed")})
+		return
+	}
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		if k.Languages == nil {
+			k.Languages = make(map[string]string)
+		}
+
+		k.Languages[userID] = code
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store language of user %s: %s", userID, err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "language.failed")})
+		return
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "language.set")})
+}
@@ -0,0 +1,366 @@
+package subscriptions
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/interactions"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// max. number of notifications waiting to be sent, more are dropped
+const notifyQueueSize = 100
+
+// Subscriptions keeps track of which user wants DMs for what, stored in the bot cache
+type Subscriptions struct {
+	cache *cache.Store
+
+	// servers of the bot, to show the names of subscribed servers
+	servers []cfg.ConfigRconServer
+
+	// notifications waiting to be sent by Run
+	queue chan notification
+}
+
+// notification is a DM to the users, queued by Notify
+type notification struct {
+	session  *discordgo.Session
+	userIDs  []string
+	fallback utils.Language
+	render   func(lang utils.Language) string
+}
+
+func New(cache *cache.Store) *Subscriptions {
+	return &Subscriptions{cache: cache, queue: make(chan notification, notifyQueueSize)}
+}
+
+// Activity returns all users subscribed to join/leave activity of the given player on the given server
+func (s *Subscriptions) Activity(server string, player string) []string {
+	return s.matching(func(sub cache.Subscription) bool {
+		return slices.Contains(sub.Servers, server) || slices.ContainsFunc(sub.Players, func(p string) bool {
+			return strings.EqualFold(p, player)
+		})
+	})
+}
+
+// Outages returns all users subscribed to outage notifications
+func (s *Subscriptions) Outages() []string {
+	return s.matching(func(sub cache.Subscription) bool { return sub.Outages })
+}
+
+// Events returns all users subscribed to event notifications
+func (s *Subscriptions) Events() []string {
+	return s.matching(func(sub cache.Subscription) bool { return sub.Events })
+}
+
+// Friends returns the followed players of all users subscribed to friends notifications
+func (s *Subscriptions) Friends() map[string][]string {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load subscriptions from cache: %s", err))
+		return nil
+	}
+
+	res := make(map[string][]string)
+
+	for userID, sub := range cacheData.Subscriptions {
+		if sub.Friends && len(sub.Players) > 1 {
+			res[userID] = sub.Players
+		}
+	}
+
+	return res
+}
+
+// Notify queues the message as DM to all given users, rendered in their language, or in the fallback
+// language for users without one. The DMs are sent by Run, so callers like the poll loop don't wait for them.
+func (s *Subscriptions) Notify(session *discordgo.Session, userIDs []string, fallback utils.Language, render func(lang utils.Language) string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	select {
+	case s.queue <- notification{session: session, userIDs: userIDs, fallback: fallback, render: render}:
+	default:
+		slog.Warn(fmt.Sprintf("Dropping DM to %d users, %d notifications are waiting to be sent", len(userIDs), notifyQueueSize))
+	}
+}
+
+// Run sends the queued notifications
+func (s *Subscriptions) Run() {
+	for n := range s.queue {
+		s.send(n)
+	}
+}
+
+func (s *Subscriptions) send(n notification) {
+	for _, userID := range n.userIDs {
+		msg := n.render(s.Language(userID, n.fallback))
+
+		channel, err := n.session.UserChannelCreate(userID)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to open DM channel to user %s: %s", userID, err))
+			continue
+		}
+
+		if err := retry.Send(output.FromDiscord(n.session), channel.ID, msg, nil); err != nil {
+			slog.Error(fmt.Sprintf("Failed to send DM to user %s: %s", userID, err))
+		}
+	}
+}
+
+// Language returns the language the user has chosen, or the fallback language
+func (s *Subscriptions) Language(userID string, fallback utils.Language) utils.Language {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load languages from cache: %s", err))
+		return fallback
+	}
+
+	if lang, ok := i18n.Parse(cacheData.Languages[userID]); ok {
+		return lang
+	}
+
+	return fallback
+}
+
+// RenamePlayer moves all subscriptions of the player to the new name
+func (s *Subscriptions) RenamePlayer(oldName string, newName string) error {
+	return s.cache.Update(func(k *cache.CacheData) {
+		for userID, sub := range k.Subscriptions {
+			idx := slices.IndexFunc(sub.Players, func(p string) bool { return strings.EqualFold(p, oldName) })
+
+			if idx < 0 {
+				continue
+			}
+
+			sub.Players = slices.Clone(sub.Players)
+			sub.Players[idx] = newName
+			k.Subscriptions[userID] = sub
+		}
+	})
+}
+
+func (s *Subscriptions) matching(fn func(cache.Subscription) bool) []string {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to load subscriptions from cache: %s", err))
+		return nil
+	}
+
+	var res []string
+
+	for userID, sub := range cacheData.Subscriptions {
+		if fn(sub) {
+			res = append(res, userID)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// Register adds the /subscribe command. Server related subscriptions are only offered if the bot
+// has a server status, event subscriptions only if it has an eventer.
+func (s *Subscriptions) Register(d *interactions.Dispatcher, bot *cfg.ConfigBot) {
+	s.registerLanguage(d)
+	s.registerForgetMe(d)
+
+	var options []*discordgo.ApplicationCommandOption
+
+	if bot.ServerStatus != nil {
+		s.servers = bot.ServerStatus.Rcon.Servers
+
+		options = append(options,
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "server",
+				Description: "Toggle DMs for players joining or leaving a server",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "server",
+					Description: "The server",
+					Required:    true,
+					Choices:     interactions.ServerChoices(bot.ServerStatus.Rcon.Servers),
+				}},
+			},
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "player",
+				Description: "Toggle DMs for a player joining or leaving any server",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "The player name",
+					Required:    true,
+				}},
+			},
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "friends",
+				Description: "Toggle one DM when two or more players you follow are on the same server",
+			},
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "outages",
+				Description: "Toggle DMs for servers going down and coming back",
+			})
+	}
+
+	if bot.Eventer != nil {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "events",
+			Description: "Toggle DMs for new events and event reminders",
+		})
+	}
+
+	if len(options) == 0 {
+		return
+	}
+
+	options = append(options, &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "list",
+		Description: "Show your subscriptions",
+	})
+
+	d.AddCommand(&discordgo.ApplicationCommand{
+		Name:        "subscribe",
+		Description: "Choose what you get DMs for",
+		Options:     options,
+	}, s.handleSubscribe)
+}
+
+func (s *Subscriptions) handleSubscribe(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactions.UserID(i)
+	lang := s.Language(userID, utils.English)
+	sub := i.ApplicationCommandData().Options[0]
+
+	if sub.Name == "list" {
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: s.describe(userID, lang)})
+		return
+	}
+
+	var what string
+	var subscribed bool
+
+	err := s.cache.Update(func(k *cache.CacheData) {
+		if k.Subscriptions == nil {
+			k.Subscriptions = make(map[string]cache.Subscription)
+		}
+
+		current := k.Subscriptions[userID]
+
+		switch sub.Name {
+		case "server":
+			what = i18n.T(lang, "subscribe.server", cfg.ServerName(s.servers, sub.Options[0].StringValue()))
+			current.Servers, subscribed = toggle(current.Servers, sub.Options[0].StringValue())
+		case "player":
+			what = i18n.T(lang, "subscribe.player", sub.Options[0].StringValue())
+			current.Players, subscribed = toggle(current.Players, sub.Options[0].StringValue())
+		case "friends":
+			what = i18n.T(lang, "subscribe.friends")
+			current.Friends = !current.Friends
+			subscribed = current.Friends
+		case "outages":
+			what = i18n.T(lang, "subscribe.outages")
+			current.Outages = !current.Outages
+			subscribed = current.Outages
+		case "events":
+			what = i18n.T(lang, "subscribe.events")
+			current.Events = !current.Events
+			subscribed = current.Events
+		}
+
+		if len(current.Servers) == 0 && len(current.Players) == 0 && !current.Outages && !current.Events && !current.Friends {
+			delete(k.Subscriptions, userID)
+		} else {
+			k.Subscriptions[userID] = current
+		}
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store subscription of user %s: %s", userID, err))
+		interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: i18n.T(lang, "subscribe.failed")})
+		return
+	}
+
+	msg := i18n.T(lang, "subscribe.off", what)
+
+	if subscribed {
+		msg = i18n.T(lang, "subscribe.on", what)
+	}
+
+	interactions.RespondEphemeral(session, i, &discordgo.InteractionResponseData{Content: msg})
+}
+
+func (s *Subscriptions) describe(userID string, lang utils.Language) string {
+	cacheData, err := s.cache.Get()
+
+	if err != nil {
+		return i18n.T(lang, "subscribe.list.failed")
+	}
+
+	sub, ok := cacheData.Subscriptions[userID]
+
+	if !ok {
+		return i18n.T(lang, "subscribe.list.none")
+	}
+
+	var lines []string
+
+	if len(sub.Servers) > 0 {
+		var servers []string
+
+		for _, key := range sub.Servers {
+			servers = append(servers, cfg.ServerName(s.servers, key))
+		}
+
+		lines = append(lines, i18n.T(lang, "subscribe.list.servers", strings.Join(servers, ", ")))
+	}
+
+	if len(sub.Players) > 0 {
+		lines = append(lines, i18n.T(lang, "subscribe.list.players", strings.Join(sub.Players, ", ")))
+	}
+
+	if sub.Friends {
+		lines = append(lines, i18n.T(lang, "subscribe.list.friends"))
+	}
+
+	if sub.Outages {
+		lines = append(lines, i18n.T(lang, "subscribe.list.outages"))
+	}
+
+	if sub.Events {
+		lines = append(lines, i18n.T(lang, "subscribe.list.events"))
+	}
+
+	return i18n.T(lang, "subscribe.list", strings.Join(lines, "\n"))
+}
+
+// toggle adds the value to the list if not yet contained, otherwise it is removed. Returns
+// the new list and whether the value is contained now.
+func toggle(list []string, value string) ([]string, bool) {
+	idx := slices.IndexFunc(list, func(v string) bool { return strings.EqualFold(v, value) })
+
+	if idx >= 0 {
+		return slices.Delete(list, idx, idx+1), false
+	}
+
+	return append(list, value), true
+}
@@ -0,0 +1,102 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/alerts"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+)
+
+// polling counts as stalled after this many poll intervals without an update
+const stallPolls = 3
+
+// superviseRcon runs the RCON poll loop and forwards its updates to the server status loop. When no
+// update arrives for stallPolls intervals, the admins are alerted and the loop is replaced by a new one.
+func (bot *DiscordBot) superviseRcon() {
+	run := rcon.Run
+
+	if cfg.Config.Simulate {
+		run = rcon.RunSimulation
+	}
+
+	polls := make(chan model.ServerUpdate, 100)
+
+	start := func() context.CancelFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			defer errorreport.Catch("rcon")
+
+			err := run(ctx, bot.config().ServerStatus.Rcon, bot.refresh, bot.pollInterval, polls, bot.rconErrors)
+
+			if err != nil {
+				slog.Error(fmt.Sprintf("Failed to start RCON connection(s): %s", err))
+				os.Exit(1)
+			}
+		}()
+
+		return cancel
+	}
+
+	cancel := start()
+	lastUpdate := bot.clock.Now()
+
+	// the poll loop is stopped if the supervisor panics, it is replaced on restart
+
+	defer func() { cancel() }()
+	stalled := false
+
+	timer := time.NewTimer(bot.stallTimeout())
+	defer timer.Stop()
+
+	for {
+		select {
+		case update := <-polls:
+			if stalled {
+				slog.Info("RCON polling recovered")
+				alerts.PollingRecovered(bot.session, bot.config().Admin)
+			}
+
+			lastUpdate = bot.clock.Now()
+			stalled = false
+			bot.rconUpdates <- update
+
+		case <-timer.C:
+			since := bot.clock.Now().Sub(lastUpdate)
+
+			slog.Warn(fmt.Sprintf("No RCON update for %s, restarting RCON polling", since.Round(time.Second)))
+
+			// alert once per stall, but keep restarting until updates arrive again
+
+			if !stalled {
+				alerts.PollingStalled(bot.session, bot.config().Admin, since)
+			}
+
+			stalled = true
+
+			cancel()
+			cancel = start()
+
+		case <-bot.rconRestart:
+			slog.Info(fmt.Sprintf("Polling %d RCON servers after config reload", len(bot.config().ServerStatus.Rcon.Servers)))
+
+			cancel()
+			cancel = start()
+		}
+
+		timer.Reset(bot.stallTimeout())
+	}
+}
+
+// stallTimeout is the time without updates after which the polling counts as stalled, the poll
+// interval can be changed at runtime
+func (bot *DiscordBot) stallTimeout() time.Duration {
+	return time.Duration(stallPolls*bot.config().ServerStatus.Rcon.QueryEverySeconds) * time.Second
+}
@@ -0,0 +1,174 @@
+package wipes
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/output"
+	"github.com/patrickjane/lazydodo-bot/internal/discord/retry"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+	"github.com/patrickjane/lazydodo-bot/internal/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/utils"
+)
+
+// how often the reminders are checked
+const checkInterval = time.Minute
+
+// reminders and actions which became due while the bot was down are skipped once they are older than this
+const gracePeriod = time.Hour
+
+// MaintenanceFunc runs the maintenance action with the given name on a server, see admin.RunMaintenance
+type MaintenanceFunc func(server string, action string) *discordgo.MessageEmbed
+
+// Wiper sends the reminders of the configured wipes and runs their maintenance action once they are due
+type Wiper struct {
+	live        *cfg.Live
+	cache       *cache.Store
+	out         output.Session
+	maintenance MaintenanceFunc
+}
+
+// New creates the wiper, maintenance may be nil without admin commands
+func New(s *discordgo.Session, live *cfg.Live, cache *cache.Store, maintenance MaintenanceFunc) *Wiper {
+	return &Wiper{live: live, cache: cache, out: output.FromDiscord(s), maintenance: maintenance}
+}
+
+func (w *Wiper) config() *cfg.ConfigServerStatus {
+	return w.live.Load().ServerStatus
+}
+
+func (w *Wiper) Run() {
+	for _, wipe := range w.config().Wipes {
+		slog.Info(fmt.Sprintf("Season '%s' ends with a wipe at %s", wipe.Name, wipe.Date.Format("02.01.2006 15:04 MST")))
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now())
+
+		<-ticker.C
+	}
+}
+
+func (w *Wiper) check(now time.Time) {
+	for _, wipe := range w.config().Wipes {
+		for _, offset := range wipe.ReminderOffsets {
+			due := wipe.Date.Add(-offset)
+
+			if now.Before(due) || !now.Before(wipe.Date) || now.Sub(due) > gracePeriod || !w.take(wipe, offset.String()) {
+				continue
+			}
+
+			lang := w.live.Load().Languages.Language("")
+			w.announce(wipe, i18n.T(lang, "wipe.reminder", wipe.Name, utils.FormatDuration(wipe.Date.Sub(now).Round(time.Minute), lang)))
+		}
+
+		if now.Before(wipe.Date) || now.Sub(wipe.Date) > gracePeriod || !w.take(wipe, "wipe") {
+			continue
+		}
+
+		w.announce(wipe, i18n.T(w.live.Load().Languages.Language(""), "wipe.now", wipe.Name))
+
+		if wipe.Action != "" {
+			w.runAction(wipe)
+		}
+	}
+}
+
+// take marks the reminder (or the action) of the wipe as done, false if it was done already
+func (w *Wiper) take(wipe cfg.ConfigWipe, what string) bool {
+	key := wipe.Name + "/" + what
+	taken := false
+
+	err := w.cache.Update(func(k *cache.CacheData) {
+		if k.Wipes == nil {
+			k.Wipes = make(map[string]time.Time)
+		}
+
+		// entries of past wipes are pruned
+
+		for key, date := range k.Wipes {
+			if time.Since(date) > 2*gracePeriod {
+				delete(k.Wipes, key)
+			}
+		}
+
+		if date, ok := k.Wipes[key]; ok && date.Equal(wipe.Date) {
+			return
+		}
+
+		k.Wipes[key] = wipe.Date
+		taken = true
+	})
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to store wipe reminder in cache: %s", err))
+		return false
+	}
+
+	return taken
+}
+
+// announce broadcasts the message to the servers of the wipe and posts it to its channel
+func (w *Wiper) announce(wipe cfg.ConfigWipe, message string) {
+	slog.Info(fmt.Sprintf("Wipe '%s': %s", wipe.Name, message))
+
+	for _, server := range w.config().Rcon.Servers {
+		if !wipe.Includes(server.Key) || !rcon.SupportsBroadcast(server) {
+			continue
+		}
+
+		if err := rcon.Broadcast(w.config().Rcon, server.Key, message); err != nil {
+			slog.Error(fmt.Sprintf("Failed to broadcast wipe reminder to %s: %s", server.Name, err))
+		}
+	}
+
+	if wipe.ChannelID == "" {
+		return
+	}
+
+	if err := retry.Send(w.out, wipe.ChannelID, message, nil); err != nil {
+		slog.Error(fmt.Sprintf("Failed to post wipe reminder: %s", err))
+	}
+}
+
+// runAction runs the maintenance action of the wipe on all its servers, and posts the results to the admin channel
+func (w *Wiper) runAction(wipe cfg.ConfigWipe) {
+	if w.maintenance == nil {
+		slog.Error(fmt.Sprintf("Cannot run action '%s' of wipe '%s' without admin commands", wipe.Action, wipe.Name))
+		return
+	}
+
+	var embeds []*discordgo.MessageEmbed
+
+	for _, server := range w.config().Rcon.Servers {
+		if wipe.Includes(server.Key) {
+			slog.Info(fmt.Sprintf("Running maintenance action '%s' on %s for wipe '%s'", wipe.Action, server.Name, wipe.Name))
+
+			embeds = append(embeds, w.maintenance(server.Key, wipe.Action))
+		}
+	}
+
+	// discord allows at most 10 embeds per message
+
+	for len(embeds) > 0 {
+		n := min(len(embeds), 10)
+
+		err := retry.SendComplex(w.out, w.live.Load().Admin.ChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("**Wipe '%s'**", wipe.Name),
+			Embeds:  embeds[:n],
+		}, nil)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Failed to post results of wipe '%s': %s", wipe.Name, err))
+		}
+
+		embeds = embeds[n:]
+	}
+}
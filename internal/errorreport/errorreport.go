@@ -0,0 +1,294 @@
+package errorreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/metrics"
+)
+
+// how long reports are waited for on panic and shutdown
+const flushTimeout = 5 * time.Second
+
+// a supervised worker which panicked is started again after this delay, so it doesn't spin
+const restartDelay = 5 * time.Second
+
+// Occurrences counts each error by subsystem, server and kind, to report only repeated ones
+type Occurrences struct {
+	sync.Mutex
+	Since    map[string]time.Time
+	Counts   map[string]int
+	Reported map[string]time.Time
+}
+
+var occurrences = &Occurrences{
+	Since:    make(map[string]time.Time),
+	Counts:   make(map[string]int),
+	Reported: make(map[string]time.Time),
+}
+
+var sentryEnabled bool
+var pending sync.WaitGroup
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Payload is the JSON posted to the webhook
+type Payload struct {
+	Subsystem string    `json:"subsystem"`
+	Server    string    `json:"server,omitempty"`
+	Message   string    `json:"message"`
+	Count     int       `json:"count,omitempty"`
+	Stack     string    `json:"stack,omitempty"`
+	Panic     bool      `json:"panic"`
+	Host      string    `json:"host"`
+	Version   string    `json:"version"`
+	Time      time.Time `json:"time"`
+}
+
+// Init configures sentry. Without error report config, nothing is reported.
+func Init() error {
+	config := cfg.Config.ErrorReports
+
+	if config == nil || config.SentryDSN == "" {
+		return nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         config.SentryDSN,
+		Environment: config.Environment,
+		Release:     "lazydodobot@" + cfg.Version,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sentryEnabled = true
+
+	return nil
+}
+
+// Flush waits for all pending reports to be sent
+func Flush() {
+	if sentryEnabled {
+		sentry.Flush(flushTimeout)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(flushTimeout):
+	}
+}
+
+// Repeated records an error of the subsystem (e.g. "rcon") and server, which may be empty. Errors are
+// counted by their kind, the error is reported once its kind occurred the configured number of times
+// within the window, and then at most once per window.
+func Repeated(subsystem string, server string, kind string, err error) {
+	config := cfg.Config.ErrorReports
+
+	if config == nil {
+		return
+	}
+
+	key := subsystem + "/" + server + "/" + kind
+	now := time.Now()
+
+	occurrences.Lock()
+
+	if now.Sub(occurrences.Since[key]) > config.Window {
+		occurrences.Since[key] = now
+		occurrences.Counts[key] = 0
+	}
+
+	occurrences.Counts[key]++
+	count := occurrences.Counts[key]
+
+	report := count >= config.Threshold && now.Sub(occurrences.Reported[key]) > config.Window
+
+	if report {
+		occurrences.Reported[key] = now
+	}
+
+	occurrences.Unlock()
+
+	if report {
+		send(Payload{Subsystem: subsystem, Server: server, Message: err.Error(), Count: count}, err)
+	}
+}
+
+// Report reports the error right away
+func Report(subsystem string, server string, err error) {
+	if cfg.Config.ErrorReports == nil {
+		return
+	}
+
+	send(Payload{Subsystem: subsystem, Server: server, Message: err.Error()}, err)
+}
+
+// Recover reports a panic along with its stack and panics again, so the process still crashes.
+// To be deferred first thing in main and in goroutines.
+func Recover(subsystem string) {
+	r := recover()
+
+	if r == nil {
+		return
+	}
+
+	if cfg.Config.ErrorReports != nil {
+		stack := string(debug.Stack())
+
+		slog.Error(fmt.Sprintf("Panic in %s: %v\n%s", subsystem, r, stack))
+
+		if sentryEnabled {
+			hub := sentry.CurrentHub().Clone()
+			hub.Scope().SetTag("subsystem", subsystem)
+			hub.Recover(r)
+		}
+
+		post(Payload{Subsystem: subsystem, Message: fmt.Sprint(r), Stack: stack, Panic: true})
+		Flush()
+	}
+
+	panic(r)
+}
+
+// Catch recovers a panic, which is logged, counted and reported, so the rest of the bot keeps running.
+// To be deferred first thing in event handlers and goroutines.
+func Catch(subsystem string) {
+	if r := recover(); r != nil {
+		caught(subsystem, r)
+	}
+}
+
+// Go runs fn in a new goroutine, recovering a panic like Catch
+func Go(subsystem string, fn func()) {
+	go func() {
+		defer Catch(subsystem)
+
+		fn()
+	}()
+}
+
+// Supervise runs the worker, and runs it again after a panic. It returns once the worker returns.
+func Supervise(subsystem string, worker func()) {
+	for {
+		if !run(subsystem, worker) {
+			return
+		}
+
+		slog.Warn(fmt.Sprintf("Restarting %s in %s", subsystem, restartDelay))
+		time.Sleep(restartDelay)
+	}
+}
+
+// run runs the worker and returns if it panicked
+func run(subsystem string, worker func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			caught(subsystem, r)
+			panicked = true
+		}
+	}()
+
+	worker()
+
+	return false
+}
+
+func caught(subsystem string, r any) {
+	stack := string(debug.Stack())
+
+	slog.Error(fmt.Sprintf("Recovered panic in %s: %v\n%s", subsystem, r, stack))
+	metrics.CountPanic(subsystem)
+
+	if cfg.Config.ErrorReports == nil {
+		return
+	}
+
+	if sentryEnabled {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetTag("subsystem", subsystem)
+		hub.Recover(r)
+	}
+
+	pending.Add(1)
+
+	go func() {
+		defer pending.Done()
+		post(Payload{Subsystem: subsystem, Message: fmt.Sprint(r), Stack: stack, Panic: true})
+	}()
+}
+
+func send(payload Payload, err error) {
+	slog.Info(fmt.Sprintf("Reporting error of %s: %s", payload.Subsystem, payload.Message))
+
+	if sentryEnabled {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag("subsystem", payload.Subsystem)
+
+			if payload.Server != "" {
+				scope.SetTag("server", payload.Server)
+			}
+
+			if payload.Count > 0 {
+				scope.SetExtra("count", payload.Count)
+			}
+
+			sentry.CaptureException(err)
+		})
+	}
+
+	pending.Add(1)
+
+	go func() {
+		defer pending.Done()
+		post(payload)
+	}()
+}
+
+// post sends the report to the webhook, if configured
+func post(payload Payload) {
+	if cfg.Config.ErrorReports.Webhook == "" {
+		return
+	}
+
+	payload.Host, _ = os.Hostname()
+	payload.Version = cfg.Version
+	payload.Time = time.Now()
+
+	dat, err := json.Marshal(payload)
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to encode error report: %s", err))
+		return
+	}
+
+	res, err := client.Post(cfg.Config.ErrorReports.Webhook, "application/json", bytes.NewReader(dat))
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to send error report: %s", err))
+		return
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		slog.Error(fmt.Sprintf("Failed to send error report: %s", res.Status))
+	}
+}
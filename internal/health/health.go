@@ -0,0 +1,136 @@
+package health
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/errorreport"
+)
+
+// how often the health of the loops is checked and the heartbeat refreshed
+const checkInterval = 15 * time.Second
+
+type Loops struct {
+	sync.Mutex
+	Deadlines map[string]time.Time
+}
+
+var loops = &Loops{Deadlines: make(map[string]time.Time)}
+
+// Beat is called by a main loop on every iteration, announcing its next beat within the given duration.
+// A loop missing its deadline is considered wedged.
+func Beat(name string, within time.Duration) {
+	loops.Lock()
+	defer loops.Unlock()
+
+	loops.Deadlines[name] = time.Now().Add(within)
+}
+
+// Forget removes the loop, once it stopped
+func Forget(name string) {
+	loops.Lock()
+	defer loops.Unlock()
+
+	delete(loops.Deadlines, name)
+}
+
+// Wedged returns the names of all loops which missed their deadline
+func Wedged() []string {
+	loops.Lock()
+	defer loops.Unlock()
+
+	var res []string
+
+	now := time.Now()
+
+	for name, deadline := range loops.Deadlines {
+		if now.After(deadline) {
+			res = append(res, name)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// Start refreshes the configured heartbeat file and keeps the health port open as long as all
+// loops are healthy. Once a loop is wedged, the file is removed and the port closed.
+func Start() {
+	go func() {
+		var listener net.Listener
+		healthy := true
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			wedged := Wedged()
+
+			if len(wedged) > 0 {
+				if healthy {
+					slog.Error(fmt.Sprintf("Unhealthy, wedged loops: %v", wedged))
+					errorreport.Report("health", "", fmt.Errorf("wedged loops: %v", wedged))
+				}
+
+				healthy = false
+
+				if cfg.Config.HeartbeatFile != "" {
+					os.Remove(cfg.Config.HeartbeatFile)
+				}
+
+				if listener != nil {
+					listener.Close()
+					listener = nil
+				}
+
+				continue
+			}
+
+			if !healthy {
+				slog.Info("Healthy again, all loops are running")
+			}
+
+			healthy = true
+
+			if cfg.Config.HeartbeatFile != "" {
+				dat := []byte(fmt.Sprintf("%d\n", time.Now().Unix()))
+
+				if err := os.WriteFile(cfg.Config.HeartbeatFile, dat, 0644); err != nil {
+					slog.Error(fmt.Sprintf("Failed to write heartbeat file: %s", err))
+				}
+			}
+
+			if cfg.Config.HealthPort != 0 && listener == nil {
+				l, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Config.HealthPort))
+
+				if err != nil {
+					slog.Error(fmt.Sprintf("Failed to open health port: %s", err))
+					continue
+				}
+
+				listener = l
+				go accept(listener)
+			}
+		}
+	}()
+}
+
+// accept closes every connection right away, a successful connect is all a health check needs
+func accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		conn.Close()
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// Check returns why a dependency of the bot isn't usable, nil if it is
+type Check func() error
+
+type Checks struct {
+	sync.Mutex
+	Readiness map[string]Check
+}
+
+var checks = &Checks{Readiness: make(map[string]Check)}
+
+// Status is the JSON body of /healthz and /readyz, with a problem (or "ok") by loop and check
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// AddReadiness registers a check which must pass for the bot to be ready, e.g. the gateway connection of a bot
+func AddReadiness(name string, check Check) {
+	checks.Lock()
+	defer checks.Unlock()
+
+	checks.Readiness[name] = check
+}
+
+// Serve serves /healthz, failing once a loop is wedged, and /readyz, which additionally fails while a
+// readiness check fails. Both answer 200 or 503 along with the status.
+func Serve() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { respond(w, liveness()) })
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) { respond(w, readiness()) })
+
+	addr := fmt.Sprintf(":%d", cfg.Config.HealthHTTPPort)
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error(fmt.Sprintf("Failed to start health endpoint: %s", err))
+		}
+	}()
+}
+
+func liveness() Status {
+	res := Status{Status: "ok", Checks: make(map[string]string)}
+
+	for _, name := range Wedged() {
+		res.Status = "unhealthy"
+		res.Checks[name] = "wedged"
+	}
+
+	return res
+}
+
+func readiness() Status {
+	res := liveness()
+
+	checks.Lock()
+	defer checks.Unlock()
+
+	names := make([]string, 0, len(checks.Readiness))
+
+	for name := range checks.Readiness {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := checks.Readiness[name](); err != nil {
+			res.Status = "unhealthy"
+			res.Checks[name] = err.Error()
+		} else {
+			res.Checks[name] = "ok"
+		}
+	}
+
+	return res
+}
+
+func respond(w http.ResponseWriter, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if status.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,589 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// Resolution is the length of one history bucket
+const Resolution = 5 * time.Minute
+
+// Retention is how long samples are kept
+const Retention = 35 * 24 * time.Hour
+
+// Sample aggregates all polls of a server within one bucket
+type Sample struct {
+	Time        time.Time `json:"t"`
+	Players     int       `json:"p"`
+	Polls       int       `json:"n"`
+	Unreachable int       `json:"d"`
+}
+
+// Session is a player's continuous presence on a server, with the resolution of the history buckets
+type Session struct {
+	Player string    `json:"name"`
+	Server string    `json:"-"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// Reachable reports whether the server was reachable for the majority of the bucket
+func (s Sample) Reachable() bool {
+	return s.Unreachable*2 < s.Polls
+}
+
+// version of the history file which has the sessions, older ones only have the samples with the names of
+// the players seen in each bucket
+const fileVersion = 2
+
+type storeFile struct {
+	Version  int                  `json:"version"`
+	Samples  map[string][]Sample  `json:"samples"`
+	Sessions map[string][]Session `json:"sessions"`
+}
+
+// legacySample is a sample of a history file before the sessions
+type legacySample struct {
+	Sample
+	Names []string `json:"names"`
+}
+
+type Store struct {
+	mu      sync.RWMutex
+	file    string
+	samples map[string][]Sample
+
+	// per server the sessions of the players ordered by start, and the index of each player's last session
+	sessions map[string][]Session
+	last     map[string]map[string]int
+}
+
+var singletonStore *Store
+
+func Init() error {
+	singletonStore = newStore(cfg.Config.HistoryPath)
+
+	return singletonStore.load()
+}
+
+func newStore(file string) *Store {
+	return &Store{file: file, samples: make(map[string][]Sample), sessions: make(map[string][]Session), last: make(map[string]map[string]int)}
+}
+
+// Record adds the result of one poll to the history.
+func Record(serverInfos map[string]*model.ServerInfo) error {
+	if singletonStore == nil {
+		return fmt.Errorf("History not initialized")
+	}
+
+	singletonStore.mu.Lock()
+	defer singletonStore.mu.Unlock()
+
+	bucket := time.Now().Truncate(Resolution)
+	rolled := false
+
+	for serverKey, serverInfo := range serverInfos {
+		samples := singletonStore.samples[serverKey]
+
+		if len(samples) == 0 || !samples[len(samples)-1].Time.Equal(bucket) {
+			samples = append(samples, Sample{Time: bucket})
+			rolled = true
+		}
+
+		cur := &samples[len(samples)-1]
+		cur.Polls++
+
+		if !serverInfo.Reachable {
+			cur.Unreachable++
+		}
+
+		if len(serverInfo.Players) > cur.Players {
+			cur.Players = len(serverInfo.Players)
+		}
+
+		for _, player := range serverInfo.Players {
+			if player.Name != "" {
+				singletonStore.see(serverKey, player.Name, bucket)
+			}
+		}
+
+		singletonStore.samples[serverKey] = samples
+	}
+
+	// only persist when a new bucket starts, a crash loses at most one bucket
+
+	if !rolled {
+		return nil
+	}
+
+	singletonStore.prune()
+
+	return singletonStore.save()
+}
+
+// see extends the player's session on the server by the bucket, or starts a new session. A session ends with
+// the first bucket the player is not seen in, a gap in the history (bot not running) ends it as well.
+func (s *Store) see(serverKey string, player string, bucket time.Time) {
+	if s.last[serverKey] == nil {
+		s.last[serverKey] = make(map[string]int)
+	}
+
+	if i, ok := s.last[serverKey][player]; ok {
+		session := &s.sessions[serverKey][i]
+
+		if !session.End.Before(bucket) {
+			session.End = maxTime(session.End, bucket.Add(Resolution))
+			return
+		}
+	}
+
+	s.last[serverKey][player] = len(s.sessions[serverKey])
+	s.sessions[serverKey] = append(s.sessions[serverKey], Session{Player: player, Start: bucket, End: bucket.Add(Resolution)})
+}
+
+// index finds the last session of each player again, after sessions were removed or reordered
+func (s *Store) index() {
+	s.last = make(map[string]map[string]int)
+
+	for serverKey, sessions := range s.sessions {
+		s.last[serverKey] = make(map[string]int)
+
+		for i, session := range sessions {
+			s.last[serverKey][session.Player] = i
+		}
+	}
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// Samples returns a copy of all samples of the given server newer than since.
+func Samples(serverKey string, since time.Time) []Sample {
+	if singletonStore == nil {
+		return nil
+	}
+
+	singletonStore.mu.RLock()
+	defer singletonStore.mu.RUnlock()
+
+	var res []Sample
+
+	for _, sample := range singletonStore.samples[serverKey] {
+		if !sample.Time.Before(since) {
+			res = append(res, sample)
+		}
+	}
+
+	return res
+}
+
+// Peak returns the maximum number of players seen on the given server since the given time.
+func Peak(serverKey string, since time.Time) int {
+	peak := 0
+
+	for _, sample := range Samples(serverKey, since) {
+		if sample.Players > peak {
+			peak = sample.Players
+		}
+	}
+
+	return peak
+}
+
+// PlayersSeen returns the sorted names of all players seen on the given servers (all servers, if none
+// are given) between from and to.
+func PlayersSeen(serverKeys []string, from time.Time, to time.Time) []string {
+	if singletonStore == nil {
+		return nil
+	}
+
+	singletonStore.mu.RLock()
+	defer singletonStore.mu.RUnlock()
+
+	seen := make(map[string]bool)
+
+	for serverKey, sessions := range singletonStore.sessions {
+		if len(serverKeys) > 0 && !slices.Contains(serverKeys, serverKey) {
+			continue
+		}
+
+		for _, session := range sessions {
+			if session.Start.Before(to) && session.End.After(from) {
+				seen[session.Player] = true
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// Sessions returns the sessions of all players on the given server (all servers, if empty) which overlap
+// from and to, ordered by start. A session ends with the first bucket the player was not seen in.
+func Sessions(serverKey string, from time.Time, to time.Time) []Session {
+	if singletonStore == nil {
+		return nil
+	}
+
+	singletonStore.mu.RLock()
+	defer singletonStore.mu.RUnlock()
+
+	var res []Session
+
+	for server, sessions := range singletonStore.sessions {
+		if serverKey != "" && server != serverKey {
+			continue
+		}
+
+		for _, session := range sessions {
+			if session.Start.Before(to) && session.End.After(from) {
+				session.Server = server
+				res = append(res, session)
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Start.Equal(res[j].Start) {
+			return res[i].Start.Before(res[j].Start)
+		}
+
+		return res[i].Player < res[j].Player
+	})
+
+	return res
+}
+
+// LastRestart returns the start of the most recent bucket in which the given server became reachable
+// again after being unreachable. Returns false if no such transition is in the history.
+func LastRestart(serverKey string) (time.Time, bool) {
+	samples := Samples(serverKey, time.Time{})
+
+	for i := len(samples) - 1; i > 0; i-- {
+		if samples[i].Reachable() && !samples[i-1].Reachable() {
+			return samples[i].Time, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// UptimeReport summarizes the reachability of a server over a period
+type UptimeReport struct {
+	Uptime  float64 // percentage of reachable buckets
+	Outages int
+	Longest time.Duration
+	Covered time.Duration // time covered by samples, buckets in which the bot did not run are not counted
+}
+
+// Uptime computes the uptime of the given server between from and to.
+func Uptime(serverKey string, from time.Time, to time.Time) UptimeReport {
+	var report UptimeReport
+	var reachable int
+	var total int
+	var current time.Duration
+
+	down := false
+
+	for _, sample := range Samples(serverKey, from) {
+		if !sample.Time.Before(to) {
+			break
+		}
+
+		total++
+
+		if sample.Reachable() {
+			reachable++
+			down = false
+			continue
+		}
+
+		if !down {
+			report.Outages++
+			current = 0
+			down = true
+		}
+
+		current += Resolution
+
+		if current > report.Longest {
+			report.Longest = current
+		}
+	}
+
+	if total > 0 {
+		report.Uptime = float64(reachable) * 100 / float64(total)
+		report.Covered = time.Duration(total) * Resolution
+	}
+
+	return report
+}
+
+// Forecast is the expected peak of a server for the rest of a day
+type Forecast struct {
+	Peak float64 // average of the peaks on the same weekday of the past weeks
+	Hour int     // hour with the most players on average
+	Days int     // number of past days the forecast is based on
+}
+
+// ForecastPeak forecasts the peak of the given server from now until the end of the day, based on the same
+// time span on the same weekday of the past weeks. Returns false if there is no history of that weekday.
+func ForecastPeak(serverKey string, now time.Time) (Forecast, bool) {
+	var forecast Forecast
+	var peaks float64
+	var sums [24]float64
+	var counts [24]int
+
+	samples := Samples(serverKey, now.Add(-Retention))
+
+	for week := 1; time.Duration(week)*7*24*time.Hour < Retention; week++ {
+		from := now.AddDate(0, 0, -7*week)
+		to := time.Date(from.Year(), from.Month(), from.Day()+1, 0, 0, 0, 0, now.Location())
+
+		peak := -1
+
+		for _, sample := range samples {
+			if sample.Time.Before(from) || !sample.Time.Before(to) || !sample.Reachable() {
+				continue
+			}
+
+			peak = max(peak, sample.Players)
+
+			hour := sample.Time.In(now.Location()).Hour()
+			sums[hour] += float64(sample.Players)
+			counts[hour]++
+		}
+
+		if peak >= 0 {
+			peaks += float64(peak)
+			forecast.Days++
+		}
+	}
+
+	if forecast.Days == 0 {
+		return forecast, false
+	}
+
+	forecast.Peak = peaks / float64(forecast.Days)
+	busiest := -1.0
+
+	for hour := range 24 {
+		if counts[hour] > 0 && sums[hour]/float64(counts[hour]) > busiest {
+			busiest = sums[hour] / float64(counts[hour])
+			forecast.Hour = hour
+		}
+	}
+
+	return forecast, true
+}
+
+func (s *Store) prune() {
+	cutoff := time.Now().Add(-Retention)
+
+	for serverKey, samples := range s.samples {
+		i := 0
+
+		for i < len(samples) && samples[i].Time.Before(cutoff) {
+			i++
+		}
+
+		s.samples[serverKey] = samples[i:]
+	}
+
+	for serverKey, sessions := range s.sessions {
+		s.sessions[serverKey] = slices.DeleteFunc(sessions, func(session Session) bool { return session.End.Before(cutoff) })
+	}
+
+	s.index()
+}
+
+func (s *Store) save() error {
+	tmp := s.file + ".tmp"
+	f, err := os.Create(tmp)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(f).Encode(storeFile{Version: fileVersion, Samples: s.samples, Sessions: s.sessions}); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.file)
+}
+
+func (s *Store) load() error {
+	f, err := os.Open(s.file)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer f.Close()
+
+	var raw map[string]json.RawMessage
+
+	if err := json.NewDecoder(f).Decode(&raw); err != nil {
+		return err
+	}
+
+	var version int
+
+	if json.Unmarshal(raw["version"], &version) == nil && version == fileVersion {
+		var stored storeFile
+
+		if err := remarshal(raw, &stored); err != nil {
+			return err
+		}
+
+		for serverKey, samples := range stored.Samples {
+			s.samples[serverKey] = samples
+		}
+
+		for serverKey, sessions := range stored.Sessions {
+			s.sessions[serverKey] = sessions
+		}
+
+		s.index()
+
+		return nil
+	}
+
+	// older files are keyed by server, the sessions are made up from the names of each bucket
+
+	var legacy map[string][]legacySample
+
+	if err := remarshal(raw, &legacy); err != nil {
+		return err
+	}
+
+	for serverKey, samples := range legacy {
+		for _, sample := range samples {
+			s.samples[serverKey] = append(s.samples[serverKey], sample.Sample)
+
+			for _, name := range sample.Names {
+				s.see(serverKey, name, sample.Time)
+			}
+		}
+	}
+
+	return nil
+}
+
+func remarshal(raw map[string]json.RawMessage, v any) error {
+	dat, err := json.Marshal(raw)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(dat, v)
+}
+
+// MigrateServer moves the samples of the server with the key from to the server with the key to, samples
+// of the same bucket are combined. It returns the number of samples moved.
+func MigrateServer(from string, to string) (int, error) {
+	if singletonStore == nil {
+		return 0, fmt.Errorf("History not initialized")
+	}
+
+	singletonStore.mu.Lock()
+	defer singletonStore.mu.Unlock()
+
+	moved := singletonStore.samples[from]
+
+	if len(moved) == 0 {
+		return 0, nil
+	}
+
+	times := make(map[time.Time]bool)
+
+	for _, sample := range moved {
+		times[sample.Time] = true
+	}
+
+	singletonStore.samples[to] = mergeSamples(singletonStore.samples[to], moved)
+	delete(singletonStore.samples, from)
+
+	singletonStore.sessions[to] = mergeSessions(singletonStore.sessions[to], singletonStore.sessions[from])
+	delete(singletonStore.sessions, from)
+	singletonStore.index()
+	singletonStore.recount(to, times)
+
+	return len(moved), singletonStore.save()
+}
+
+// recount sets the player counts of the server's samples in the buckets to the distinct players of the
+// sessions overlapping them, e.g. a player in both of the merged histories is counted once. The counts are
+// never lowered, they include the players without a name and the forgotten ones.
+func (s *Store) recount(serverKey string, buckets map[time.Time]bool) {
+	players := make(map[time.Time]map[string]bool)
+
+	for _, session := range s.sessions[serverKey] {
+		for t := session.Start.Truncate(Resolution); t.Before(session.End); t = t.Add(Resolution) {
+			if !buckets[t] {
+				continue
+			}
+
+			if players[t] == nil {
+				players[t] = make(map[string]bool)
+			}
+
+			players[t][session.Player] = true
+		}
+	}
+
+	for i := range s.samples[serverKey] {
+		sample := &s.samples[serverKey][i]
+		sample.Players = max(sample.Players, len(players[sample.Time]))
+	}
+}
+
+// ForgetPlayer removes the sessions of the player with any of the names. The player counts are kept. It
+// returns the number of sessions removed.
+func ForgetPlayer(names []string) (int, error) {
+	if singletonStore == nil {
+		return 0, fmt.Errorf("History not initialized")
+	}
+
+	singletonStore.mu.Lock()
+	defer singletonStore.mu.Unlock()
+
+	removed := 0
+
+	for serverKey, sessions := range singletonStore.sessions {
+		n := len(sessions)
+		singletonStore.sessions[serverKey] = slices.DeleteFunc(sessions, func(session Session) bool { return slices.Contains(names, session.Player) })
+		removed += n - len(singletonStore.sessions[serverKey])
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	singletonStore.index()
+
+	return removed, singletonStore.save()
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+func TestSessions(t *testing.T) {
+	singletonStore = newStore(filepath.Join(t.TempDir(), "history.json"))
+	t.Cleanup(func() { singletonStore = nil })
+
+	start := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+
+	// alice is online for three buckets, bob for one, then again after a gap
+
+	for n, names := range [][]string{{"Alice", "Bob"}, {"Alice"}, {"Alice", "Bob"}} {
+		for _, name := range names {
+			singletonStore.see("island", name, start.Add(time.Duration(n)*Resolution))
+		}
+	}
+
+	sessions := Sessions("", start, start.Add(time.Hour))
+
+	want := []Session{
+		{Player: "Alice", Server: "island", Start: start, End: start.Add(3 * Resolution)},
+		{Player: "Bob", Server: "island", Start: start, End: start.Add(Resolution)},
+		{Player: "Bob", Server: "island", Start: start.Add(2 * Resolution), End: start.Add(3 * Resolution)},
+	}
+
+	if len(sessions) != len(want) {
+		t.Fatalf("expected %d sessions, got %v", len(want), sessions)
+	}
+
+	for n := range want {
+		if sessions[n] != want[n] {
+			t.Errorf("session %d is %v, want %v", n, sessions[n], want[n])
+		}
+	}
+
+	if seen := PlayersSeen(nil, start.Add(Resolution), start.Add(2*Resolution)); len(seen) != 1 || seen[0] != "Alice" {
+		t.Errorf("players seen in the second bucket: %v", seen)
+	}
+}
+
+func TestLoadLegacyHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.json")
+
+	legacy := `{"island": [
+		{"t": "2026-10-14T20:00:00Z", "p": 1, "n": 5, "d": 0, "names": ["Alice"]},
+		{"t": "2026-10-14T20:05:00Z", "p": 1, "n": 5, "d": 0, "names": ["Alice"]}]}`
+
+	if err := os.WriteFile(file, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newStore(file)
+
+	if err := store.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+
+	if sessions := store.sessions["island"]; len(sessions) != 1 || !sessions[0].Start.Equal(start) || !sessions[0].End.Equal(start.Add(2*Resolution)) {
+		t.Fatalf("legacy names became the sessions %v", sessions)
+	}
+
+	// the new format keeps them
+
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newStore(file)
+
+	if err := reloaded.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reloaded.samples["island"]) != 2 || len(reloaded.sessions["island"]) != 1 {
+		t.Errorf("reloaded %d samples and %d sessions", len(reloaded.samples["island"]), len(reloaded.sessions["island"]))
+	}
+}
+
+func TestImportTranslatedJoinLeave(t *testing.T) {
+	singletonStore = newStore(filepath.Join(t.TempDir(), "history.json"))
+	t.Cleanup(func() { singletonStore = nil })
+
+	start := time.Now().Add(-time.Hour).Truncate(Resolution)
+	at := func(d time.Duration) string { return start.Add(d).Format("2006-01-02 15:04:05Z07:00") }
+
+	log := strings.Join([]string{
+		at(0) + " [The Island] Alice joined the server",
+		at(time.Minute) + " 🦖 [The Island] Bob ist dem Server beigetreten",
+		at(6*time.Minute) + " [The Island -> The Center] Bob hat den Server gewechselt",
+		at(11*time.Minute) + " [The Center] Carol joined the server",
+		at(12*time.Minute) + " [The Center] Bob hat den Server verlassen",
+		at(12*time.Minute) + " [The Center] Carol left the server",
+		at(12*time.Minute) + " [The Island] Alice left the server",
+	}, "\n")
+
+	servers := []cfg.ConfigRconServer{{Key: "island", Name: "The Island"}, {Key: "center", Name: "The Center"}}
+
+	res, err := Import(strings.NewReader(log), ImportJoinLeave, "", servers)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Events != 8 || res.Sessions != 4 {
+		t.Fatalf("imported %d events and %d sessions, want 8 and 4", res.Events, res.Sessions)
+	}
+
+	// the merged buckets count the distinct players of both servers, bob once where he moved
+
+	if _, err := MigrateServer("center", "island"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{2, 2, 3}
+
+	if samples := singletonStore.samples["island"]; len(samples) != len(want) {
+		t.Fatalf("expected %d samples, got %v", len(want), samples)
+	}
+
+	for n, sample := range singletonStore.samples["island"] {
+		if sample.Players != want[n] {
+			t.Errorf("%d players at %s, want %d", sample.Players, sample.Time, want[n])
+		}
+	}
+}
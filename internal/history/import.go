@@ -0,0 +1,354 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
+)
+
+// formats of the logs which can be imported
+const ImportJoinLeave = "joinleave"
+const ImportArk = "ark"
+
+var ImportFormats = []string{ImportJoinLeave, ImportArk}
+
+var (
+	// join/leave messages of the bot with a leading time, e.g. "2024-01-15 20:11:03 [The Island] Rexy joined the server"
+	reImportTime = regexp.MustCompile(`^\[?(\d{4})[-/.](\d{2})[-/.](\d{2})[ T_-](\d{2})[:.](\d{2})[:.](\d{2})(\S*?)\]?\s+(.+)$`)
+
+	// the messages are posted in the language of the guild, so all translations are recognized
+	reImportJoined = importPatterns("status.joined")
+	reImportLeft   = importPatterns("status.left")
+	reImportMoved  = importPatterns("status.moved")
+
+	// ShooterGame.log of ARK, e.g. "[2024.01.15-19.11.03:123][  0]2024.01.15_20.11.03: Rexy joined this ARK!",
+	// ASA adds the platform ID after the name
+	reImportArk = regexp.MustCompile(`(\d{4})\.(\d{2})\.(\d{2})_(\d{2})\.(\d{2})\.(\d{2}): (.+?)(?: \[UniqueNetId:[^\]]*\])? (joined|left) this ARK!`)
+)
+
+// ImportResult counts what an import found and added
+type ImportResult struct {
+	Lines    int
+	Events   int
+	Sessions int
+	Samples  int
+
+	// sessions older than the retention, which would be pruned right away
+	Expired int
+}
+
+type importEvent struct {
+	time   time.Time
+	server string
+	player string
+	joined bool
+}
+
+// Import reads the joins and leaves of the log and adds the resulting sessions to the history. Join/leave
+// messages name their server, the lines of a server log belong to the server with the given key. Players
+// which didn't leave until the end of the log are assumed to have left with its last line.
+func Import(r io.Reader, format string, serverKey string, servers []cfg.ConfigRconServer) (ImportResult, error) {
+	var res ImportResult
+
+	if singletonStore == nil {
+		return res, fmt.Errorf("History not initialized")
+	}
+
+	if format == ImportArk && serverKey == "" {
+		return res, fmt.Errorf("the key of the server is required for server logs")
+	}
+
+	var events []importEvent
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		res.Lines++
+
+		switch format {
+		case ImportJoinLeave:
+			events = append(events, parseJoinLeave(scanner.Text(), servers)...)
+		case ImportArk:
+			if e, ok := parseArk(scanner.Text(), serverKey); ok {
+				events = append(events, e)
+			}
+		default:
+			return res, fmt.Errorf("unknown format '%s' (expected one of %s)", format, strings.Join(ImportFormats, ", "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return res, err
+	}
+
+	res.Events = len(events)
+
+	if len(events) == 0 {
+		return res, nil
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].time.Before(events[j].time) })
+
+	cutoff := time.Now().Add(-Retention)
+
+	// per server the buckets of the sessions, the player counts of their samples are taken from the sessions
+	buckets := make(map[string]map[time.Time]bool)
+	sessions := make(map[string][]Session)
+
+	addSession := func(server string, player string, start time.Time, end time.Time) {
+		if end.Before(cutoff) {
+			res.Expired++
+			return
+		}
+
+		res.Sessions++
+
+		if buckets[server] == nil {
+			buckets[server] = make(map[time.Time]bool)
+		}
+
+		session := Session{Player: player, Start: start.Truncate(Resolution)}
+
+		for t := session.Start; t.Before(end) || t.Equal(session.Start); t = t.Add(Resolution) {
+			session.End = t.Add(Resolution)
+
+			if !t.Before(cutoff) {
+				buckets[server][t] = true
+			}
+		}
+
+		sessions[server] = append(sessions[server], session)
+	}
+
+	// a join without a leave (e.g. a server crash) is ignored until the player leaves
+
+	open := make(map[[2]string]time.Time)
+
+	for _, e := range events {
+		key := [2]string{e.server, e.player}
+		start, ok := open[key]
+
+		switch {
+		case e.joined && !ok:
+			open[key] = e.time
+		case !e.joined && ok:
+			addSession(e.server, e.player, start, e.time)
+			delete(open, key)
+		}
+	}
+
+	last := events[len(events)-1].time
+
+	for key, start := range open {
+		addSession(key[0], key[1], start, last)
+	}
+
+	singletonStore.mu.Lock()
+	defer singletonStore.mu.Unlock()
+
+	for server, imported := range sessions {
+		singletonStore.sessions[server] = mergeSessions(singletonStore.sessions[server], imported)
+	}
+
+	for server, times := range buckets {
+		var imported []Sample
+
+		for t := range times {
+			imported = append(imported, Sample{Time: t, Polls: 1})
+		}
+
+		res.Samples += len(imported)
+		singletonStore.samples[server] = mergeSamples(singletonStore.samples[server], imported)
+		singletonStore.recount(server, times)
+	}
+
+	singletonStore.index()
+
+	return res, singletonStore.save()
+}
+
+func parseJoinLeave(line string, servers []cfg.ConfigRconServer) []importEvent {
+	match := reImportTime.FindStringSubmatch(strings.TrimSpace(line))
+
+	if match == nil {
+		return nil
+	}
+
+	t, ok := importTime(match[1:7], match[7])
+
+	if !ok {
+		return nil
+	}
+
+	for _, joined := range []bool{true, false} {
+		patterns := reImportLeft
+
+		if joined {
+			patterns = reImportJoined
+		}
+
+		if m := matchAny(patterns, match[8]); m != nil {
+			server, ok := serverNamed(servers, m[1])
+
+			if !ok {
+				return nil
+			}
+
+			return []importEvent{{time: t, server: server, player: m[2], joined: joined}}
+		}
+	}
+
+	if m := matchAny(reImportMoved, match[8]); m != nil {
+		from, ok := serverNamed(servers, m[1])
+		to, ok2 := serverNamed(servers, m[2])
+
+		if !ok || !ok2 {
+			return nil
+		}
+
+		return []importEvent{{time: t, server: from, player: m[3]}, {time: t, server: to, player: m[3], joined: true}}
+	}
+
+	return nil
+}
+
+// importPatterns returns a pattern for each translation of the join/leave message, with the arguments of
+// the message as groups. The lines may start with the emoji of the server.
+func importPatterns(key string) []*regexp.Regexp {
+	var res []*regexp.Regexp
+
+	for _, format := range i18n.Translations(key) {
+		parts := strings.Split(format, "%s")
+
+		for n := range parts {
+			parts[n] = regexp.QuoteMeta(parts[n])
+		}
+
+		res = append(res, regexp.MustCompile(`^(?:\S+ )?`+strings.Join(parts, `(.+?)`)+`$`))
+	}
+
+	return res
+}
+
+// matchAny returns the groups of the first pattern matching the text, nil if none does
+func matchAny(patterns []*regexp.Regexp, text string) []string {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(text); m != nil {
+			return m
+		}
+	}
+
+	return nil
+}
+
+func parseArk(line string, serverKey string) (importEvent, bool) {
+	match := reImportArk.FindStringSubmatch(line)
+
+	if match == nil {
+		return importEvent{}, false
+	}
+
+	t, ok := importTime(match[1:7], "")
+
+	return importEvent{time: t, server: serverKey, player: match[7], joined: match[8] == "joined"}, ok
+}
+
+// importTime returns the time of the date and time fields, in the local time zone unless the suffix
+// (e.g. ".123+01:00" or "Z" of RFC 3339) has a zone
+func importTime(fields []string, suffix string) (time.Time, bool) {
+	var n [6]int
+
+	for i, f := range fields {
+		v, err := strconv.Atoi(f)
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		n[i] = v
+	}
+
+	loc := time.Local
+	suffix = strings.TrimLeft(suffix, ".,0123456789")
+
+	if suffix != "" {
+		zone, err := time.Parse("Z07:00", suffix)
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		loc = zone.Location()
+	}
+
+	return time.Date(n[0], time.Month(n[1]), n[2], n[3], n[4], n[5], 0, loc), true
+}
+
+// serverNamed returns the key of the server with the given name (or key), as shown in join/leave messages
+func serverNamed(servers []cfg.ConfigRconServer, name string) (string, bool) {
+	for _, server := range servers {
+		if server.Name == name || server.Key == name {
+			return server.Key, true
+		}
+	}
+
+	return "", false
+}
+
+// mergeSamples returns the samples of both, ordered by time. Samples of the same bucket are combined,
+// keeping the higher player count until recount takes it from the merged sessions.
+func mergeSamples(a []Sample, b []Sample) []Sample {
+	buckets := make(map[time.Time]int)
+	var merged []Sample
+
+	for _, sample := range append(slices.Clone(a), b...) {
+		i, ok := buckets[sample.Time]
+
+		if !ok {
+			buckets[sample.Time] = len(merged)
+			merged = append(merged, sample)
+			continue
+		}
+
+		cur := &merged[i]
+
+		cur.Players = max(cur.Players, sample.Players)
+		cur.Polls += sample.Polls
+		cur.Unreachable += sample.Unreachable
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+
+	return merged
+}
+
+// mergeSessions returns the sessions of both, ordered by start. Overlapping sessions of a player are combined.
+func mergeSessions(a []Session, b []Session) []Session {
+	sessions := append(slices.Clone(a), b...)
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].Start.Before(sessions[j].Start) })
+
+	var merged []Session
+	last := make(map[string]int)
+
+	for _, session := range sessions {
+		if i, ok := last[session.Player]; ok && !merged[i].End.Before(session.Start) {
+			merged[i].End = maxTime(merged[i].End, session.End)
+			continue
+		}
+
+		last[session.Player] = len(merged)
+		merged = append(merged, session)
+	}
+
+	return merged
+}
@@ -0,0 +1,217 @@
+package i18n
+
+type entry = map[Language]string
+
+// catalog maps message keys to their translations, as format strings for T.
+//
+// Slash commands are keyed by their path, e.g. "command.config.set.value", with a ".name" or
+// ".description" suffix and choices by their value. The english texts are part of the command
+// definitions, so only other languages are listed for commands.
+var catalog = map[string]entry{
+	"duration.week.one":     {English: "%d week", German: "%d Woche", French: "%d semaine", Spanish: "%d semana", Dutch: "%d week"},
+	"duration.week.other":   {English: "%d weeks", German: "%d Wochen", French: "%d semaines", Spanish: "%d semanas", Dutch: "%d weken"},
+	"duration.week.short":   {English: "%dw", German: "%dW", French: "%dsem", Spanish: "%dsem"},
+	"duration.day.one":      {English: "%d day", German: "%d Tag", French: "%d jour", Spanish: "%d día", Dutch: "%d dag"},
+	"duration.day.other":    {English: "%d days", German: "%d Tage", French: "%d jours", Spanish: "%d días", Dutch: "%d dagen"},
+	"duration.day.short":    {English: "%dd", German: "%dT", French: "%dj"},
+	"duration.hour.one":     {English: "%d hour", German: "%d Stunde", French: "%d heure", Spanish: "%d hora", Dutch: "%d uur"},
+	"duration.hour.other":   {English: "%d hours", German: "%d Stunden", French: "%d heures", Spanish: "%d horas", Dutch: "%d uur"},
+	"duration.hour.short":   {English: "%dh"},
+	"duration.minute.one":   {English: "%d minute", German: "%d Minute", French: "%d minute", Spanish: "%d minuto", Dutch: "%d minuut"},
+	"duration.minute.other": {English: "%d minutes", German: "%d Minuten", French: "%d minutes", Spanish: "%d minutos", Dutch: "%d minuten"},
+	"duration.minute.short": {English: "%dm"},
+	"duration.second.one":   {English: "%d second", German: "%d Sekunde", French: "%d seconde", Spanish: "%d segundo", Dutch: "%d seconde"},
+	"duration.second.other": {English: "%d seconds", German: "%d Sekunden", French: "%d secondes", Spanish: "%d segundos", Dutch: "%d seconden"},
+	"duration.second.short": {English: "%ds"},
+
+	"language": {English: "English", German: "Deutsch"},
+
+	"language.set":    {English: "I will reply to you in English from now on.", German: "Ich antworte dir ab jetzt auf Deutsch."},
+	"language.failed": {English: "Failed to store your language.", German: "Deine Sprache konnte nicht gespeichert werden."},
+
+	"forgetme.done": {English: "I deleted your subscriptions and settings. What is stored about your in-game players can be deleted by an admin.",
+		German: "Ich habe deine Abos und Einstellungen gelöscht. Was über deine Spieler im Spiel gespeichert ist, kann ein Admin löschen."},
+	"forgetme.nothing": {English: "Nothing is stored about you. What is stored about your in-game players can be deleted by an admin.",
+		German: "Über dich ist nichts gespeichert. Was über deine Spieler im Spiel gespeichert ist, kann ein Admin löschen."},
+	"forgetme.failed": {English: "Failed to delete your data, please try again later.", German: "Deine Daten konnten nicht gelöscht werden, bitte versuche es später noch einmal."},
+
+	"help.title":   {English: "Commands", German: "Befehle"},
+	"admin.denied": {English: "You are not allowed to use this command.", German: "Du darfst diesen Befehl nicht verwenden."},
+
+	"cooldown.wait": {English: "Please try again in %s.", German: "Bitte versuche es in %s noch einmal."},
+	"cooldown.busy": {English: "I'm busy right now, please try again in a moment.", German: "Ich bin gerade beschäftigt, bitte versuche es gleich noch einmal."},
+
+	"event.starts_now":     {English: "Event '%s' starts NOW!", German: "Event '%s' startet JETZT!"},
+	"event.started":        {English: "Event '%s' started at %s!", German: "Event '%s' hat um %s begonnen!"},
+	"event.starts_at":      {English: "Event '%s' starts on %s at %s! (in %s)", German: "Event '%s' startet am %s um %s! (in %s)"},
+	"event.location":       {English: "**Location: %s**\n", German: "**Ort: %s**\n"},
+	"event.created":        {English: "**New event created**", German: "**Neues Event wurde erstellt**"},
+	"event.cancelled":      {English: "**Event CANCELLED**", German: "**Event wurde GECANCELT**"},
+	"event.digest":         {English: "**%d new events created**", German: "**%d neue Events wurden erstellt**"},
+	"event.rich.start":     {English: "Start", German: "Beginn"},
+	"event.rich.duration":  {English: "Duration", German: "Dauer"},
+	"event.rich.location":  {English: "Location", German: "Ort"},
+	"event.voice.started":  {English: "**Event '%s' has started!**\n\nJoin <#%s>", German: "**Event '%s' hat begonnen!**\n\nKomm in <#%s>"},
+	"event.voice.ended":    {English: "**Event '%s' has ended**\n\nAttendees (%d): %s", German: "**Event '%s' ist beendet**\n\nTeilnehmer (%d): %s"},
+	"event.voice.nobody":   {English: "**Event '%s' has ended**\n\nNo attendees.", German: "**Event '%s' ist beendet**\n\nKeine Teilnehmer."},
+	"event.cancelled.body": {English: "Event '%s - %s' was cancelled.", German: "Event '%s - %s' wurde gecancelt."},
+	"event.snoozed": {English: "Alright, I will remind you of '%s' via DM in 10 minutes.",
+		German: "Alles klar, ich erinnere dich in 10 Minuten per DM an '%s'."},
+	"event.recap":               {English: "**Recap of event '%s'**", German: "**Rückblick auf Event '%s'**"},
+	"event.recap.interested":    {English: "Interested (%d): %s", German: "Interessiert (%d): %s"},
+	"event.recap.players":       {English: "Seen in game (%d): %s", German: "Im Spiel gesehen (%d): %s"},
+	"event.recap.more":          {English: "%s and %d more", German: "%s und %d weitere"},
+	"event.game.next":           {English: "Next event: '%s' on %s at %s (in %s)", German: "Nächstes Event: '%s' am %s um %s (in %s)"},
+	"event.game.location":       {English: "on %s", German: "auf %s"},
+	"event.game.none":           {English: "No events are planned.", German: "Es sind keine Events geplant."},
+	"event.history.title":       {English: "Events in %s", German: "Events im %s"},
+	"event.history.none":        {English: "There were no events in %s.", German: "Im %s gab es keine Events."},
+	"event.history.invalid":     {English: "'%s' is not a month like 2025-06.", German: "'%s' ist kein Monat wie 2025-06."},
+	"event.history.guild":       {English: "This command only works in a server.", German: "Dieser Befehl funktioniert nur auf einem Server."},
+	"event.history.interested":  {English: "%d interested", German: "%d interessiert"},
+	"event.history.players":     {English: "%d in game", German: "%d im Spiel"},
+	"event.history.total":       {English: "%d events, %d interested", German: "%d Events, %d interessiert"},
+	"event.history.more":        {English: "... and %d more", German: "... und %d weitere"},
+	"event.recap.nobody":        {English: "nobody", German: "niemand"},
+	"event.trigger.usage":       {English: "Usage: %s <name> [weekday|today|tomorrow|dd.mm.] <HH:MM>", German: "Verwendung: %s <Name> [Wochentag|heute|morgen|TT.MM.] <HH:MM>"},
+	"event.trigger.created":     {English: "Event '%s' created for %s at %s", German: "Event '%s' für den %s um %s erstellt"},
+	"event.trigger.failed":      {English: "Failed to create event '%s'", German: "Event '%s' konnte nicht erstellt werden"},
+	"event.trigger.description": {English: "Created in game by %s", German: "Im Spiel erstellt von %s"},
+	"event.gone":                {English: "The event no longer takes place.", German: "Das Event findet nicht mehr statt."},
+
+	"status.joined":       {English: "[%s] %s joined the server", German: "[%s] %s ist dem Server beigetreten"},
+	"status.left":         {English: "[%s] %s left the server", German: "[%s] %s hat den Server verlassen"},
+	"status.moved":        {English: "[%s -> %s] %s moved servers", German: "[%s -> %s] %s hat den Server gewechselt"},
+	"status.friends":      {English: "%s are on %s right now", German: "%s sind gerade auf %s"},
+	"status.friends.and":  {English: "%s and %s", German: "%s und %s"},
+	"status.game.players": {English: "%d players online: %s", German: "%d Spieler online: %s"},
+	"status.game.offline": {English: "%s offline", German: "%s offline"},
+	"status.renamed":      {English: "[%s] %s is now known as %s", German: "[%s] %s heißt jetzt %s"},
+	"status.unreachable":  {English: "[%s] Server is unreachable", German: "[%s] Server ist nicht erreichbar"},
+	"status.reachable":    {English: "[%s] Server is reachable again", German: "[%s] Server ist wieder erreichbar"},
+
+	"wipe.reminder": {English: "%s ends in %s, the servers will be wiped.", German: "%s endet in %s, die Server werden dann zurückgesetzt."},
+	"wipe.now":      {English: "%s is over, the servers are wiped now.", German: "%s ist vorbei, die Server werden jetzt zurückgesetzt."},
+
+	"subscribe.on":           {English: "You will now get DMs for %s.", German: "Du bekommst jetzt DMs für %s."},
+	"subscribe.off":          {English: "You will no longer get DMs for %s.", German: "Du bekommst keine DMs mehr für %s."},
+	"subscribe.failed":       {English: "Failed to store your subscription.", German: "Dein Abonnement konnte nicht gespeichert werden."},
+	"subscribe.server":       {English: "activity on %s", German: "Aktivität auf %s"},
+	"subscribe.player":       {English: "activity of %s", German: "Aktivität von %s"},
+	"subscribe.outages":      {English: "server outages", German: "Serverausfälle"},
+	"subscribe.events":       {English: "events", German: "Events"},
+	"subscribe.friends":      {English: "players you follow being on the same server", German: "gefolgte Spieler auf demselben Server"},
+	"subscribe.list":         {English: "You get DMs for:\n%s", German: "Du bekommst DMs für:\n%s"},
+	"subscribe.list.none":    {English: "You have no subscriptions.", German: "Du hast keine Abonnements."},
+	"subscribe.list.failed":  {English: "Failed to load your subscriptions.", German: "Deine Abonnements konnten nicht geladen werden."},
+	"subscribe.list.servers": {English: "- Servers: %s", German: "- Server: %s"},
+	"subscribe.list.players": {English: "- Players: %s", German: "- Spieler: %s"},
+	"subscribe.list.outages": {English: "- Server outages", German: "- Serverausfälle"},
+	"subscribe.list.events":  {English: "- Events", German: "- Events"},
+	"subscribe.list.friends": {English: "- Players you follow being on the same server", German: "- Gefolgte Spieler auf demselben Server"},
+
+	"command.language.name":                 {German: "sprache"},
+	"command.language.description":          {German: "Wähle die Sprache der DMs und Antworten des Bots"},
+	"command.language.language.name":        {German: "sprache"},
+	"command.language.language.description": {German: "Die Sprache"},
+
+	"command.help.name":        {German: "hilfe"},
+	"command.help.description": {German: "Zeigt die verfügbaren Befehle"},
+
+	"command.subscribe.name":                      {German: "abonnieren"},
+	"command.subscribe.description":               {German: "Wähle, wofür du DMs bekommst"},
+	"command.subscribe.server.description":        {German: "DMs, wenn Spieler einem Server beitreten oder ihn verlassen"},
+	"command.subscribe.server.server.description": {German: "Der Server"},
+	"command.subscribe.player.name":               {German: "spieler"},
+	"command.subscribe.player.description":        {German: "DMs, wenn ein Spieler einem Server beitritt oder ihn verlässt"},
+	"command.subscribe.player.name.description":   {German: "Der Spielername"},
+	"command.subscribe.outages.name":              {German: "ausfälle"},
+	"command.subscribe.outages.description":       {German: "DMs, wenn Server ausfallen und wieder erreichbar sind"},
+	"command.subscribe.events.description":        {German: "DMs für neue Events und Event-Reminder"},
+	"command.subscribe.friends.name":              {German: "freunde"},
+	"command.subscribe.friends.description":       {German: "Eine DM, wenn mehrere gefolgte Spieler auf demselben Server sind"},
+	"command.subscribe.list.name":                 {German: "liste"},
+	"command.subscribe.list.description":          {German: "Zeigt deine Abonnements"},
+
+	"command.serverinfo.description":        {German: "Zeigt detaillierte Informationen zu einem Server"},
+	"command.serverinfo.server.description": {German: "Der Server"},
+
+	"command.uptime.name":                {German: "verfügbarkeit"},
+	"command.uptime.description":         {German: "Zeigt die Verfügbarkeit eines Servers"},
+	"command.uptime.server.description":  {German: "Der Server"},
+	"command.uptime.period.name":         {German: "zeitraum"},
+	"command.uptime.period.description":  {German: "Der Zeitraum (Standard 7 Tage)"},
+	"command.uptime.period.7d":           {German: "7 Tage"},
+	"command.uptime.period.30d":          {German: "30 Tage"},
+	"command.heatmap.description":        {German: "Zeigt die durchschnittliche Spielerzahl eines Servers nach Wochentag und Uhrzeit"},
+	"command.heatmap.server.description": {German: "Der Server"},
+
+	"command.events.description":               {German: "Rückblick auf die Events dieses Servers"},
+	"command.events.history.name":              {German: "verlauf"},
+	"command.events.history.description":       {German: "Listet die vergangenen Events eines Monats mit ihrer Teilnahme"},
+	"command.events.history.month.name":        {German: "monat"},
+	"command.events.history.month.description": {German: "Der Monat als JJJJ-MM (Standard dieser Monat)"},
+
+	"command.botstats.description": {German: "Zeigt Diagnosedaten des Bots (nur Admins)"},
+
+	"command.config.name":                    {German: "konfiguration"},
+	"command.config.description":             {German: "Zeigt oder ändert die Konfiguration des Bots (nur Admins)"},
+	"command.config.show.name":               {German: "anzeigen"},
+	"command.config.show.description":        {German: "Zeigt die aktive Konfiguration"},
+	"command.config.set.name":                {German: "setzen"},
+	"command.config.set.description":         {German: "Ändert eine Einstellung zur Laufzeit"},
+	"command.config.set.setting.name":        {German: "einstellung"},
+	"command.config.set.setting.description": {German: "Die Einstellung"},
+	"command.config.set.value.name":          {German: "wert"},
+	"command.config.set.value.description":   {German: "Abfrageintervall in Sekunden, oder Reminder-Zeiten wie '1 day, 2 hours'"},
+	"command.config.apply.name":              {German: "anwenden"},
+	"command.config.apply.description":       {German: "Startet den Bot neu, um Änderungen der Konfigurationsdatei anzuwenden"},
+
+	"command.maintenance.name":               {German: "wartung"},
+	"command.maintenance.description":        {German: "Führt eine Wartungsaktion auf einem Server aus (nur Admins)"},
+	"command.maintenance.server.description": {German: "Der Server"},
+	"command.maintenance.action.name":        {German: "aktion"},
+	"command.maintenance.action.description": {German: "Die auszuführende Aktion"},
+
+	"command.report.name":               {German: "melden"},
+	"command.report.description":        {German: "Meldet einen Spieler an die Moderatoren"},
+	"command.report.player.name":        {German: "spieler"},
+	"command.report.player.description": {German: "Der Name des Spielers"},
+	"command.report.reason.name":        {German: "grund"},
+	"command.report.reason.description": {German: "Was ist passiert?"},
+
+	"command.ban.name":                 {German: "bannen"},
+	"command.ban.description":          {German: "Bannt einen Spieler auf allen Servern (nur Admins)"},
+	"command.ban.player.name":          {German: "spieler"},
+	"command.ban.player.description":   {German: "Plattform-ID oder Name eines bekannten Spielers"},
+	"command.ban.reason.name":          {German: "grund"},
+	"command.ban.reason.description":   {German: "Der Grund, wird dem Spieler angezeigt, wenn möglich"},
+	"command.unban.name":               {German: "entbannen"},
+	"command.unban.description":        {German: "Hebt den Bann eines Spielers auf allen Servern auf (nur Admins)"},
+	"command.unban.player.name":        {German: "spieler"},
+	"command.unban.player.description": {German: "Plattform-ID oder Name eines bekannten Spielers"},
+
+	"command.find.name":               {German: "suchen"},
+	"command.find.description":        {German: "Sucht bekannte Spieler nach (früherem) Namen (nur Admins)"},
+	"command.find.player.name":        {German: "spieler"},
+	"command.find.player.description": {German: "Der Name oder ein Teil davon"},
+
+	"command.rotatepassword.name":               {German: "passwortwechsel"},
+	"command.rotatepassword.description":        {German: "Setzt ein neues zufälliges RCON-Passwort auf einem Server (nur Admins)"},
+	"command.rotatepassword.server.description": {German: "Der Server"},
+
+	"command.announcements.name":                      {German: "ankuendigungen"},
+	"command.announcements.description":               {German: "Verwaltet die Ankündigungen im Spiel (nur Admins)"},
+	"command.announcements.list.name":                 {German: "anzeigen"},
+	"command.announcements.list.description":          {German: "Zeigt die rotierenden Ankündigungen"},
+	"command.announcements.add.name":                  {German: "hinzufuegen"},
+	"command.announcements.add.description":           {German: "Fügt eine Ankündigung hinzu"},
+	"command.announcements.add.message.name":          {German: "nachricht"},
+	"command.announcements.add.message.description":   {German: "Die Nachricht, die im Spiel gesendet wird"},
+	"command.announcements.remove.name":               {German: "entfernen"},
+	"command.announcements.remove.description":        {German: "Entfernt eine Ankündigung"},
+	"command.announcements.remove.number.name":        {German: "nummer"},
+	"command.announcements.remove.number.description": {German: "Nummer der Ankündigung wie in der Liste"},
+	"command.announcements.reset.name":                {German: "zuruecksetzen"},
+	"command.announcements.reset.description":         {German: "Verwendet wieder die Ankündigungen der Konfigurationsdatei"},
+}
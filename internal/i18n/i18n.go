@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Language represents the output language of messages and durations
+type Language int
+
+const (
+	English Language = iota
+	German
+	French
+	Spanish
+	Dutch
+)
+
+// Locales are the discord client locales of the languages the bot has command translations for
+var Locales = map[Language]discordgo.Locale{
+	English: discordgo.EnglishUS,
+	German:  discordgo.German,
+}
+
+// Codes are the language codes users can choose from, the languages with translated messages
+var Codes = map[string]Language{
+	"en": English,
+	"de": German,
+}
+
+// Parse returns the language of the code, false if there is no such language
+func Parse(code string) (Language, bool) {
+	lang, ok := Codes[code]
+
+	return lang, ok
+}
+
+// Name returns the name of the language in the language itself
+func Name(lang Language) string {
+	return T(lang, "language")
+}
+
+// Lookup returns the translation of the catalog key, false if there is none for the language
+func Lookup(lang Language, key string) (string, bool) {
+	res, ok := catalog[key][lang]
+
+	return res, ok
+}
+
+// Translations returns the translations of the catalog key in all languages which have one, ordered by language
+func Translations(key string) []string {
+	var res []string
+
+	for _, lang := range slices.Sorted(maps.Keys(catalog[key])) {
+		res = append(res, catalog[key][lang])
+	}
+
+	return res
+}
+
+// T returns the translation of the catalog key formatted with args, falling back to english and
+// finally to the key itself
+func T(lang Language, key string, args ...any) string {
+	format, ok := Lookup(lang, key)
+
+	if !ok {
+		format, ok = Lookup(English, key)
+	}
+
+	if !ok {
+		format = key
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,252 @@
+package lease
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// how often the holder renews its lease, and after which time a lease which wasn't renewed is stale
+const renewInterval = 10 * time.Second
+const Timeout = 3 * renewInterval
+
+// an instance taking over a stale lease checks after this time that no other one took it over at the same time
+var settleDelay = time.Second
+
+// ErrHeld is returned by Acquire if another instance holds the lease
+var ErrHeld = errors.New("lease is held by another instance")
+
+// envID passes the ID of the instance to the process replacing it on a restart, see Environ
+const envID = "LAZYDODO_INSTANCE_ID"
+
+// id tells the instances apart. Host and PID don't, containers sharing the hostname all run as PID 1.
+var id = instanceID()
+
+func instanceID() string {
+	if id := os.Getenv(envID); id != "" {
+		os.Unsetenv(envID)
+		return id
+	}
+
+	return rand.Text()
+}
+
+// Environ returns the environment of the process which replaces this one on a restart, with the ID of
+// the instance, so the lease stays its own
+func Environ() []string {
+	return append(os.Environ(), envID+"="+id)
+}
+
+// Holder is the content of the lease file
+type Holder struct {
+	ID      string    `json:"id"`
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	Renewed time.Time `json:"renewed"`
+}
+
+// ours returns if the lease was written by this instance, possibly before it restarted itself
+func (h Holder) ours() bool {
+	return h.ID == id
+}
+
+func (h Holder) String() string {
+	return fmt.Sprintf("%s (pid %d), renewed %s ago", h.Host, h.PID, time.Since(h.Renewed).Round(time.Second))
+}
+
+// Lease is a lock file naming the instance which holds it. It is renewed periodically, a lease
+// which wasn't renewed within Timeout may be taken over, so a crashed instance doesn't block the
+// next start.
+type Lease struct {
+	file string
+	stop chan struct{}
+	lost chan struct{}
+}
+
+// Acquire takes the lease in the given file and keeps renewing it until Release. If another instance
+// holds it, an error wrapping ErrHeld is returned.
+func Acquire(file string) (*Lease, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+
+	holder, err := read(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if holder != nil && !holder.ours() && time.Since(holder.Renewed) < Timeout {
+		return nil, fmt.Errorf("%w: %s", ErrHeld, holder)
+	}
+
+	switch {
+	case holder == nil:
+		// only one of the instances starting at the same time can create the file
+
+		err = create(file)
+
+		if errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("%w: it was just created by another instance", ErrHeld)
+		}
+	case holder.ours():
+		err = write(file)
+	default:
+		slog.Warn(fmt.Sprintf("Taking over stale lease %s of %s", file, holder))
+
+		if err = write(file); err == nil {
+			err = confirm(file)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lease{file: file, stop: make(chan struct{}), lost: make(chan struct{})}
+
+	go l.renew()
+
+	return l, nil
+}
+
+// Wait blocks until the lease in the given file is acquired, i.e. until its holder releases it or
+// stops renewing it
+func Wait(file string) *Lease {
+	for {
+		l, err := Acquire(file)
+
+		if err == nil {
+			return l
+		}
+
+		if !errors.Is(err, ErrHeld) {
+			slog.Error(fmt.Sprintf("Failed to acquire lease %s: %s", file, err))
+		}
+
+		time.Sleep(renewInterval)
+	}
+}
+
+// Lost is closed once another instance took over the lease
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops renewing the lease and removes the lease file, so another instance can start right away
+func (l *Lease) Release() {
+	close(l.stop)
+
+	if holder, err := read(l.file); err == nil && holder != nil && holder.ours() {
+		os.Remove(l.file)
+	}
+}
+
+func (l *Lease) renew() {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// another instance may have taken over while this one was stalled, the lease is left to it
+
+		if holder, err := read(l.file); err == nil && holder != nil && !holder.ours() {
+			slog.Error(fmt.Sprintf("Lease %s was taken over by %s, another instance is running", l.file, holder))
+			close(l.lost)
+			return
+		}
+
+		if err := write(l.file); err != nil {
+			slog.Error(fmt.Sprintf("Failed to renew lease %s: %s", l.file, err))
+		}
+	}
+}
+
+// confirm waits for other instances taking over the lease at the same time, the last one renaming its file wins
+func confirm(file string) error {
+	time.Sleep(settleDelay)
+
+	holder, err := read(file)
+
+	if err != nil {
+		return err
+	}
+
+	if holder == nil || !holder.ours() {
+		return fmt.Errorf("%w: it was taken over by another instance at the same time", ErrHeld)
+	}
+
+	return nil
+}
+
+// read returns the holder of the lease file, nil if there is none
+func read(file string) (*Holder, error) {
+	dat, err := os.ReadFile(file)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var holder Holder
+
+	if err := json.Unmarshal(dat, &holder); err != nil {
+		return nil, fmt.Errorf("failed to parse lease file %s: %w", file, err)
+	}
+
+	return &holder, nil
+}
+
+// write renews the lease file atomically
+func write(file string) error {
+	tmp, err := writeTemp(file)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// create writes the lease file if there is none, failing with os.ErrExist otherwise. The complete file is
+// linked in place, so other instances never read a partially written one.
+func create(file string) error {
+	tmp, err := writeTemp(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp)
+
+	return os.Link(tmp, file)
+}
+
+// writeTemp writes the lease of this instance next to the lease file, named by the instance so instances
+// don't overwrite each other's
+func writeTemp(file string) (string, error) {
+	host, _ := os.Hostname()
+
+	dat, err := json.Marshal(Holder{ID: id, Host: host, PID: os.Getpid(), Renewed: time.Now()})
+
+	if err != nil {
+		return "", err
+	}
+
+	tmp := file + "." + id + ".tmp"
+
+	return tmp, os.WriteFile(tmp, dat, 0644)
+}
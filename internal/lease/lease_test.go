@@ -0,0 +1,127 @@
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeHolder writes the lease file of another instance renewed at the given time
+func writeHolder(t *testing.T, file string, renewed time.Time) {
+	t.Helper()
+
+	dat, err := json.Marshal(Holder{ID: "other", Host: "other-host", PID: 1, Renewed: renewed})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, dat, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireCreatesLease(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bot.lock")
+
+	l, err := Acquire(file)
+
+	if err != nil {
+		t.Fatalf("acquire failed: %s", err)
+	}
+
+	holder, err := read(file)
+
+	if err != nil || holder == nil || !holder.ours() {
+		t.Fatalf("lease file not written by this instance: %v, %v", holder, err)
+	}
+
+	l.Release()
+
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lease file kept after release: %v", err)
+	}
+}
+
+func TestAcquireHeld(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bot.lock")
+
+	writeHolder(t, file, time.Now())
+
+	if _, err := Acquire(file); !errors.Is(err, ErrHeld) {
+		t.Fatalf("expected ErrHeld, got %v", err)
+	}
+}
+
+func TestAcquireStale(t *testing.T) {
+	settleDelay = 0
+	file := filepath.Join(t.TempDir(), "bot.lock")
+
+	writeHolder(t, file, time.Now().Add(-2*Timeout))
+
+	l, err := Acquire(file)
+
+	if err != nil {
+		t.Fatalf("stale lease not taken over: %s", err)
+	}
+
+	defer l.Release()
+
+	if holder, _ := read(file); holder == nil || !holder.ours() {
+		t.Errorf("lease file not written by this instance: %v", holder)
+	}
+}
+
+func TestCreateExisting(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bot.lock")
+
+	writeHolder(t, file, time.Now())
+
+	if err := create(file); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected os.ErrExist, got %v", err)
+	}
+
+	if holder, _ := read(file); holder == nil || holder.ID != "other" {
+		t.Errorf("existing lease overwritten: %v", holder)
+	}
+}
+
+func TestAcquireHeldBySameHostAndPID(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bot.lock")
+	host, _ := os.Hostname()
+
+	// another container with the same hostname, also running as the same PID
+
+	dat, err := json.Marshal(Holder{ID: "other", Host: host, PID: os.Getpid(), Renewed: time.Now()})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, dat, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Acquire(file); !errors.Is(err, ErrHeld) {
+		t.Fatalf("expected ErrHeld, got %v", err)
+	}
+}
+
+func TestEnvironKeepsID(t *testing.T) {
+	for _, env := range Environ() {
+		if env == envID+"="+id {
+			t.Setenv(envID, id)
+
+			if instanceID() != id {
+				t.Errorf("restarted instance got a new ID")
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("%s not passed to the restarted process", envID)
+}
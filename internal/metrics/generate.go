@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+const dashboardFile = "lazydodobot-dashboard.json"
+const rulesFile = "lazydodobot-rules.yml"
+
+// the capacity alert fires when a server is this full
+const capacityRatio = 0.9
+
+type panel map[string]any
+
+// Generate writes a Grafana dashboard and Prometheus alert rules for the metrics and the configured
+// servers of all bots into the directory
+func Generate(dir string) error {
+	servers := configuredServers()
+
+	dashboard, err := json.MarshalIndent(buildDashboard(servers), "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, dashboardFile), dashboard, 0644); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, rulesFile), []byte(buildRules(servers)), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s and %s to %s\n", dashboardFile, rulesFile, dir)
+
+	return nil
+}
+
+type monitoredServer struct {
+	server       cfg.ConfigRconServer
+	pollInterval time.Duration
+	downAfter    time.Duration
+}
+
+// configuredServers returns the servers of all bots, a server monitored by several bots only once
+func configuredServers() []monitoredServer {
+	var res []monitoredServer
+
+	seen := make(map[string]bool)
+
+	for _, bot := range cfg.Config.AllBots() {
+		if bot.ServerStatus == nil {
+			continue
+		}
+
+		for _, server := range bot.ServerStatus.Rcon.Servers {
+			if seen[server.Key] {
+				continue
+			}
+
+			seen[server.Key] = true
+
+			res = append(res, monitoredServer{
+				server:       server,
+				pollInterval: time.Duration(bot.ServerStatus.Rcon.QueryEverySeconds) * time.Second,
+				downAfter:    bot.ServerStatus.IncidentThreshold,
+			})
+		}
+	}
+
+	return res
+}
+
+func buildDashboard(servers []monitoredServer) map[string]any {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+
+	target := func(expr string, legend string) map[string]string {
+		return map[string]string{"expr": expr, "legendFormat": legend, "refId": "A"}
+	}
+
+	var panels []panel
+
+	// one stat per server, 6 in a row
+
+	for n, s := range servers {
+		panels = append(panels, panel{
+			"type":       "stat",
+			"title":      s.server.Name,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": n % 6 * 4, "y": n / 6 * 4, "w": 4, "h": 4},
+			"targets":    []map[string]string{target(fmt.Sprintf("%s{server=%q}", MetricPlayers, s.server.Key), "players")},
+			"fieldConfig": map[string]any{"defaults": map[string]any{
+				"noValue": "down",
+				"max":     s.server.MaxPlayers,
+			}},
+		})
+	}
+
+	y := (len(servers) + 5) / 6 * 4
+
+	panels = append(panels,
+		panel{
+			"type":       "timeseries",
+			"title":      "Players",
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": 0, "y": y, "w": 24, "h": 9},
+			"targets":    []map[string]string{target(fmt.Sprintf("sum by (server) (%s)", MetricPlayers), "{{server}}")},
+		},
+		panel{
+			"type":       "state-timeline",
+			"title":      "Reachability",
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": 0, "y": y + 9, "w": 12, "h": 8},
+			"targets":    []map[string]string{target(MetricServerUp, "{{server}}")},
+		},
+		panel{
+			"type":       "timeseries",
+			"title":      "RCON errors",
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": 12, "y": y + 9, "w": 12, "h": 8},
+			"targets":    []map[string]string{target(fmt.Sprintf("sum by (server, kind) (increase(%s[15m]))", MetricRconErrors), "{{server}}: {{kind}}")},
+		},
+	)
+
+	for id, p := range panels {
+		p["id"] = id + 1
+	}
+
+	return map[string]any{
+		"title":         "LazyDodoBot",
+		"uid":           "lazydodobot",
+		"schemaVersion": 39,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-24h", "to": "now"},
+		"templating": map[string]any{"list": []map[string]any{{
+			"name":  "datasource",
+			"label": "Data source",
+			"type":  "datasource",
+			"query": "prometheus",
+		}}},
+		"panels": panels,
+	}
+}
+
+func buildRules(servers []monitoredServer) string {
+	var b strings.Builder
+
+	b.WriteString("groups:\n  - name: lazydodobot\n    rules:\n")
+
+	rule := func(alert string, expr string, wait time.Duration, severity string, summary string) {
+		fmt.Fprintf(&b, "      - alert: %s\n        expr: %s\n", alert, yamlQuote(expr))
+
+		if wait > 0 {
+			fmt.Fprintf(&b, "        for: %ds\n", int(wait.Seconds()))
+		}
+
+		fmt.Fprintf(&b, "        labels:\n          severity: %s\n        annotations:\n          summary: %s\n", severity, yamlQuote(summary))
+	}
+
+	for _, s := range servers {
+		label := fmt.Sprintf("{server=%q}", s.server.Key)
+
+		rule("LazyDodoServerDown", MetricServerUp+label+" == 0", s.downAfter, "critical",
+			fmt.Sprintf("%s is unreachable", s.server.Name))
+
+		rule("LazyDodoPollingStalled", fmt.Sprintf("time() - %s%s > %d", MetricLastPoll, label, int((3*s.pollInterval).Seconds())), 0, "warning",
+			fmt.Sprintf("No successful poll of %s for 3 intervals", s.server.Name))
+
+		if s.server.MaxPlayers > 0 {
+			rule("LazyDodoServerFull", fmt.Sprintf("%s%s >= %g", MetricPlayers, label, capacityRatio*float64(s.server.MaxPlayers)), 10*time.Minute, "info",
+				fmt.Sprintf("%s is close to its %d player slots", s.server.Name, s.server.MaxPlayers))
+		}
+	}
+
+	rule("LazyDodoRconAuthFailures", fmt.Sprintf(`increase(%s{kind="authentication failure"}[15m]) > 0`, MetricRconErrors), 0, "warning",
+		"RCON authentication fails on {{ $labels.server }}")
+
+	return b.String()
+}
+
+// yamlQuote returns the text as single quoted YAML string, which needs no escaping except for single quotes
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// metric names, also used by the generated dashboard and alert rules
+const (
+	MetricPlayers    = "lazydodo_players"
+	MetricServerUp   = "lazydodo_server_up"
+	MetricLastPoll   = "lazydodo_last_poll_timestamp_seconds"
+	MetricRconErrors = "lazydodo_rcon_errors_total"
+	MetricPanics     = "lazydodo_panics_total"
+)
+
+type Registry struct {
+	sync.Mutex
+	Players  map[string]int
+	Up       map[string]bool
+	LastPoll map[string]time.Time
+	Errors   map[string]map[string]int
+	Panics   map[string]int
+}
+
+var registry = &Registry{
+	Players:  make(map[string]int),
+	Up:       make(map[string]bool),
+	LastPoll: make(map[string]time.Time),
+	Errors:   make(map[string]map[string]int),
+	Panics:   make(map[string]int),
+}
+
+// RecordPoll updates the server metrics with the result of a poll, the servers are labelled by their key
+func RecordPoll(serverInfos map[string]*model.ServerInfo) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	now := time.Now()
+
+	for serverKey, serverInfo := range serverInfos {
+		registry.Up[serverKey] = serverInfo.Reachable
+
+		if serverInfo.Reachable {
+			registry.Players[serverKey] = len(serverInfo.Players)
+			registry.LastPoll[serverKey] = now
+		}
+	}
+}
+
+// CountError counts a failed RCON query of the server, kind is the error class
+func CountError(serverKey string, kind string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.Errors[serverKey] == nil {
+		registry.Errors[serverKey] = make(map[string]int)
+	}
+
+	registry.Errors[serverKey][kind]++
+}
+
+// CountPanic counts a recovered panic of the subsystem
+func CountPanic(subsystem string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.Panics[subsystem]++
+}
+
+// Start serves the metrics in the Prometheus text format at /metrics on the configured port
+func Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+
+	addr := fmt.Sprintf(":%d", cfg.Config.MetricsPort)
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error(fmt.Sprintf("Failed to start metrics endpoint: %s", err))
+		}
+	}()
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(render()))
+}
+
+func render() string {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var b strings.Builder
+
+	writeHeader(&b, MetricPlayers, "gauge", "Players online at the last successful poll")
+
+	for _, server := range sortedKeys(registry.Players) {
+		fmt.Fprintf(&b, "%s{server=%q} %d\n", MetricPlayers, server, registry.Players[server])
+	}
+
+	writeHeader(&b, MetricServerUp, "gauge", "Whether the server was reachable at the last poll")
+
+	for _, server := range sortedKeys(registry.Up) {
+		up := 0
+
+		if registry.Up[server] {
+			up = 1
+		}
+
+		fmt.Fprintf(&b, "%s{server=%q} %d\n", MetricServerUp, server, up)
+	}
+
+	writeHeader(&b, MetricLastPoll, "gauge", "Unix time of the last successful poll")
+
+	for _, server := range sortedKeys(registry.LastPoll) {
+		fmt.Fprintf(&b, "%s{server=%q} %d\n", MetricLastPoll, server, registry.LastPoll[server].Unix())
+	}
+
+	writeHeader(&b, MetricRconErrors, "counter", "Failed RCON queries by error class")
+
+	for _, server := range sortedKeys(registry.Errors) {
+		for _, kind := range sortedKeys(registry.Errors[server]) {
+			fmt.Fprintf(&b, "%s{server=%q,kind=%q} %d\n", MetricRconErrors, server, kind, registry.Errors[server][kind])
+		}
+	}
+
+	writeHeader(&b, MetricPanics, "counter", "Recovered panics by subsystem")
+
+	for _, subsystem := range sortedKeys(registry.Panics) {
+		fmt.Fprintf(&b, "%s{subsystem=%q} %d\n", MetricPanics, subsystem, registry.Panics[subsystem])
+	}
+
+	return b.String()
+}
+
+func writeHeader(b *strings.Builder, name string, kind string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
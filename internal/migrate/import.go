@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+)
+
+// Import adds the player sessions of the log file to the history, see history.Import for the formats.
+// The history is shared by all bots, so none of them may be running.
+func Import(format string, file string, serverKey string) error {
+	if format == "" || file == "" {
+		return fmt.Errorf("expected the format and the log file")
+	}
+
+	var servers []cfg.ConfigRconServer
+
+	for _, bot := range cfg.Config.AllBots() {
+		if bot.ServerStatus != nil {
+			servers = append(servers, bot.ServerStatus.Rcon.Servers...)
+		}
+	}
+
+	if _, ok := cfg.ServerByKey(servers, serverKey); serverKey != "" && !ok {
+		return fmt.Errorf("no bot monitors a server with the key '%s'", serverKey)
+	}
+
+	f, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	release, err := lockBots(cfg.Config.AllBots())
+
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if err := history.Init(); err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	res, err := history.Import(f, format, serverKey, servers)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %d lines with %d joins and leaves, added %d sessions as %d history samples\n", res.Lines, res.Events, res.Sessions, res.Samples)
+
+	if res.Expired > 0 {
+		fmt.Printf("Skipped %d sessions older than the history retention of %s\n", res.Expired, history.Retention)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/patrickjane/lazydodo-bot/internal/cache"
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/history"
+	"github.com/patrickjane/lazydodo-bot/internal/lease"
+	"github.com/patrickjane/lazydodo-bot/internal/store"
+)
+
+// Server moves the history, the status threads, incidents and subscriptions of the server with the key from
+// to the server with the key to, after the server was renamed or merged into another one. The bots must
+// not be running, their instance locks are taken while migrating.
+func Server(from string, to string) error {
+	if from == "" || to == "" || from == to {
+		return fmt.Errorf("expected the old and the new key of the server")
+	}
+
+	var bots []*cfg.ConfigBot
+
+	for _, bot := range cfg.Config.AllBots() {
+		if bot.ServerStatus == nil {
+			continue
+		}
+
+		if !slices.ContainsFunc(bot.ServerStatus.Rcon.Servers, func(s cfg.ConfigRconServer) bool { return s.Key == to }) {
+			continue
+		}
+
+		bots = append(bots, bot)
+	}
+
+	if len(bots) == 0 {
+		return fmt.Errorf("no bot monitors a server with the key '%s'", to)
+	}
+
+	release, err := lockBots(bots)
+
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if err := history.Init(); err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	samples, err := history.MigrateServer(from, to)
+
+	if err != nil {
+		return fmt.Errorf("failed to migrate history: %w", err)
+	}
+
+	fmt.Printf("Moved %d history samples from '%s' to '%s'\n", samples, from, to)
+
+	if cfg.Config.Database != "" {
+		database, err := store.Open(cfg.Config.Database)
+
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+
+		defer database.Close()
+
+		rows, err := database.MigrateServer(from, to)
+
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+
+		fmt.Printf("Moved %d join/leave events and snapshots from '%s' to '%s'\n", rows, from, to)
+	}
+
+	for _, bot := range bots {
+		var store *cache.Store
+
+		if bot.StateDir != "" {
+			store, err = cache.OpenDir(bot.StateDir, bot.CachePath)
+		} else {
+			store, err = cache.Open(bot.CachePath)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to load cache of bot '%s': %w", bot.Name, err)
+		}
+
+		changed, err := store.MigrateServer(from, to)
+
+		if err != nil {
+			return fmt.Errorf("failed to migrate cache of bot '%s': %w", bot.Name, err)
+		}
+
+		fmt.Printf("Bot '%s': moved %d threads, incidents, subscriptions and players\n", bot.Name, changed)
+	}
+
+	return nil
+}
+
+// lockBots takes the instance locks of the bots, so none of them runs while their state is changed
+func lockBots(bots []*cfg.ConfigBot) (func(), error) {
+	var leases []*lease.Lease
+
+	release := func() {
+		for _, l := range leases {
+			l.Release()
+		}
+	}
+
+	if cfg.Config.InstanceLock == cfg.InstanceLockOff {
+		return release, nil
+	}
+
+	for _, bot := range bots {
+		l, err := lease.Acquire(bot.LockFile)
+
+		if errors.Is(err, lease.ErrHeld) {
+			release()
+			return nil, fmt.Errorf("bot '%s' is running, stop it first: %w", bot.Name, err)
+		}
+
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("failed to acquire instance lock of bot '%s': %w", bot.Name, err)
+		}
+
+		leases = append(leases, l)
+	}
+
+	return release, nil
+}
@@ -1,11 +1,23 @@
 package model
 
+import "context"
+
 type PlayerInfo struct {
 	Name  string
 	Tribe string
+
+	// platform ID (steam ID, EOS ID on ASA) and ping, if reported by the server
+	ID   string `json:",omitempty"`
+	Ping int    `json:",omitempty"`
+
+	// team and squad on servers grouping their players, e.g. squad
+	Team  string `json:",omitempty"`
+	Squad string `json:",omitempty"`
 }
 
+// ServerInfo is the polled state of a server, maps of those are keyed by the server key
 type ServerInfo struct {
+	Key       string `json:"-"`
 	Name      string `json:"-"`
 	Map       string `json:"-"`
 	Reachable bool   `json:"-"`
@@ -15,3 +27,9 @@ type ServerInfo struct {
 	ServerVersion string
 	Time          string
 }
+
+// ServerUpdate is the result of one poll of all servers. Ctx carries the poll's trace span.
+type ServerUpdate struct {
+	Ctx     context.Context
+	Servers map[string]*ServerInfo
+}
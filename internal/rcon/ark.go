@@ -0,0 +1,156 @@
+package rcon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorcon/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+var eosID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// reply of ARK servers to commands without output
+const arkNoResponse = "Server received, But no response"
+
+// arkProvider queries ARK servers via source RCON, ASE and ASA only differ in the player list format
+type arkProvider struct {
+	parse func(line string) (*model.PlayerInfo, error)
+}
+
+func (p *arkProvider) Players(server config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	response, err := executeSource(server, "ListPlayers")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseArkPlayers(response, p.parse)
+}
+
+// parseArkPlayers returns the players of the ListPlayers response, each line is parsed with parse
+func parseArkPlayers(response string, parse func(line string) (*model.PlayerInfo, error)) ([]model.PlayerInfo, error) {
+	players := make([]model.PlayerInfo, 0)
+
+	for _, raw := range strings.Split(response, "\n") {
+		line := strings.TrimSpace(raw)
+
+		if len(line) == 0 || strings.Contains(line, "No Players Connected") {
+			continue
+		}
+
+		player, err := parse(line)
+
+		if err != nil {
+			return nil, err
+		}
+
+		players = append(players, *player)
+	}
+
+	return players, nil
+}
+
+func (p *arkProvider) Execute(server config.ConfigRconServer, command string) (string, error) {
+	return executeSource(server, command)
+}
+
+// Chat returns the chat messages written since the last call, GetChat returns every message only once. Lines
+// look like "Player 1 (Character): hello", messages of the server (including our own) start with "SERVER:".
+func (p *arkProvider) Chat(server config.ConfigRconServer) ([]ChatMessage, error) {
+	response, err := executeSource(server, "GetChat", rcon.SetDialTimeout(chatTimeout), rcon.SetDeadline(chatTimeout))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []ChatMessage
+
+	now := time.Now()
+
+	for _, raw := range strings.Split(response, "\n") {
+		line := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(line, arkNoResponse) || strings.HasPrefix(line, "SERVER:") {
+			continue
+		}
+
+		sender, message, ok := strings.Cut(line, ": ")
+
+		if !ok {
+			continue
+		}
+
+		// the character name is dropped, like the platform name is used everywhere else
+
+		if name, _, ok := strings.Cut(sender, " ("); ok {
+			sender = name
+		}
+
+		// ARK has no time of the messages, each line is stamped apart so a repeated line isn't taken for one
+		// read before
+
+		messages = append(messages, ChatMessage{Server: server.Name, Sender: sender, Message: message, Time: now.Add(time.Duration(len(messages)))})
+	}
+
+	return messages, nil
+}
+
+func (p *arkProvider) Say(server config.ConfigRconServer, sender string, message string) error {
+	_, err := executeSource(server, fmt.Sprintf("ServerChat [Discord] %s: %s", sender, message))
+
+	return err
+}
+
+// parseAsePlayer parses one line of the player list, which looks like this:
+// '
+// 0. Player 1, 76561198012345678
+// 1. Player 2, 76561198087654321
+// '
+func parseAsePlayer(line string) (*model.PlayerInfo, error) {
+	// Split at ". " to remove the leading index
+
+	parts := strings.SplitN(line, ". ", 2)
+
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: invalid format: missing '. '", ErrParse)
+	}
+
+	// From the remaining string, take everything before the comma
+
+	name, id, _ := strings.Cut(parts[1], ",")
+
+	return &model.PlayerInfo{Name: strings.TrimSpace(name), ID: strings.TrimSpace(id)}, nil
+}
+
+// parseAsaPlayer parses one line of the ASA player list. ASA reports EOS IDs instead of steam IDs
+// and does not restrict player names, so names may contain commas:
+// '
+// 0. Player 1, 0002821382231233322321312300abc2
+// 1. Player, 2, 0002822312322312321321312300abc5
+// '
+func parseAsaPlayer(line string) (*model.PlayerInfo, error) {
+	parts := strings.SplitN(line, ". ", 2)
+
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: invalid format: missing '. '", ErrParse)
+	}
+
+	idx := strings.LastIndex(parts[1], ",")
+
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: invalid format: missing ','", ErrParse)
+	}
+
+	name := strings.TrimSpace(parts[1][:idx])
+	id := strings.ToLower(strings.TrimSpace(parts[1][idx+1:]))
+
+	if !eosID.MatchString(id) {
+		return nil, fmt.Errorf("%w: invalid EOS ID '%s'", ErrParse, id)
+	}
+
+	return &model.PlayerInfo{Name: name, ID: id}, nil
+}
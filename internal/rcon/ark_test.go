@@ -0,0 +1,64 @@
+package rcon
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+func TestParseArkPlayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(line string) (*model.PlayerInfo, error)
+		response string
+		want     []model.PlayerInfo
+	}{
+		{"ase", parseAsePlayer, "0. Rexy, 76561198012345678\n1. Player 2, 76561198087654321\n \n", []model.PlayerInfo{
+			{Name: "Rexy", ID: "76561198012345678"},
+			{Name: "Player 2", ID: "76561198087654321"},
+		}},
+		{"ase without players", parseAsePlayer, "No Players Connected\n", []model.PlayerInfo{}},
+		{"asa", parseAsaPlayer, "0. Rexy, 0002821382231233322321312300abc2\n1. Player, 2, 0002822312322312321321312300ABC5\n", []model.PlayerInfo{
+			{Name: "Rexy", ID: "0002821382231233322321312300abc2"},
+			{Name: "Player, 2", ID: "0002822312322312321321312300abc5"},
+		}},
+		{"asa name ending in a comma", parseAsaPlayer, "0. Rexy,, 0002821382231233322321312300abc2\n", []model.PlayerInfo{
+			{Name: "Rexy,", ID: "0002821382231233322321312300abc2"},
+		}},
+		{"asa without players", parseAsaPlayer, " No Players Connected \n", []model.PlayerInfo{}},
+	}
+
+	for _, test := range tests {
+		players, err := parseArkPlayers(test.response, test.parse)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !slices.Equal(players, test.want) {
+			t.Errorf("%s: parsed %v, want %v", test.name, players, test.want)
+		}
+	}
+}
+
+func TestParseArkPlayerErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(line string) (*model.PlayerInfo, error)
+		line  string
+	}{
+		{"ase without index", parseAsePlayer, "Rexy, 76561198012345678"},
+		{"asa without index", parseAsaPlayer, "Rexy, 0002821382231233322321312300abc2"},
+		{"asa without id", parseAsaPlayer, "0. Rexy"},
+		{"asa with a steam id", parseAsaPlayer, "0. Rexy, 76561198012345678"},
+	}
+
+	for _, test := range tests {
+		if _, err := test.parse(test.line); !errors.Is(err, ErrParse) {
+			t.Errorf("%s: expected ErrParse, got %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+var ErrNotSupported = errors.New("not supported")
+
+// platform IDs in ban lists: steam64, legacy steam IDs ('STEAM_0:1:123', '[U:1:123]') and EOS IDs
+var banListID = regexp.MustCompile(`\b7656\d{13}\b|STEAM_\d:\d:\d+|\[U:\d:\d+\]|\b[0-9a-f]{32}\b`)
+
+// commands to ban and unban a player by platform ID (%[1]s), with the reason as %[2]s. The ban list
+// command is empty for server types which can't list their bans.
+type banCommands struct {
+	Ban   []string
+	Unban []string
+	List  string
+}
+
+var bans = map[string]banCommands{
+	config.ServerTypeArkAse: {Ban: []string{"BanPlayer %[1]s"}, Unban: []string{"UnbanPlayer %[1]s"}},
+	config.ServerTypeArkAsa: {Ban: []string{"BanPlayer %[1]s"}, Unban: []string{"UnbanPlayer %[1]s"}},
+	config.ServerTypeRust: {Ban: []string{`banid %[1]s "" "%[2]s"`, "server.writecfg"}, Unban: []string{"unban %[1]s", "server.writecfg"},
+		List: "banlistex"},
+	config.ServerTypeSource: {Ban: []string{"banid 0 %[1]s", "writeid"}, Unban: []string{"removeid %[1]s", "writeid"}, List: "listid"},
+	config.ServerTypeSquad:  {Ban: []string{"AdminBan %[1]s 0 %[2]s"}},
+}
+
+func banCommandsFor(server config.ConfigRconServer) banCommands {
+	return bans[serverType(server)]
+}
+
+// CanListBans reports whether the ban list of the server can be read
+func CanListBans(server config.ConfigRconServer) bool {
+	return banCommandsFor(server).List != ""
+}
+
+// Ban bans the player with the given platform ID on the configured server with the given key
+func Ban(cfg config.ConfigRcon, serverKey string, id string, reason string) error {
+	return runBanCommands(cfg, serverKey, func(c banCommands) []string { return c.Ban }, id, reason)
+}
+
+// Unban lifts the ban of the player with the given platform ID on the configured server with the given key
+func Unban(cfg config.ConfigRcon, serverKey string, id string) error {
+	return runBanCommands(cfg, serverKey, func(c banCommands) []string { return c.Unban }, id, "")
+}
+
+func runBanCommands(cfg config.ConfigRcon, serverKey string, commands func(c banCommands) []string, id string, reason string) error {
+	for _, server := range cfg.Servers {
+		if server.Key != serverKey {
+			continue
+		}
+
+		list := commands(banCommandsFor(server))
+
+		if len(list) == 0 {
+			return fmt.Errorf("%w on %s servers", ErrNotSupported, serverType(server))
+		}
+
+		// the ID and reason end up in a command line, quotes would break it up
+
+		reason = strings.ReplaceAll(reason, `"`, "'")
+
+		for _, command := range list {
+			if _, err := Execute(cfg, serverKey, fmt.Sprintf(command, id, reason)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown server '%s'", serverKey)
+}
+
+// Bans returns the platform IDs banned on the configured server with the given key
+func Bans(cfg config.ConfigRcon, serverKey string) ([]string, error) {
+	for _, server := range cfg.Servers {
+		if server.Key != serverKey {
+			continue
+		}
+
+		command := banCommandsFor(server).List
+
+		if command == "" {
+			return nil, fmt.Errorf("%w on %s servers", ErrNotSupported, serverType(server))
+		}
+
+		response, err := Execute(cfg, serverKey, command)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return banListID.FindAllString(response, -1), nil
+	}
+
+	return nil, fmt.Errorf("unknown server '%s'", serverKey)
+}
+
+// ValidPlatformID reports whether the text looks like a platform ID which can be banned
+func ValidPlatformID(id string) bool {
+	return banListID.FindString(id) == id
+}
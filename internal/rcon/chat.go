@@ -0,0 +1,114 @@
+package rcon
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+// chat messages sent to the servers are cut to these lengths (in characters)
+const maxSenderLength = 32
+const maxChatLength = 200
+
+// the chat is read every few seconds, a server not answering within this is skipped until the next read
+const chatTimeout = 3 * time.Second
+
+// ChatFeed reads the chat of servers and returns every message once. Servers report the time of a message
+// in seconds (or the time it was read, like ARK, apart by a nanosecond per line), so the messages of the latest second are remembered to
+// skip them when they are read again. Different servers may be read at the same time, a server only by one
+// caller at a time.
+type ChatFeed struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+	seen map[string]map[string]bool
+}
+
+// NewChatFeed creates a feed of the servers which only returns messages written from now on
+func NewChatFeed(servers []config.ConfigRconServer) *ChatFeed {
+	f := &ChatFeed{last: make(map[string]time.Time), seen: make(map[string]map[string]bool)}
+
+	for _, server := range servers {
+		f.last[server.Key] = time.Now()
+	}
+
+	return f
+}
+
+// Read returns the chat messages of the server which were not returned before, oldest first. Of a server
+// the feed didn't read before, only messages written from now on are returned.
+func (f *ChatFeed) Read(server config.ConfigRconServer) ([]ChatMessage, error) {
+	f.mu.Lock()
+
+	if _, ok := f.last[server.Key]; !ok {
+		f.last[server.Key] = time.Now()
+	}
+
+	f.mu.Unlock()
+
+	messages, err := ReadChat(server)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var res []ChatMessage
+
+	for _, m := range messages {
+		key := m.SenderID + "\x00" + m.Sender + "\x00" + m.Message
+
+		if m.Time.Before(f.last[server.Key]) || (m.Time.Equal(f.last[server.Key]) && f.seen[server.Key][key]) {
+			continue
+		}
+
+		if !m.Time.Equal(f.last[server.Key]) {
+			f.last[server.Key] = m.Time
+			f.seen[server.Key] = make(map[string]bool)
+		}
+
+		f.seen[server.Key][key] = true
+
+		res = append(res, m)
+	}
+
+	return res, nil
+}
+
+// SupportsChat reports whether the chat of the server can be read and written
+func SupportsChat(server config.ConfigRconServer) bool {
+	if server.Address == "simulated" {
+		return true
+	}
+
+	_, err := chatProviderFor(server)
+
+	return err == nil
+}
+
+// chatText makes text safe to be sent as part of a chat command: line breaks and other control characters,
+// which could end the command, become spaces, quotes are replaced and the text is cut to max characters
+func chatText(text string, max int) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return ' '
+		case r == '"':
+			return '\''
+		}
+
+		return r
+	}, text)
+
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > max {
+		text = string(runes[:max-3]) + "..."
+	}
+
+	return text
+}
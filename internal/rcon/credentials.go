@@ -0,0 +1,156 @@
+package rcon
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/gorcon/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+type Passwords struct {
+	sync.RWMutex
+	Reloaded map[string]string
+}
+
+// passwords re-read after an authentication failure or rotated, by bot and server key
+var passwords = &Passwords{Reloaded: make(map[string]string)}
+
+// passwordKey identifies the server of the bot, the passwords of all bots share the map
+func passwordKey(server config.ConfigRconServer) string {
+	return server.Bot + "/" + server.Key
+}
+
+func currentPassword(server config.ConfigRconServer) string {
+	passwords.RLock()
+	defer passwords.RUnlock()
+
+	if password, ok := passwords.Reloaded[passwordKey(server)]; ok {
+		return password
+	}
+
+	return server.Password
+}
+
+// dial connects to the server. If authentication fails and the password comes from a file, environment
+// variable or command, it is re-read and the connection retried once, as some hosts rotate the RCON
+// password on every restart.
+func dial(server config.ConfigRconServer, options ...rcon.Option) (*rcon.Conn, error) {
+	conn, err := rcon.Dial(server.Address, currentPassword(server), options...)
+
+	if err == nil || !server.HasPasswordSource() ||
+		!(errors.Is(err, rcon.ErrAuthFailed) || errors.Is(err, rcon.ErrInvalidAuthResponse)) {
+		return conn, err
+	}
+
+	password, ok := reloadPassword(server)
+
+	if !ok {
+		return nil, err
+	}
+
+	return rcon.Dial(server.Address, password, options...)
+}
+
+// reloadPassword re-reads the password of the server from its password source after an authentication failure
+func reloadPassword(server config.ConfigRconServer) (string, bool) {
+	password, err := server.LoadPassword()
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to reload RCON password for %s: %s", server.Name, err))
+		return "", false
+	}
+
+	passwords.Lock()
+	passwords.Reloaded[passwordKey(server)] = password
+	passwords.Unlock()
+
+	slog.Info(fmt.Sprintf("Reloaded RCON password for %s after authentication failure, retrying", server.Name))
+
+	return password, true
+}
+
+// Rotation is the result of a password rotation
+type Rotation struct {
+	Password string
+
+	// password file the new password was written to, empty if the config must be updated manually
+	File string
+}
+
+// RotatePassword generates a new RCON password, sets it on the configured server with the given key and
+// verifies the server accepts it. The bot uses the new password right away, it is written to the password
+// file if the server has one.
+func RotatePassword(cfg config.ConfigRcon, serverKey string) (Rotation, error) {
+	for _, server := range cfg.Servers {
+		if server.Key != serverKey {
+			continue
+		}
+
+		if server.Address == "simulated" {
+			return Rotation{}, fmt.Errorf("simulated servers have no password")
+		}
+
+		provider, err := providerFor(server)
+
+		if err != nil {
+			return Rotation{}, err
+		}
+
+		changer, ok := provider.(PasswordChanger)
+
+		if !ok {
+			return Rotation{}, fmt.Errorf("the RCON password of %s servers can not be changed at runtime", serverType(server))
+		}
+
+		rotation := Rotation{Password: rand.Text()}
+
+		slog.Info(fmt.Sprintf("Rotating RCON password of %s", server.Name))
+
+		if err := changer.SetPassword(server, rotation.Password); err != nil {
+			return Rotation{}, fmt.Errorf("failed to set the new password: %w", err)
+		}
+
+		passwords.Lock()
+		passwords.Reloaded[passwordKey(server)] = rotation.Password
+		passwords.Unlock()
+
+		if server.PasswordFile != "" {
+			if err := writePasswordFile(server.PasswordFile, rotation.Password); err != nil {
+				return rotation, fmt.Errorf("failed to write password file: %w", err)
+			}
+
+			rotation.File = server.PasswordFile
+		}
+
+		// verify without falling back to the password source, which may still hold the old password
+
+		verify := server
+		verify.PasswordFile, verify.PasswordEnv, verify.PasswordCommand = "", "", ""
+
+		if _, err := provider.Players(verify); err != nil {
+			return rotation, fmt.Errorf("the server does not accept the new password: %w", err)
+		}
+
+		slog.Info(fmt.Sprintf("Rotated RCON password of %s", server.Name))
+
+		return rotation, nil
+	}
+
+	return Rotation{}, fmt.Errorf("unknown server '%s'", serverKey)
+}
+
+// writePasswordFile replaces the password file atomically
+func writePasswordFile(file string, password string) error {
+	tmp := file + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(password+"\n"), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,53 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/gorcon/rcon"
+)
+
+var (
+	ErrAuthFailure       = errors.New("authentication failure")
+	ErrTimeout           = errors.New("timeout")
+	ErrParse             = errors.New("parse error")
+	ErrConnectionRefused = errors.New("connection refused")
+	ErrOther             = errors.New("other error")
+)
+
+// QueryError is reported on the error channel for every failed server query, Server is its key. Kind is one of the
+// Err* classes above, so consumers can check the class with errors.Is.
+type QueryError struct {
+	Server string
+	Kind   error
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", e.Server, e.Err, e.Kind)
+}
+
+func (e *QueryError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func classify(server string, err error) *QueryError {
+	var netErr net.Error
+
+	kind := ErrOther
+
+	switch {
+	case errors.Is(err, rcon.ErrAuthFailed), errors.Is(err, rcon.ErrInvalidAuthResponse), errors.Is(err, ErrAuthFailure):
+		kind = ErrAuthFailure
+	case errors.Is(err, ErrParse):
+		kind = ErrParse
+	case errors.Is(err, syscall.ECONNREFUSED):
+		kind = ErrConnectionRefused
+	case errors.As(err, &netErr) && netErr.Timeout():
+		kind = ErrTimeout
+	}
+
+	return &QueryError{Server: server, Kind: kind, Err: err}
+}
@@ -0,0 +1,128 @@
+package rcon
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// Provider queries one type of game server
+type Provider interface {
+	// Players returns the players currently connected to the server
+	Players(server config.ConfigRconServer) ([]model.PlayerInfo, error)
+
+	// Execute runs a single command on the server and returns the response
+	Execute(server config.ConfigRconServer, command string) (string, error)
+}
+
+// ChatProvider is implemented by providers which relay chat via RCON instead of the database
+type ChatProvider interface {
+	// Chat returns the recent chat messages of the server, oldest first
+	Chat(server config.ConfigRconServer) ([]ChatMessage, error)
+
+	// Say sends a chat message from the given sender to the server
+	Say(server config.ConfigRconServer, sender string, message string) error
+}
+
+// PasswordChanger is implemented by providers which can change the RCON password of a running server
+type PasswordChanger interface {
+	// SetPassword sets the new RCON password, connections opened afterwards must use it
+	SetPassword(server config.ConfigRconServer, password string) error
+}
+
+// ChatMessage is a chat message read from a game server
+type ChatMessage struct {
+	Server   string
+	Sender   string
+	SenderID string
+	Message  string
+	Time     time.Time
+}
+
+var providers = map[string]Provider{
+	config.ServerTypeArkAse:  &arkProvider{parse: parseAsePlayer},
+	config.ServerTypeArkAsa:  &arkProvider{parse: parseAsaPlayer},
+	config.ServerTypeRust:    &rustProvider{},
+	config.ServerTypeValheim: &valheimProvider{},
+	config.ServerTypeSource:  &sourceProvider{},
+	config.ServerTypeSquad:   &squadProvider{},
+}
+
+// serverType returns the type of the server, ARK ASE if none is configured
+func serverType(server config.ConfigRconServer) string {
+	if server.Type == "" {
+		return config.ServerTypeArkAse
+	}
+
+	return server.Type
+}
+
+func providerFor(server config.ConfigRconServer) (Provider, error) {
+	if server.Type == "" {
+		return providers[config.ServerTypeArkAse], nil
+	}
+
+	provider, ok := providers[server.Type]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown server type '%s'", server.Type)
+	}
+
+	return provider, nil
+}
+
+// ReadChat returns the recent chat messages of the server
+func ReadChat(server config.ConfigRconServer) ([]ChatMessage, error) {
+	if server.Address == "simulated" {
+		return simulatedChat(server), nil
+	}
+
+	provider, err := chatProviderFor(server)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Chat(server)
+}
+
+// SendChat sends a chat message from the given sender to the server
+func SendChat(server config.ConfigRconServer, sender string, message string) error {
+	if server.Address == "simulated" {
+		slog.Info(fmt.Sprintf("Simulated chat message of %s on %s: %s", sender, server.Name, message))
+		return nil
+	}
+
+	provider, err := chatProviderFor(server)
+
+	if err != nil {
+		return err
+	}
+
+	sender, message = chatText(sender, maxSenderLength), chatText(message, maxChatLength)
+
+	if message == "" {
+		return nil
+	}
+
+	return provider.Say(server, sender, message)
+}
+
+func chatProviderFor(server config.ConfigRconServer) (ChatProvider, error) {
+	provider, err := providerFor(server)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chatProvider, ok := provider.(ChatProvider)
+
+	if !ok {
+		return nil, fmt.Errorf("server type '%s' does not support chat", server.Type)
+	}
+
+	return chatProvider, nil
+}
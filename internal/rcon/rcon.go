@@ -1,24 +1,87 @@
 package rcon
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/gorcon/rcon"
 	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
 	"github.com/patrickjane/lazydodo-bot/internal/model"
+	"github.com/patrickjane/lazydodo-bot/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func Run(cfg config.ConfigRcon, updateChan chan<- map[string]*model.ServerInfo) error {
-	ticker := time.NewTicker(time.Duration(cfg.QueryEverySeconds) * time.Second)
+type PollStats struct {
+	sync.RWMutex
+	LastSuccess map[string]time.Time
+}
+
+var pollStats = &PollStats{LastSuccess: make(map[string]time.Time)}
+
+// LastSuccess returns the time of the last successful poll of the server with the given key
+func LastSuccess(serverKey string) time.Time {
+	pollStats.RLock()
+	defer pollStats.RUnlock()
+
+	return pollStats.LastSuccess[serverKey]
+}
+
+func markSuccess(serverKey string) {
+	pollStats.Lock()
+	defer pollStats.Unlock()
+
+	pollStats.LastSuccess[serverKey] = time.Now()
+}
+
+// healthName is the name of the poll loop of the given servers in health reports
+func healthName(cfg config.ConfigRcon) string {
+	var names []string
+
+	for _, server := range cfg.Servers {
+		names = append(names, server.Name)
+	}
+
+	return fmt.Sprintf("rcon (%s)", strings.Join(names, ", "))
+}
+
+// pollDeadline is the time until the next poll is expected; the updates must be consumed and
+// slow servers are tolerated, so the poll loop only counts as wedged after a few missed polls
+func pollDeadline(every time.Duration) time.Duration {
+	return 3*every + time.Minute
+}
+
+// RequestRefresh triggers an immediate poll of all servers outside of the regular interval.
+// Requests made while a refresh is already pending are dropped.
+func RequestRefresh(refresh chan<- struct{}) {
+	select {
+	case refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Run polls the configured servers and sends the results to updateChan until ctx is cancelled.
+func Run(ctx context.Context, cfg config.ConfigRcon, refresh <-chan struct{}, interval <-chan int, updateChan chan<- model.ServerUpdate, errorChan chan<- error) error {
+	every := time.Duration(cfg.QueryEverySeconds) * time.Second
+	name := healthName(cfg)
+
+	ticker := time.NewTicker(every)
 	defer ticker.Stop()
 
+	health.Beat(name, pollDeadline(every))
+
+	// a loop replaced after a config reload may poll other servers, under another name
+
+	defer health.Forget(name)
+
 	ifos := make(map[string]*model.ServerInfo)
 
 	for _, rconServerConf := range cfg.Servers {
-		ifos[rconServerConf.Name] = &model.ServerInfo{
+		ifos[rconServerConf.Key] = &model.ServerInfo{
+			Key:       rconServerConf.Key,
 			Name:      rconServerConf.Name,
 			Map:       rconServerConf.Map,
 			Reachable: true,
@@ -26,93 +89,143 @@ func Run(cfg config.ConfigRcon, updateChan chan<- map[string]*model.ServerInfo)
 		}
 	}
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-refresh:
+		case seconds := <-interval:
+			slog.Info(fmt.Sprintf("Query RCON servers every %d seconds", seconds))
+			every = time.Duration(seconds) * time.Second
+			ticker.Reset(every)
+			health.Beat(name, pollDeadline(every))
+			continue
+		}
+
+		pollCtx, pollSpan := tracing.Start(context.Background(), "rcon.poll")
+
 		for _, rconServerConfig := range cfg.Servers {
-			_, err := queryServer(rconServerConfig)
+			_, span := tracing.Start(pollCtx, "rcon.query", attribute.String("server", rconServerConfig.Name))
+			players, err := queryServer(rconServerConfig)
+			tracing.End(span, err)
 
 			if err != nil {
 				slog.Error(fmt.Sprintf("Failed to query server %s: %s", rconServerConfig.Address, err))
 
-				ifos[rconServerConfig.Name].Reachable = false
-				ifos[rconServerConfig.Name].Players = []model.PlayerInfo{}
+				// never block polling because nobody consumes the errors
+
+				select {
+				case errorChan <- classify(rconServerConfig.Key, err):
+				default:
+				}
+
+				ifos[rconServerConfig.Key].Reachable = false
+				ifos[rconServerConfig.Key].Players = []model.PlayerInfo{}
 			} else {
-				ifos[rconServerConfig.Name].Reachable = true
-				ifos[rconServerConfig.Name].Players = []model.PlayerInfo{} // players
+				ifos[rconServerConfig.Key].Reachable = true
+				ifos[rconServerConfig.Key].Players = players
+
+				markSuccess(rconServerConfig.Key)
 			}
 		}
 
-		updateChan <- ifos
-	}
+		pollSpan.End()
 
-	return nil
-}
+		// a loop which was replaced after stalling must not deliver its late results
 
-func queryServer(cfg config.ConfigRconServer) ([]string, error) {
-	conn, err := rcon.Dial(cfg.Address, cfg.Password)
+		select {
+		case <-ctx.Done():
+			return nil
+		case updateChan <- model.ServerUpdate{Ctx: pollCtx, Servers: ifos}:
+		}
 
-	slog.Debug(fmt.Sprintf("Opening RCON connection to %s (%s) ...", cfg.Address, cfg.Name))
+		health.Beat(name, pollDeadline(every))
+	}
+}
+
+func queryServer(cfg config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	provider, err := providerFor(cfg)
 
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := conn.Execute("ListPlayers")
+	slog.Debug(fmt.Sprintf("Querying players of %s (%s) ...", cfg.Address, cfg.Name))
+
+	players, err := provider.Players(cfg)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var newPlayers []string
+	return players, nil
+}
 
-	for _, raw := range strings.Split(response, "\n") {
-		rawTrimmed := strings.Trim(raw, " ")
+// Execute runs a single command on the configured server with the given key and returns the response.
+func Execute(cfg config.ConfigRcon, serverKey string, command string) (string, error) {
+	for _, rconServerConfig := range cfg.Servers {
+		if rconServerConfig.Key != serverKey {
+			continue
+		}
 
-		if !strings.Contains(rawTrimmed, "No Players Connected") {
-			name, err := parseName(rawTrimmed)
+		if rconServerConfig.Address == "simulated" {
+			return fmt.Sprintf("Simulated execution of '%s'", command), nil
+		}
 
-			if err != nil {
-				return nil, err
-			}
+		provider, err := providerFor(rconServerConfig)
 
-			if len(name) > 0 {
-				newPlayers = append(newPlayers, name)
-			}
+		if err != nil {
+			return "", err
 		}
+
+		slog.Info(fmt.Sprintf("Executing RCON command '%s' on %s (%s)", command, rconServerConfig.Address, rconServerConfig.Name))
+
+		return provider.Execute(rconServerConfig, command)
 	}
 
-	conn.Close()
+	return "", fmt.Errorf("unknown server '%s'", serverKey)
+}
 
-	return newPlayers, nil
+// in-game broadcast command by server type
+var broadcastCommands = map[string]string{
+	config.ServerTypeArkAse: "ServerChat %s",
+	config.ServerTypeArkAsa: "ServerChat %s",
+	config.ServerTypeRust:   "say %s",
+	config.ServerTypeSource: "say %s",
+	config.ServerTypeSquad:  "AdminBroadcast %s",
 }
 
-func parseName(line string) (string, error) {
-	if len(strings.Trim(line, " ")) == 0 {
-		return "", nil
-	}
+// SupportsBroadcast reports whether messages can be broadcast to the in-game chat of the server
+func SupportsBroadcast(server config.ConfigRconServer) bool {
+	_, ok := broadcastCommands[serverType(server)]
 
-	// player list return from RCON command looks like this:
-	// '
-	// 0. Player 1, 00038213822312333223213123abc2
-	// 1. Player 2, 00038223123223123213213123abc5
-	// 2. Player 3, 00038436382231232132777123abc8
-	// '
+	return ok
+}
 
-	// Split at ". " to remove the leading index
+// Broadcast sends the message to the in-game chat of the configured server with the given key
+func Broadcast(cfg config.ConfigRcon, serverKey string, message string) error {
+	for _, rconServerConfig := range cfg.Servers {
+		if rconServerConfig.Key != serverKey {
+			continue
+		}
 
-	parts := strings.SplitN(line, ". ", 2)
+		serverType := rconServerConfig.Type
 
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid format: missing '. '")
-	}
+		if serverType == "" {
+			serverType = config.ServerTypeArkAse
+		}
+
+		command, ok := broadcastCommands[serverType]
 
-	// From the remaining string, take everything before the comma
+		if !ok {
+			return fmt.Errorf("server type '%s' does not support broadcasts", serverType)
+		}
 
-	rest := parts[1]
-	namePart := strings.SplitN(rest, ",", 2)
+		_, err := Execute(cfg, serverKey, fmt.Sprintf(command, message))
 
-	if len(namePart) == 0 {
-		return "", fmt.Errorf("invalid format: missing ','")
+		return err
 	}
 
-	return strings.TrimSpace(namePart[0]), nil
+	return fmt.Errorf("unknown server '%s'", serverKey)
 }
@@ -0,0 +1,200 @@
+package rcon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+const rustTimeout = 10 * time.Second
+
+// number of recent chat messages requested with every chat poll
+const rustChatTail = 50
+
+// rustProvider queries Rust servers via WebRCON, which sends JSON payloads over a websocket
+type rustProvider struct {
+	identifier atomic.Int32
+}
+
+type rustRequest struct {
+	Identifier int32
+	Message    string
+	Name       string
+}
+
+type rustResponse struct {
+	Identifier int32
+	Message    string
+	Type       string
+}
+
+type rustPlayer struct {
+	SteamID     string
+	DisplayName string
+}
+
+type rustChatMessage struct {
+	Channel  int
+	Message  string
+	UserId   string
+	Username string
+	Time     int64
+}
+
+func (p *rustProvider) Players(server config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	response, err := p.Execute(server, "playerlist")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRustPlayers(response)
+}
+
+// parseRustPlayers returns the players of the JSON list the playerlist command responds with
+func parseRustPlayers(response string) ([]model.PlayerInfo, error) {
+	var rustPlayers []rustPlayer
+
+	if err := json.Unmarshal([]byte(response), &rustPlayers); err != nil {
+		return nil, fmt.Errorf("%w: invalid player list: %s", ErrParse, err)
+	}
+
+	players := make([]model.PlayerInfo, 0, len(rustPlayers))
+
+	for _, player := range rustPlayers {
+		players = append(players, model.PlayerInfo{Name: player.DisplayName, ID: player.SteamID})
+	}
+
+	return players, nil
+}
+
+func (p *rustProvider) Execute(server config.ConfigRconServer, command string) (string, error) {
+	return p.execute(server, command, rustTimeout)
+}
+
+// execute runs the command, waiting at most timeout for the connection and for the response each
+func (p *rustProvider) execute(server config.ConfigRconServer, command string, timeout time.Duration) (string, error) {
+	conn, err := p.dial(server, timeout)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	id := p.identifier.Add(1)
+
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+
+	if err := conn.WriteJSON(rustRequest{Identifier: id, Message: command, Name: "LazyDodo"}); err != nil {
+		return "", err
+	}
+
+	// the server also pushes console output to every client, skip everything until our response
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	for {
+		var response rustResponse
+
+		if err := conn.ReadJSON(&response); err != nil {
+			return "", err
+		}
+
+		if response.Identifier == id {
+			return response.Message, nil
+		}
+	}
+}
+
+// Chat returns the recent global chat messages of the server, oldest first
+func (p *rustProvider) Chat(server config.ConfigRconServer) ([]ChatMessage, error) {
+	response, err := p.execute(server, fmt.Sprintf("chat.tail %d", rustChatTail), chatTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRustChat(server, response)
+}
+
+// parseRustChat returns the global chat messages of players of the JSON list chat.tail responds with
+func parseRustChat(server config.ConfigRconServer, response string) ([]ChatMessage, error) {
+	var rustMessages []rustChatMessage
+
+	if err := json.Unmarshal([]byte(response), &rustMessages); err != nil {
+		return nil, fmt.Errorf("%w: invalid chat messages: %s", ErrParse, err)
+	}
+
+	var messages []ChatMessage
+
+	for _, m := range rustMessages {
+		// only global chat of players, no team chat and no server messages (which includes our own)
+
+		if m.Channel != 0 || m.UserId == "0" {
+			continue
+		}
+
+		messages = append(messages, ChatMessage{
+			Server:   server.Name,
+			Sender:   m.Username,
+			SenderID: m.UserId,
+			Message:  m.Message,
+			Time:     time.Unix(m.Time, 0),
+		})
+	}
+
+	return messages, nil
+}
+
+func (p *rustProvider) Say(server config.ConfigRconServer, sender string, message string) error {
+	_, err := p.Execute(server, fmt.Sprintf("say [Discord] %s: %s", sender, message))
+
+	return err
+}
+
+func (p *rustProvider) SetPassword(server config.ConfigRconServer, password string) error {
+	_, err := p.Execute(server, fmt.Sprintf("rcon.password \"%s\"", password))
+
+	return err
+}
+
+// dial opens the websocket, the password is part of the URL. A rejected handshake is handled like
+// an authentication failure of source RCON.
+func (p *rustProvider) dial(server config.ConfigRconServer, timeout time.Duration) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: timeout}
+
+	conn, response, err := dialer.Dial(rustURL(server.Address, currentPassword(server)), nil)
+
+	if err == nil || response == nil {
+		return conn, err
+	}
+
+	if !server.HasPasswordSource() {
+		return nil, fmt.Errorf("%w: %s", ErrAuthFailure, err)
+	}
+
+	password, ok := reloadPassword(server)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAuthFailure, err)
+	}
+
+	conn, response, err = dialer.Dial(rustURL(server.Address, password), nil)
+
+	if err != nil && response != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAuthFailure, err)
+	}
+
+	return conn, err
+}
+
+func rustURL(address string, password string) string {
+	return fmt.Sprintf("ws://%s/%s", address, url.PathEscape(password))
+}
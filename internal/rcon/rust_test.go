@@ -0,0 +1,90 @@
+package rcon
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+func TestParseRustPlayers(t *testing.T) {
+	response := `[
+  {
+    "SteamID": "76561198012345678",
+    "OwnerSteamID": "0",
+    "DisplayName": "Rexy",
+    "Ping": 35,
+    "Address": "1.2.3.4:61234",
+    "ConnectedSeconds": 1234,
+    "VoiationLevel": 0.0,
+    "CurrentLevel": 0.0,
+    "UnspentXp": 0.0,
+    "Health": 100.0
+  },
+  {
+    "SteamID": "76561198087654321",
+    "OwnerSteamID": "0",
+    "DisplayName": "Player [2]",
+    "Ping": 80,
+    "Address": "5.6.7.8:61235",
+    "ConnectedSeconds": 60,
+    "VoiationLevel": 0.0,
+    "CurrentLevel": 0.0,
+    "UnspentXp": 0.0,
+    "Health": 42.5
+  }
+]`
+
+	players, err := parseRustPlayers(response)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.PlayerInfo{{Name: "Rexy", ID: "76561198012345678"}, {Name: "Player [2]", ID: "76561198087654321"}}
+
+	if !slices.Equal(players, want) {
+		t.Errorf("parsed %v, want %v", players, want)
+	}
+
+	if players, err := parseRustPlayers("[]"); err != nil || len(players) != 0 {
+		t.Errorf("empty server parsed as %v, %v", players, err)
+	}
+
+	if _, err := parseRustPlayers("Unknown command: playerlist"); !errors.Is(err, ErrParse) {
+		t.Errorf("expected ErrParse, got %v", err)
+	}
+}
+
+func TestParseRustChat(t *testing.T) {
+	response := `[
+  {"Channel": 0, "Message": "hello", "UserId": "76561198012345678", "Username": "Rexy", "Color": "#5af", "Time": 1760472000},
+  {"Channel": 1, "Message": "team only", "UserId": "76561198012345678", "Username": "Rexy", "Color": "#5af", "Time": 1760472005},
+  {"Channel": 0, "Message": "[Discord] Bob: hi", "UserId": "0", "Username": "SERVER", "Color": "#eee", "Time": 1760472010},
+  {"Channel": 0, "Message": "gg", "UserId": "76561198087654321", "Username": "Player 2", "Color": "#5af", "Time": 1760472020}
+]`
+
+	server := config.ConfigRconServer{Key: "rust", Name: "Rust Main"}
+
+	messages, err := parseRustChat(server, response)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ChatMessage{
+		{Server: "Rust Main", Sender: "Rexy", SenderID: "76561198012345678", Message: "hello", Time: time.Unix(1760472000, 0)},
+		{Server: "Rust Main", Sender: "Player 2", SenderID: "76561198087654321", Message: "gg", Time: time.Unix(1760472020, 0)},
+	}
+
+	if !slices.Equal(messages, want) {
+		t.Errorf("parsed %v, want %v", messages, want)
+	}
+
+	if _, err := parseRustChat(server, ""); !errors.Is(err, ErrParse) {
+		t.Errorf("expected ErrParse, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/health"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+var simulatedNames = []string{
+	"Rexy", "DodoWhisperer", "Bronto Bob", "Anky", "Parasaur Pete", "Trike Tina",
+	"Raptor Rick", "Quetzy", "Gigantosaur", "Sabertooth Sam", "Mammoth Max", "Pteranodon Pam",
+	"Carno Carl", "Stego Steve", "Argy", "Thylacoleo", "Yuty", "Megalosaur",
+}
+
+var simulatedTribes = []string{"", "", "Lazy Dodos", "Meat Shields", "Berry Pickers"}
+
+var simulatedChatLines = []string{
+	"anyone wanna tame a rex?", "brb", "who took my berries", "gg", "server lagging for anyone else?",
+	"need help at the volcano", "lol", "selling kibble, dm me",
+}
+
+// RunSimulation behaves like Run, but instead of querying RCON servers it generates
+// synthetic players randomly joining, leaving and moving between the configured servers.
+func RunSimulation(ctx context.Context, cfg config.ConfigRcon, refresh <-chan struct{}, interval <-chan int, updateChan chan<- model.ServerUpdate, errorChan chan<- error) error {
+	every := time.Duration(cfg.QueryEverySeconds) * time.Second
+	name := healthName(cfg)
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	health.Beat(name, pollDeadline(every))
+
+	defer health.Forget(name)
+
+	ifos := make(map[string]*model.ServerInfo)
+	tribes := make(map[string]string)
+
+	for _, name := range simulatedNames {
+		tribes[name] = simulatedTribes[rand.IntN(len(simulatedTribes))]
+	}
+
+	for _, rconServerConf := range cfg.Servers {
+		ifos[rconServerConf.Key] = &model.ServerInfo{
+			Key:           rconServerConf.Key,
+			Name:          rconServerConf.Name,
+			Map:           rconServerConf.Map,
+			Reachable:     true,
+			Players:       make([]model.PlayerInfo, 0),
+			Day:           rand.IntN(1000) + 1,
+			ServerVersion: "simulated",
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Simulating %d servers with random player activity", len(cfg.Servers)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-refresh:
+		case seconds := <-interval:
+			slog.Info(fmt.Sprintf("Query RCON servers every %d seconds", seconds))
+			every = time.Duration(seconds) * time.Second
+			ticker.Reset(every)
+			health.Beat(name, pollDeadline(every))
+			continue
+		}
+
+		online := make(map[string]bool)
+
+		for _, ifo := range ifos {
+			for _, p := range ifo.Players {
+				online[p.Name] = true
+			}
+		}
+
+		for _, rconServerConfig := range cfg.Servers {
+			ifo := ifos[rconServerConfig.Key]
+
+			// every now and then a server goes down for a cycle
+
+			if rand.IntN(50) == 0 {
+				ifo.Reachable = false
+				ifo.Players = []model.PlayerInfo{}
+
+				select {
+				case errorChan <- &QueryError{Server: rconServerConfig.Key, Kind: ErrTimeout, Err: fmt.Errorf("simulated outage")}:
+				default:
+				}
+
+				continue
+			}
+
+			markSuccess(rconServerConfig.Key)
+
+			ifo.Reachable = true
+			ifo.Day++
+			ifo.Time = fmt.Sprintf("%02d:%02d", rand.IntN(24), rand.IntN(60))
+
+			var players []model.PlayerInfo
+
+			for _, p := range ifo.Players {
+				if rand.IntN(8) == 0 {
+					delete(online, p.Name)
+					continue
+				}
+
+				players = append(players, p)
+			}
+
+			if rand.IntN(3) == 0 {
+				name := simulatedNames[rand.IntN(len(simulatedNames))]
+
+				if !online[name] {
+					online[name] = true
+					players = append(players, model.PlayerInfo{Name: name, Tribe: tribes[name]})
+				}
+			}
+
+			ifo.Players = players
+		}
+
+		// a loop which was replaced after stalling must not deliver its late results
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case updateChan <- model.ServerUpdate{Ctx: context.Background(), Servers: ifos}:
+		}
+
+		health.Beat(name, pollDeadline(every))
+	}
+}
+
+// simulatedChat returns a random chat message every now and then
+func simulatedChat(server config.ConfigRconServer) []ChatMessage {
+	if rand.IntN(6) != 0 {
+		return nil
+	}
+
+	return []ChatMessage{{
+		Server:  server.Name,
+		Sender:  simulatedNames[rand.IntN(len(simulatedNames))],
+		Message: simulatedChatLines[rand.IntN(len(simulatedChatLines))],
+		Time:    time.Now(),
+	}}
+}
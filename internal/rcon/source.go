@@ -0,0 +1,126 @@
+package rcon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorcon/rcon"
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// player line of the status command on TF2, GMod and CS:GO, with an optional slot after the user id:
+// '# 2 "Player 1" [U:1:12345] 01:23 50 0 active 1.2.3.4:27005'
+// '# 3 1 "Player 2" STEAM_1:0:123 05:12 37 0 active 128000 1.2.3.4:27005'
+// '# 4 "Bot" BOT active'
+var sourceStatusLine = regexp.MustCompile(`^#\s*\d+\s+(?:\d+\s+)?"(.*)"\s+(\S+)\s*(.*)$`)
+
+// id of the connection slot CS2 lists for clients still connecting
+const cs2ChallengingID = "65535"
+
+// sourceProvider queries any source engine server (CS2, TF2, GMod, ...) with the status command
+type sourceProvider struct{}
+
+func (p *sourceProvider) Players(server config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	response, err := executeSource(server, "status")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSourceStatus(response), nil
+}
+
+func (p *sourceProvider) Execute(server config.ConfigRconServer, command string) (string, error) {
+	return executeSource(server, command)
+}
+
+func (p *sourceProvider) SetPassword(server config.ConfigRconServer, password string) error {
+	_, err := executeSource(server, fmt.Sprintf("rcon_password \"%s\"", password))
+
+	return err
+}
+
+// parseSourceStatus returns the players of the status output, without bots and SourceTV
+func parseSourceStatus(response string) []model.PlayerInfo {
+	players := make([]model.PlayerInfo, 0)
+	cs2 := false
+
+	for _, raw := range strings.Split(response, "\n") {
+		line := strings.TrimSpace(raw)
+
+		switch {
+		case strings.HasPrefix(line, "---------players"):
+			cs2 = true
+		case line == "#end":
+			cs2 = false
+		case cs2:
+			if player, ok := parseCs2StatusLine(line); ok {
+				players = append(players, player)
+			}
+		default:
+			if player, ok := parseSourceStatusLine(line); ok {
+				players = append(players, player)
+			}
+		}
+	}
+
+	return players
+}
+
+func parseSourceStatusLine(line string) (model.PlayerInfo, bool) {
+	m := sourceStatusLine.FindStringSubmatch(line)
+
+	if m == nil || m[2] == "BOT" {
+		return model.PlayerInfo{}, false
+	}
+
+	// remaining fields: connected, ping, loss, state, ...
+
+	player := model.PlayerInfo{Name: m[1], ID: m[2]}
+
+	if fields := strings.Fields(m[3]); len(fields) > 1 {
+		player.Ping, _ = strconv.Atoi(fields[1])
+	}
+
+	return player, true
+}
+
+// parseCs2StatusLine parses a line of the CS2 player list, which reports no steam IDs:
+//
+//	id     time ping loss      state   rate adr name
+//	 2    00:41   38    0     active 786432 1.2.3.4:27005 'Player 1'
+//	 3      BOT    0    0     active      0 'Bot'
+func parseCs2StatusLine(line string) (model.PlayerInfo, bool) {
+	start := strings.Index(line, "'")
+	end := strings.LastIndex(line, "'")
+
+	if start < 0 || end <= start {
+		return model.PlayerInfo{}, false
+	}
+
+	fields := strings.Fields(line[:start])
+
+	if len(fields) < 3 || fields[0] == cs2ChallengingID || fields[1] == "BOT" {
+		return model.PlayerInfo{}, false
+	}
+
+	ping, _ := strconv.Atoi(fields[2])
+
+	return model.PlayerInfo{Name: line[start+1 : end], Ping: ping}, true
+}
+
+// executeSource runs a command via source RCON, which is used by ARK and all source engine games
+func executeSource(server config.ConfigRconServer, command string, options ...rcon.Option) (string, error) {
+	conn, err := dial(server, options...)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	return conn.Execute(command)
+}
@@ -0,0 +1,55 @@
+package rcon
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+func TestParseSourceStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []model.PlayerInfo
+	}{
+		{"tf2", `hostname: Dodo TF2
+version : 8622567/24 8622567 secure
+udp/ip  : 1.2.3.4:27015  (public ip: 1.2.3.4)
+steamid : [G:1:1234567] (85568392921234567)
+account : not logged in  (No account specified)
+map     : ctf_2fort at: 0 x, 0 y, 0 z
+tags    : cp
+players : 2 humans, 1 bots (24 max)
+edicts  : 1206 used of 2048 max
+# userid name                uniqueid            connected ping loss state  adr
+#      2 "Rexy"              [U:1:12345]         01:23       50    0 active 5.6.7.8:27005
+#      3 "Player "2""        [U:1:67890]         05:12       37    0 active 9.10.11.12:27005
+#      4 "Heavy"             BOT                                     active
+`, []model.PlayerInfo{{Name: "Rexy", ID: "[U:1:12345]", Ping: 50}, {Name: `Player "2"`, ID: "[U:1:67890]", Ping: 37}}},
+		{"csgo with slots", `hostname: Dodo CS:GO
+# userid name uniqueid connected ping loss state rate adr
+# 2 1 "Rexy" STEAM_1:0:123 05:12 37 0 active 128000 5.6.7.8:27005
+#  3 2 "Bot Alex" BOT active 64
+#end
+`, []model.PlayerInfo{{Name: "Rexy", ID: "STEAM_1:0:123", Ping: 37}}},
+		{"cs2", `Server:  Running [0.0.0.0:27015]
+Client:  Disconnected
+@ Current  :  game
+---------players--------
+  id     time ping loss      state   rate adr name
+65535 [NoChan]    0    0 challenging      0unknown ''
+ 2    00:41   38    0     active 786432 5.6.7.8:27005 'Rexy'
+ 3      BOT    0    0     active      0 'Bot Kate'
+ 4    12:03   61    0     active 786432 9.10.11.12:27005 'it's me'
+#end
+`, []model.PlayerInfo{{Name: "Rexy", Ping: 38}, {Name: "it's me", Ping: 61}}},
+		{"empty", "hostname: Dodo\nplayers : 0 humans, 0 bots (24 max)\n# userid name uniqueid connected ping loss state adr\n", []model.PlayerInfo{}},
+	}
+
+	for _, test := range tests {
+		if players := parseSourceStatus(test.response); !slices.Equal(players, test.want) {
+			t.Errorf("%s: parsed %v, want %v", test.name, players, test.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package rcon
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+// team header of ListSquads: 'Team ID: 1 (Manticore Security Task Force)'
+var squadTeamLine = regexp.MustCompile(`^Team ID: (\d+) \((.*)\)$`)
+
+// squadProvider queries OWI servers (Squad, Post Scriptum). Their RCON is source RCON, the player
+// and squad lists are lines of '|' separated key/value pairs.
+type squadProvider struct{}
+
+func (p *squadProvider) Players(server config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	players, err := executeSource(server, "ListPlayers")
+
+	if err != nil {
+		return nil, err
+	}
+
+	squads, err := executeSource(server, "ListSquads")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSquadPlayers(players, parseSquadTeams(squads)), nil
+}
+
+func (p *squadProvider) Execute(server config.ConfigRconServer, command string) (string, error) {
+	return executeSource(server, command)
+}
+
+type squadTeams struct {
+	teams  map[string]string
+	squads map[string]string
+}
+
+// parseSquadTeams returns the team names and the squad names by team and squad ID:
+// '
+// ----- Active Squads -----
+// Team ID: 1 (Manticore Security Task Force)
+// ID: 1 | Name: INFANTRY | Size: 5 | Locked: False | Creator Name: Player 1 | Creator Online IDs: ...
+// '
+func parseSquadTeams(response string) squadTeams {
+	res := squadTeams{teams: make(map[string]string), squads: make(map[string]string)}
+	team := ""
+
+	for _, raw := range strings.Split(response, "\n") {
+		line := strings.TrimSpace(raw)
+
+		if m := squadTeamLine.FindStringSubmatch(line); m != nil {
+			team = m[1]
+			res.teams[team] = m[2]
+			continue
+		}
+
+		fields := parseSquadFields(line)
+
+		if id, ok := fields["ID"]; ok && team != "" {
+			res.squads[team+"/"+id] = fields["Name"]
+		}
+	}
+
+	return res
+}
+
+// parseSquadPlayers returns the active players of the ListPlayers response:
+// '
+// ----- Active Players -----
+// ID: 0 | Online IDs: EOS: 0002... steam: 76561198012345678 | Name: Player 1 | Team ID: 1 | Squad ID: 1 | Is Leader: True | Role: ...
+// ----- Recently Disconnected Players [Max of 15] -----
+// ID: 5 | Online IDs: ... | Since Disconnect: 02m.30s | Name: Player 2
+// '
+func parseSquadPlayers(response string, teams squadTeams) []model.PlayerInfo {
+	players := make([]model.PlayerInfo, 0)
+
+	for _, raw := range strings.Split(response, "\n") {
+		line := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(line, "----- Recently Disconnected") {
+			break
+		}
+
+		fields := parseSquadFields(line)
+		name, ok := fields["Name"]
+
+		if !ok {
+			continue
+		}
+
+		player := model.PlayerInfo{Name: name, ID: fields["SteamID"]}
+
+		// newer servers list all platform IDs in one field, e.g. 'EOS: 0002... steam: 7656...'
+
+		if ids := strings.Fields(fields["Online IDs"]); len(ids) > 0 {
+			for i := 0; i+1 < len(ids); i += 2 {
+				if ids[i] == "steam:" || (ids[i] == "EOS:" && player.ID == "") {
+					player.ID = ids[i+1]
+				}
+			}
+		}
+
+		if team, ok := fields["Team ID"]; ok {
+			player.Team = teams.teams[team]
+
+			if player.Team == "" {
+				player.Team = "Team " + team
+			}
+
+			player.Squad = teams.squads[team+"/"+fields["Squad ID"]]
+		}
+
+		players = append(players, player)
+	}
+
+	return players
+}
+
+func parseSquadFields(line string) map[string]string {
+	fields := make(map[string]string)
+
+	for _, part := range strings.Split(line, " | ") {
+		key, value, ok := strings.Cut(part, ": ")
+
+		if ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return fields
+}
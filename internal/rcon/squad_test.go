@@ -0,0 +1,41 @@
+package rcon
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+func TestParseSquadPlayers(t *testing.T) {
+	squads := `----- Active Squads -----
+Team ID: 1 (Manticore Security Task Force)
+ID: 1 | Name: INFANTRY | Size: 2 | Locked: False | Creator Name: Rexy | Creator Online IDs: EOS: 0002821382231233322321312300abc2 steam: 76561198012345678
+Team ID: 2 (Irregular Battle Group)
+ID: 1 | Name: ARMOR | Size: 1 | Locked: True | Creator Name: Player | 3 | Creator Online IDs: EOS: 0002822312322312321321312300abc5
+`
+
+	players := `----- Active Players -----
+ID: 0 | Online IDs: EOS: 0002821382231233322321312300abc2 steam: 76561198012345678 | Name: Rexy | Team ID: 1 | Squad ID: 1 | Is Leader: True | Role: USA_SL_01
+ID: 1 | Online IDs: EOS: 0002822312322312321321312300abc3 steam: 76561198087654321 | Name: Player 2 | Team ID: 1 | Squad ID: N/A | Is Leader: False | Role: USA_Rifleman_01
+ID: 2 | Online IDs: EOS: 0002822312322312321321312300abc5 | Name: Console Player | Team ID: 2 | Squad ID: 1 | Is Leader: True | Role: RUS_Crewman_01
+ID: 3 | SteamID: 76561198011111111 | Name: Old Server Player | Team ID: 3 | Squad ID: N/A | Is Leader: False | Role: INS_Rifleman_01
+----- Recently Disconnected Players [Max of 15] -----
+ID: 5 | Online IDs: EOS: 0002822312322312321321312300abc9 steam: 76561198099999999 | Since Disconnect: 02m.30s | Name: Gone Player
+`
+
+	want := []model.PlayerInfo{
+		{Name: "Rexy", ID: "76561198012345678", Team: "Manticore Security Task Force", Squad: "INFANTRY"},
+		{Name: "Player 2", ID: "76561198087654321", Team: "Manticore Security Task Force"},
+		{Name: "Console Player", ID: "0002822312322312321321312300abc5", Team: "Irregular Battle Group", Squad: "ARMOR"},
+		{Name: "Old Server Player", ID: "76561198011111111", Team: "Team 3"},
+	}
+
+	if parsed := parseSquadPlayers(players, parseSquadTeams(squads)); !slices.Equal(parsed, want) {
+		t.Errorf("parsed %v, want %v", parsed, want)
+	}
+
+	if parsed := parseSquadPlayers("----- Active Players -----\n----- Recently Disconnected Players [Max of 15] -----\n", parseSquadTeams("")); len(parsed) != 0 {
+		t.Errorf("empty server parsed as %v", parsed)
+	}
+}
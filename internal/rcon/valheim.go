@@ -0,0 +1,249 @@
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+)
+
+const a2sTimeout = 5 * time.Second
+
+var a2sInfoRequest = append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 'T'}, []byte("Source Engine Query\x00")...)
+
+// log lines of the valheim server tracking connected players:
+// 'Got connection SteamID 76561198012345678'
+// 'Got character ZDOID from Player 1 : 1234567890:1'
+// 'Closing socket 76561198012345678'
+var valheimConnected = regexp.MustCompile(`Got connection SteamID (\d+)`)
+var valheimCharacter = regexp.MustCompile(`Got character ZDOID from (.+) : (-?\d+):(\d+)`)
+var valheimDisconnected = regexp.MustCompile(`Closing socket (\d+)`)
+
+// valheimProvider counts players via A2S query, Address is the query port (game port + 1). As A2S
+// does not report names for valheim, they are taken from the server log if LogFile is set.
+type valheimProvider struct {
+	sync.Mutex
+	logs map[string]*valheimLog
+}
+
+// valheimLog is the state of a tailed server log
+type valheimLog struct {
+	offset  int64
+	pending []string
+	online  map[string]string
+}
+
+func (p *valheimProvider) Players(server config.ConfigRconServer) ([]model.PlayerInfo, error) {
+	count, err := a2sPlayerCount(server.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+
+	if server.LogFile != "" {
+		names, err = p.tailLog(server, count)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// players not known from the log (yet) are listed without name
+
+	players := make([]model.PlayerInfo, 0, count)
+
+	for i := 0; i < count; i++ {
+		player := model.PlayerInfo{}
+
+		if i < len(names) {
+			player.Name = names[i]
+		}
+
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
+func (p *valheimProvider) Execute(server config.ConfigRconServer, command string) (string, error) {
+	return "", fmt.Errorf("valheim servers do not support remote commands")
+}
+
+// tailLog reads the lines appended to the server log since the last poll and returns the names of
+// the connected players
+func (p *valheimProvider) tailLog(server config.ConfigRconServer, count int) ([]string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.logs == nil {
+		p.logs = make(map[string]*valheimLog)
+	}
+
+	state, ok := p.logs[server.Key]
+
+	if !ok {
+		state = &valheimLog{online: make(map[string]string)}
+		p.logs[server.Key] = state
+	}
+
+	file, err := os.Open(server.LogFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the log was rotated or truncated, e.g. by a server restart
+
+	if info.Size() < state.offset {
+		*state = valheimLog{online: make(map[string]string)}
+	}
+
+	if _, err := file.Seek(state.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		// incomplete lines are read again with the next poll
+
+		if err != nil {
+			break
+		}
+
+		state.offset += int64(len(line))
+		state.parse(line)
+	}
+
+	if count == 0 {
+		state.online = make(map[string]string)
+	}
+
+	var names []string
+
+	for _, name := range state.online {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (l *valheimLog) parse(line string) {
+	if m := valheimConnected.FindStringSubmatch(line); m != nil {
+		l.pending = append(l.pending, m[1])
+		return
+	}
+
+	if m := valheimCharacter.FindStringSubmatch(line); m != nil {
+		// characters with ZDOID 0:0 are dead, not gone
+
+		if m[2] == "0" || len(l.pending) == 0 {
+			return
+		}
+
+		l.online[l.pending[0]] = m[1]
+		l.pending = l.pending[1:]
+		return
+	}
+
+	if m := valheimDisconnected.FindStringSubmatch(line); m != nil {
+		delete(l.online, m[1])
+	}
+}
+
+// a2sPlayerCount queries the number of connected players with the A2S_INFO query
+func a2sPlayerCount(address string) (int, error) {
+	conn, err := net.DialTimeout("udp", address, a2sTimeout)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(a2sTimeout))
+
+	response, err := a2sRequest(conn, a2sInfoRequest)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// newer servers answer with a challenge which has to be sent along with the request
+
+	if len(response) >= 9 && response[4] == 'A' {
+		response, err = a2sRequest(conn, append(append([]byte{}, a2sInfoRequest...), response[5:9]...))
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return parseA2sInfo(response)
+}
+
+func a2sRequest(conn net.Conn, request []byte) ([]byte, error) {
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1400)
+
+	n, err := conn.Read(buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// parseA2sInfo returns the player count of an A2S_INFO response: header, protocol, name, map,
+// folder, game, app ID, players, ...
+func parseA2sInfo(response []byte) (int, error) {
+	if len(response) < 6 || binary.LittleEndian.Uint32(response[:4]) != 0xFFFFFFFF || response[4] != 'I' {
+		return 0, fmt.Errorf("%w: invalid A2S_INFO response", ErrParse)
+	}
+
+	rest := response[6:]
+
+	for i := 0; i < 4; i++ {
+		idx := bytes.IndexByte(rest, 0)
+
+		if idx < 0 {
+			return 0, fmt.Errorf("%w: truncated A2S_INFO response", ErrParse)
+		}
+
+		rest = rest[idx+1:]
+	}
+
+	if len(rest) < 3 {
+		return 0, fmt.Errorf("%w: truncated A2S_INFO response", ErrParse)
+	}
+
+	return int(rest[2]), nil
+}
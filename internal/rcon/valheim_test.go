@@ -0,0 +1,85 @@
+package rcon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/patrickjane/lazydodo-bot/internal/config"
+)
+
+func TestValheimTailLog(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "valheim.log")
+	server := config.ConfigRconServer{Key: "valheim", LogFile: file}
+	p := &valheimProvider{}
+
+	write := func(flag int, text string) {
+		t.Helper()
+
+		f, err := os.OpenFile(file, flag|os.O_WRONLY|os.O_CREATE, 0644)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer f.Close()
+
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name  string
+		flag  int
+		text  string
+		count int
+		want  []string
+	}{
+		{"join", os.O_TRUNC, "10/14/2026 20:00:01: Got connection SteamID 76561198012345678\n" +
+			"10/14/2026 20:00:15: Got character ZDOID from Rexy : -123456789:1\n" +
+			"10/14/2026 20:01:02: Got connection SteamID 76561198087654321\n", 2, []string{"Rexy"}},
+		{"second character, the line is not complete yet", os.O_APPEND, "10/14/2026 20:01:20: Got character ZDOID from Player 2 : 98765", 2, []string{"Rexy"}},
+		{"line completed", os.O_APPEND, "4321:1\n", 2, []string{"Player 2", "Rexy"}},
+		{"death keeps the player", os.O_APPEND, "10/14/2026 20:30:00: Got character ZDOID from Rexy : 0:0\n", 2, []string{"Player 2", "Rexy"}},
+		{"leave", os.O_APPEND, "10/14/2026 21:00:00: Closing socket 76561198012345678\n", 1, []string{"Player 2"}},
+		{"rotated by a restart", os.O_TRUNC, "10/14/2026 22:00:00: Got connection SteamID 76561198012345678\n" +
+			"10/14/2026 22:00:10: Got character ZDOID from Rexy : 55555:1\n", 1, []string{"Rexy"}},
+		{"empty server forgets everyone", os.O_APPEND, "", 0, nil},
+	}
+
+	for _, step := range steps {
+		write(step.flag, step.text)
+
+		names, err := p.tailLog(server, step.count)
+
+		if err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+
+		if !slices.Equal(names, step.want) {
+			t.Errorf("%s: online %q, want %q", step.name, names, step.want)
+		}
+	}
+}
+
+func TestParseA2sInfo(t *testing.T) {
+	// header, protocol, name, map, folder, game, app ID (0 on valheim), 3 of 10 players, 0 bots, ...
+	response := []byte("\xFF\xFF\xFF\xFFI\x11My Valheim\x00Dodo Land\x00valheim\x00Valheim\x00\x00\x00\x03\x0A\x00dl\x00\x01")
+
+	if count, err := parseA2sInfo(response); err != nil || count != 3 {
+		t.Errorf("parsed %d players, %v, want 3", count, err)
+	}
+
+	for _, invalid := range [][]byte{
+		[]byte("\xFF\xFF\xFF\xFFA\x01\x02\x03\x04"),
+		[]byte("\xFF\xFF\xFF\xFFI\x11My Valheim\x00Dodo Land\x00"),
+		[]byte("\xFF\xFF\xFF\xFFI\x11My Valheim\x00Dodo Land\x00valheim\x00Valheim\x00\x00"),
+	} {
+		if _, err := parseA2sInfo(invalid); !errors.Is(err, ErrParse) {
+			t.Errorf("%q: expected ErrParse, got %v", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/model"
+
+	_ "modernc.org/sqlite"
+)
+
+// snapshots are taken every poll, they are kept this long. Join/leave events are kept until deleted with /forget.
+const SnapshotRetention = 90 * 24 * time.Hour
+
+// how often old snapshots are deleted
+const pruneInterval = 24 * time.Hour
+
+// Store keeps the join/leave events and the server status of every poll in a SQLite database, as a
+// long-term export to be queried with SQL. The bot only writes to it, what it needs across restarts is
+// kept elsewhere: the player counts and sessions in the history, the reminders in their reminder store.
+// A nil store stores nothing, it is used without a database configured.
+type Store struct {
+	sync.Mutex
+	db         *sql.DB
+	lastPruned time.Time
+}
+
+// Event is a player joining or leaving a server
+type Event struct {
+	Time   time.Time
+	Server string
+	Player string
+	Joined bool
+}
+
+// Open opens the database in the file, creating its tables if needed
+func Open(file string) (*Store, error) {
+	db, err := sql.Open("sqlite", file+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range []string{
+		`CREATE TABLE IF NOT EXISTS player_events (
+			time   INTEGER NOT NULL,
+			server TEXT NOT NULL,
+			player TEXT NOT NULL,
+			joined INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS player_events_time ON player_events (server, time)`,
+		`CREATE INDEX IF NOT EXISTS player_events_player ON player_events (player)`,
+		`CREATE TABLE IF NOT EXISTS snapshots (
+			time      INTEGER NOT NULL,
+			server    TEXT NOT NULL,
+			reachable INTEGER NOT NULL,
+			players   INTEGER NOT NULL,
+			day       INTEGER NOT NULL DEFAULT 0,
+			version   TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS snapshots_time ON snapshots (server, time)`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() {
+	if s != nil {
+		s.db.Close()
+	}
+}
+
+// RecordEvents stores the joins and leaves of one poll
+func (s *Store) RecordEvents(events []Event) error {
+	if s == nil || len(events) == 0 {
+		return nil
+	}
+
+	return s.transaction(func(tx *sql.Tx) error {
+		for _, e := range events {
+			if _, err := tx.Exec("INSERT INTO player_events (time, server, player, joined) VALUES (?, ?, ?, ?)",
+				e.Time.UnixMilli(), e.Server, e.Player, e.Joined); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RecordSnapshot stores the status of all servers of one poll
+func (s *Store) RecordSnapshot(t time.Time, serverInfos map[string]*model.ServerInfo) error {
+	if s == nil {
+		return nil
+	}
+
+	err := s.transaction(func(tx *sql.Tx) error {
+		for serverKey, serverInfo := range serverInfos {
+			if _, err := tx.Exec("INSERT INTO snapshots (time, server, reachable, players, day, version) VALUES (?, ?, ?, ?, ?, ?)",
+				t.UnixMilli(), serverKey, serverInfo.Reachable, len(serverInfo.Players), serverInfo.Day, serverInfo.ServerVersion); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.pruneIfDue(t)
+
+	return nil
+}
+
+// ForgetPlayer deletes the joins and leaves of the player with any of the names, it returns the number deleted
+func (s *Store) ForgetPlayer(names []string) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	deleted := 0
+
+	err := s.transaction(func(tx *sql.Tx) error {
+		for _, name := range names {
+			res, err := tx.Exec("DELETE FROM player_events WHERE player = ?", name)
+
+			if err != nil {
+				return err
+			}
+
+			n, _ := res.RowsAffected()
+			deleted += int(n)
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+func (s *Store) transaction(fn func(tx *sql.Tx) error) error {
+	s.Lock()
+	defer s.Unlock()
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) pruneIfDue(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	if now.Sub(s.lastPruned) < pruneInterval {
+		return
+	}
+
+	s.lastPruned = now
+
+	res, err := s.db.Exec("DELETE FROM snapshots WHERE time < ?", now.Add(-SnapshotRetention).UnixMilli())
+
+	if err != nil {
+		slog.Error(fmt.Sprintf("Failed to delete old snapshots: %s", err))
+		return
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		slog.Info(fmt.Sprintf("Deleted %d snapshots older than %s", n, SnapshotRetention))
+	}
+}
+
+// MigrateServer moves the events and snapshots of the server with the key from to the key to
+func (s *Store) MigrateServer(from string, to string) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	moved := 0
+
+	err := s.transaction(func(tx *sql.Tx) error {
+		for _, table := range []string{"player_events", "snapshots"} {
+			res, err := tx.Exec("UPDATE "+table+" SET server = ? WHERE server = ?", to, from)
+
+			if err != nil {
+				return err
+			}
+
+			n, _ := res.RowsAffected()
+			moved += int(n)
+		}
+
+		return nil
+	})
+
+	return moved, err
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	cfg "github.com/patrickjane/lazydodo-bot/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer trace.Tracer = otel.Tracer("lazydodobot")
+var provider *sdktrace.TracerProvider
+
+// Init configures the OTLP/HTTP exporter. Without tracing config, spans are
+// created by the global noop provider and discarded.
+func Init() error {
+	if cfg.Config.Tracing == nil {
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Config.Tracing.Endpoint)}
+
+	if cfg.Config.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+
+	if err != nil {
+		return err
+	}
+
+	res := resource.NewWithAttributes("",
+		attribute.String("service.name", cfg.Config.Tracing.ServiceName),
+		attribute.String("service.version", cfg.Version),
+	)
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(cfg.Config.Tracing.SampleRatio)),
+	)
+
+	otel.SetTracerProvider(provider)
+	tracer = provider.Tracer("lazydodobot")
+
+	return nil
+}
+
+// Shutdown flushes all pending spans.
+func Shutdown() {
+	if provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		slog.Error(fmt.Sprintf("Failed to flush traces: %s", err))
+	}
+}
+
+// Start creates a new span as child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End finishes the span and records err (if any) on it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -1,88 +1,110 @@
 package utils
 
 import (
-	"fmt"
+	"strings"
 	"time"
+
+	"github.com/patrickjane/lazydodo-bot/internal/i18n"
 )
 
 // Language represents the output language for duration formatting.
-type Language int
+type Language = i18n.Language
 
 const (
-	English Language = iota
-	German
+	English = i18n.English
+	German  = i18n.German
+	French  = i18n.French
+	Spanish = i18n.Spanish
+	Dutch   = i18n.Dutch
 )
 
-// unit holds the singular and plural forms of a time unit in a given language.
+// DurationStyle selects the optional precision and notation of FormatDurationStyle.
+type DurationStyle struct {
+	// Seconds adds seconds as smallest unit, e.g. for countdowns
+	Seconds bool
+
+	// Compact abbreviates the units, e.g. "2h 15m"
+	Compact bool
+}
+
+// unit is a time unit, its names are looked up in the i18n catalog as "duration.<name>.one",
+// "duration.<name>.other" and "duration.<name>.short".
 type unit struct {
-	singular string
-	plural   string
+	name string
+	size time.Duration
 }
 
-var units = map[Language]map[string]unit{
-	English: {
-		"day":    {singular: "day", plural: "days"},
-		"hour":   {singular: "hour", plural: "hours"},
-		"minute": {singular: "minute", plural: "minutes"},
-	},
-	German: {
-		"day":    {singular: "Tag", plural: "Tage"},
-		"hour":   {singular: "Stunde", plural: "Stunden"},
-		"minute": {singular: "Minute", plural: "Minuten"},
-	},
+// units from largest to smallest, the last one is only used with seconds precision
+var units = []unit{
+	{name: "week", size: 7 * 24 * time.Hour},
+	{name: "day", size: 24 * time.Hour},
+	{name: "hour", size: time.Hour},
+	{name: "minute", size: time.Minute},
+	{name: "second", size: time.Second},
 }
 
-// pluralize returns the correctly pluralized unit label for the given count.
-func pluralize(count int, u unit) string {
-	if count == 1 {
-		return u.singular
+// format returns the count with the correctly pluralized or abbreviated unit label.
+func (u unit) format(count int, lang Language, compact bool) string {
+	key := "duration." + u.name + ".other"
+
+	switch {
+	case compact:
+		key = "duration." + u.name + ".short"
+	case count == 1:
+		key = "duration." + u.name + ".one"
 	}
-	return u.plural
+
+	return i18n.T(lang, key, count)
 }
 
 // FormatDuration pretty-formats a time.Duration in the given language.
 //
 // Output format:
+//   - d >= 1 week: "XX weeks [YY days]"    /  "XX Wochen [YY Tage]"
 //   - d >= 1 day:  "XX days [YY hours]"    /  "XX Tage [YY Stunden]"
 //   - d >= 1 hour: "XX hours [YY minutes]" / "XX Stunden [YY Minuten]"
 //   - d <  1 hour: "XX minutes"            / "XX Minuten"
 //
 // The secondary unit (in brackets) is omitted when its value is zero.
 func FormatDuration(d time.Duration, lang Language) string {
+	return FormatDurationStyle(d, lang, DurationStyle{})
+}
+
+// FormatDurationStyle is FormatDuration with optional seconds precision ("4 minutes 30 seconds")
+// and compact notation ("2h 15m").
+func FormatDurationStyle(d time.Duration, lang Language, style DurationStyle) string {
 	// Work with absolute value so negative durations are handled gracefully.
 	if d < 0 {
 		return ""
 	}
 
-	u, ok := units[lang]
-	if !ok {
-		u = units[English]
+	available := units
+
+	if !style.Seconds {
+		available = units[:len(units)-1]
 	}
 
-	totalMinutes := int(d.Minutes())
-	totalHours := int(d.Hours())
-	days := totalHours / 24
-	hours := totalHours % 24
-	minutes := totalMinutes % 60
+	// largest unit which fits, the smallest one for shorter durations
 
-	switch {
-	case days >= 1:
-		if hours == 0 {
-			return fmt.Sprintf("%d %s", days, pluralize(days, u["day"]))
+	idx := len(available) - 1
+
+	for i, u := range available {
+		if d >= u.size {
+			idx = i
+			break
 		}
-		return fmt.Sprintf("%d %s %d %s",
-			days, pluralize(days, u["day"]),
-			hours, pluralize(hours, u["hour"]),
-		)
-	case totalHours >= 1:
-		if minutes == 0 {
-			return fmt.Sprintf("%d %s", totalHours, pluralize(totalHours, u["hour"]))
+	}
+
+	major := available[idx]
+	parts := []string{major.format(int(d/major.size), lang, style.Compact)}
+
+	if idx+1 < len(available) {
+		minor := available[idx+1]
+
+		if count := int(d % major.size / minor.size); count > 0 {
+			parts = append(parts, minor.format(count, lang, style.Compact))
 		}
-		return fmt.Sprintf("%d %s %d %s",
-			totalHours, pluralize(totalHours, u["hour"]),
-			minutes, pluralize(minutes, u["minute"]),
-		)
-	default:
-		return fmt.Sprintf("%d %s", totalMinutes, pluralize(totalMinutes, u["minute"]))
 	}
+
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// emoji beyond this number are dropped from player names
+const maxNameEmoji = 3
+
+// SanitizeName prepares a player name for rendering: look-alike letters (e.g. fullwidth or styled
+// math letters) are folded to their plain form, invisible characters like zero-width spaces and
+// RTL overrides are removed and only the first few emoji are kept
+func SanitizeName(name string) string {
+	var b strings.Builder
+
+	emoji := 0
+
+	for _, r := range norm.NFKC.String(name) {
+		if invisible(r) {
+			continue
+		}
+
+		if unicode.Is(unicode.So, r) {
+			emoji++
+
+			if emoji > maxNameEmoji {
+				continue
+			}
+		}
+
+		b.WriteRune(r)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// EscapeName shows the name as reported by the server, with its invisible characters written as
+// code points (e.g. "\u202E"), safe to be put into inline code
+func EscapeName(name string) string {
+	var b strings.Builder
+
+	for _, r := range name {
+		switch {
+		case invisible(r):
+			b.WriteString(fmt.Sprintf("\\u%04X", r))
+		case r == '`':
+			b.WriteRune('\'')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// invisible reports control and format characters, which covers zero-width characters, bidi
+// overrides and isolates, and the byte order mark
+func invisible(r rune) bool {
+	return unicode.Is(unicode.Cc, r) || unicode.Is(unicode.Cf, r)
+}
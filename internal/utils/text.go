@@ -0,0 +1,19 @@
+package utils
+
+import "unicode/utf8"
+
+// Truncate cuts text to at most max bytes, ending in "..." if it was cut. Characters are never split, a
+// cut inside of one would leave invalid UTF-8 which discord rejects.
+func Truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+
+	cut := max - 3
+
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	return text[:cut] + "..."
+}